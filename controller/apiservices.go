@@ -0,0 +1,260 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog"
+	apiregistrationv1 "k8s.io/kube-aggregator/pkg/apis/apiregistration/v1"
+	apiregistrationclient "k8s.io/kube-aggregator/pkg/client/clientset_generated/clientset/typed/apiregistration/v1"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/certs"
+)
+
+const (
+	managedByLabel = "app.kubernetes.io/managed-by"
+	managedBy      = "custom-metrics-router"
+
+	apiServiceGroupPriorityMinimum = 100
+	apiServiceSyncInterval         = time.Minute
+)
+
+// APIServiceController registers the router as the APIService of the metrics
+// APIs. It keeps a self-signed CA and serving certificate in a Secret, writes
+// the certificate to the files which the server reloads it from and injects
+// the CA into the APIServices.
+type APIServiceController struct {
+	kubeClient    kubernetes.Interface
+	apiServices   apiregistrationclient.APIServiceInterface
+	service       apiregistrationv1.ServiceReference
+	secretName    string
+	certFile      string
+	keyFile       string
+	groupVersions []schema.GroupVersion
+}
+
+func NewAPIServiceController(kubeClient kubernetes.Interface, apiServices apiregistrationclient.APIServiceInterface, service apiregistrationv1.ServiceReference, secretName, certDir string, groupVersions []schema.GroupVersion) *APIServiceController {
+	return &APIServiceController{
+		kubeClient:    kubeClient,
+		apiServices:   apiServices,
+		service:       service,
+		secretName:    secretName,
+		certFile:      filepath.Join(certDir, certs.CertKey),
+		keyFile:       filepath.Join(certDir, certs.KeyKey),
+		groupVersions: groupVersions,
+	}
+}
+
+// CertFiles returns the files of the serving certificate and key.
+func (c *APIServiceController) CertFiles() (string, string) {
+	return c.certFile, c.keyFile
+}
+
+func (c *APIServiceController) Run(stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+	klog.Infof("Starting APIService controller")
+	defer klog.Infof("Shutting down APIService controller")
+
+	wait.Until(func() {
+		if err := c.Sync(); err != nil {
+			utilruntime.HandleError(err)
+		}
+	}, apiServiceSyncInterval, stopCh)
+}
+
+// Sync renews the certificates when needed and updates the files and the
+// APIServices. It is called before the server starts, so that the server
+// finds its certificate.
+func (c *APIServiceController) Sync() error {
+	bundle, err := c.syncSecret()
+	if err != nil {
+		return err
+	}
+	// the key is written first, the server skips the reload of a certificate
+	// which doesn't match its key until both are written.
+	if err := writeFileIfChanged(c.keyFile, bundle.Key, 0600); err != nil {
+		return err
+	}
+	if err := writeFileIfChanged(c.certFile, bundle.Cert, 0644); err != nil {
+		return err
+	}
+	for i, groupVersion := range c.groupVersions {
+		if err := c.syncAPIService(groupVersion, c.versionPriority(i), bundle.CACert); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncSecret returns the certificates of the Secret and renews them when
+// needed. Replicas of the router share the Secret, so when another replica
+// created or updated it first its certificates are used instead.
+func (c *APIServiceController) syncSecret() (*certs.Bundle, error) {
+	hosts := []string{
+		fmt.Sprintf("%s.%s.svc", c.service.Name, c.service.Namespace),
+		fmt.Sprintf("%s.%s", c.service.Name, c.service.Namespace),
+	}
+	secrets := c.kubeClient.CoreV1().Secrets(c.service.Namespace)
+	secret, err := secrets.Get(context.TODO(), c.secretName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		bundle, err := certs.Ensure(&certs.Bundle{}, hosts, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		klog.Infof("Creating serving certificate secret %s/%s", c.service.Namespace, c.secretName)
+		_, err = secrets.Create(context.TODO(), &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: c.service.Namespace,
+				Name:      c.secretName,
+				Labels:    map[string]string{managedByLabel: managedBy},
+			},
+			Type: corev1.SecretTypeOpaque,
+			Data: bundle.Data(),
+		}, metav1.CreateOptions{})
+		if errors.IsAlreadyExists(err) {
+			return c.winningBundle(hosts)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to create serving certificate secret: %v", err)
+		}
+		return bundle, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get serving certificate secret: %v", err)
+	}
+
+	current := certs.BundleFromData(secret.Data)
+	bundle, err := certs.Ensure(current, hosts, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if bundle != current {
+		klog.Infof("Renewing serving certificate in secret %s/%s", c.service.Namespace, c.secretName)
+		secret = secret.DeepCopy()
+		secret.Data = bundle.Data()
+		_, err := secrets.Update(context.TODO(), secret, metav1.UpdateOptions{})
+		if errors.IsConflict(err) {
+			return c.winningBundle(hosts)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to update serving certificate secret: %v", err)
+		}
+	}
+	return bundle, nil
+}
+
+// winningBundle returns the certificates which another replica wrote to the
+// Secret while this one tried to write its own.
+func (c *APIServiceController) winningBundle(hosts []string) (*certs.Bundle, error) {
+	secret, err := c.kubeClient.CoreV1().Secrets(c.service.Namespace).Get(context.TODO(), c.secretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get serving certificate secret: %v", err)
+	}
+	current := certs.BundleFromData(secret.Data)
+	bundle, err := certs.Ensure(current, hosts, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if bundle != current {
+		return nil, fmt.Errorf("serving certificate secret %s/%s written by another replica isn't valid", c.service.Namespace, c.secretName)
+	}
+	klog.Infof("Using the serving certificate another replica wrote to secret %s/%s", c.service.Namespace, c.secretName)
+	return bundle, nil
+}
+
+// versionPriority orders the versions of a group by their preference.
+func (c *APIServiceController) versionPriority(index int) int32 {
+	group := c.groupVersions[index].Group
+	priority := int32(0)
+	for _, groupVersion := range c.groupVersions[index:] {
+		if groupVersion.Group == group {
+			priority += 100
+		}
+	}
+	return priority
+}
+
+func (c *APIServiceController) syncAPIService(groupVersion schema.GroupVersion, versionPriority int32, caBundle []byte) error {
+	service := c.service
+	spec := apiregistrationv1.APIServiceSpec{
+		Service:              &service,
+		Group:                groupVersion.Group,
+		Version:              groupVersion.Version,
+		CABundle:             caBundle,
+		GroupPriorityMinimum: apiServiceGroupPriorityMinimum,
+		VersionPriority:      versionPriority,
+	}
+	name := groupVersion.Version + "." + groupVersion.Group
+	apiService, err := c.apiServices.Get(context.TODO(), name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		klog.Infof("Creating APIService %s", name)
+		_, err = c.apiServices.Create(context.TODO(), &apiregistrationv1.APIService{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{managedByLabel: managedBy}},
+			Spec:       spec,
+		}, metav1.CreateOptions{})
+		// another replica created it and the next sync checks its spec.
+		if err != nil && !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to create APIService %s: %v", name, err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get APIService %s: %v", name, err)
+	}
+	if equality.Semantic.DeepEqual(apiService.Spec, spec) && apiService.Labels[managedByLabel] == managedBy {
+		return nil
+	}
+	if other := apiService.Spec.Service; other != nil && (other.Namespace != service.Namespace || other.Name != service.Name) {
+		klog.Warningf("Taking over APIService %s from service %s/%s", name, other.Namespace, other.Name)
+	}
+	apiService = apiService.DeepCopy()
+	if apiService.Labels == nil {
+		apiService.Labels = make(map[string]string)
+	}
+	apiService.Labels[managedByLabel] = managedBy
+	apiService.Spec = spec
+	if _, err := c.apiServices.Update(context.TODO(), apiService, metav1.UpdateOptions{}); err != nil && !errors.IsConflict(err) {
+		return fmt.Errorf("failed to update APIService %s: %v", name, err)
+	}
+	return nil
+}
+
+// writeFileIfChanged replaces a file by renaming a temporary file, so that the
+// server never reads a partially written file.
+func writeFileIfChanged(path string, data []byte, perm os.FileMode) error {
+	if current, err := ioutil.ReadFile(path); err == nil && bytes.Equal(current, data) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
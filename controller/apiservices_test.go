@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"context"
+	"crypto/tls"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	apiregistrationv1 "k8s.io/kube-aggregator/pkg/apis/apiregistration/v1"
+	aggregatorfake "k8s.io/kube-aggregator/pkg/client/clientset_generated/clientset/fake"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/certs"
+)
+
+func TestAPIServiceControllerSync(t *testing.T) {
+	port := int32(443)
+	kubeClient := fake.NewSimpleClientset()
+	aggregatorClient := aggregatorfake.NewSimpleClientset(&apiregistrationv1.APIService{
+		ObjectMeta: metav1.ObjectMeta{Name: "v1beta1.external.metrics.k8s.io"},
+		Spec: apiregistrationv1.APIServiceSpec{
+			Service:               &apiregistrationv1.ServiceReference{Namespace: "keda", Name: "keda-metrics-apiserver"},
+			Group:                 "external.metrics.k8s.io",
+			Version:               "v1beta1",
+			InsecureSkipTLSVerify: true,
+		},
+	})
+	apiServices := aggregatorClient.ApiregistrationV1().APIServices()
+	certDir := t.TempDir()
+	c := NewAPIServiceController(kubeClient, apiServices,
+		apiregistrationv1.ServiceReference{Namespace: "custom-metrics", Name: "custom-metrics-router", Port: &port},
+		"serving-cert", certDir,
+		[]schema.GroupVersion{
+			{Group: "custom.metrics.k8s.io", Version: "v1beta1"},
+			{Group: "custom.metrics.k8s.io", Version: "v1beta2"},
+			{Group: "external.metrics.k8s.io", Version: "v1beta1"},
+		})
+
+	require.NoError(t, c.Sync())
+	secret, err := kubeClient.CoreV1().Secrets("custom-metrics").Get(context.Background(), "serving-cert", metav1.GetOptions{})
+	require.NoError(t, err)
+	bundle := certs.BundleFromData(secret.Data)
+	certFile, keyFile := c.CertFiles()
+	require.Equal(t, filepath.Join(certDir, "tls.crt"), certFile)
+	_, err = tls.LoadX509KeyPair(certFile, keyFile)
+	require.NoError(t, err)
+	written, err := ioutil.ReadFile(certFile)
+	require.NoError(t, err)
+	require.Equal(t, bundle.Cert, written)
+
+	for _, tc := range []struct {
+		name            string
+		versionPriority int32
+	}{
+		{name: "v1beta1.custom.metrics.k8s.io", versionPriority: 200},
+		{name: "v1beta2.custom.metrics.k8s.io", versionPriority: 100},
+		{name: "v1beta1.external.metrics.k8s.io", versionPriority: 100},
+	} {
+		apiService, err := apiServices.Get(context.Background(), tc.name, metav1.GetOptions{})
+		require.NoError(t, err)
+		require.Equal(t, "custom-metrics-router", apiService.Spec.Service.Name)
+		require.Equal(t, bundle.CACert, apiService.Spec.CABundle)
+		require.False(t, apiService.Spec.InsecureSkipTLSVerify)
+		require.Equal(t, tc.versionPriority, apiService.Spec.VersionPriority)
+		require.Equal(t, managedBy, apiService.Labels[managedByLabel])
+	}
+
+	// certificates which are still valid are kept.
+	require.NoError(t, c.Sync())
+	secret, err = kubeClient.CoreV1().Secrets("custom-metrics").Get(context.Background(), "serving-cert", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, bundle, certs.BundleFromData(secret.Data))
+}
+
+func TestAPIServiceControllerSecretRace(t *testing.T) {
+	port := int32(443)
+	service := apiregistrationv1.ServiceReference{Namespace: "custom-metrics", Name: "custom-metrics-router", Port: &port}
+	groupVersions := []schema.GroupVersion{{Group: "custom.metrics.k8s.io", Version: "v1beta1"}}
+	kubeClient := fake.NewSimpleClientset()
+	aggregatorClient := aggregatorfake.NewSimpleClientset()
+
+	// another replica creates the secret after this one found none.
+	winner := NewAPIServiceController(kubeClient, aggregatorClient.ApiregistrationV1().APIServices(), service, "serving-cert", t.TempDir(), groupVersions)
+	require.NoError(t, winner.Sync())
+	hidden := false
+	kubeClient.PrependReactor("get", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if hidden {
+			return false, nil, nil
+		}
+		hidden = true
+		return true, nil, apierrors.NewNotFound(corev1.Resource("secrets"), "serving-cert")
+	})
+	loser := NewAPIServiceController(kubeClient, aggregatorClient.ApiregistrationV1().APIServices(), service, "serving-cert", t.TempDir(), groupVersions)
+	require.NoError(t, loser.Sync())
+
+	winnerCert, _ := winner.CertFiles()
+	loserCert, _ := loser.CertFiles()
+	expected, err := ioutil.ReadFile(winnerCert)
+	require.NoError(t, err)
+	written, err := ioutil.ReadFile(loserCert)
+	require.NoError(t, err)
+	require.Equal(t, expected, written)
+}
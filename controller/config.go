@@ -0,0 +1,154 @@
+package controller
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/backend"
+	"github.com/arjunrn/custom-metrics-router/pkg/config"
+	"github.com/arjunrn/custom-metrics-router/pkg/routes"
+	"github.com/arjunrn/custom-metrics-router/pkg/snapshot"
+)
+
+// ConfigController keeps the routes up to date with a routing config file
+// instead of CustomMetricsSource and MetricRoute objects. The file is polled
+// rather than watched, because the kubelet updates ConfigMap volumes by
+// swapping a symlink.
+type ConfigController struct {
+	syncState
+	path              string
+	clientSet         kubernetes.Interface
+	customRoutes      *routes.Routes
+	reloadInterval    time.Duration
+	namespaceInformer cache.SharedIndexInformer
+	recorder          *snapshot.Recorder
+	deps              backend.Dependencies
+
+	// data is the content of the file when it was last read and config is
+	// the last valid config. They differ while the file is invalid.
+	data     []byte
+	config   *config.Config
+	lastSync time.Time
+}
+
+func NewConfigController(path string, clientSet kubernetes.Interface, customRoutes *routes.Routes, recorder *snapshot.Recorder, deps backend.Dependencies, reloadInterval time.Duration) *ConfigController {
+	namespaceInformer := informers.NewSharedInformerFactory(clientSet, time.Minute).Core().V1().Namespaces()
+	customRoutes.SetNamespaceLister(namespaceInformer.Lister())
+	return &ConfigController{
+		path:              path,
+		clientSet:         clientSet,
+		customRoutes:      customRoutes,
+		reloadInterval:    reloadInterval,
+		namespaceInformer: namespaceInformer.Informer(),
+		recorder:          recorder,
+		deps:              deps,
+	}
+}
+
+// Load reads and applies the config. It is called before Run, so that the
+// router doesn't start with an invalid config.
+func (c *ConfigController) Load() error {
+	data, err := ioutil.ReadFile(c.path)
+	if err != nil {
+		return fmt.Errorf("failed to read routing config: %v", err)
+	}
+	routingConfig, err := config.Parse(data)
+	if err != nil {
+		return fmt.Errorf("invalid routing config %s: %v", c.path, err)
+	}
+	c.data = data
+	c.apply(routingConfig)
+	// sources which were removed from the config while the router was down
+	// are removed from the snapshot.
+	services := make(map[types.NamespacedName]struct{}, len(routingConfig.Sources))
+	for _, source := range routingConfig.Sources {
+		service := source.Backend.Service
+		services[types.NamespacedName{Namespace: service.Namespace, Name: service.Name}] = struct{}{}
+	}
+	pruneSnapshot(c.customRoutes, c.recorder, RoutingConfigOwner, services)
+	return nil
+}
+
+func (c *ConfigController) Run(stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+	go c.namespaceInformer.Run(stopCh)
+	klog.Infof("Starting routing config controller")
+	defer klog.Infof("Shutting down routing config controller")
+
+	if !cache.WaitForNamedCacheSync("routing-config", stopCh, c.namespaceInformer.HasSynced) {
+		return
+	}
+	// the sources were discovered by Load.
+	c.setSynced(nil)
+
+	wait.Until(c.reload, c.reloadInterval, stopCh)
+}
+
+// reload applies the config when the file changed and discovers the metrics
+// of the sources again once the resync period passed. An invalid config is
+// logged and the previous config stays in place until the file is fixed.
+func (c *ConfigController) reload() {
+	c.startProcessing()
+	defer c.doneProcessing()
+	data, err := ioutil.ReadFile(c.path)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("failed to read routing config: %v", err))
+		return
+	}
+	if !bytes.Equal(data, c.data) {
+		c.data = data
+		routingConfig, err := config.Parse(data)
+		if err != nil {
+			utilruntime.HandleError(fmt.Errorf("keeping the previous routing config, %s is invalid: %v", c.path, err))
+			return
+		}
+		klog.Infof("Routing config %s changed", c.path)
+		c.apply(routingConfig)
+		return
+	}
+	if c.config != nil && time.Since(c.lastSync) >= c.config.ResyncPeriod.Duration {
+		c.apply(c.config)
+	}
+}
+
+// apply replaces the sources and metric routes of the previous config. Sources
+// which fail discovery keep their previous routes and are retried with the
+// next resync.
+func (c *ConfigController) apply(routingConfig *config.Config) {
+	sources := routingConfig.CustomMetricsSources()
+	services := make(map[types.NamespacedName]struct{}, len(sources))
+	for _, source := range sources {
+		service := source.Spec.Backend.Service
+		services[types.NamespacedName{Namespace: service.Namespace, Name: service.Name}] = struct{}{}
+	}
+	if c.config != nil {
+		for _, source := range c.config.Sources {
+			service := source.Backend.Service
+			if _, ok := services[types.NamespacedName{Namespace: service.Namespace, Name: service.Name}]; !ok {
+				klog.Infof("Custom Metrics Source %s has been removed from the routing config", source.Name)
+				c.customRoutes.RemoveService(service.Name, service.Namespace)
+				c.recorder.Forget(service.Namespace, service.Name)
+			}
+		}
+	}
+	for _, source := range sources {
+		if err := addSource(c.deps, c.customRoutes, c.recorder, RoutingConfigOwner, source); err != nil {
+			utilruntime.HandleError(fmt.Errorf("failed to update routes of custom metrics source %s: %v", source.Name, err))
+		}
+	}
+	if err := c.customRoutes.SetMetricRoutes(routingConfig.MetricRouteObjects()); err != nil {
+		utilruntime.HandleError(err)
+	}
+	c.config = routingConfig
+	c.lastSync = time.Now()
+}
@@ -0,0 +1,66 @@
+package controller
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/kubernetes-sigs/custom-metrics-apiserver/pkg/provider"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/backend"
+	"github.com/arjunrn/custom-metrics-router/pkg/routes"
+)
+
+func testRoutingConfig(metrics ...string) string {
+	config := `
+defaultAuthentication:
+  mode: None
+sources:
+- name: static
+  backend:
+    service: {namespace: monitoring, name: static, port: 443}
+  routing:
+    priority: 1
+    metricTypes: [ExternalMetrics]
+  staticMetrics:
+    disableDiscovery: true
+    externalMetrics:`
+	for _, metric := range metrics {
+		config += "\n    - " + metric
+	}
+	return config
+}
+
+func TestConfigControllerReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(config string) {
+		require.NoError(t, ioutil.WriteFile(path, []byte(config), 0644))
+	}
+	customRoutes := routes.New(nil)
+	kubeClient := fake.NewSimpleClientset()
+	c := NewConfigController(path, kubeClient, customRoutes, nil, backend.Dependencies{KubeClient: kubeClient}, 0)
+
+	write("sources: [")
+	require.Error(t, c.Load())
+
+	write(testRoutingConfig("queue_depth"))
+	require.NoError(t, c.Load())
+	require.Equal(t, []provider.ExternalMetricInfo{{Metric: "queue_depth"}}, customRoutes.ListAllExternalMetrics())
+
+	write(testRoutingConfig("stream_lag"))
+	c.reload()
+	require.Equal(t, []provider.ExternalMetricInfo{{Metric: "stream_lag"}}, customRoutes.ListAllExternalMetrics())
+
+	// an invalid config keeps the routes of the previous one.
+	write(testRoutingConfig("stream_lag") + "\n    unknown: field")
+	c.reload()
+	require.Equal(t, []provider.ExternalMetricInfo{{Metric: "stream_lag"}}, customRoutes.ListAllExternalMetrics())
+	c.reload()
+	require.Equal(t, []provider.ExternalMetricInfo{{Metric: "stream_lag"}}, customRoutes.ListAllExternalMetrics())
+
+	write("sources: []")
+	c.reload()
+	require.Empty(t, customRoutes.ListAllExternalMetrics())
+}
@@ -5,38 +5,47 @@ import (
 	"time"
 
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog"
 
-	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1alpha1"
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+	"github.com/arjunrn/custom-metrics-router/pkg/backend"
 	"github.com/arjunrn/custom-metrics-router/pkg/client/informers/externalversions"
-	alpha1 "github.com/arjunrn/custom-metrics-router/pkg/client/informers/externalversions/metricsrouter.io/v1alpha1"
-	mrLister "github.com/arjunrn/custom-metrics-router/pkg/client/listers/metricsrouter.io/v1alpha1"
+	beta1 "github.com/arjunrn/custom-metrics-router/pkg/client/informers/externalversions/metricsrouter.io/v1beta1"
+	mrLister "github.com/arjunrn/custom-metrics-router/pkg/client/listers/metricsrouter.io/v1beta1"
 	"github.com/arjunrn/custom-metrics-router/pkg/clientset"
 	"github.com/arjunrn/custom-metrics-router/pkg/routes"
+	"github.com/arjunrn/custom-metrics-router/pkg/snapshot"
 )
 
 type Controller struct {
+	syncState
 	clientSet              clientset.Interface
 	customRoutes           *routes.Routes
 	queue                  workqueue.RateLimitingInterface
 	informer               cache.SharedIndexInformer
 	customMetricsLister    mrLister.CustomMetricsSourceLister
 	customMetricsHasSynced func() bool
-	customMetricsInformer  alpha1.CustomMetricsSourceInformer
+	customMetricsInformer  beta1.CustomMetricsSourceInformer
+	recorder               *snapshot.Recorder
+	deps                   backend.Dependencies
 }
 
-func NewController(clientSet clientset.Interface, customRoutes *routes.Routes) *Controller {
+func NewController(clientSet clientset.Interface, customRoutes *routes.Routes, recorder *snapshot.Recorder, deps backend.Dependencies) *Controller {
 	factory := externalversions.NewSharedInformerFactory(clientSet, time.Minute)
-	customMetricsInformer := factory.Metricsrouter().V1alpha1().CustomMetricsSources()
+	customMetricsInformer := factory.Metricsrouter().V1beta1().CustomMetricsSources()
 	controller := &Controller{
 		customRoutes: customRoutes,
 		clientSet:    clientSet,
 		queue:        workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "metricsrouter"),
 		informer:     customMetricsInformer.Informer(),
+		recorder:     recorder,
+		deps:         deps,
 	}
 	customMetricsInformer.Informer().AddEventHandlerWithResyncPeriod(cache.ResourceEventHandlerFuncs{
 		AddFunc: controller.enqueueRoute,
@@ -63,6 +72,21 @@ func (c *Controller) Run(stopCh <-chan struct{}) {
 	if !cache.WaitForNamedCacheSync("metrics-router", stopCh, c.customMetricsHasSynced) {
 		return
 	}
+	sources, err := c.customMetricsLister.List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("failed to list custom metrics sources: %v", err))
+	}
+	var keys []string
+	services := make(map[types.NamespacedName]struct{}, len(sources))
+	for _, source := range sources {
+		keys = append(keys, source.Name)
+		service := source.Spec.Backend.Service
+		services[types.NamespacedName{Namespace: service.Namespace, Name: service.Name}] = struct{}{}
+	}
+	// sources which were deleted while the router was down are removed from
+	// the snapshot. The others are replaced once they are discovered.
+	pruneSnapshot(c.customRoutes, c.recorder, SourcesOwner, services)
+	c.setSynced(keys)
 
 	// start a single worker (we may wish to start more in the future)
 	go wait.Until(c.worker, time.Second, stopCh)
@@ -79,33 +103,32 @@ func (c *Controller) enqueueRoute(obj interface{}) {
 }
 
 func (c *Controller) deleteRoute(obj interface{}) {
-	provider := obj.(*v1alpha1.CustomMetricsSource)
-	c.customRoutes.RemoveService(provider.Name, provider.Namespace)
+	provider := obj.(*v1beta1.CustomMetricsSource)
+	service := provider.Spec.Backend.Service
+	c.customRoutes.RemoveService(service.Name, service.Namespace)
+	c.recorder.Forget(service.Namespace, service.Name)
 	c.queue.Forget(obj)
 }
 
-func (c *Controller) updateRoutes(provider *v1alpha1.CustomMetricsSource) error {
-	var (
-		customMetrics, externalMetrics bool
-	)
-	for _, metricType := range provider.Spec.MetricTypes {
-		if metricType == v1alpha1.CustomMetricsType {
-			customMetrics = true
-		}
-		if metricType == v1alpha1.ExternalMetricsType {
-			externalMetrics = true
-		}
+func (c *Controller) updateRoutes(provider *v1beta1.CustomMetricsSource) error {
+	return addSource(c.deps, c.customRoutes, c.recorder, SourcesOwner, provider)
+}
+
+// addSource discovers the metrics of a source and adds them to the routes.
+// The discovered metrics are recorded in the snapshot of the owner.
+func addSource(deps backend.Dependencies, customRoutes *routes.Routes, recorder *snapshot.Recorder, owner string, source *v1beta1.CustomMetricsSource) error {
+	metricsBackend, err := backend.New(source, deps)
+	if err != nil {
+		return err
+	}
+	service := source.Spec.Backend.Service
+	if err := customRoutes.AddService(service.Name, service.Namespace, metricsBackend, routes.SourceRouting(source)); err != nil {
+		return err
+	}
+	if customMetricInfos, externalMetricInfos, resourceMetrics, ok := customRoutes.ServiceMetrics(service.Name, service.Namespace); ok {
+		recorder.Record(owner, source, customMetricInfos, externalMetricInfos, resourceMetrics)
 	}
-	return c.customRoutes.AddService(
-		provider.Spec.Service.Name,
-		provider.Spec.Service.Namespace,
-		provider.Spec.Service.Port,
-		provider.Spec.Priority,
-		provider.Spec.InsecureSkipTLSVerify,
-		provider.ObjectMeta.CreationTimestamp.Time,
-		customMetrics,
-		externalMetrics,
-	)
+	return nil
 }
 
 func (c *Controller) worker() {
@@ -120,10 +143,14 @@ func (c *Controller) processNextWorkItem() bool {
 		return false
 	}
 	defer c.queue.Done(key)
+	c.startProcessing()
+	defer c.doneProcessing()
 
 	deleted, err := c.reconcileKey(key.(string))
 	if err != nil {
 		utilruntime.HandleError(err)
+	} else {
+		c.processed(key.(string))
 	}
 	if !deleted {
 		c.queue.AddRateLimited(key)
@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"k8s.io/apiserver/pkg/server/healthz"
+)
+
+// Readiness is implemented by the controllers which need to catch up before
+// the router routes all metrics.
+type Readiness interface {
+	// Synced is true once the informers of the controller are synced.
+	Synced() bool
+	// Pending returns the number of items which existed when the informers
+	// were synced and weren't processed successfully yet.
+	Pending() int
+}
+
+// Liveness is implemented by the controllers with a worker.
+type Liveness interface {
+	// ProcessingSince returns when the worker started processing its
+	// current item. It is zero while the worker waits for items.
+	ProcessingSince() time.Time
+}
+
+// ReadyzCheck fails until the controller is synced and processed the items
+// which existed at that time. Items which still fail after the timeout no
+// longer delay the readiness, so that a broken backend doesn't keep the
+// router from serving the others.
+func ReadyzCheck(name string, timeout time.Duration, controller Readiness) healthz.HealthChecker {
+	start := time.Now()
+	return healthz.NamedCheck(name, func(*http.Request) error {
+		if !controller.Synced() {
+			return fmt.Errorf("informers not synced")
+		}
+		if pending := controller.Pending(); pending > 0 && time.Since(start) < timeout {
+			return fmt.Errorf("%d items not processed yet", pending)
+		}
+		return nil
+	})
+}
+
+// LivezCheck fails when the worker of the controller is stuck on an item for
+// longer than the threshold.
+func LivezCheck(name string, threshold time.Duration, controller Liveness) healthz.HealthChecker {
+	return healthz.NamedCheck(name, func(*http.Request) error {
+		since := controller.ProcessingSince()
+		if !since.IsZero() && time.Since(since) > threshold {
+			return fmt.Errorf("worker processing an item since %s", since.Format(time.RFC3339))
+		}
+		return nil
+	})
+}
+
+// syncState implements Readiness and Liveness for the controllers.
+type syncState struct {
+	lock            sync.Mutex
+	synced          bool
+	pending         map[string]struct{}
+	processingSince time.Time
+}
+
+func (s *syncState) setSynced(keys []string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.synced = true
+	s.pending = make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		s.pending[key] = struct{}{}
+	}
+}
+
+func (s *syncState) processed(key string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.pending, key)
+}
+
+func (s *syncState) startProcessing() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.processingSince = time.Now()
+}
+
+func (s *syncState) doneProcessing() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.processingSince = time.Time{}
+}
+
+func (s *syncState) Synced() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.synced
+}
+
+func (s *syncState) Pending() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return len(s.pending)
+}
+
+func (s *syncState) ProcessingSince() time.Time {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.processingSince
+}
@@ -0,0 +1,40 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadyzCheck(t *testing.T) {
+	state := &syncState{}
+	check := ReadyzCheck("sources", time.Hour, state)
+	require.Equal(t, "sources", check.Name())
+	require.Error(t, check.Check(nil))
+
+	state.setSynced([]string{"prometheus", "keda"})
+	require.Error(t, check.Check(nil))
+	state.processed("prometheus")
+	require.Error(t, check.Check(nil))
+	state.processed("keda")
+	require.NoError(t, check.Check(nil))
+
+	// sources which aren't discovered in time don't delay the readiness.
+	state.setSynced([]string{"prometheus"})
+	require.NoError(t, ReadyzCheck("sources", 0, state).Check(nil))
+	require.Error(t, ReadyzCheck("sources", 0, &syncState{}).Check(nil))
+}
+
+func TestLivezCheck(t *testing.T) {
+	state := &syncState{}
+	check := LivezCheck("sources", time.Minute, state)
+	require.NoError(t, check.Check(nil))
+
+	state.startProcessing()
+	require.NoError(t, check.Check(nil))
+	state.processingSince = time.Now().Add(-2 * time.Minute)
+	require.Error(t, check.Check(nil))
+	state.doneProcessing()
+	require.NoError(t, check.Check(nil))
+}
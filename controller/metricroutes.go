@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/client/informers/externalversions"
+	mrLister "github.com/arjunrn/custom-metrics-router/pkg/client/listers/metricsrouter.io/v1beta1"
+	"github.com/arjunrn/custom-metrics-router/pkg/clientset"
+	"github.com/arjunrn/custom-metrics-router/pkg/routes"
+)
+
+// metricRoutesKey is the only key of the queue. All MetricRoutes are applied
+// to the routes together, so changes to several of them are coalesced.
+const metricRoutesKey = "metricroutes"
+
+// MetricRouteController keeps the MetricRoutes of the routes up to date.
+type MetricRouteController struct {
+	syncState
+	customRoutes          *routes.Routes
+	queue                 workqueue.RateLimitingInterface
+	metricRouteInformer   cache.SharedIndexInformer
+	metricRouteLister     mrLister.MetricRouteLister
+	namespaceInformer     cache.SharedIndexInformer
+	metricRoutesHasSynced func() bool
+}
+
+func NewMetricRouteController(clientSet clientset.Interface, customRoutes *routes.Routes) *MetricRouteController {
+	factory := externalversions.NewSharedInformerFactory(clientSet, time.Minute)
+	metricRouteInformer := factory.Metricsrouter().V1beta1().MetricRoutes()
+	namespaceInformer := informers.NewSharedInformerFactory(clientSet, time.Minute).Core().V1().Namespaces()
+	controller := &MetricRouteController{
+		customRoutes:          customRoutes,
+		queue:                 workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "metricroutes"),
+		metricRouteInformer:   metricRouteInformer.Informer(),
+		metricRouteLister:     metricRouteInformer.Lister(),
+		namespaceInformer:     namespaceInformer.Informer(),
+		metricRoutesHasSynced: metricRouteInformer.Informer().HasSynced,
+	}
+	enqueue := func(interface{}) { controller.queue.Add(metricRoutesKey) }
+	metricRouteInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: enqueue,
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			enqueue(newObj)
+		},
+		DeleteFunc: enqueue,
+	})
+	customRoutes.SetNamespaceLister(namespaceInformer.Lister())
+	return controller
+}
+
+func (c *MetricRouteController) Run(stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+	defer c.queue.ShutDown()
+	go c.metricRouteInformer.Run(stopCh)
+	go c.namespaceInformer.Run(stopCh)
+	klog.Infof("Starting metric route controller")
+	defer klog.Infof("Shutting down metric route controller")
+
+	if !cache.WaitForNamedCacheSync("metric-routes", stopCh, c.metricRoutesHasSynced, c.namespaceInformer.HasSynced) {
+		return
+	}
+	c.setSynced([]string{metricRoutesKey})
+
+	go wait.Until(c.worker, time.Second, stopCh)
+	<-stopCh
+}
+
+func (c *MetricRouteController) worker() {
+	for c.processNextWorkItem() {
+	}
+}
+
+func (c *MetricRouteController) processNextWorkItem() bool {
+	key, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	defer c.queue.Done(key)
+	c.startProcessing()
+	defer c.doneProcessing()
+
+	metricRoutes, err := c.metricRouteLister.List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("failed to list metric routes: %v", err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	// invalid routes don't get better by retrying, they are skipped until
+	// they are updated.
+	if err := c.customRoutes.SetMetricRoutes(metricRoutes); err != nil {
+		utilruntime.HandleError(err)
+	}
+	c.processed(metricRoutesKey)
+	c.queue.Forget(key)
+	return true
+}
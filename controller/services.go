@@ -0,0 +1,305 @@
+package controller
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+	"github.com/arjunrn/custom-metrics-router/pkg/backend"
+	"github.com/arjunrn/custom-metrics-router/pkg/client/informers/externalversions"
+	mrLister "github.com/arjunrn/custom-metrics-router/pkg/client/listers/metricsrouter.io/v1beta1"
+	"github.com/arjunrn/custom-metrics-router/pkg/clientset"
+	"github.com/arjunrn/custom-metrics-router/pkg/routes"
+	"github.com/arjunrn/custom-metrics-router/pkg/snapshot"
+	"github.com/arjunrn/custom-metrics-router/pkg/validation"
+)
+
+const (
+	// MetricTypesAnnotation registers a Service as a source for a comma
+	// separated list of metric types, e.g. "CustomMetrics,ExternalMetrics".
+	MetricTypesAnnotation = "metricsrouter.io/metric-types"
+	// PriorityAnnotation is the routing priority of the Service. Defaults to 0.
+	PriorityAnnotation = "metricsrouter.io/priority"
+	// PortAnnotation is the port of the metrics API. It can be omitted for
+	// Services with a single port.
+	PortAnnotation = "metricsrouter.io/port"
+)
+
+// ServiceController registers Services with the MetricTypesAnnotation as if a
+// CustomMetricsSource existed for them. A CustomMetricsSource for the same
+// Service takes precedence over the annotations. Only Services in the allowed
+// namespaces are registered, and their priority is at least minPriority, so
+// that they can't take over the metrics of other sources.
+type ServiceController struct {
+	syncState
+	clientSet             clientset.Interface
+	customRoutes          *routes.Routes
+	queue                 workqueue.RateLimitingInterface
+	serviceInformer       cache.SharedIndexInformer
+	serviceLister         corelisters.ServiceLister
+	customMetricsInformer cache.SharedIndexInformer
+	customMetricsLister   mrLister.CustomMetricsSourceLister
+	recorder              *snapshot.Recorder
+	deps                  backend.Dependencies
+	namespaces            map[string]struct{}
+	minPriority           int
+
+	// registered are the keys of the Services which were added from their
+	// annotations or seeded from the snapshot. It is only accessed by the
+	// worker once it started.
+	registered map[string]struct{}
+}
+
+func NewServiceController(clientSet clientset.Interface, customRoutes *routes.Routes, recorder *snapshot.Recorder, deps backend.Dependencies, namespaces []string, minPriority int) *ServiceController {
+	serviceInformer := informers.NewSharedInformerFactory(clientSet, time.Minute).Core().V1().Services()
+	customMetricsInformer := externalversions.NewSharedInformerFactory(clientSet, time.Minute).Metricsrouter().V1beta1().CustomMetricsSources()
+	controller := &ServiceController{
+		clientSet:             clientSet,
+		customRoutes:          customRoutes,
+		queue:                 workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "annotatedservices"),
+		serviceInformer:       serviceInformer.Informer(),
+		serviceLister:         serviceInformer.Lister(),
+		customMetricsInformer: customMetricsInformer.Informer(),
+		customMetricsLister:   customMetricsInformer.Lister(),
+		recorder:              recorder,
+		deps:                  deps,
+		namespaces:            make(map[string]struct{}, len(namespaces)),
+		minPriority:           minPriority,
+		registered:            make(map[string]struct{}),
+	}
+	for _, namespace := range namespaces {
+		controller.namespaces[namespace] = struct{}{}
+	}
+	serviceInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: controller.enqueueService,
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			controller.enqueueService(newObj)
+		},
+		DeleteFunc: controller.enqueueService,
+	})
+	// the Service of a source is reconciled when the source changes, so that
+	// the annotations are used again once the source is deleted.
+	customMetricsInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: controller.enqueueSourceService,
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			controller.enqueueSourceService(oldObj)
+			controller.enqueueSourceService(newObj)
+		},
+		DeleteFunc: controller.enqueueSourceService,
+	})
+	return controller
+}
+
+func (c *ServiceController) Run(stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+	defer c.queue.ShutDown()
+	go c.serviceInformer.Run(stopCh)
+	go c.customMetricsInformer.Run(stopCh)
+	klog.Infof("Starting annotated service controller")
+	defer klog.Infof("Shutting down annotated service controller")
+
+	if !cache.WaitForNamedCacheSync("annotated-services", stopCh, c.serviceInformer.HasSynced, c.customMetricsInformer.HasSynced) {
+		return
+	}
+	services, err := c.serviceLister.List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("failed to list services: %v", err))
+	}
+	var keys []string
+	for _, service := range services {
+		if _, ok := service.Annotations[MetricTypesAnnotation]; ok && c.allowed(service.Namespace) {
+			keys = append(keys, service.Namespace+"/"+service.Name)
+		}
+	}
+	c.setSynced(keys)
+	// the Services of the snapshot are reconciled like registered Services, so
+	// that they are removed when they lost their annotations meanwhile.
+	for _, source := range c.recorder.Sources(AnnotatedServicesOwner) {
+		key := source.Spec.Backend.Service.Namespace + "/" + source.Spec.Backend.Service.Name
+		c.registered[key] = struct{}{}
+		c.queue.Add(key)
+	}
+
+	go wait.Until(c.worker, time.Second, stopCh)
+	<-stopCh
+}
+
+func (c *ServiceController) enqueueService(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("unable to get key for object %+v: %v", obj, err))
+		return
+	}
+	c.queue.Add(key)
+}
+
+func (c *ServiceController) enqueueSourceService(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	source, ok := obj.(*v1beta1.CustomMetricsSource)
+	if !ok {
+		return
+	}
+	service := source.Spec.Backend.Service
+	c.queue.Add(service.Namespace + "/" + service.Name)
+}
+
+func (c *ServiceController) worker() {
+	for c.processNextWorkItem() {
+	}
+}
+
+func (c *ServiceController) processNextWorkItem() bool {
+	key, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	defer c.queue.Done(key)
+	c.startProcessing()
+	defer c.doneProcessing()
+
+	if err := c.reconcileKey(key.(string)); err != nil {
+		utilruntime.HandleError(err)
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.processed(key.(string))
+	c.queue.Forget(key)
+	return true
+}
+
+func (c *ServiceController) reconcileKey(key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return err
+	}
+	explicit, err := c.hasSource(namespace, name)
+	if err != nil {
+		return err
+	}
+	if explicit {
+		// the routes of the Service now belong to the source.
+		delete(c.registered, key)
+		return nil
+	}
+
+	service, err := c.serviceLister.Services(namespace).Get(name)
+	if errors.IsNotFound(err) {
+		c.unregister(key, namespace, name)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if _, ok := service.Annotations[MetricTypesAnnotation]; !ok || !c.allowed(namespace) {
+		c.unregister(key, namespace, name)
+		return nil
+	}
+	source, err := sourceForService(service, c.minPriority)
+	if err != nil {
+		// invalid annotations don't get better by retrying.
+		utilruntime.HandleError(fmt.Errorf("invalid metrics router annotations on service %s: %v", key, err))
+		c.unregister(key, namespace, name)
+		return nil
+	}
+	if err := addSource(c.deps, c.customRoutes, c.recorder, AnnotatedServicesOwner, source); err != nil {
+		return err
+	}
+	c.registered[key] = struct{}{}
+	return nil
+}
+
+func (c *ServiceController) allowed(namespace string) bool {
+	_, ok := c.namespaces[namespace]
+	return ok
+}
+
+func (c *ServiceController) hasSource(namespace, name string) (bool, error) {
+	sources, err := c.customMetricsLister.List(labels.Everything())
+	if err != nil {
+		return false, err
+	}
+	for _, source := range sources {
+		service := source.Spec.Backend.Service
+		if service.Namespace == namespace && service.Name == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (c *ServiceController) unregister(key, namespace, name string) {
+	if _, ok := c.registered[key]; !ok {
+		return
+	}
+	klog.Infof("Service %s is no longer a custom metrics source", key)
+	c.customRoutes.RemoveService(name, namespace)
+	c.recorder.Forget(namespace, name)
+	delete(c.registered, key)
+}
+
+// sourceForService returns the source described by the annotations of a
+// Service. The source is named <service>.<namespace>, which is also the
+// resource used to authorize requests for its metrics. Lower priorities than
+// minPriority are raised to it, and the router doesn't send credentials to the
+// backend, because anyone who may annotate the Service controls it.
+func sourceForService(service *corev1.Service, minPriority int) (*v1beta1.CustomMetricsSource, error) {
+	source := &v1beta1.CustomMetricsSource{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              service.Name + "." + service.Namespace,
+			CreationTimestamp: service.CreationTimestamp,
+		},
+		Spec: v1beta1.CustomMetricsSourceSpec{
+			Backend: v1beta1.Backend{
+				Service:        v1beta1.ServiceReference{Namespace: service.Namespace, Name: service.Name},
+				Authentication: &v1beta1.Authentication{Mode: v1beta1.NoAuthentication},
+			},
+			Routing: v1beta1.Routing{Priority: minPriority},
+		},
+	}
+	for _, metricType := range strings.Split(service.Annotations[MetricTypesAnnotation], ",") {
+		if metricType = strings.TrimSpace(metricType); metricType != "" {
+			source.Spec.Routing.MetricTypes = append(source.Spec.Routing.MetricTypes, v1beta1.MetricType(metricType))
+		}
+	}
+	if value, ok := service.Annotations[PriorityAnnotation]; ok {
+		priority, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s annotation %q: %v", PriorityAnnotation, value, err)
+		}
+		if priority > minPriority {
+			source.Spec.Routing.Priority = priority
+		}
+	}
+	if value, ok := service.Annotations[PortAnnotation]; ok {
+		port, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s annotation %q: %v", PortAnnotation, value, err)
+		}
+		source.Spec.Backend.Service.Port = int32(port)
+	} else if len(service.Spec.Ports) == 1 {
+		source.Spec.Backend.Service.Port = service.Spec.Ports[0].Port
+	} else {
+		return nil, fmt.Errorf("%s annotation is required for services with %d ports", PortAnnotation, len(service.Spec.Ports))
+	}
+	if allErrs := validation.ValidateCustomMetricsSourceSpec(&source.Spec, field.NewPath("spec")); len(allErrs) > 0 {
+		return nil, allErrs.ToAggregate()
+	}
+	return source, nil
+}
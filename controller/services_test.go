@@ -0,0 +1,164 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+	mrLister "github.com/arjunrn/custom-metrics-router/pkg/client/listers/metricsrouter.io/v1beta1"
+	"github.com/arjunrn/custom-metrics-router/pkg/routes"
+)
+
+func testService(annotations map[string]string, ports ...int32) *corev1.Service {
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "monitoring", Name: "prometheus-adapter", Annotations: annotations},
+	}
+	for _, port := range ports {
+		service.Spec.Ports = append(service.Spec.Ports, corev1.ServicePort{Port: port})
+	}
+	return service
+}
+
+func TestSourceForService(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		service       *corev1.Service
+		minPriority   int
+		expected      v1beta1.CustomMetricsSourceSpec
+		expectedError bool
+	}{
+		{
+			name: "single port",
+			service: testService(map[string]string{
+				MetricTypesAnnotation: "CustomMetrics, ExternalMetrics",
+				PriorityAnnotation:    "100",
+			}, 6443),
+			expected: v1beta1.CustomMetricsSourceSpec{
+				Backend: v1beta1.Backend{
+					Service:        v1beta1.ServiceReference{Namespace: "monitoring", Name: "prometheus-adapter", Port: 6443},
+					Authentication: &v1beta1.Authentication{Mode: v1beta1.NoAuthentication},
+				},
+				Routing: v1beta1.Routing{Priority: 100, MetricTypes: []v1beta1.MetricType{v1beta1.CustomMetricsType, v1beta1.ExternalMetricsType}},
+			},
+		},
+		{
+			name: "priority below the minimum",
+			service: testService(map[string]string{
+				MetricTypesAnnotation: "ExternalMetrics",
+				PriorityAnnotation:    "-10",
+			}, 443),
+			minPriority: 1000,
+			expected: v1beta1.CustomMetricsSourceSpec{
+				Backend: v1beta1.Backend{
+					Service:        v1beta1.ServiceReference{Namespace: "monitoring", Name: "prometheus-adapter", Port: 443},
+					Authentication: &v1beta1.Authentication{Mode: v1beta1.NoAuthentication},
+				},
+				Routing: v1beta1.Routing{Priority: 1000, MetricTypes: []v1beta1.MetricType{v1beta1.ExternalMetricsType}},
+			},
+		},
+		{
+			name: "port annotation",
+			service: testService(map[string]string{
+				MetricTypesAnnotation: "ExternalMetrics",
+				PortAnnotation:        "443",
+			}, 80, 443),
+			expected: v1beta1.CustomMetricsSourceSpec{
+				Backend: v1beta1.Backend{
+					Service:        v1beta1.ServiceReference{Namespace: "monitoring", Name: "prometheus-adapter", Port: 443},
+					Authentication: &v1beta1.Authentication{Mode: v1beta1.NoAuthentication},
+				},
+				Routing: v1beta1.Routing{MetricTypes: []v1beta1.MetricType{v1beta1.ExternalMetricsType}},
+			},
+		},
+		{
+			name:          "several ports without port annotation",
+			service:       testService(map[string]string{MetricTypesAnnotation: "ExternalMetrics"}, 80, 443),
+			expectedError: true,
+		},
+		{
+			name:          "invalid priority",
+			service:       testService(map[string]string{MetricTypesAnnotation: "ExternalMetrics", PriorityAnnotation: "high"}, 443),
+			expectedError: true,
+		},
+		{
+			name:          "invalid port",
+			service:       testService(map[string]string{MetricTypesAnnotation: "ExternalMetrics", PortAnnotation: "70000"}, 443),
+			expectedError: true,
+		},
+		{
+			name:          "unknown metric type",
+			service:       testService(map[string]string{MetricTypesAnnotation: "Custom"}, 443),
+			expectedError: true,
+		},
+		{
+			name:          "no metric types",
+			service:       testService(map[string]string{MetricTypesAnnotation: ""}, 443),
+			expectedError: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			source, err := sourceForService(tc.service, tc.minPriority)
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "prometheus-adapter.monitoring", source.Name)
+			require.Equal(t, tc.expected, source.Spec)
+		})
+	}
+}
+
+func TestServiceControllerPrecedence(t *testing.T) {
+	services := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	sources := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	c := &ServiceController{
+		customRoutes:        routes.New(nil),
+		serviceLister:       corelisters.NewServiceLister(services),
+		customMetricsLister: mrLister.NewCustomMetricsSourceLister(sources),
+		namespaces:          map[string]struct{}{"monitoring": {}},
+		registered:          map[string]struct{}{"monitoring/prometheus-adapter": {}},
+	}
+	const key = "monitoring/prometheus-adapter"
+	require.NoError(t, services.Add(testService(map[string]string{MetricTypesAnnotation: "ExternalMetrics"}, 443)))
+
+	// a source for the service takes over its routes.
+	source := &v1beta1.CustomMetricsSource{ObjectMeta: metav1.ObjectMeta{Name: "prometheus"}}
+	source.Spec.Backend.Service = v1beta1.ServiceReference{Namespace: "monitoring", Name: "prometheus-adapter", Port: 443}
+	require.NoError(t, sources.Add(source))
+	require.NoError(t, c.reconcileKey(key))
+	require.Empty(t, c.registered)
+
+	// the annotations are ignored as long as the source exists.
+	require.NoError(t, services.Delete(testService(nil)))
+	require.NoError(t, c.reconcileKey(key))
+	require.Empty(t, c.registered)
+
+	// services without annotations are left alone.
+	require.NoError(t, sources.Delete(source))
+	require.NoError(t, services.Add(testService(nil, 443)))
+	require.NoError(t, c.reconcileKey(key))
+	require.Empty(t, c.registered)
+}
+
+func TestServiceControllerNamespaces(t *testing.T) {
+	services := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	sources := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	c := &ServiceController{
+		customRoutes:        routes.New(nil),
+		serviceLister:       corelisters.NewServiceLister(services),
+		customMetricsLister: mrLister.NewCustomMetricsSourceLister(sources),
+		namespaces:          map[string]struct{}{"team-a": {}},
+		registered:          map[string]struct{}{"monitoring/prometheus-adapter": {}},
+	}
+	require.NoError(t, services.Add(testService(map[string]string{MetricTypesAnnotation: "ExternalMetrics"}, 443)))
+
+	// services outside of the allowed namespaces are no sources.
+	require.NoError(t, c.reconcileKey("monitoring/prometheus-adapter"))
+	require.Empty(t, c.registered)
+}
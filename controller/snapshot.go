@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/klog"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/backend"
+	"github.com/arjunrn/custom-metrics-router/pkg/routes"
+	"github.com/arjunrn/custom-metrics-router/pkg/snapshot"
+)
+
+// The owners of the sources in a discovery snapshot.
+const (
+	SourcesOwner           = "custom-metrics-sources"
+	AnnotatedServicesOwner = "annotated-services"
+	RoutingConfigOwner     = "routing-config"
+)
+
+// SeedRoutes adds the routes of the sources in the snapshot before the
+// controllers discover them, so that metrics are served right after a restart
+// even when backends are slow or down. The seeded routes are stale until the
+// controllers replace them.
+func SeedRoutes(deps backend.Dependencies, customRoutes *routes.Routes, recorder *snapshot.Recorder) error {
+	sources, err := recorder.Load()
+	if err != nil {
+		return err
+	}
+	for i := range sources {
+		source := sources[i].CustomMetricsSource()
+		metricsBackend, err := backend.New(source, deps)
+		if err == nil {
+			service := source.Spec.Backend.Service
+			customMetricInfos, externalMetricInfos, resourceMetrics := sources[i].Metrics()
+			err = customRoutes.SeedService(service.Name, service.Namespace, metricsBackend, routes.SourceRouting(source), customMetricInfos, externalMetricInfos, resourceMetrics)
+		}
+		if err != nil {
+			utilruntime.HandleError(fmt.Errorf("failed to seed routes of custom metrics source %s: %v", source.Name, err))
+			continue
+		}
+		klog.Infof("Seeded routes of custom metrics source %s discovered at %s", source.Name, sources[i].DiscoveredAt)
+	}
+	return nil
+}
+
+// pruneSnapshot forgets the sources of an owner whose services are no longer
+// sources. Their routes are removed unless they were discovered since.
+func pruneSnapshot(customRoutes *routes.Routes, recorder *snapshot.Recorder, owner string, services map[types.NamespacedName]struct{}) {
+	for _, source := range recorder.Sources(owner) {
+		service := source.Spec.Backend.Service
+		if _, ok := services[types.NamespacedName{Namespace: service.Namespace, Name: service.Name}]; ok {
+			continue
+		}
+		if customRoutes.IsStale(service.Name, service.Namespace) {
+			klog.Infof("Custom Metrics Source %s of the discovery snapshot no longer exists", source.Name)
+			customRoutes.RemoveService(service.Name, service.Namespace)
+		}
+		recorder.Forget(service.Namespace, service.Name)
+	}
+}
@@ -0,0 +1,52 @@
+package controller
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/kubernetes-sigs/custom-metrics-apiserver/pkg/provider"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/backend"
+	"github.com/arjunrn/custom-metrics-router/pkg/routes"
+	"github.com/arjunrn/custom-metrics-router/pkg/snapshot"
+)
+
+func TestSeedRoutes(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	write := func(config string) {
+		require.NoError(t, ioutil.WriteFile(path, []byte(config), 0644))
+	}
+	store := &snapshot.FileStore{Path: filepath.Join(dir, "snapshot.json")}
+	clientSet := fake.NewSimpleClientset()
+	deps := backend.Dependencies{KubeClient: clientSet}
+
+	write(testRoutingConfig("queue_depth"))
+	recorder := snapshot.NewRecorder(store)
+	require.NoError(t, NewConfigController(path, clientSet, routes.New(nil), recorder, deps, 0).Load())
+	require.NoError(t, recorder.Save())
+
+	// the routes of the snapshot are stale until the source is discovered.
+	customRoutes := routes.New(nil)
+	recorder = snapshot.NewRecorder(store)
+	require.NoError(t, SeedRoutes(deps, customRoutes, recorder))
+	require.Equal(t, []provider.ExternalMetricInfo{{Metric: "queue_depth"}}, customRoutes.ListAllExternalMetrics())
+	require.True(t, customRoutes.IsStale("static", "monitoring"))
+
+	write(testRoutingConfig("stream_lag"))
+	require.NoError(t, NewConfigController(path, clientSet, customRoutes, recorder, deps, 0).Load())
+	require.Equal(t, []provider.ExternalMetricInfo{{Metric: "stream_lag"}}, customRoutes.ListAllExternalMetrics())
+	require.False(t, customRoutes.IsStale("static", "monitoring"))
+
+	// sources which were removed meanwhile are pruned.
+	customRoutes = routes.New(nil)
+	recorder = snapshot.NewRecorder(store)
+	require.NoError(t, SeedRoutes(deps, customRoutes, recorder))
+	write("sources: []")
+	require.NoError(t, NewConfigController(path, clientSet, customRoutes, recorder, deps, 0).Load())
+	require.Empty(t, customRoutes.ListAllExternalMetrics())
+	require.Empty(t, recorder.Sources(RoutingConfigOwner))
+}
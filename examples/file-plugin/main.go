@@ -0,0 +1,103 @@
+// file-plugin is an example metrics plugin. It serves an external metric for
+// every file in a directory with the value in the file, so that any process
+// which can write a file, e.g. a cron job, can publish metrics.
+package main
+
+import (
+	"context"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kubernetes-sigs/custom-metrics-apiserver/pkg/provider"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+	"k8s.io/metrics/pkg/apis/custom_metrics"
+	"k8s.io/metrics/pkg/apis/external_metrics"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/plugin/sdk"
+)
+
+type filePlugin struct {
+	dir string
+}
+
+func (p *filePlugin) ListMetrics(context.Context) ([]provider.CustomMetricInfo, []provider.ExternalMetricInfo, error) {
+	files, err := ioutil.ReadDir(p.dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	var externalMetricInfos []provider.ExternalMetricInfo
+	for _, file := range files {
+		if file.Mode().IsRegular() && !strings.HasPrefix(file.Name(), ".") {
+			externalMetricInfos = append(externalMetricInfos, provider.ExternalMetricInfo{Metric: file.Name()})
+		}
+	}
+	return nil, externalMetricInfos, nil
+}
+
+func (p *filePlugin) GetMetricByName(_ context.Context, _ types.NamespacedName, info provider.CustomMetricInfo, _ labels.Selector) (*custom_metrics.MetricValue, error) {
+	return nil, apierrors.NewNotFound(info.GroupResource, info.Metric)
+}
+
+func (p *filePlugin) GetMetricBySelector(_ context.Context, _ string, _ labels.Selector, info provider.CustomMetricInfo, _ labels.Selector) (*custom_metrics.MetricValueList, error) {
+	return nil, apierrors.NewNotFound(info.GroupResource, info.Metric)
+}
+
+func (p *filePlugin) GetExternalMetric(_ context.Context, _ string, _ labels.Selector, info provider.ExternalMetricInfo) (*external_metrics.ExternalMetricValueList, error) {
+	notFound := apierrors.NewNotFound(schema.GroupResource{Resource: "externalmetrics"}, info.Metric)
+	if strings.ContainsAny(info.Metric, "/\\") || strings.HasPrefix(info.Metric, ".") {
+		return nil, notFound
+	}
+	path := filepath.Join(p.dir, info.Metric)
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, notFound
+	} else if err != nil {
+		return nil, err
+	}
+	value, err := resource.ParseQuantity(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, apierrors.NewInternalError(err)
+	}
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	return &external_metrics.ExternalMetricValueList{Items: []external_metrics.ExternalMetricValue{{
+		MetricName: info.Metric,
+		Timestamp:  metav1.NewTime(stat.ModTime()),
+		Value:      value,
+	}}}, nil
+}
+
+func main() {
+	address := flag.String("address", "unix:///var/run/metrics-plugin/plugin.sock", "unix:///path of a socket or host:port to listen on.")
+	dir := flag.String("metrics-dir", "/var/lib/metrics", "Directory with a file for every metric.")
+	certFile := flag.String("tls-cert-file", "", "Serving certificate for TCP addresses. TLS is disabled when it is empty.")
+	keyFile := flag.String("tls-key-file", "", "Key of the serving certificate.")
+	klog.InitFlags(nil)
+	flag.Parse()
+
+	var options []grpc.ServerOption
+	if *certFile != "" {
+		creds, err := credentials.NewServerTLSFromFile(*certFile, *keyFile)
+		if err != nil {
+			klog.Fatalf("Failed to load serving certificate: %v", err)
+		}
+		options = append(options, grpc.Creds(creds))
+	}
+	klog.Infof("Serving metrics of %s on %s", *dir, *address)
+	if err := sdk.Serve(*address, &filePlugin{dir: *dir}, options...); err != nil {
+		klog.Fatalf("Failed to serve plugin: %v", err)
+	}
+}
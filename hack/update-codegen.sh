@@ -27,7 +27,7 @@ CODEGEN_PKG=${CODEGEN_PKG:-$(cd ${SCRIPT_ROOT}; ls -d -1 ./vendor/k8s.io/code-ge
 #                  instead of the $GOPATH directly. For normal projects this can be dropped.
 ${CODEGEN_PKG}/generate-groups.sh all \
   github.com/arjunrn/custom-metrics-router/pkg/client github.com/arjunrn/custom-metrics-router/pkg/apis \
-  "metricsrouter.io:v1alpha1" \
+  "metricsrouter.io:v1alpha1,v1beta1" \
   --output-base "$(dirname ${BASH_SOURCE})/../../../.." \
   --go-header-file "${SCRIPT_ROOT}"/hack/boilerplate.go.txt
 
@@ -3,27 +3,188 @@ package main
 import (
 	"flag"
 	"os"
+	"time"
 
 	basecmd "github.com/kubernetes-sigs/custom-metrics-apiserver/pkg/cmd"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apiserver/pkg/server/healthz"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/discovery/cached/memory"
 	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/klog"
+	apiregistrationv1 "k8s.io/kube-aggregator/pkg/apis/apiregistration/v1"
+	aggregator "k8s.io/kube-aggregator/pkg/client/clientset_generated/clientset"
 
 	"github.com/arjunrn/custom-metrics-router/controller"
+	"github.com/arjunrn/custom-metrics-router/pkg/apiserver"
+	"github.com/arjunrn/custom-metrics-router/pkg/authorization"
+	"github.com/arjunrn/custom-metrics-router/pkg/backend"
 	"github.com/arjunrn/custom-metrics-router/pkg/clientset"
+	"github.com/arjunrn/custom-metrics-router/pkg/objectcache"
+	"github.com/arjunrn/custom-metrics-router/pkg/otlp"
 	"github.com/arjunrn/custom-metrics-router/pkg/provider"
+	"github.com/arjunrn/custom-metrics-router/pkg/push"
+	"github.com/arjunrn/custom-metrics-router/pkg/rates"
+	"github.com/arjunrn/custom-metrics-router/pkg/remotewrite"
 	"github.com/arjunrn/custom-metrics-router/pkg/routes"
+	"github.com/arjunrn/custom-metrics-router/pkg/series"
+	"github.com/arjunrn/custom-metrics-router/pkg/snapshot"
+	"github.com/arjunrn/custom-metrics-router/pkg/webhook"
+
+	// The built-in backend types register themselves.
+	_ "github.com/arjunrn/custom-metrics-router/pkg/httpjson"
+	_ "github.com/arjunrn/custom-metrics-router/pkg/metricsclient"
+	_ "github.com/arjunrn/custom-metrics-router/pkg/objectfield"
+	_ "github.com/arjunrn/custom-metrics-router/pkg/plugin"
+	_ "github.com/arjunrn/custom-metrics-router/pkg/prometheus"
+	_ "github.com/arjunrn/custom-metrics-router/pkg/scrape"
 )
 
 type RoutedAdapter struct {
 	basecmd.AdapterBase
+
+	MetricsAuthorization  bool
+	AuthorizationAllowTTL time.Duration
+	AuthorizationDenyTTL  time.Duration
+
+	TokenReviewAuthenticatedTTL   time.Duration
+	TokenReviewUnauthenticatedTTL time.Duration
+
+	WebhookBindAddress     string
+	WebhookCertFile        string
+	WebhookKeyFile         string
+	WebhookDryRunDiscovery bool
+
+	RoutingConfig               string
+	RoutingConfigReloadInterval time.Duration
+
+	AnnotatedServices            bool
+	AnnotatedServicesNamespaces  []string
+	AnnotatedServicesMinPriority int
+
+	ManageAPIServices      bool
+	RouterServiceNamespace string
+	RouterServiceName      string
+	RouterServicePort      int32
+	ServingCertSecret      string
+
+	ReadinessDiscoveryTimeout time.Duration
+	WorkerStuckThreshold      time.Duration
+
+	DiscoverySnapshotFile      string
+	DiscoverySnapshotConfigMap string
+	DiscoverySnapshotInterval  time.Duration
+
+	DiscoveryGracePeriod time.Duration
+
+	PushBindAddress string
+	PushCertFile    string
+	PushKeyFile     string
+	PushDefaultTTL  time.Duration
+	PushMaxTTL      time.Duration
+	PushMaxSamples  int
+	PushConfigMap   string
+	PushPriority    int
+
+	RemoteWriteBindAddress string
+	RemoteWriteCertFile    string
+	RemoteWriteKeyFile     string
+	RemoteWriteMaxSeries   int
+	RemoteWriteMaxAge      time.Duration
+	OTLPBindAddress        string
+	OTLPCertFile           string
+	OTLPKeyFile            string
+	OTLPMaxSeries          int
+	OTLPMaxAge             time.Duration
+}
+
+func (a *RoutedAdapter) addFlags() {
+	a.Flags().BoolVar(&a.MetricsAuthorization, "metrics-authorization", false,
+		"check with a SubjectAccessReview that the requester may read a metric from its source before the request is routed")
+	a.Flags().DurationVar(&a.AuthorizationAllowTTL, "metrics-authorization-allow-ttl", 5*time.Minute,
+		"duration to cache allowed metrics authorization decisions")
+	a.Flags().DurationVar(&a.AuthorizationDenyTTL, "metrics-authorization-deny-ttl", 30*time.Second,
+		"duration to cache denied metrics authorization decisions")
+	a.Flags().DurationVar(&a.TokenReviewAuthenticatedTTL, "token-review-authenticated-ttl", 2*time.Minute,
+		"duration to cache the users of the bearer tokens of the push, remote-write and OTLP endpoints")
+	a.Flags().DurationVar(&a.TokenReviewUnauthenticatedTTL, "token-review-unauthenticated-ttl", 10*time.Second,
+		"duration to cache that a bearer token of the push, remote-write and OTLP endpoints isn't valid")
+	a.Flags().StringVar(&a.WebhookBindAddress, "webhook-bind-address", "",
+		"address to serve the CustomMetricsSource validating webhook on, e.g. :9443. The webhook is disabled when empty")
+	a.Flags().StringVar(&a.WebhookCertFile, "webhook-cert-file", "", "TLS certificate for the webhook server")
+	a.Flags().StringVar(&a.WebhookKeyFile, "webhook-key-file", "", "TLS private key for the webhook server")
+	a.Flags().BoolVar(&a.WebhookDryRunDiscovery, "webhook-dry-run-discovery", false,
+		"list the metrics of the backend before a CustomMetricsSource is admitted")
+	a.Flags().StringVar(&a.RoutingConfig, "routing-config", "",
+		"file with the sources and metric routes to use instead of CustomMetricsSource and MetricRoute objects")
+	a.Flags().DurationVar(&a.RoutingConfigReloadInterval, "routing-config-reload-interval", 10*time.Second,
+		"interval in which the routing config file is checked for changes")
+	a.Flags().BoolVar(&a.AnnotatedServices, "annotated-services", false,
+		"route metrics to Services with the metricsrouter.io/metric-types annotation as if a CustomMetricsSource existed for them")
+	a.Flags().StringSliceVar(&a.AnnotatedServicesNamespaces, "annotated-services-namespaces", nil,
+		"namespaces whose annotated Services are routed to. Required with --annotated-services")
+	a.Flags().IntVar(&a.AnnotatedServicesMinPriority, "annotated-services-min-priority", 1000,
+		"lowest routing priority of annotated Services. Lower priorities from their annotations are raised to it")
+	a.Flags().BoolVar(&a.ManageAPIServices, "manage-apiservices", false,
+		"register the router as the APIService of the custom and external metrics APIs with a self-signed serving certificate")
+	a.Flags().StringVar(&a.RouterServiceNamespace, "router-service-namespace", "custom-metrics", "namespace of the router's Service and of the serving certificate secret")
+	a.Flags().StringVar(&a.RouterServiceName, "router-service-name", "custom-metrics-router", "name of the router's Service")
+	a.Flags().Int32Var(&a.RouterServicePort, "router-service-port", 443, "port of the router's Service")
+	a.Flags().StringVar(&a.ServingCertSecret, "serving-cert-secret", "custom-metrics-router-serving-cert",
+		"secret which stores the self-signed serving certificate of the router")
+	a.Flags().DurationVar(&a.ReadinessDiscoveryTimeout, "readiness-discovery-timeout", time.Minute,
+		"maximum duration for which /readyz waits for the first discovery of all sources")
+	a.Flags().DurationVar(&a.WorkerStuckThreshold, "worker-stuck-threshold", 5*time.Minute,
+		"duration after which /livez fails for a controller worker which is still processing the same item")
+	a.Flags().StringVar(&a.DiscoverySnapshotFile, "discovery-snapshot-file", "",
+		"file to persist the discovered metrics of the sources in, which are routed after a restart until the sources are discovered again")
+	a.Flags().StringVar(&a.DiscoverySnapshotConfigMap, "discovery-snapshot-configmap", "",
+		"<namespace>/<name> of a ConfigMap to persist the discovered metrics of the sources in, as an alternative to --discovery-snapshot-file")
+	a.Flags().DurationVar(&a.DiscoverySnapshotInterval, "discovery-snapshot-interval", time.Minute,
+		"interval in which changes of the discovered metrics are persisted")
+	a.Flags().DurationVar(&a.DiscoveryGracePeriod, "discovery-grace-period", routes.DefaultDiscoveryGracePeriod,
+		"duration for which the routes of a source are kept while its discovery fails. With 0 they are kept until discovery succeeds again")
+	a.Flags().StringVar(&a.PushBindAddress, "push-bind-address", "",
+		"address to accept pushed external metrics on, e.g. :9444. Pushing is disabled when empty")
+	a.Flags().StringVar(&a.PushCertFile, "push-cert-file", "", "TLS certificate for the push server")
+	a.Flags().StringVar(&a.PushKeyFile, "push-key-file", "", "TLS private key for the push server")
+	a.Flags().DurationVar(&a.PushDefaultTTL, "push-default-ttl", push.DefaultTTL, "duration for which pushed samples without a TTL are served")
+	a.Flags().DurationVar(&a.PushMaxTTL, "push-max-ttl", push.DefaultMaxTTL, "maximum TTL of pushed samples")
+	a.Flags().IntVar(&a.PushMaxSamples, "push-max-samples", push.DefaultMaxSamples, "maximum number of pushed samples which are kept, samples of further series are dropped")
+	a.Flags().StringVar(&a.PushConfigMap, "push-configmap", "",
+		"<namespace>/<name> of a ConfigMap to persist the pushed samples in, which are served again after a restart")
+	a.Flags().IntVar(&a.PushPriority, "push-priority", 1000,
+		"routing priority of the pushed metrics. The default is lower than the one of most sources, so that pushers can't take over their metrics")
+	a.Flags().StringVar(&a.RemoteWriteBindAddress, "remote-write-bind-address", "",
+		"address to receive Prometheus remote-write requests on, e.g. :9445. The receiver is disabled when empty")
+	a.Flags().StringVar(&a.RemoteWriteCertFile, "remote-write-cert-file", "", "TLS certificate for the remote-write receiver")
+	a.Flags().StringVar(&a.RemoteWriteKeyFile, "remote-write-key-file", "", "TLS private key for the remote-write receiver")
+	a.Flags().IntVar(&a.RemoteWriteMaxSeries, "remote-write-max-series", remotewrite.DefaultMaxSeries,
+		"maximum number of received series which are kept. Further series are dropped")
+	a.Flags().DurationVar(&a.RemoteWriteMaxAge, "remote-write-max-age", remotewrite.DefaultMaxAge,
+		"duration for which a received series is kept after its latest sample")
+	a.Flags().StringVar(&a.OTLPBindAddress, "otlp-bind-address", "",
+		"address to receive OTLP/HTTP metrics requests on, e.g. :4318. The receiver is disabled when empty")
+	a.Flags().StringVar(&a.OTLPCertFile, "otlp-cert-file", "", "TLS certificate for the OTLP receiver")
+	a.Flags().StringVar(&a.OTLPKeyFile, "otlp-key-file", "", "TLS private key for the OTLP receiver")
+	a.Flags().IntVar(&a.OTLPMaxSeries, "otlp-max-series", otlp.DefaultMaxSeries,
+		"maximum number of received OTLP series which are kept. Further data points are dropped")
+	a.Flags().DurationVar(&a.OTLPMaxAge, "otlp-max-age", otlp.DefaultMaxAge,
+		"duration for which a received OTLP series is kept after its latest data point")
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
+
 	cmd := &RoutedAdapter{}
+	cmd.addFlags()
+	// the probes of the kubelet aren't authorized to check the health.
+	cmd.Authorization.WithAlwaysAllowPaths("/healthz", "/readyz", "/livez")
 	cmd.Flags().AddGoFlagSet(flag.CommandLine) // make sure you get the klog flags
 	err := cmd.Flags().Parse(os.Args)
 	if err != nil {
@@ -47,15 +208,207 @@ func main() {
 	cachedDiscoveryClient := memory.NewMemCacheClient(discoveryClient)
 	mapper := restmapper.NewDeferredDiscoveryRESTMapper(cachedDiscoveryClient)
 	customRoutes := routes.New(mapper)
+	customRoutes.SetDiscoveryGracePeriod(cmd.DiscoveryGracePeriod)
+
+	var readyzChecks, livezChecks []healthz.HealthChecker
+	addHealthChecks := func(name string, c interface {
+		controller.Readiness
+		controller.Liveness
+	}) {
+		readyzChecks = append(readyzChecks, controller.ReadyzCheck(name, cmd.ReadinessDiscoveryTimeout, c))
+		livezChecks = append(livezChecks, controller.LivezCheck(name, cmd.WorkerStuckThreshold, c))
+	}
 
-	c := controller.NewController(clientSet, customRoutes)
 	stopCh := make(chan struct{})
-	go c.Run(stopCh)
 	defer close(stopCh)
 
-	routedProvider := provider.NewRoutedProvider(customRoutes)
-	cmd.WithCustomMetrics(routedProvider)
-	cmd.WithExternalMetrics(routedProvider)
+	objects := objectcache.New(clientSet.Dynamic(), objectcache.DefaultIdleTimeout)
+	go objects.Run(stopCh)
+	deps := backend.Dependencies{
+		KubeClient:    clientSet,
+		DynamicClient: clientSet.Dynamic(),
+		Mapper:        mapper,
+		Objects:       objects,
+		Rates:         rates.NewTracker(),
+	}
+	if cmd.RemoteWriteBindAddress != "" {
+		deps.RemoteWriteSeries = series.NewStore(cmd.RemoteWriteMaxSeries, cmd.RemoteWriteMaxAge)
+		go deps.RemoteWriteSeries.Run(stopCh)
+	}
+	if cmd.OTLPBindAddress != "" {
+		deps.OTLPSeries = series.NewStore(cmd.OTLPMaxSeries, cmd.OTLPMaxAge)
+		go deps.OTLPSeries.Run(stopCh)
+	}
+
+	var recorder *snapshot.Recorder
+	if cmd.DiscoverySnapshotFile != "" && cmd.DiscoverySnapshotConfigMap != "" {
+		klog.Fatalf("--discovery-snapshot-file and --discovery-snapshot-configmap can't be used together")
+	}
+	if cmd.DiscoverySnapshotFile != "" {
+		recorder = snapshot.NewRecorder(&snapshot.FileStore{Path: cmd.DiscoverySnapshotFile})
+	}
+	if cmd.DiscoverySnapshotConfigMap != "" {
+		namespace, name, err := cache.SplitMetaNamespaceKey(cmd.DiscoverySnapshotConfigMap)
+		if err != nil || namespace == "" {
+			klog.Fatalf("invalid --discovery-snapshot-configmap %q, expected <namespace>/<name>", cmd.DiscoverySnapshotConfigMap)
+		}
+		recorder = snapshot.NewRecorder(&snapshot.ConfigMapStore{Client: clientSet, Namespace: namespace, Name: name})
+	}
+	if recorder != nil {
+		if err := controller.SeedRoutes(deps, customRoutes, recorder); err != nil {
+			klog.Errorf("failed to seed routes from the discovery snapshot: %v", err)
+		}
+		go recorder.Run(cmd.DiscoverySnapshotInterval, stopCh)
+	}
+
+	if cmd.RoutingConfig != "" {
+		if cmd.WebhookBindAddress != "" || cmd.AnnotatedServices {
+			klog.Fatalf("--webhook-bind-address and --annotated-services can't be used together with --routing-config")
+		}
+		c := controller.NewConfigController(cmd.RoutingConfig, clientSet, customRoutes, recorder, deps, cmd.RoutingConfigReloadInterval)
+		if err := c.Load(); err != nil {
+			klog.Fatalf("failed to load routing config: %v", err)
+		}
+		addHealthChecks("routing-config", c)
+		go c.Run(stopCh)
+	} else {
+		c := controller.NewController(clientSet, customRoutes, recorder, deps)
+		addHealthChecks("custom-metrics-sources", c)
+		go c.Run(stopCh)
+		metricRouteController := controller.NewMetricRouteController(clientSet, customRoutes)
+		addHealthChecks("metric-routes", metricRouteController)
+		go metricRouteController.Run(stopCh)
+		if cmd.AnnotatedServices {
+			if len(cmd.AnnotatedServicesNamespaces) == 0 {
+				klog.Fatalf("--annotated-services requires --annotated-services-namespaces")
+			}
+			serviceController := controller.NewServiceController(clientSet, customRoutes, recorder, deps,
+				cmd.AnnotatedServicesNamespaces, cmd.AnnotatedServicesMinPriority)
+			addHealthChecks("annotated-services", serviceController)
+			go serviceController.Run(stopCh)
+		}
+	}
+
+	if cmd.WebhookBindAddress != "" {
+		validator := webhook.NewSourceValidator(clientSet, customRoutes, mapper, deps, cmd.WebhookDryRunDiscovery)
+		go func() {
+			if err := webhook.NewServer(validator).Run(cmd.WebhookBindAddress, cmd.WebhookCertFile, cmd.WebhookKeyFile, stopCh); err != nil {
+				klog.Fatalf("failed to run webhook server: %v", err)
+			}
+		}()
+	}
+
+	// the endpoints which receive metrics authenticate and authorize their
+	// clients regardless of --metrics-authorization.
+	endpointAuthenticator := authorization.NewTokenReviewAuthenticator(
+		clientSet.AuthenticationV1().TokenReviews(),
+		cmd.TokenReviewAuthenticatedTTL,
+		cmd.TokenReviewUnauthenticatedTTL,
+	)
+	endpointAuthorizer := authorization.NewSubjectAccessReviewAuthorizer(
+		clientSet.AuthorizationV1().SubjectAccessReviews(),
+		cmd.AuthorizationAllowTTL,
+		cmd.AuthorizationDenyTTL,
+	)
+	if cmd.PushBindAddress != "" {
+		var persistence push.Persistence
+		if cmd.PushConfigMap != "" {
+			namespace, name, err := cache.SplitMetaNamespaceKey(cmd.PushConfigMap)
+			if err != nil || namespace == "" {
+				klog.Fatalf("invalid --push-configmap %q, expected <namespace>/<name>", cmd.PushConfigMap)
+			}
+			persistence = &push.ConfigMapPersistence{Client: clientSet, Namespace: namespace, Name: name}
+		}
+		store := push.NewStore(cmd.PushDefaultTTL, cmd.PushMaxTTL, cmd.PushMaxSamples, persistence)
+		// the pushed metrics are routed like a source without a service,
+		// which is discovered again whenever a metric appears or expires.
+		routing := routes.ServiceRouting{Priority: cmd.PushPriority, CreationTimestamp: time.Now(), ExternalMetrics: true}
+		store.SetOnChange(func() {
+			if err := customRoutes.AddService(push.SourceName, "", store, routing); err != nil {
+				klog.Errorf("failed to route pushed metrics: %v", err)
+			}
+		})
+		if err := store.Load(); err != nil {
+			klog.Errorf("failed to restore pushed metrics: %v", err)
+		}
+		go store.Run(stopCh)
+		pushServer := push.NewServer(store, endpointAuthenticator, endpointAuthorizer)
+		go func() {
+			if err := pushServer.Run(cmd.PushBindAddress, cmd.PushCertFile, cmd.PushKeyFile, stopCh); err != nil {
+				klog.Fatalf("failed to run push server: %v", err)
+			}
+		}()
+	}
+	if cmd.RemoteWriteBindAddress != "" {
+		remoteWriteServer := remotewrite.NewServer(deps.RemoteWriteSeries, endpointAuthenticator, endpointAuthorizer)
+		go func() {
+			if err := remoteWriteServer.Run(cmd.RemoteWriteBindAddress, cmd.RemoteWriteCertFile, cmd.RemoteWriteKeyFile, stopCh); err != nil {
+				klog.Fatalf("failed to run remote-write receiver: %v", err)
+			}
+		}()
+	}
+	if cmd.OTLPBindAddress != "" {
+		otlpServer := otlp.NewServer(deps.OTLPSeries, endpointAuthenticator, endpointAuthorizer)
+		go func() {
+			if err := otlpServer.Run(cmd.OTLPBindAddress, cmd.OTLPCertFile, cmd.OTLPKeyFile, stopCh); err != nil {
+				klog.Fatalf("failed to run OTLP receiver: %v", err)
+			}
+		}()
+	}
+
+	authorizer := authorization.NewAlwaysAllowAuthorizer()
+	if cmd.MetricsAuthorization {
+		authorizer = authorization.NewSubjectAccessReviewAuthorizer(
+			clientSet.AuthorizationV1().SubjectAccessReviews(),
+			cmd.AuthorizationAllowTTL,
+			cmd.AuthorizationDenyTTL,
+		)
+	}
+	if cmd.ManageAPIServices {
+		serverCert := &cmd.SecureServing.ServerCert
+		if serverCert.CertKey.CertFile != "" || serverCert.CertKey.KeyFile != "" {
+			klog.Fatalf("--tls-cert-file and --tls-private-key-file can't be used together with --manage-apiservices")
+		}
+		aggregatorClient, err := aggregator.NewForConfig(config)
+		if err != nil {
+			klog.Fatalf("failed to create aggregator client: %v", err)
+		}
+		apiServiceController := controller.NewAPIServiceController(
+			clientSet,
+			aggregatorClient.ApiregistrationV1().APIServices(),
+			apiregistrationv1.ServiceReference{
+				Namespace: cmd.RouterServiceNamespace,
+				Name:      cmd.RouterServiceName,
+				Port:      &cmd.RouterServicePort,
+			},
+			cmd.ServingCertSecret,
+			serverCert.CertDirectory,
+			apiserver.AggregatedGroupVersions(),
+		)
+		if err := apiServiceController.Sync(); err != nil {
+			klog.Fatalf("failed to set up APIServices: %v", err)
+		}
+		serverCert.CertKey.CertFile, serverCert.CertKey.KeyFile = apiServiceController.CertFiles()
+		go apiServiceController.Run(stopCh)
+	}
+
+	// the checks are part of the config because the server only adds checks
+	// to all of /healthz, /livez and /readyz.
+	serverConfig, err := cmd.Config()
+	if err != nil {
+		klog.Fatalf("failed to create metrics server config: %v", err)
+	}
+	serverConfig.GenericConfig.ReadyzChecks = append(serverConfig.GenericConfig.ReadyzChecks, readyzChecks...)
+	serverConfig.GenericConfig.LivezChecks = append(serverConfig.GenericConfig.LivezChecks, livezChecks...)
+
+	routedProvider := provider.NewRoutedProvider(customRoutes, authorizer)
+	server, err := cmd.Server()
+	if err != nil {
+		klog.Fatalf("failed to create metrics server: %v", err)
+	}
+	if err := apiserver.InstallMetricsAPIs(server.GenericAPIServer, routedProvider); err != nil {
+		klog.Fatalf("failed to install metrics APIs: %v", err)
+	}
 
 	if err := cmd.Run(wait.NeverStop); err != nil {
 		klog.Fatalf("unable to run custom metrics routedProvider: %v", err)
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+
+	"github.com/spf13/pflag"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog"
+	apiregistrationv1 "k8s.io/kube-aggregator/pkg/apis/apiregistration/v1"
+	aggregator "k8s.io/kube-aggregator/pkg/client/clientset_generated/clientset"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/clientset"
+	"github.com/arjunrn/custom-metrics-router/pkg/migrate"
+)
+
+// runMigrate runs the migrate subcommand, which moves the backends of the
+// existing metrics APIServices into sources and points the APIServices at
+// the router.
+func runMigrate(args []string) {
+	flags := pflag.NewFlagSet("migrate", pflag.ExitOnError)
+	kubeconfig := flags.String("kubeconfig", "", "kubeconfig file pointing at the cluster. The in-cluster config is used when empty")
+	dryRun := flags.Bool("dry-run", false, "only print the planned changes")
+	routerNamespace := flags.String("router-service-namespace", "custom-metrics", "namespace of the router's Service")
+	routerName := flags.String("router-service-name", "custom-metrics-router", "name of the router's Service")
+	routerPort := flags.Int32("router-service-port", 443, "port of the router's Service")
+	routerCABundleFile := flags.String("router-ca-bundle-file", "",
+		"CA which verifies the router's serving certificate. The APIServices skip the verification when empty")
+	if err := flags.Parse(args); err != nil {
+		klog.Fatalf("failed to parse flags: %v", err)
+	}
+
+	config, err := clientcmd.BuildConfigFromFlags("", *kubeconfig)
+	if err != nil {
+		klog.Fatalf("failed to build client config: %v", err)
+	}
+	clientSet, err := clientset.NewForConfig(config)
+	if err != nil {
+		klog.Fatalf("failed to create client: %v", err)
+	}
+	aggregatorClient, err := aggregator.NewForConfig(config)
+	if err != nil {
+		klog.Fatalf("failed to create aggregator client: %v", err)
+	}
+	options := migrate.Options{
+		Router: apiregistrationv1.ServiceReference{
+			Namespace: *routerNamespace,
+			Name:      *routerName,
+			Port:      routerPort,
+		},
+		DryRun: *dryRun,
+		Out:    os.Stdout,
+	}
+	if *routerCABundleFile != "" {
+		options.RouterCABundle, err = ioutil.ReadFile(*routerCABundleFile)
+		if err != nil {
+			klog.Fatalf("failed to read router CA bundle: %v", err)
+		}
+	}
+	if err := migrate.Migrate(context.Background(), aggregatorClient.ApiregistrationV1().APIServices(),
+		clientSet.MetricsrouterV1beta1().CustomMetricsSources(), options); err != nil {
+		klog.Fatalf("migration failed: %v", err)
+	}
+}
@@ -0,0 +1,154 @@
+package v1alpha1
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+)
+
+// SpecAnnotation keeps the v1beta1 spec of a source which is read as
+// v1alpha1, so that fields which only exist in v1beta1 survive a round trip
+// through this version.
+const SpecAnnotation = "metricsrouter.io/v1beta1-spec"
+
+// ConvertTo converts the source to the v1beta1 hub version.
+func (src *CustomMetricsSource) ConvertTo(dst *v1beta1.CustomMetricsSource) error {
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	dst.TypeMeta.APIVersion = v1beta1.SchemeGroupVersion.String()
+	dst.TypeMeta.Kind = src.Kind
+
+	dst.Spec = v1beta1.CustomMetricsSourceSpec{}
+	if annotation, ok := src.Annotations[SpecAnnotation]; ok {
+		if err := json.Unmarshal([]byte(annotation), &dst.Spec); err != nil {
+			return fmt.Errorf("failed to restore v1beta1 spec of %s from annotation: %v", src.Name, err)
+		}
+		delete(dst.Annotations, SpecAnnotation)
+		if len(dst.Annotations) == 0 {
+			dst.Annotations = nil
+		}
+	}
+
+	spec := &src.Spec
+	dst.Spec.Backend.Service = v1beta1.ServiceReference{
+		Namespace: spec.Service.Namespace,
+		Name:      spec.Service.Name,
+		Port:      spec.Service.Port,
+	}
+	dst.Spec.Backend.TLS.InsecureSkipVerify = spec.InsecureSkipTLSVerify
+	dst.Spec.Backend.Authentication = convertAuthenticationTo(spec.Authentication)
+	dst.Spec.Backend.RequesterForwarding = v1beta1.RequesterForwarding(spec.RequesterForwarding)
+	dst.Spec.Routing.Priority = spec.Priority
+	dst.Spec.Routing.MetricTypes = nil
+	for _, metricType := range spec.MetricTypes {
+		dst.Spec.Routing.MetricTypes = append(dst.Spec.Routing.MetricTypes, v1beta1.MetricType(metricType))
+	}
+	return nil
+}
+
+// ConvertFrom converts the source from the v1beta1 hub version.
+func (dst *CustomMetricsSource) ConvertFrom(src *v1beta1.CustomMetricsSource) error {
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	dst.TypeMeta.APIVersion = SchemeGroupVersion.String()
+	dst.TypeMeta.Kind = src.Kind
+
+	spec := &src.Spec
+	dst.Spec = CustomMetricsSourceSpec{
+		Service: Service{
+			Namespace: spec.Backend.Service.Namespace,
+			Name:      spec.Backend.Service.Name,
+			Port:      spec.Backend.Service.Port,
+		},
+		InsecureSkipTLSVerify: spec.Backend.TLS.InsecureSkipVerify,
+		Priority:              spec.Routing.Priority,
+		Authentication:        convertAuthenticationFrom(spec.Backend.Authentication),
+		RequesterForwarding:   RequesterForwarding(spec.Backend.RequesterForwarding),
+	}
+	for _, metricType := range spec.Routing.MetricTypes {
+		dst.Spec.MetricTypes = append(dst.Spec.MetricTypes, MetricType(metricType))
+	}
+
+	// only keep the v1beta1 spec when this version can't represent it.
+	restored := &v1beta1.CustomMetricsSource{}
+	if err := dst.ConvertTo(restored); err != nil {
+		return err
+	}
+	if equality.Semantic.DeepEqual(restored.Spec, src.Spec) {
+		return nil
+	}
+	annotation, err := json.Marshal(spec)
+	if err != nil {
+		return fmt.Errorf("failed to store v1beta1 spec of %s in annotation: %v", src.Name, err)
+	}
+	if dst.Annotations == nil {
+		dst.Annotations = make(map[string]string)
+	}
+	dst.Annotations[SpecAnnotation] = string(annotation)
+	return nil
+}
+
+func convertAuthenticationTo(in *Authentication) *v1beta1.Authentication {
+	if in == nil {
+		return nil
+	}
+	out := &v1beta1.Authentication{Mode: v1beta1.AuthenticationMode(in.Mode)}
+	if in.SecretToken != nil {
+		out.SecretToken = &v1beta1.SecretKeySelector{
+			Namespace: in.SecretToken.Namespace,
+			Name:      in.SecretToken.Name,
+			Key:       in.SecretToken.Key,
+		}
+	}
+	if in.ProjectedToken != nil {
+		out.ProjectedToken = &v1beta1.ProjectedToken{
+			ServiceAccount: v1beta1.ServiceAccountReference{
+				Namespace: in.ProjectedToken.ServiceAccount.Namespace,
+				Name:      in.ProjectedToken.ServiceAccount.Name,
+			},
+			Audience:          in.ProjectedToken.Audience,
+			ExpirationSeconds: in.ProjectedToken.ExpirationSeconds,
+		}
+	}
+	if in.Impersonation != nil {
+		out.Impersonation = &v1beta1.Impersonation{
+			UserName: in.Impersonation.UserName,
+			Groups:   in.Impersonation.Groups,
+			Extra:    in.Impersonation.Extra,
+		}
+	}
+	return out
+}
+
+func convertAuthenticationFrom(in *v1beta1.Authentication) *Authentication {
+	if in == nil {
+		return nil
+	}
+	out := &Authentication{Mode: AuthenticationMode(in.Mode)}
+	if in.SecretToken != nil {
+		out.SecretToken = &SecretKeySelector{
+			Namespace: in.SecretToken.Namespace,
+			Name:      in.SecretToken.Name,
+			Key:       in.SecretToken.Key,
+		}
+	}
+	if in.ProjectedToken != nil {
+		out.ProjectedToken = &ProjectedToken{
+			ServiceAccount: ServiceAccountReference{
+				Namespace: in.ProjectedToken.ServiceAccount.Namespace,
+				Name:      in.ProjectedToken.ServiceAccount.Name,
+			},
+			Audience:          in.ProjectedToken.Audience,
+			ExpirationSeconds: in.ProjectedToken.ExpirationSeconds,
+		}
+	}
+	if in.Impersonation != nil {
+		out.Impersonation = &Impersonation{
+			UserName: in.Impersonation.UserName,
+			Groups:   in.Impersonation.Groups,
+			Extra:    in.Impersonation.Extra,
+		}
+	}
+	return out
+}
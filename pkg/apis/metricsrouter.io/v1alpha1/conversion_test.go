@@ -0,0 +1,86 @@
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+)
+
+func TestConversionRoundTrip(t *testing.T) {
+	for _, tc := range []struct {
+		name             string
+		source           *v1beta1.CustomMetricsSource
+		expectAnnotation bool
+	}{
+		{
+			name: "representable in v1alpha1",
+			source: &v1beta1.CustomMetricsSource{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Labels: map[string]string{"team": "a"}},
+				Spec: v1beta1.CustomMetricsSourceSpec{
+					Backend: v1beta1.Backend{
+						Service: v1beta1.ServiceReference{Namespace: "custom-metrics", Name: "adapter", Port: 443},
+						TLS:     v1beta1.TLSConfig{InsecureSkipVerify: true},
+						Authentication: &v1beta1.Authentication{
+							Mode:        v1beta1.SecretTokenAuthentication,
+							SecretToken: &v1beta1.SecretKeySelector{Namespace: "team-a", Name: "token", Key: "token"},
+						},
+						RequesterForwarding: v1beta1.FrontProxyRequesterForwarding,
+					},
+					Routing: v1beta1.Routing{
+						Priority:    10,
+						MetricTypes: []v1beta1.MetricType{v1beta1.CustomMetricsType, v1beta1.ExternalMetricsType},
+					},
+				},
+			},
+		},
+		{
+			name: "v1beta1 only fields",
+			source: &v1beta1.CustomMetricsSource{
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+				Spec: v1beta1.CustomMetricsSourceSpec{
+					Backend: v1beta1.Backend{
+						Service: v1beta1.ServiceReference{Namespace: "custom-metrics", Name: "adapter", Port: 443},
+						TLS:     v1beta1.TLSConfig{CABundle: []byte("ca")},
+					},
+					Routing: v1beta1.Routing{Priority: 10, MetricTypes: []v1beta1.MetricType{v1beta1.CustomMetricsType}},
+				},
+			},
+			expectAnnotation: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			alpha := &CustomMetricsSource{}
+			require.NoError(t, alpha.ConvertFrom(tc.source))
+			_, ok := alpha.Annotations[SpecAnnotation]
+			require.Equal(t, tc.expectAnnotation, ok)
+
+			beta := &v1beta1.CustomMetricsSource{}
+			require.NoError(t, alpha.ConvertTo(beta))
+			require.Equal(t, tc.source.Spec, beta.Spec)
+			require.Equal(t, tc.source.ObjectMeta, beta.ObjectMeta)
+		})
+	}
+}
+
+func TestConvertToPrefersV1alpha1Fields(t *testing.T) {
+	alpha := &CustomMetricsSource{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test",
+			Annotations: map[string]string{SpecAnnotation: `{"backend":{"service":{"namespace":"a","name":"b","port":443},"tls":{"caBundle":"Y2E="}},"routing":{"priority":1,"metricTypes":["CustomMetrics"]}}`},
+		},
+		Spec: CustomMetricsSourceSpec{
+			Service:     Service{Namespace: "a", Name: "b", Port: 443},
+			Priority:    5,
+			MetricTypes: []MetricType{ExternalMetricsType},
+		},
+	}
+	beta := &v1beta1.CustomMetricsSource{}
+	require.NoError(t, alpha.ConvertTo(beta))
+	require.Equal(t, []byte("ca"), beta.Spec.Backend.TLS.CABundle)
+	require.Equal(t, 5, beta.Spec.Routing.Priority)
+	require.Equal(t, []v1beta1.MetricType{v1beta1.ExternalMetricsType}, beta.Spec.Routing.MetricTypes)
+	require.Nil(t, beta.Annotations)
+}
@@ -19,12 +19,100 @@ const (
 	ExternalMetricsType = "ExternalMetrics"
 )
 
+// +kubebuilder:validation:Enum=None;ServiceAccount;SecretToken;ProjectedToken;Impersonation
+type AuthenticationMode string
+
+const (
+	// NoAuthentication sends requests to the backend without any credentials.
+	NoAuthentication = "None"
+	// ServiceAccountAuthentication sends the token of the router's own service account.
+	ServiceAccountAuthentication = "ServiceAccount"
+	// SecretTokenAuthentication sends a bearer token read from a Secret.
+	SecretTokenAuthentication = "SecretToken"
+	// ProjectedTokenAuthentication sends a token requested through the TokenRequest API.
+	ProjectedTokenAuthentication = "ProjectedToken"
+	// ImpersonationAuthentication sends a short-lived token of the impersonator
+	// service account along with impersonation headers for the configured user.
+	ImpersonationAuthentication = "Impersonation"
+)
+
+// +k8s:deepcopy-gen=true
+type SecretKeySelector struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Key       string `json:"key"`
+}
+
+// +k8s:deepcopy-gen=true
+type ProjectedToken struct {
+	// ServiceAccount is the service account the token is requested for.
+	ServiceAccount ServiceAccountReference `json:"serviceAccount"`
+	Audience       string                  `json:"audience"`
+	// +optional
+	ExpirationSeconds *int64 `json:"expirationSeconds,omitempty"`
+}
+
+// +k8s:deepcopy-gen=true
+type ServiceAccountReference struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// +k8s:deepcopy-gen=true
+type Impersonation struct {
+	UserName string `json:"userName"`
+	// +optional
+	Groups []string `json:"groups,omitempty"`
+	// +optional
+	Extra map[string][]string `json:"extra,omitempty"`
+}
+
+// Authentication describes the credentials the router presents to a backend.
+// When it is not set the router's service account token is used.
+// +k8s:deepcopy-gen=true
+type Authentication struct {
+	Mode AuthenticationMode `json:"mode"`
+	// SecretToken is required when mode is SecretToken. The Secret must be in
+	// the namespace of the backend service.
+	// +optional
+	SecretToken *SecretKeySelector `json:"secretToken,omitempty"`
+	// ProjectedToken is required when mode is ProjectedToken. The service
+	// account must be in the namespace of the backend service.
+	// +optional
+	ProjectedToken *ProjectedToken `json:"projectedToken,omitempty"`
+	// Impersonation is required when mode is Impersonation.
+	// +optional
+	Impersonation *Impersonation `json:"impersonation,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=None;Impersonation;FrontProxy
+type RequesterForwarding string
+
+const (
+	// NoRequesterForwarding calls the backend as the router.
+	NoRequesterForwarding = "None"
+	// ImpersonationRequesterForwarding sends the user of the original request
+	// as Impersonate-User and Impersonate-Group headers.
+	ImpersonationRequesterForwarding = "Impersonation"
+	// FrontProxyRequesterForwarding sends the user of the original request as
+	// X-Remote-User, X-Remote-Group and X-Remote-Extra-* headers, the way the
+	// kube-aggregator does. The backend must trust the router's proxy client
+	// certificate.
+	FrontProxyRequesterForwarding = "FrontProxy"
+)
+
 // +k8s:deepcopy-gen=true
 type CustomMetricsSourceSpec struct {
 	Service               Service      `json:"service"`
 	InsecureSkipTLSVerify bool         `json:"insecureSkipTLSVerify"`
 	Priority              int          `json:"priority"`
 	MetricTypes           []MetricType `json:"metricTypes"`
+	// +optional
+	Authentication *Authentication `json:"authentication,omitempty"`
+	// RequesterForwarding passes the identity of the user who made the metrics
+	// request on to the backend. Defaults to None.
+	// +optional
+	RequesterForwarding RequesterForwarding `json:"requesterForwarding,omitempty"`
 }
 
 // +genclient
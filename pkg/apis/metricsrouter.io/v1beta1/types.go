@@ -0,0 +1,644 @@
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// +k8s:deepcopy-gen=true
+type ServiceReference struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Port      int32  `json:"port"`
+}
+
+// +kubebuilder:validation:Enum=CustomMetrics;ExternalMetrics;ResourceMetrics
+type MetricType string
+
+const (
+	CustomMetricsType   = "CustomMetrics"
+	ExternalMetricsType = "ExternalMetrics"
+	// ResourceMetricsType routes the nodes and pods of metrics.k8s.io.
+	ResourceMetricsType = "ResourceMetrics"
+)
+
+// +kubebuilder:validation:Enum=None;ServiceAccount;SecretToken;ProjectedToken;Impersonation
+type AuthenticationMode string
+
+const (
+	// NoAuthentication sends requests to the backend without any credentials.
+	NoAuthentication = "None"
+	// ServiceAccountAuthentication sends the token of the router's own service account.
+	ServiceAccountAuthentication = "ServiceAccount"
+	// SecretTokenAuthentication sends a bearer token read from a Secret.
+	SecretTokenAuthentication = "SecretToken"
+	// ProjectedTokenAuthentication sends a token requested through the TokenRequest API.
+	ProjectedTokenAuthentication = "ProjectedToken"
+	// ImpersonationAuthentication sends a short-lived token of the impersonator
+	// service account along with impersonation headers for the configured user.
+	ImpersonationAuthentication = "Impersonation"
+)
+
+// +k8s:deepcopy-gen=true
+type SecretKeySelector struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Key       string `json:"key"`
+}
+
+// +k8s:deepcopy-gen=true
+type ProjectedToken struct {
+	// ServiceAccount is the service account the token is requested for.
+	ServiceAccount ServiceAccountReference `json:"serviceAccount"`
+	Audience       string                  `json:"audience"`
+	// +optional
+	ExpirationSeconds *int64 `json:"expirationSeconds,omitempty"`
+}
+
+// +k8s:deepcopy-gen=true
+type ServiceAccountReference struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// +k8s:deepcopy-gen=true
+type Impersonation struct {
+	UserName string `json:"userName"`
+	// +optional
+	Groups []string `json:"groups,omitempty"`
+	// +optional
+	Extra map[string][]string `json:"extra,omitempty"`
+}
+
+// Authentication describes the credentials the router presents to a backend.
+// When it is not set the router's service account token is used.
+// +k8s:deepcopy-gen=true
+type Authentication struct {
+	Mode AuthenticationMode `json:"mode"`
+	// SecretToken is required when mode is SecretToken. The Secret must be in
+	// the namespace of the backend service.
+	// +optional
+	SecretToken *SecretKeySelector `json:"secretToken,omitempty"`
+	// ProjectedToken is required when mode is ProjectedToken. The service
+	// account must be in the namespace of the backend service.
+	// +optional
+	ProjectedToken *ProjectedToken `json:"projectedToken,omitempty"`
+	// Impersonation is required when mode is Impersonation.
+	// +optional
+	Impersonation *Impersonation `json:"impersonation,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=None;Impersonation;FrontProxy
+type RequesterForwarding string
+
+const (
+	// NoRequesterForwarding calls the backend as the router.
+	NoRequesterForwarding = "None"
+	// ImpersonationRequesterForwarding sends the user of the original request
+	// as Impersonate-User and Impersonate-Group headers.
+	ImpersonationRequesterForwarding = "Impersonation"
+	// FrontProxyRequesterForwarding sends the user of the original request as
+	// X-Remote-User, X-Remote-Group and X-Remote-Extra-* headers, the way the
+	// kube-aggregator does. The backend must trust the router's proxy client
+	// certificate.
+	FrontProxyRequesterForwarding = "FrontProxy"
+)
+
+// +k8s:deepcopy-gen=true
+type TLSConfig struct {
+	// InsecureSkipVerify disables the verification of the backend's serving
+	// certificate.
+	// +optional
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+	// CABundle is a PEM encoded CA bundle used to verify the backend's serving
+	// certificate. The CA of the router's service account is used when it is
+	// empty.
+	// +optional
+	CABundle []byte `json:"caBundle,omitempty"`
+}
+
+// BackendType selects the implementation of a backend. Types besides the
+// built-in ones can be registered with the backend package.
+type BackendType string
+
+const (
+	// MetricsAPIBackendType calls the custom and external metrics APIs served
+	// by the service.
+	MetricsAPIBackendType = "MetricsAPI"
+	// PrometheusBackendType evaluates PromQL queries with the HTTP API of the
+	// Prometheus behind the service.
+	PrometheusBackendType = "Prometheus"
+	// PluginBackendType calls the gRPC service of a metrics plugin, see
+	// pkg/plugin/v1/plugin.proto.
+	PluginBackendType = "Plugin"
+	// HTTPBackendType extracts external metrics from the JSON responses of an
+	// HTTP API of the service.
+	HTTPBackendType = "HTTP"
+	// ScrapeBackendType serves custom metrics of pods by scraping the
+	// Prometheus text endpoints of the pods.
+	ScrapeBackendType = "Scrape"
+	// ObjectFieldBackendType serves custom metrics from fields of Kubernetes
+	// objects.
+	ObjectFieldBackendType = "ObjectField"
+	// RemoteWriteBackendType serves metrics from the series which were sent
+	// to the Prometheus remote-write receiver of the router.
+	RemoteWriteBackendType = "RemoteWrite"
+	// OTLPBackendType serves metrics from the data points which were sent to
+	// the OTLP receiver of the router.
+	OTLPBackendType = "OTLP"
+)
+
+// Backend describes how the router reaches a metrics backend.
+// +k8s:deepcopy-gen=true
+type Backend struct {
+	// Type defaults to MetricsAPI.
+	// +optional
+	Type    BackendType      `json:"type,omitempty"`
+	Service ServiceReference `json:"service"`
+	// +optional
+	TLS TLSConfig `json:"tls,omitempty"`
+	// +optional
+	Authentication *Authentication `json:"authentication,omitempty"`
+	// RequesterForwarding passes the identity of the user who made the metrics
+	// request on to the backend. Defaults to None.
+	// +optional
+	RequesterForwarding RequesterForwarding `json:"requesterForwarding,omitempty"`
+	// Prometheus is required for the type Prometheus.
+	// +optional
+	Prometheus *PrometheusBackend `json:"prometheus,omitempty"`
+	// Plugin configures the connection of the type Plugin.
+	// +optional
+	Plugin *PluginBackend `json:"plugin,omitempty"`
+	// HTTP is required for the type HTTP.
+	// +optional
+	HTTP *HTTPBackend `json:"http,omitempty"`
+	// Scrape is required for the type Scrape.
+	// +optional
+	Scrape *ScrapeBackend `json:"scrape,omitempty"`
+	// ObjectField is required for the type ObjectField.
+	// +optional
+	ObjectField *ObjectFieldBackend `json:"objectField,omitempty"`
+	// RemoteWrite is required for the type RemoteWrite.
+	// +optional
+	RemoteWrite *RemoteWriteBackend `json:"remoteWrite,omitempty"`
+	// OTLP is required for the type OTLP.
+	// +optional
+	OTLP *OTLPBackend `json:"otlp,omitempty"`
+	// Parameters configure backend types which aren't built in.
+	// +optional
+	Parameters map[string]string `json:"parameters,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=http;https
+type Scheme string
+
+const (
+	HTTPScheme  = "http"
+	HTTPSScheme = "https"
+)
+
+// PrometheusBackend maps the metrics of a source to PromQL instant queries.
+// +k8s:deepcopy-gen=true
+type PrometheusBackend struct {
+	// Scheme of the Prometheus API. Defaults to http.
+	// +optional
+	Scheme Scheme `json:"scheme,omitempty"`
+	// PathPrefix is prepended to the paths of the API, e.g. /prometheus.
+	// +optional
+	PathPrefix string `json:"pathPrefix,omitempty"`
+	// +optional
+	CustomMetrics []PrometheusCustomMetric `json:"customMetrics,omitempty"`
+	// +optional
+	ExternalMetrics []PrometheusExternalMetric `json:"externalMetrics,omitempty"`
+}
+
+// PrometheusCustomMetric is a custom metric which is queried from Prometheus.
+// +k8s:deepcopy-gen=true
+type PrometheusCustomMetric struct {
+	Name string `json:"name"`
+	// Resource is the resource the metric describes, e.g. pods or
+	// deployments.apps.
+	Resource   string `json:"resource"`
+	Namespaced bool   `json:"namespaced"`
+	// Query is a Go template of the PromQL query. It is executed with
+	// {{.Namespace}}, {{.Names}}, a regular expression matching the names of
+	// the requested objects, and {{.LabelMatchers}}, the metric selector of the
+	// request as comma separated label matchers.
+	Query string `json:"query"`
+	// ObjectLabel is the label of the query results with the name of the
+	// object. Defaults to the singular name of the resource, e.g. pod.
+	// +optional
+	ObjectLabel string `json:"objectLabel,omitempty"`
+}
+
+// PrometheusExternalMetric is an external metric which is queried from
+// Prometheus.
+// +k8s:deepcopy-gen=true
+type PrometheusExternalMetric struct {
+	Name string `json:"name"`
+	// Query is a Go template of the PromQL query. It is executed with
+	// {{.Namespace}} and {{.LabelMatchers}}, the metric selector of the request
+	// as comma separated label matchers. Every result is a value of the metric.
+	Query string `json:"query"`
+}
+
+// PluginBackend describes how the router connects to a metrics plugin.
+// +k8s:deepcopy-gen=true
+type PluginBackend struct {
+	// Socket is the path of a Unix socket the plugin listens on, e.g. in a
+	// volume shared with a sidecar of the router. The service of the backend
+	// is only used to identify the source then. The router connects to the
+	// port of the service over TCP when it is empty.
+	// +optional
+	Socket string `json:"socket,omitempty"`
+	// Plaintext disables TLS for TCP connections. No credentials are sent
+	// over plaintext connections and Unix sockets.
+	// +optional
+	Plaintext bool `json:"plaintext,omitempty"`
+}
+
+// HTTPBackend maps external metrics to JSON documents of an HTTP API.
+// +k8s:deepcopy-gen=true
+type HTTPBackend struct {
+	// Scheme of the API. Defaults to http.
+	// +optional
+	Scheme Scheme `json:"scheme,omitempty"`
+	// Timeout of the requests to the API. Defaults to 10s.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+	// CacheTTL is how long a response is reused for requests of the same URL.
+	// Responses aren't cached when it is unset.
+	// +optional
+	CacheTTL        *metav1.Duration     `json:"cacheTTL,omitempty"`
+	ExternalMetrics []HTTPExternalMetric `json:"externalMetrics"`
+}
+
+// HTTPExternalMetric is an external metric which is read from a JSON
+// document. Value and Labels are either JSONPath templates, e.g. {.length},
+// or Go templates, e.g. {{.length}}, which are executed for each item.
+// +k8s:deepcopy-gen=true
+type HTTPExternalMetric struct {
+	Name string `json:"name"`
+	// Path is a Go template of the path and query of the URL. It is executed
+	// with {{.Namespace}}, {{.Selector}}, the metric selector of the request
+	// escaped for a query parameter, and {{.Labels}}, the labels the metric
+	// selector requires to have a value escaped for a path segment, e.g.
+	// /queues/{{.Labels.queue}}.
+	Path string `json:"path"`
+	// Items is a JSONPath of the items of the document which are values of
+	// the metric, e.g. {.queues[*]}. The whole document is a single item when
+	// it is empty.
+	// +optional
+	Items string `json:"items,omitempty"`
+	// Value extracts the value of an item, a quantity like 42 or 1500m.
+	Value string `json:"value"`
+	// Labels extract the labels of the value of an item. Values whose labels
+	// don't match the metric selector of a request are dropped.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// ScrapeBackend serves custom metrics of pods from the metric families which
+// the pods expose in the Prometheus text format. The service of the source
+// only identifies it.
+// +k8s:deepcopy-gen=true
+type ScrapeBackend struct {
+	// Scheme of the metrics endpoints. Defaults to http.
+	// +optional
+	Scheme Scheme `json:"scheme,omitempty"`
+	// Port is the number or the name of a container port of the pods.
+	Port intstr.IntOrString `json:"port"`
+	// Path of the metrics endpoints. Defaults to /metrics.
+	// +optional
+	Path string `json:"path,omitempty"`
+	// Timeout of a scrape. Defaults to 10s.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+	// MaxConcurrentScrapes limits how many pods are scraped at once for a
+	// request. Defaults to 10.
+	// +optional
+	MaxConcurrentScrapes int32          `json:"maxConcurrentScrapes,omitempty"`
+	Metrics              []ScrapeMetric `json:"metrics"`
+}
+
+// ScrapeMetric is a custom metric of pods which is read from a metric family.
+// The value of a pod is the sum of the samples of the family whose labels
+// match the metric selector of a request.
+// +k8s:deepcopy-gen=true
+type ScrapeMetric struct {
+	Name string `json:"name"`
+	// Family is the name of the metric family. Defaults to the name of the
+	// metric.
+	// +optional
+	Family string `json:"family,omitempty"`
+	// RateWindow serves the per-second rate of the family over the window
+	// instead of its value, e.g. for counters. A pod has a rate from its
+	// second scrape on.
+	// +optional
+	RateWindow *metav1.Duration `json:"rateWindow,omitempty"`
+}
+
+// ObjectFieldBackend serves custom metrics from fields of Kubernetes objects,
+// e.g. the backlog an operator publishes in the status of a custom resource.
+// The objects are read from an informer cache of the router. The service of
+// the source only identifies it.
+// +k8s:deepcopy-gen=true
+type ObjectFieldBackend struct {
+	Metrics []ObjectFieldMetric `json:"metrics"`
+}
+
+// ObjectFieldMetric is a custom metric of the objects of a resource. The
+// metric has no labels, so it only matches requests without a metric
+// selector.
+// +k8s:deepcopy-gen=true
+type ObjectFieldMetric struct {
+	Name string `json:"name"`
+	// Resource is the resource of the objects, e.g. queues.example.com or
+	// deployments.apps. Its preferred version is read.
+	Resource   string `json:"resource"`
+	Namespaced bool   `json:"namespaced"`
+	// Field extracts the value, a quantity like 42 or 1500m, from an object.
+	// It is a JSONPath template, e.g. {.status.backlog}, or a Go template,
+	// e.g. {{.status.backlog}}. Objects without the field have no value.
+	Field string `json:"field"`
+}
+
+// RemoteWriteBackend maps series which were received with the Prometheus
+// remote-write protocol to metrics. Only the latest sample of every series is
+// kept. The service of the source only identifies it.
+// +k8s:deepcopy-gen=true
+type RemoteWriteBackend struct {
+	// +optional
+	CustomMetrics []RemoteWriteCustomMetric `json:"customMetrics,omitempty"`
+	// +optional
+	ExternalMetrics []RemoteWriteExternalMetric `json:"externalMetrics,omitempty"`
+}
+
+// RemoteWriteCustomMetric is a custom metric whose objects are named by labels
+// of the series. The other labels of the series are the labels of the metric,
+// and the values of all series of an object which match the metric selector
+// of a request are summed.
+// +k8s:deepcopy-gen=true
+type RemoteWriteCustomMetric struct {
+	Name string `json:"name"`
+	// Series is the name of the received series, e.g. jobs_pending.
+	Series string `json:"series"`
+	// MatchLabels restricts the series to the ones with these labels.
+	// +optional
+	MatchLabels map[string]string `json:"matchLabels,omitempty"`
+	// Resource is the resource the metric describes, e.g. pods or
+	// deployments.apps.
+	Resource   string `json:"resource"`
+	Namespaced bool   `json:"namespaced"`
+	// NamespaceLabel is the label of the series with the namespace of the
+	// object. Defaults to namespace.
+	// +optional
+	NamespaceLabel string `json:"namespaceLabel,omitempty"`
+	// ObjectLabel is the label of the series with the name of the object.
+	// Defaults to the singular name of the resource, e.g. pod.
+	// +optional
+	ObjectLabel string `json:"objectLabel,omitempty"`
+}
+
+// RemoteWriteExternalMetric is an external metric with a value for every
+// selected series. The labels of the series are the labels of the metric.
+// +k8s:deepcopy-gen=true
+type RemoteWriteExternalMetric struct {
+	Name string `json:"name"`
+	// Series is the name of the received series, e.g. jobs_pending.
+	Series string `json:"series"`
+	// MatchLabels restricts the series to the ones with these labels.
+	// +optional
+	MatchLabels map[string]string `json:"matchLabels,omitempty"`
+	// NamespaceLabel is a label of the series with the namespace in which
+	// the series is served. Without it the series are served in every
+	// namespace.
+	// +optional
+	NamespaceLabel string `json:"namespaceLabel,omitempty"`
+}
+
+// OTLPBackend maps gauge and cumulative sum data points which were received
+// with the OpenTelemetry protocol to metrics. The attributes of a data point
+// include the attributes of its resource. Only the latest value of every
+// series is kept. The service of the source only identifies it.
+// +k8s:deepcopy-gen=true
+type OTLPBackend struct {
+	// +optional
+	CustomMetrics []OTLPCustomMetric `json:"customMetrics,omitempty"`
+	// +optional
+	ExternalMetrics []OTLPExternalMetric `json:"externalMetrics,omitempty"`
+}
+
+// OTLPCustomMetric is a custom metric whose objects are named by attributes
+// of the data points. The values of all data points of an object which match
+// the metric selector of a request are summed.
+// +k8s:deepcopy-gen=true
+type OTLPCustomMetric struct {
+	Name string `json:"name"`
+	// Metric is the name of the received metric, e.g. queue.messages.
+	Metric string `json:"metric"`
+	// MatchAttributes restricts the data points to the ones with these
+	// attributes.
+	// +optional
+	MatchAttributes map[string]string `json:"matchAttributes,omitempty"`
+	// Resource is the resource the metric describes, e.g. pods or
+	// deployments.apps.
+	Resource   string `json:"resource"`
+	Namespaced bool   `json:"namespaced"`
+	// NamespaceAttribute is the attribute with the namespace of the object.
+	// Defaults to k8s.namespace.name.
+	// +optional
+	NamespaceAttribute string `json:"namespaceAttribute,omitempty"`
+	// ObjectAttribute is the attribute with the name of the object. Defaults
+	// to the attribute of the resource in the semantic conventions, e.g.
+	// k8s.pod.name.
+	// +optional
+	ObjectAttribute string `json:"objectAttribute,omitempty"`
+	// Labels maps the labels of the metric to attributes of the data points.
+	// Without it the metric has no labels.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// OTLPExternalMetric is an external metric with a value for every selected
+// series of data points.
+// +k8s:deepcopy-gen=true
+type OTLPExternalMetric struct {
+	Name string `json:"name"`
+	// Metric is the name of the received metric, e.g. queue.messages.
+	Metric string `json:"metric"`
+	// MatchAttributes restricts the data points to the ones with these
+	// attributes.
+	// +optional
+	MatchAttributes map[string]string `json:"matchAttributes,omitempty"`
+	// NamespaceAttribute is an attribute with the namespace in which the
+	// series is served. Without it the series are served in every namespace.
+	// +optional
+	NamespaceAttribute string `json:"namespaceAttribute,omitempty"`
+	// Labels maps the labels of the metric to attributes of the data points.
+	// Without it the metric has no labels.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// MetricPattern matches metrics which a backend serves without listing them
+// in its discovery document. Exactly one of Regex and Glob must be set.
+// +k8s:deepcopy-gen=true
+type MetricPattern struct {
+	MetricType MetricType `json:"metricType"`
+	// Regex is a regular expression the whole name of the metric must match.
+	// +optional
+	Regex string `json:"regex,omitempty"`
+	// Glob is a shell pattern for the name of the metric, where * matches any
+	// sequence of characters and ? a single character.
+	// +optional
+	Glob string `json:"glob,omitempty"`
+	// GroupResource restricts custom metrics to a resource, e.g. pods or
+	// deployments.apps. It must be empty for external metrics.
+	// +optional
+	GroupResource string `json:"groupResource,omitempty"`
+}
+
+// Routing describes which metrics requests are routed to a backend.
+// +k8s:deepcopy-gen=true
+type Routing struct {
+	// Priority orders sources serving the same metric. The source with the
+	// lowest priority is preferred.
+	Priority    int          `json:"priority"`
+	MetricTypes []MetricType `json:"metricTypes"`
+	// MetricPatterns route metrics which no source lists in its discovery
+	// document to this source.
+	// +optional
+	MetricPatterns []MetricPattern `json:"metricPatterns,omitempty"`
+	// Default routes metrics of its metric types which no source lists and
+	// no pattern matches to this source.
+	// +optional
+	Default bool `json:"default,omitempty"`
+}
+
+// +k8s:deepcopy-gen=true
+type StaticCustomMetric struct {
+	// Resource is the resource the metric describes, e.g. pods or
+	// deployments.apps.
+	Resource   string `json:"resource"`
+	Name       string `json:"name"`
+	Namespaced bool   `json:"namespaced"`
+}
+
+// StaticMetrics are routed to a source without asking its discovery API.
+// +k8s:deepcopy-gen=true
+type StaticMetrics struct {
+	// +optional
+	CustomMetrics []StaticCustomMetric `json:"customMetrics,omitempty"`
+	// +optional
+	ExternalMetrics []string `json:"externalMetrics,omitempty"`
+	// DisableDiscovery routes only the static metrics to the source. Otherwise
+	// the metrics listed by discovery are routed as well, and a failing
+	// discovery doesn't prevent the static metrics from being routed.
+	// +optional
+	DisableDiscovery bool `json:"disableDiscovery,omitempty"`
+}
+
+// +k8s:deepcopy-gen=true
+type CustomMetricsSourceSpec struct {
+	Backend Backend `json:"backend"`
+	Routing Routing `json:"routing"`
+	// +optional
+	StaticMetrics *StaticMetrics `json:"staticMetrics,omitempty"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:storageversion
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// +k8s:deepcopy-gen=true
+type CustomMetricsSource struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              CustomMetricsSourceSpec `json:"spec"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +k8s:deepcopy-gen=true
+type CustomMetricsSourceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CustomMetricsSource `json:"items"`
+}
+
+// +kubebuilder:validation:Enum=Failover;Strict
+type RouteStrategy string
+
+const (
+	// FailoverRouteStrategy uses the first listed source which serves the
+	// metric and falls back to the priority of the other sources when none of
+	// them does.
+	FailoverRouteStrategy = "Failover"
+	// StrictRouteStrategy only uses the listed sources. Requests for the
+	// metric fail when none of them serves it.
+	StrictRouteStrategy = "Strict"
+)
+
+// MetricMatch selects the metrics a MetricRoute applies to. Empty fields
+// match every metric.
+// +k8s:deepcopy-gen=true
+type MetricMatch struct {
+	MetricType MetricType `json:"metricType"`
+	// Name is the exact name of the metric. It can't be combined with NameRegex.
+	// +optional
+	Name string `json:"name,omitempty"`
+	// NameRegex is a regular expression the whole name of the metric must match.
+	// +optional
+	NameRegex string `json:"nameRegex,omitempty"`
+	// GroupResource restricts custom metrics to a resource, e.g. pods or
+	// deployments.apps. It must be empty for external metrics.
+	// +optional
+	GroupResource string `json:"groupResource,omitempty"`
+	// NamespaceSelector restricts the route to requests in namespaces with
+	// matching labels. Requests for cluster scoped objects never match it.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+}
+
+// +k8s:deepcopy-gen=true
+type SourceReference struct {
+	// Name is the name of a CustomMetricsSource.
+	Name string `json:"name"`
+}
+
+// +k8s:deepcopy-gen=true
+type MetricRouteSpec struct {
+	Match MetricMatch `json:"match"`
+	// Sources are tried in order.
+	// +kubebuilder:validation:MinItems=1
+	Sources []SourceReference `json:"sources"`
+	// Strategy defaults to Failover.
+	// +optional
+	Strategy RouteStrategy `json:"strategy,omitempty"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// MetricRoute overrides the priority of sources for the metrics it matches.
+// When several routes match a metric the one with the lowest name is used.
+// +k8s:deepcopy-gen=true
+type MetricRoute struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              MetricRouteSpec `json:"spec"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +k8s:deepcopy-gen=true
+type MetricRouteList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MetricRoute `json:"items"`
+}
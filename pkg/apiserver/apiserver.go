@@ -0,0 +1,135 @@
+package apiserver
+
+import (
+	"context"
+
+	cmapiserver "github.com/kubernetes-sigs/custom-metrics-apiserver/pkg/apiserver"
+	specificapi "github.com/kubernetes-sigs/custom-metrics-apiserver/pkg/apiserver/installer"
+	upstreamprovider "github.com/kubernetes-sigs/custom-metrics-apiserver/pkg/provider"
+	cmregistry "github.com/kubernetes-sigs/custom-metrics-apiserver/pkg/registry/custom_metrics"
+	emregistry "github.com/kubernetes-sigs/custom-metrics-apiserver/pkg/registry/external_metrics"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metainternalversion "k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	genericapi "k8s.io/apiserver/pkg/endpoints"
+	"k8s.io/apiserver/pkg/endpoints/discovery"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+	"k8s.io/metrics/pkg/apis/custom_metrics"
+	"k8s.io/metrics/pkg/apis/external_metrics"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/provider"
+)
+
+// InstallMetricsAPIs registers the custom, external and resource metrics APIs
+// on server.
+// Unlike the installation done by the custom-metrics-apiserver library, every
+// request is served by a provider bound to the request context so that the
+// requesting user is known when the backend is called.
+func InstallMetricsAPIs(server *genericapiserver.GenericAPIServer, metricsProvider provider.FullMetricsProvider) error {
+	if err := installCustomMetricsAPI(server, metricsProvider); err != nil {
+		return err
+	}
+	if err := installExternalMetricsAPI(server, metricsProvider); err != nil {
+		return err
+	}
+	return installResourceMetricsAPI(server, metricsProvider)
+}
+
+// AggregatedGroupVersions returns the versions of the custom and external
+// metrics APIs which are registered with the aggregator for the router,
+// ordered by preference within their group.
+func AggregatedGroupVersions() []schema.GroupVersion {
+	groupVersions := cmapiserver.Scheme.PrioritizedVersionsForGroup(custom_metrics.GroupName)
+	return append(groupVersions, cmapiserver.Scheme.PrioritizedVersionsForGroup(external_metrics.GroupName)[0])
+}
+
+func installCustomMetricsAPI(server *genericapiserver.GenericAPIServer, metricsProvider provider.FullMetricsProvider) error {
+	groupInfo := genericapiserver.NewDefaultAPIGroupInfo(custom_metrics.GroupName, cmapiserver.Scheme, runtime.NewParameterCodec(cmapiserver.Scheme), cmapiserver.Codecs)
+	container := server.Handler.GoRestfulContainer
+	storage := &customMetricsStorage{REST: cmregistry.NewREST(metricsProvider), provider: metricsProvider}
+
+	for versionIndex, groupVersion := range groupInfo.PrioritizedVersions {
+		api := &specificapi.MetricsAPIGroupVersion{
+			DynamicStorage:  storage,
+			APIGroupVersion: apiGroupVersion(&groupInfo, groupVersion),
+			ResourceLister:  upstreamprovider.NewCustomMetricResourceLister(metricsProvider),
+			Handlers:        &specificapi.CMHandlers{},
+		}
+		if err := api.InstallREST(container); err != nil {
+			return err
+		}
+		if versionIndex == 0 {
+			addGroup(server, groupVersion)
+		}
+	}
+	return nil
+}
+
+func installExternalMetricsAPI(server *genericapiserver.GenericAPIServer, metricsProvider provider.FullMetricsProvider) error {
+	groupInfo := genericapiserver.NewDefaultAPIGroupInfo(external_metrics.GroupName, cmapiserver.Scheme, metav1.ParameterCodec, cmapiserver.Codecs)
+	groupVersion := groupInfo.PrioritizedVersions[0]
+
+	api := &specificapi.MetricsAPIGroupVersion{
+		DynamicStorage:  &externalMetricsStorage{REST: emregistry.NewREST(metricsProvider), provider: metricsProvider},
+		APIGroupVersion: apiGroupVersion(&groupInfo, groupVersion),
+		ResourceLister:  upstreamprovider.NewExternalMetricResourceLister(metricsProvider),
+		Handlers:        &specificapi.EMHandlers{},
+	}
+	if err := api.InstallREST(server.Handler.GoRestfulContainer); err != nil {
+		return err
+	}
+	addGroup(server, groupVersion)
+	return nil
+}
+
+func apiGroupVersion(groupInfo *genericapiserver.APIGroupInfo, groupVersion schema.GroupVersion) *genericapi.APIGroupVersion {
+	return &genericapi.APIGroupVersion{
+		Root:             genericapiserver.APIGroupPrefix,
+		GroupVersion:     groupVersion,
+		MetaGroupVersion: groupInfo.MetaGroupVersion,
+
+		ParameterCodec:  groupInfo.ParameterCodec,
+		Serializer:      groupInfo.NegotiatedSerializer,
+		Creater:         groupInfo.Scheme,
+		Convertor:       groupInfo.Scheme,
+		UnsafeConvertor: runtime.UnsafeObjectConvertor(groupInfo.Scheme),
+		Typer:           groupInfo.Scheme,
+		Linker:          runtime.SelfLinker(meta.NewAccessor()),
+	}
+}
+
+func addGroup(server *genericapiserver.GenericAPIServer, groupVersion schema.GroupVersion) {
+	discoveryVersion := metav1.GroupVersionForDiscovery{
+		GroupVersion: groupVersion.String(),
+		Version:      groupVersion.Version,
+	}
+	apiGroup := metav1.APIGroup{
+		Name:             groupVersion.Group,
+		Versions:         []metav1.GroupVersionForDiscovery{discoveryVersion},
+		PreferredVersion: discoveryVersion,
+	}
+	server.DiscoveryGroupManager.AddGroup(apiGroup)
+	server.Handler.GoRestfulContainer.Add(discovery.NewAPIGroupHandler(server.Serializer, apiGroup).WebService())
+}
+
+// customMetricsStorage reuses the request parsing of the upstream storage but
+// hands every request to a provider bound to the request context.
+type customMetricsStorage struct {
+	*cmregistry.REST
+	provider provider.FullMetricsProvider
+}
+
+func (s *customMetricsStorage) List(ctx context.Context, options *metainternalversion.ListOptions, metricOpts runtime.Object) (runtime.Object, error) {
+	return cmregistry.NewREST(s.provider.ForRequest(ctx)).List(ctx, options, metricOpts)
+}
+
+type externalMetricsStorage struct {
+	*emregistry.REST
+	provider provider.FullMetricsProvider
+}
+
+func (s *externalMetricsStorage) List(ctx context.Context, options *metainternalversion.ListOptions) (runtime.Object, error) {
+	return emregistry.NewREST(s.provider.ForRequest(ctx)).List(ctx, options)
+}
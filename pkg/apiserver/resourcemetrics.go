@@ -0,0 +1,136 @@
+package apiserver
+
+import (
+	"context"
+
+	metainternalversion "k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/registry/rest"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+	"k8s.io/metrics/pkg/apis/metrics"
+	"k8s.io/metrics/pkg/apis/metrics/install"
+	"k8s.io/metrics/pkg/apis/metrics/v1beta1"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/provider"
+)
+
+var (
+	resourceMetricsScheme = runtime.NewScheme()
+	resourceMetricsCodecs = serializer.NewCodecFactory(resourceMetricsScheme)
+)
+
+func init() {
+	install.Install(resourceMetricsScheme)
+	// the list and get options are registered in the empty group.
+	metav1.AddToGroupVersion(resourceMetricsScheme, schema.GroupVersion{Version: "v1"})
+	unversioned := schema.GroupVersion{Group: "", Version: "v1"}
+	resourceMetricsScheme.AddUnversionedTypes(unversioned,
+		&metav1.Status{},
+		&metav1.APIVersions{},
+		&metav1.APIGroupList{},
+		&metav1.APIGroup{},
+		&metav1.APIResourceList{},
+	)
+}
+
+func installResourceMetricsAPI(server *genericapiserver.GenericAPIServer, metricsProvider provider.FullMetricsProvider) error {
+	groupInfo := genericapiserver.NewDefaultAPIGroupInfo(metrics.GroupName, resourceMetricsScheme, metav1.ParameterCodec, resourceMetricsCodecs)
+	groupInfo.VersionedResourcesStorageMap[v1beta1.SchemeGroupVersion.Version] = map[string]rest.Storage{
+		"nodes": &nodeMetricsStorage{
+			TableConvertor: rest.NewDefaultTableConvertor(v1beta1.Resource("nodemetrics")),
+			provider:       metricsProvider,
+		},
+		"pods": &podMetricsStorage{
+			TableConvertor: rest.NewDefaultTableConvertor(v1beta1.Resource("podmetrics")),
+			provider:       metricsProvider,
+		},
+	}
+	return server.InstallAPIGroup(&groupInfo)
+}
+
+// listOptions converts the options of a request to the options for a backend.
+func listOptions(options *metainternalversion.ListOptions) metav1.ListOptions {
+	out := metav1.ListOptions{}
+	if options == nil {
+		return out
+	}
+	if options.LabelSelector != nil {
+		out.LabelSelector = options.LabelSelector.String()
+	}
+	if options.FieldSelector != nil {
+		out.FieldSelector = options.FieldSelector.String()
+	}
+	return out
+}
+
+type nodeMetricsStorage struct {
+	rest.TableConvertor
+	provider provider.FullMetricsProvider
+}
+
+var _ rest.KindProvider = &nodeMetricsStorage{}
+var _ rest.Scoper = &nodeMetricsStorage{}
+var _ rest.Getter = &nodeMetricsStorage{}
+var _ rest.Lister = &nodeMetricsStorage{}
+
+func (s *nodeMetricsStorage) New() runtime.Object {
+	return &metrics.NodeMetrics{}
+}
+
+func (s *nodeMetricsStorage) NewList() runtime.Object {
+	return &metrics.NodeMetricsList{}
+}
+
+func (s *nodeMetricsStorage) Kind() string {
+	return "NodeMetrics"
+}
+
+func (s *nodeMetricsStorage) NamespaceScoped() bool {
+	return false
+}
+
+func (s *nodeMetricsStorage) Get(ctx context.Context, name string, _ *metav1.GetOptions) (runtime.Object, error) {
+	return s.provider.ForRequest(ctx).GetNodeMetrics(name)
+}
+
+func (s *nodeMetricsStorage) List(ctx context.Context, options *metainternalversion.ListOptions) (runtime.Object, error) {
+	return s.provider.ForRequest(ctx).ListNodeMetrics(listOptions(options))
+}
+
+type podMetricsStorage struct {
+	rest.TableConvertor
+	provider provider.FullMetricsProvider
+}
+
+var _ rest.KindProvider = &podMetricsStorage{}
+var _ rest.Scoper = &podMetricsStorage{}
+var _ rest.Getter = &podMetricsStorage{}
+var _ rest.Lister = &podMetricsStorage{}
+
+func (s *podMetricsStorage) New() runtime.Object {
+	return &metrics.PodMetrics{}
+}
+
+func (s *podMetricsStorage) NewList() runtime.Object {
+	return &metrics.PodMetricsList{}
+}
+
+func (s *podMetricsStorage) Kind() string {
+	return "PodMetrics"
+}
+
+func (s *podMetricsStorage) NamespaceScoped() bool {
+	return true
+}
+
+func (s *podMetricsStorage) Get(ctx context.Context, name string, _ *metav1.GetOptions) (runtime.Object, error) {
+	return s.provider.ForRequest(ctx).GetPodMetrics(genericapirequest.NamespaceValue(ctx), name)
+}
+
+func (s *podMetricsStorage) List(ctx context.Context, options *metainternalversion.ListOptions) (runtime.Object, error) {
+	return s.provider.ForRequest(ctx).ListPodMetrics(genericapirequest.NamespaceValue(ctx), listOptions(options))
+}
@@ -0,0 +1,94 @@
+package apiserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	upstreamprovider "github.com/kubernetes-sigs/custom-metrics-apiserver/pkg/provider"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+	"k8s.io/client-go/rest"
+	"k8s.io/metrics/pkg/apis/metrics"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/provider"
+)
+
+type fakeResourceMetricsProvider struct {
+	provider.FullMetricsProvider
+	listOptions *metav1.ListOptions
+}
+
+func (p *fakeResourceMetricsProvider) ForRequest(context.Context) provider.FullMetricsProvider {
+	return p
+}
+
+func (p *fakeResourceMetricsProvider) ListAllMetrics() []upstreamprovider.CustomMetricInfo {
+	return nil
+}
+
+func (p *fakeResourceMetricsProvider) ListAllExternalMetrics() []upstreamprovider.ExternalMetricInfo {
+	return nil
+}
+
+func (p *fakeResourceMetricsProvider) GetPodMetrics(namespace, name string) (*metrics.PodMetrics, error) {
+	if name != "web" {
+		return nil, apierrors.NewNotFound(metrics.Resource("pods"), name)
+	}
+	return &metrics.PodMetrics{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}, nil
+}
+
+func (p *fakeResourceMetricsProvider) ListNodeMetrics(options metav1.ListOptions) (*metrics.NodeMetricsList, error) {
+	p.listOptions = &options
+	return &metrics.NodeMetricsList{Items: []metrics.NodeMetrics{{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}}}}, nil
+}
+
+func TestResourceMetricsAPI(t *testing.T) {
+	config := genericapiserver.NewConfig(resourceMetricsCodecs)
+	config.ExternalAddress = "127.0.0.1:443"
+	config.LoopbackClientConfig = &rest.Config{}
+	server, err := config.Complete(nil).New("test", genericapiserver.NewEmptyDelegate())
+	require.NoError(t, err)
+	metricsProvider := &fakeResourceMetricsProvider{}
+	require.NoError(t, InstallMetricsAPIs(server, metricsProvider))
+
+	for _, tc := range []struct {
+		name         string
+		path         string
+		expectedCode int
+		expectedKind string
+	}{
+		{
+			name:         "pod",
+			path:         "/apis/metrics.k8s.io/v1beta1/namespaces/team-a/pods/web",
+			expectedCode: http.StatusOK,
+			expectedKind: "PodMetrics",
+		},
+		{
+			name:         "missing pod",
+			path:         "/apis/metrics.k8s.io/v1beta1/namespaces/team-a/pods/db",
+			expectedCode: http.StatusNotFound,
+			expectedKind: "Status",
+		},
+		{
+			name:         "nodes",
+			path:         "/apis/metrics.k8s.io/v1beta1/nodes?labelSelector=role%3Dworker",
+			expectedCode: http.StatusOK,
+			expectedKind: "NodeMetricsList",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tc.path, nil))
+			require.Equal(t, tc.expectedCode, recorder.Code, recorder.Body.String())
+			typeMeta := metav1.TypeMeta{}
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &typeMeta))
+			require.Equal(t, tc.expectedKind, typeMeta.Kind)
+		})
+	}
+	require.Equal(t, "role=worker", metricsProvider.listOptions.LabelSelector)
+}
@@ -0,0 +1,99 @@
+package authorization
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/cache"
+	"k8s.io/apiserver/pkg/authentication/user"
+	authenticationclient "k8s.io/client-go/kubernetes/typed/authentication/v1"
+)
+
+// Authenticator identifies the user of a request to the endpoints which the
+// router serves besides the metrics APIs.
+type Authenticator interface {
+	// AuthenticateRequest returns the user of a request, or nil when the
+	// request has no valid credentials.
+	AuthenticateRequest(r *http.Request) (user.Info, error)
+}
+
+// authentication is a cached TokenReview result, the user is nil for tokens
+// which aren't valid.
+type authentication struct {
+	user user.Info
+}
+
+type tokenReviewAuthenticator struct {
+	client             authenticationclient.TokenReviewInterface
+	cache              *cache.Expiring
+	authenticatedTTL   time.Duration
+	unauthenticatedTTL time.Duration
+}
+
+// NewTokenReviewAuthenticator returns an Authenticator which asks the
+// Kubernetes API server with a TokenReview for the user of the bearer token
+// of a request. Results are cached by a hash of the token for
+// authenticatedTTL or unauthenticatedTTL respectively.
+func NewTokenReviewAuthenticator(client authenticationclient.TokenReviewInterface, authenticatedTTL, unauthenticatedTTL time.Duration) Authenticator {
+	return &tokenReviewAuthenticator{
+		client:             client,
+		cache:              cache.NewExpiring(),
+		authenticatedTTL:   authenticatedTTL,
+		unauthenticatedTTL: unauthenticatedTTL,
+	}
+}
+
+func (a *tokenReviewAuthenticator) AuthenticateRequest(r *http.Request) (user.Info, error) {
+	header := r.Header.Get("Authorization")
+	token := strings.TrimPrefix(header, "Bearer ")
+	if token == "" || token == header {
+		return nil, nil
+	}
+	hash := sha256.Sum256([]byte(token))
+	key := hex.EncodeToString(hash[:])
+	if cached, ok := a.cache.Get(key); ok {
+		return cached.(authentication).user, nil
+	}
+	requester, err := a.review(r.Context(), token)
+	if err != nil {
+		return nil, err
+	}
+	ttl := a.unauthenticatedTTL
+	if requester != nil {
+		ttl = a.authenticatedTTL
+	}
+	a.cache.Set(key, authentication{user: requester}, ttl)
+	return requester, nil
+}
+
+// review returns the user of a token, or nil when it isn't valid.
+func (a *tokenReviewAuthenticator) review(ctx context.Context, token string) (user.Info, error) {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	review, err := a.client.Create(ctx, &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token review: %v", err)
+	}
+	if !review.Status.Authenticated {
+		return nil, nil
+	}
+	extra := make(map[string][]string, len(review.Status.User.Extra))
+	for k, v := range review.Status.User.Extra {
+		extra[k] = v
+	}
+	return &user.DefaultInfo{
+		Name:   review.Status.User.Username,
+		UID:    review.Status.User.UID,
+		Groups: review.Status.User.Groups,
+		Extra:  extra,
+	}, nil
+}
@@ -0,0 +1,59 @@
+package authorization
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestTokenReviewAuthenticator(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	reviews := 0
+	client.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+		reviews++
+		if review.Spec.Token == "agent-token" {
+			review.Status.Authenticated = true
+			review.Status.User = authenticationv1.UserInfo{Username: "system:serviceaccount:monitoring:agent", Groups: []string{"system:serviceaccounts"}}
+		}
+		return true, review, nil
+	})
+	authenticator := NewTokenReviewAuthenticator(client.AuthenticationV1().TokenReviews(), time.Minute, time.Minute)
+
+	for _, tc := range []struct {
+		name     string
+		header   string
+		expected string
+		reviews  int
+	}{
+		{name: "without token", reviews: 0},
+		{name: "basic auth", header: "Basic YWdlbnQ6c2VjcmV0", reviews: 0},
+		{name: "valid token", header: "Bearer agent-token", expected: "system:serviceaccount:monitoring:agent", reviews: 1},
+		{name: "cached valid token", header: "Bearer agent-token", expected: "system:serviceaccount:monitoring:agent", reviews: 1},
+		{name: "invalid token", header: "Bearer other-token", reviews: 2},
+		{name: "cached invalid token", header: "Bearer other-token", reviews: 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, "/", nil)
+			if tc.header != "" {
+				request.Header.Set("Authorization", tc.header)
+			}
+			requester, err := authenticator.AuthenticateRequest(request)
+			require.NoError(t, err)
+			if tc.expected == "" {
+				require.Nil(t, requester)
+			} else {
+				require.Equal(t, tc.expected, requester.GetName())
+				require.Equal(t, []string{"system:serviceaccounts"}, requester.GetGroups())
+			}
+			require.Equal(t, tc.reviews, reviews)
+		})
+	}
+}
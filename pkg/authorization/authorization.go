@@ -0,0 +1,145 @@
+package authorization
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/cache"
+	"k8s.io/apiserver/pkg/authentication/user"
+	authorizationclient "k8s.io/client-go/kubernetes/typed/authorization/v1"
+)
+
+// MetricsGroup is the API group of the virtual resources which are checked
+// with a SubjectAccessReview. The resource is the name of the source serving
+// the metric and the resource name is the metric itself, so a Role like
+//
+//   rules:
+//   - apiGroups: ["metrics.metricsrouter.io"]
+//     resources: ["billing-adapter"]
+//     resourceNames: ["revenue"]
+//     verbs: ["get"]
+//
+// bound in a namespace allows reading the revenue metric of the
+// billing-adapter source in that namespace.
+const MetricsGroup = "metrics.metricsrouter.io"
+
+const requestTimeout = 10 * time.Second
+
+// Attributes describe a metrics request that is checked before it is
+// dispatched to a backend.
+type Attributes struct {
+	User user.Info
+	// Verb defaults to get.
+	Verb      string
+	Namespace string
+	Source    string
+	Metric    string
+}
+
+type Authorizer interface {
+	// Authorize returns whether the request is allowed and the reason for the
+	// decision.
+	Authorize(attributes Attributes) (bool, string, error)
+}
+
+type alwaysAllowAuthorizer struct{}
+
+// NewAlwaysAllowAuthorizer returns an Authorizer which allows every request.
+func NewAlwaysAllowAuthorizer() Authorizer {
+	return alwaysAllowAuthorizer{}
+}
+
+func (alwaysAllowAuthorizer) Authorize(Attributes) (bool, string, error) {
+	return true, "", nil
+}
+
+type decision struct {
+	allowed bool
+	reason  string
+}
+
+type subjectAccessReviewAuthorizer struct {
+	client   authorizationclient.SubjectAccessReviewInterface
+	cache    *cache.Expiring
+	allowTTL time.Duration
+	denyTTL  time.Duration
+}
+
+// NewSubjectAccessReviewAuthorizer returns an Authorizer which asks the
+// Kubernetes API server with a SubjectAccessReview. Decisions are cached for
+// allowTTL or denyTTL respectively.
+func NewSubjectAccessReviewAuthorizer(client authorizationclient.SubjectAccessReviewInterface, allowTTL, denyTTL time.Duration) Authorizer {
+	return &subjectAccessReviewAuthorizer{
+		client:   client,
+		cache:    cache.NewExpiring(),
+		allowTTL: allowTTL,
+		denyTTL:  denyTTL,
+	}
+}
+
+func (a *subjectAccessReviewAuthorizer) Authorize(attributes Attributes) (bool, string, error) {
+	if attributes.User == nil {
+		return false, "no user found for the request", nil
+	}
+	key := cacheKey(attributes)
+	if cached, ok := a.cache.Get(key); ok {
+		d := cached.(decision)
+		return d.allowed, d.reason, nil
+	}
+
+	verb := attributes.Verb
+	if verb == "" {
+		verb = "get"
+	}
+	extra := make(map[string]authorizationv1.ExtraValue, len(attributes.User.GetExtra()))
+	for k, v := range attributes.User.GetExtra() {
+		extra[k] = v
+	}
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: attributes.Namespace,
+				Verb:      verb,
+				Group:     MetricsGroup,
+				Resource:  attributes.Source,
+				Name:      attributes.Metric,
+			},
+			User:   attributes.User.GetName(),
+			Groups: attributes.User.GetGroups(),
+			Extra:  extra,
+			UID:    attributes.User.GetUID(),
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	result, err := a.client.Create(ctx, review, metav1.CreateOptions{})
+	if err != nil {
+		return false, "", fmt.Errorf("failed to create subject access review: %v", err)
+	}
+
+	d := decision{allowed: result.Status.Allowed, reason: result.Status.Reason}
+	ttl := a.denyTTL
+	if d.allowed {
+		ttl = a.allowTTL
+	}
+	a.cache.Set(key, d, ttl)
+	return d.allowed, d.reason, nil
+}
+
+func cacheKey(attributes Attributes) string {
+	groups := append([]string(nil), attributes.User.GetGroups()...)
+	sort.Strings(groups)
+	extra := make([]string, 0, len(attributes.User.GetExtra()))
+	for k, v := range attributes.User.GetExtra() {
+		extra = append(extra, fmt.Sprintf("%s=%s", k, strings.Join(v, ",")))
+	}
+	sort.Strings(extra)
+	return fmt.Sprintf("%q/%q/%q/%q/%q/%q/%q/%q",
+		attributes.User.GetName(), attributes.User.GetUID(), groups, extra,
+		attributes.Verb, attributes.Namespace, attributes.Source, attributes.Metric)
+}
@@ -0,0 +1,62 @@
+package authorization
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestSubjectAccessReviewAuthorizer(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	var reviews []authorizationv1.SubjectAccessReviewSpec
+	client.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		reviews = append(reviews, review.Spec)
+		review.Status.Allowed = review.Spec.ResourceAttributes.Namespace == "team-a"
+		return true, review, nil
+	})
+	authorizer := NewSubjectAccessReviewAuthorizer(client.AuthorizationV1().SubjectAccessReviews(), time.Minute, time.Minute)
+	jane := &user.DefaultInfo{Name: "jane", Groups: []string{"team-a"}}
+
+	for _, tc := range []struct {
+		name      string
+		namespace string
+		allowed   bool
+		reviews   int
+	}{
+		{name: "allowed", namespace: "team-a", allowed: true, reviews: 1},
+		{name: "cached", namespace: "team-a", allowed: true, reviews: 1},
+		{name: "denied", namespace: "team-b", allowed: false, reviews: 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			allowed, _, err := authorizer.Authorize(Attributes{
+				User:      jane,
+				Namespace: tc.namespace,
+				Source:    "billing-adapter",
+				Metric:    "revenue",
+			})
+			require.NoError(t, err)
+			require.Equal(t, tc.allowed, allowed)
+			require.Len(t, reviews, tc.reviews)
+		})
+	}
+
+	require.Equal(t, &authorizationv1.ResourceAttributes{
+		Namespace: "team-a",
+		Verb:      "get",
+		Group:     MetricsGroup,
+		Resource:  "billing-adapter",
+		Name:      "revenue",
+	}, reviews[0].ResourceAttributes)
+	require.Equal(t, "jane", reviews[0].User)
+
+	allowed, _, err := authorizer.Authorize(Attributes{Namespace: "team-a", Source: "billing-adapter", Metric: "revenue"})
+	require.NoError(t, err)
+	require.False(t, allowed)
+}
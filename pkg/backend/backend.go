@@ -0,0 +1,132 @@
+package backend
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/kubernetes-sigs/custom-metrics-apiserver/pkg/provider"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/metrics/pkg/apis/custom_metrics"
+	"k8s.io/metrics/pkg/apis/external_metrics"
+	"k8s.io/metrics/pkg/apis/metrics"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+	"github.com/arjunrn/custom-metrics-router/pkg/objectcache"
+	"github.com/arjunrn/custom-metrics-router/pkg/rates"
+	"github.com/arjunrn/custom-metrics-router/pkg/series"
+)
+
+// Backend serves the custom and external metrics of a source.
+type Backend interface {
+	// Source returns the name of the source the backend was created for.
+	Source() string
+	ListCustomMetricInfos() (map[provider.CustomMetricInfo]struct{}, error)
+	ListExternalMetrics() (map[provider.ExternalMetricInfo]struct{}, error)
+	GetMetricByName(name types.NamespacedName, info provider.CustomMetricInfo, metricSelector labels.Selector) (*custom_metrics.MetricValue, error)
+	GetMetricBySelector(namespace string, selector labels.Selector, info provider.CustomMetricInfo, metricSelector labels.Selector) (*custom_metrics.MetricValueList, error)
+	GetExternalMetric(name, namespace string, metricSelector labels.Selector) (*external_metrics.ExternalMetricValueList, error)
+}
+
+// ResourceMetricsBackend is a backend which also serves the nodes and pods of
+// metrics.k8s.io.
+type ResourceMetricsBackend interface {
+	Backend
+	// ListResourceMetrics returns the resources, nodes and pods, for which the
+	// backend serves resource metrics.
+	ListResourceMetrics() (map[string]struct{}, error)
+	GetNodeMetrics(name string) (*metrics.NodeMetrics, error)
+	ListNodeMetrics(options metav1.ListOptions) (*metrics.NodeMetricsList, error)
+	GetPodMetrics(namespace, name string) (*metrics.PodMetrics, error)
+	ListPodMetrics(namespace string, options metav1.ListOptions) (*metrics.PodMetricsList, error)
+}
+
+// RequesterForwarder is a backend which passes the user of a request on.
+type RequesterForwarder interface {
+	// WithRequester returns the backend which serves the requests of requester.
+	WithRequester(requester user.Info) (Backend, error)
+}
+
+// Dependencies are available to every backend.
+type Dependencies struct {
+	KubeClient kubernetes.Interface
+	// DynamicClient is nil when the router runs without a config, e.g. in
+	// tests.
+	DynamicClient dynamic.Interface
+	Mapper        meta.RESTMapper
+	// Objects is the cache of the objects which the object field backends
+	// read. It is nil without a dynamic client.
+	Objects *objectcache.Cache
+	// Rates keeps the samples of the counters which the scrape backends
+	// compute rates of.
+	Rates *rates.Tracker
+	// RemoteWriteSeries and OTLPSeries are the stores of the remote-write and
+	// the OTLP receivers. They are nil when the receiver isn't enabled.
+	RemoteWriteSeries *series.Store
+	OTLPSeries        *series.Store
+}
+
+// Factory creates the backend of a source.
+type Factory func(source *v1beta1.CustomMetricsSource, deps Dependencies) (Backend, error)
+
+var (
+	lock      sync.RWMutex
+	factories = make(map[v1beta1.BackendType]Factory)
+)
+
+// Register adds a backend type. It is usually called by the init function of
+// the package which implements the backend.
+func Register(backendType v1beta1.BackendType, factory Factory) {
+	lock.Lock()
+	defer lock.Unlock()
+	if _, ok := factories[backendType]; ok {
+		panic(fmt.Sprintf("backend type %s is registered twice", backendType))
+	}
+	factories[backendType] = factory
+}
+
+// Types returns the registered backend types.
+func Types() []string {
+	lock.RLock()
+	defer lock.RUnlock()
+	backendTypes := make([]string, 0, len(factories))
+	for backendType := range factories {
+		backendTypes = append(backendTypes, string(backendType))
+	}
+	sort.Strings(backendTypes)
+	return backendTypes
+}
+
+// IsRegistered is true for types which New can create.
+func IsRegistered(backendType v1beta1.BackendType) bool {
+	lock.RLock()
+	defer lock.RUnlock()
+	_, ok := factories[TypeOf(backendType)]
+	return ok
+}
+
+// TypeOf returns the type of a backend with its default applied.
+func TypeOf(backendType v1beta1.BackendType) v1beta1.BackendType {
+	if backendType == "" {
+		return v1beta1.MetricsAPIBackendType
+	}
+	return backendType
+}
+
+// New creates the backend of a source with the factory of its type.
+func New(source *v1beta1.CustomMetricsSource, deps Dependencies) (Backend, error) {
+	backendType := TypeOf(source.Spec.Backend.Type)
+	lock.RLock()
+	factory, ok := factories[backendType]
+	lock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown backend type %s of custom metrics source %s", backendType, source.Name)
+	}
+	return factory(source, deps)
+}
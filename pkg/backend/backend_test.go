@@ -0,0 +1,72 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/kubernetes-sigs/custom-metrics-apiserver/pkg/provider"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/metrics/pkg/apis/custom_metrics"
+	"k8s.io/metrics/pkg/apis/external_metrics"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+)
+
+type fakeBackend struct {
+	source     string
+	parameters map[string]string
+}
+
+func (b *fakeBackend) Source() string {
+	return b.source
+}
+
+func (b *fakeBackend) ListCustomMetricInfos() (map[provider.CustomMetricInfo]struct{}, error) {
+	return nil, nil
+}
+
+func (b *fakeBackend) ListExternalMetrics() (map[provider.ExternalMetricInfo]struct{}, error) {
+	return nil, nil
+}
+
+func (b *fakeBackend) GetMetricByName(types.NamespacedName, provider.CustomMetricInfo, labels.Selector) (*custom_metrics.MetricValue, error) {
+	return nil, nil
+}
+
+func (b *fakeBackend) GetMetricBySelector(string, labels.Selector, provider.CustomMetricInfo, labels.Selector) (*custom_metrics.MetricValueList, error) {
+	return nil, nil
+}
+
+func (b *fakeBackend) GetExternalMetric(string, string, labels.Selector) (*external_metrics.ExternalMetricValueList, error) {
+	return nil, nil
+}
+
+func TestRegistry(t *testing.T) {
+	Register("Fake", func(source *v1beta1.CustomMetricsSource, deps Dependencies) (Backend, error) {
+		return &fakeBackend{source: source.Name, parameters: source.Spec.Backend.Parameters}, nil
+	})
+	require.Panics(t, func() {
+		Register("Fake", nil)
+	})
+	require.Contains(t, Types(), "Fake")
+	require.True(t, IsRegistered("Fake"))
+	require.False(t, IsRegistered("Graphite"))
+	require.Equal(t, v1beta1.BackendType(v1beta1.MetricsAPIBackendType), TypeOf(""))
+
+	source := &v1beta1.CustomMetricsSource{
+		ObjectMeta: metav1.ObjectMeta{Name: "fake"},
+		Spec: v1beta1.CustomMetricsSourceSpec{Backend: v1beta1.Backend{
+			Type:       "Fake",
+			Parameters: map[string]string{"endpoint": "fake:9090"},
+		}},
+	}
+	metricsBackend, err := New(source, Dependencies{})
+	require.NoError(t, err)
+	require.Equal(t, &fakeBackend{source: "fake", parameters: map[string]string{"endpoint": "fake:9090"}}, metricsBackend)
+
+	source.Spec.Backend.Type = "Graphite"
+	_, err = New(source, Dependencies{})
+	require.Error(t, err)
+}
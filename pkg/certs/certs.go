@@ -0,0 +1,212 @@
+package certs
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"time"
+
+	"k8s.io/client-go/util/cert"
+	"k8s.io/client-go/util/keyutil"
+)
+
+const (
+	// CAValidity and CertValidity are the lifetimes of generated
+	// certificates. Both are renewed once less than a third of their
+	// lifetime is left.
+	CAValidity   = 365 * 24 * time.Hour
+	CertValidity = 90 * 24 * time.Hour
+
+	// the keys of a Bundle in a Secret.
+	CACertKey = "ca.crt"
+	CAKeyKey  = "ca.key"
+	CertKey   = "tls.crt"
+	KeyKey    = "tls.key"
+)
+
+// Bundle is a self-signed CA together with a serving certificate signed by
+// it. All values are PEM encoded.
+type Bundle struct {
+	// CACert are the trusted CAs, the one which signs the serving certificate
+	// first. The previous CA is kept while it is valid, so that clients
+	// trust the old serving certificate until the server reloads it.
+	CACert []byte
+	CAKey  []byte
+	Cert   []byte
+	Key    []byte
+}
+
+// BundleFromData reads a Bundle from the data of a Secret.
+func BundleFromData(data map[string][]byte) *Bundle {
+	return &Bundle{
+		CACert: data[CACertKey],
+		CAKey:  data[CAKeyKey],
+		Cert:   data[CertKey],
+		Key:    data[KeyKey],
+	}
+}
+
+// Data returns the Bundle as the data of a Secret.
+func (b *Bundle) Data() map[string][]byte {
+	return map[string][]byte{
+		CACertKey: b.CACert,
+		CAKeyKey:  b.CAKey,
+		CertKey:   b.Cert,
+		KeyKey:    b.Key,
+	}
+}
+
+// Ensure returns a bundle with a valid CA and a serving certificate for the
+// hosts. Missing, invalid or expiring parts of b are generated again, the
+// rest is kept. The result is b itself when nothing needed to be renewed.
+func Ensure(b *Bundle, hosts []string, now time.Time) (*Bundle, error) {
+	caCert, caKey := parseCA(b)
+	renewed := &Bundle{CACert: b.CACert, CAKey: b.CAKey, Cert: b.Cert, Key: b.Key}
+	if caCert == nil || expiring(caCert, CAValidity, now) {
+		var err error
+		caCert, caKey, err = newCA(now)
+		if err != nil {
+			return nil, err
+		}
+		renewed.CACert = encodeCert(caCert)
+		renewed.CAKey, err = keyutil.MarshalPrivateKeyToPEM(caKey)
+		if err != nil {
+			return nil, err
+		}
+		// the previous CA is still trusted until it expires.
+		if previous, err := cert.ParseCertsPEM(b.CACert); err == nil && now.Before(previous[0].NotAfter) {
+			renewed.CACert = append(renewed.CACert, encodeCert(previous[0])...)
+		}
+	}
+
+	if !validServingCert(renewed, caCert, hosts, now) {
+		servingCert, key, err := newServingCert(caCert, caKey, hosts, now)
+		if err != nil {
+			return nil, err
+		}
+		renewed.Cert = encodeCert(servingCert)
+		renewed.Key, err = keyutil.MarshalPrivateKeyToPEM(key)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if bytes.Equal(renewed.CACert, b.CACert) && bytes.Equal(renewed.Cert, b.Cert) {
+		return b, nil
+	}
+	return renewed, nil
+}
+
+func parseCA(b *Bundle) (*x509.Certificate, crypto.Signer) {
+	certs, err := cert.ParseCertsPEM(b.CACert)
+	if err != nil || !certs[0].IsCA {
+		return nil, nil
+	}
+	key, err := keyutil.ParsePrivateKeyPEM(b.CAKey)
+	if err != nil {
+		return nil, nil
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok || !reflect.DeepEqual(signer.Public(), certs[0].PublicKey) {
+		return nil, nil
+	}
+	return certs[0], signer
+}
+
+func validServingCert(b *Bundle, ca *x509.Certificate, hosts []string, now time.Time) bool {
+	certs, err := cert.ParseCertsPEM(b.Cert)
+	if err != nil {
+		return false
+	}
+	servingCert := certs[0]
+	if expiring(servingCert, CertValidity, now) || servingCert.CheckSignatureFrom(ca) != nil {
+		return false
+	}
+	key, err := keyutil.ParsePrivateKeyPEM(b.Key)
+	if err != nil {
+		return false
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok || !reflect.DeepEqual(signer.Public(), servingCert.PublicKey) {
+		return false
+	}
+	return reflect.DeepEqual(sortedCopy(servingCert.DNSNames), sortedCopy(hosts))
+}
+
+// expiring is true for certificates which are outside of their validity or
+// have less than a third of their lifetime left.
+func expiring(c *x509.Certificate, validity time.Duration, now time.Time) bool {
+	return now.Before(c.NotBefore) || now.After(c.NotAfter.Add(-validity/3))
+}
+
+func newCA(now time.Time) (*x509.Certificate, crypto.Signer, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	template := &x509.Certificate{
+		Subject:               pkix.Name{CommonName: fmt.Sprintf("custom-metrics-router-ca@%d", now.Unix())},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(CAValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caCert, err := sign(template, template, key.Public(), key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return caCert, key, nil
+}
+
+func newServingCert(ca *x509.Certificate, caKey crypto.Signer, hosts []string, now time.Time) (*x509.Certificate, crypto.Signer, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	template := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: hosts[0]},
+		DNSNames:    hosts,
+		NotBefore:   now.Add(-time.Hour),
+		NotAfter:    now.Add(CertValidity),
+		KeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	servingCert, err := sign(template, ca, key.Public(), caKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return servingCert, key, nil
+}
+
+func sign(template, parent *x509.Certificate, public crypto.PublicKey, signer crypto.Signer) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	template.SerialNumber = serial
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, public, signer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate: %v", err)
+	}
+	return x509.ParseCertificate(der)
+}
+
+func encodeCert(c *x509.Certificate) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: cert.CertificateBlockType, Bytes: c.Raw})
+}
+
+func sortedCopy(values []string) []string {
+	sorted := append([]string(nil), values...)
+	sort.Strings(sorted)
+	return sorted
+}
@@ -0,0 +1,67 @@
+package certs
+
+import (
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/util/cert"
+)
+
+var hosts = []string{"custom-metrics-router.custom-metrics.svc", "custom-metrics-router.custom-metrics"}
+
+func verify(t *testing.T, b *Bundle, host string, now time.Time) {
+	roots := x509.NewCertPool()
+	require.True(t, roots.AppendCertsFromPEM(b.CACert))
+	certs, err := cert.ParseCertsPEM(b.Cert)
+	require.NoError(t, err)
+	_, err = certs[0].Verify(x509.VerifyOptions{DNSName: host, Roots: roots, CurrentTime: now})
+	require.NoError(t, err)
+}
+
+func TestEnsure(t *testing.T) {
+	now := time.Now()
+	bundle, err := Ensure(&Bundle{}, hosts, now)
+	require.NoError(t, err)
+	verify(t, bundle, hosts[0], now)
+
+	unchanged, err := Ensure(bundle, hosts, now.Add(time.Hour))
+	require.NoError(t, err)
+	require.True(t, unchanged == bundle)
+	restored, err := Ensure(BundleFromData(bundle.Data()), hosts, now)
+	require.NoError(t, err)
+	require.Equal(t, bundle, restored)
+
+	// the serving certificate is renewed before it expires.
+	later := now.Add(CertValidity * 3 / 4)
+	renewed, err := Ensure(bundle, hosts, later)
+	require.NoError(t, err)
+	require.Equal(t, bundle.CACert, renewed.CACert)
+	require.NotEqual(t, bundle.Cert, renewed.Cert)
+	verify(t, renewed, hosts[0], later)
+
+	// so is the CA, and the previous CA is still trusted.
+	later = now.Add(CAValidity * 3 / 4)
+	rotated, err := Ensure(bundle, hosts, later)
+	require.NoError(t, err)
+	require.NotEqual(t, bundle.CAKey, rotated.CAKey)
+	caCerts, err := cert.ParseCertsPEM(rotated.CACert)
+	require.NoError(t, err)
+	require.Len(t, caCerts, 2)
+	verify(t, rotated, hosts[0], later)
+	verify(t, &Bundle{CACert: rotated.CACert, Cert: bundle.Cert}, hosts[0], now)
+
+	// new hosts need a new certificate.
+	moved, err := Ensure(bundle, []string{"router.metrics.svc"}, now)
+	require.NoError(t, err)
+	require.Equal(t, bundle.CACert, moved.CACert)
+	verify(t, moved, "router.metrics.svc", now)
+
+	// a serving certificate of another CA is replaced.
+	other, err := Ensure(&Bundle{}, hosts, now)
+	require.NoError(t, err)
+	mixed, err := Ensure(&Bundle{CACert: bundle.CACert, CAKey: bundle.CAKey, Cert: other.Cert, Key: other.Key}, hosts, now)
+	require.NoError(t, err)
+	verify(t, mixed, hosts[0], now)
+}
@@ -22,6 +22,8 @@ import (
 	clientset "github.com/arjunrn/custom-metrics-router/pkg/client/clientset/versioned"
 	metricsrouterv1alpha1 "github.com/arjunrn/custom-metrics-router/pkg/client/clientset/versioned/typed/metricsrouter.io/v1alpha1"
 	fakemetricsrouterv1alpha1 "github.com/arjunrn/custom-metrics-router/pkg/client/clientset/versioned/typed/metricsrouter.io/v1alpha1/fake"
+	metricsrouterv1beta1 "github.com/arjunrn/custom-metrics-router/pkg/client/clientset/versioned/typed/metricsrouter.io/v1beta1"
+	fakemetricsrouterv1beta1 "github.com/arjunrn/custom-metrics-router/pkg/client/clientset/versioned/typed/metricsrouter.io/v1beta1/fake"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/discovery"
@@ -80,3 +82,8 @@ var _ clientset.Interface = &Clientset{}
 func (c *Clientset) MetricsrouterV1alpha1() metricsrouterv1alpha1.MetricsrouterV1alpha1Interface {
 	return &fakemetricsrouterv1alpha1.FakeMetricsrouterV1alpha1{Fake: &c.Fake}
 }
+
+// MetricsrouterV1beta1 retrieves the MetricsrouterV1beta1Client
+func (c *Clientset) MetricsrouterV1beta1() metricsrouterv1beta1.MetricsrouterV1beta1Interface {
+	return &fakemetricsrouterv1beta1.FakeMetricsrouterV1beta1{Fake: &c.Fake}
+}
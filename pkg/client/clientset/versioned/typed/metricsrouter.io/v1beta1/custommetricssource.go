@@ -0,0 +1,168 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	"context"
+	"time"
+
+	v1beta1 "github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+	scheme "github.com/arjunrn/custom-metrics-router/pkg/client/clientset/versioned/scheme"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// CustomMetricsSourcesGetter has a method to return a CustomMetricsSourceInterface.
+// A group's client should implement this interface.
+type CustomMetricsSourcesGetter interface {
+	CustomMetricsSources() CustomMetricsSourceInterface
+}
+
+// CustomMetricsSourceInterface has methods to work with CustomMetricsSource resources.
+type CustomMetricsSourceInterface interface {
+	Create(ctx context.Context, customMetricsSource *v1beta1.CustomMetricsSource, opts v1.CreateOptions) (*v1beta1.CustomMetricsSource, error)
+	Update(ctx context.Context, customMetricsSource *v1beta1.CustomMetricsSource, opts v1.UpdateOptions) (*v1beta1.CustomMetricsSource, error)
+	Delete(ctx context.Context, name string, opts v1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error
+	Get(ctx context.Context, name string, opts v1.GetOptions) (*v1beta1.CustomMetricsSource, error)
+	List(ctx context.Context, opts v1.ListOptions) (*v1beta1.CustomMetricsSourceList, error)
+	Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1beta1.CustomMetricsSource, err error)
+	CustomMetricsSourceExpansion
+}
+
+// customMetricsSources implements CustomMetricsSourceInterface
+type customMetricsSources struct {
+	client rest.Interface
+}
+
+// newCustomMetricsSources returns a CustomMetricsSources
+func newCustomMetricsSources(c *MetricsrouterV1beta1Client) *customMetricsSources {
+	return &customMetricsSources{
+		client: c.RESTClient(),
+	}
+}
+
+// Get takes name of the customMetricsSource, and returns the corresponding customMetricsSource object, and an error if there is any.
+func (c *customMetricsSources) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1beta1.CustomMetricsSource, err error) {
+	result = &v1beta1.CustomMetricsSource{}
+	err = c.client.Get().
+		Resource("custommetricssources").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of CustomMetricsSources that match those selectors.
+func (c *customMetricsSources) List(ctx context.Context, opts v1.ListOptions) (result *v1beta1.CustomMetricsSourceList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1beta1.CustomMetricsSourceList{}
+	err = c.client.Get().
+		Resource("custommetricssources").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested customMetricsSources.
+func (c *customMetricsSources) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Resource("custommetricssources").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch(ctx)
+}
+
+// Create takes the representation of a customMetricsSource and creates it.  Returns the server's representation of the customMetricsSource, and an error, if there is any.
+func (c *customMetricsSources) Create(ctx context.Context, customMetricsSource *v1beta1.CustomMetricsSource, opts v1.CreateOptions) (result *v1beta1.CustomMetricsSource, err error) {
+	result = &v1beta1.CustomMetricsSource{}
+	err = c.client.Post().
+		Resource("custommetricssources").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(customMetricsSource).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Update takes the representation of a customMetricsSource and updates it. Returns the server's representation of the customMetricsSource, and an error, if there is any.
+func (c *customMetricsSources) Update(ctx context.Context, customMetricsSource *v1beta1.CustomMetricsSource, opts v1.UpdateOptions) (result *v1beta1.CustomMetricsSource, err error) {
+	result = &v1beta1.CustomMetricsSource{}
+	err = c.client.Put().
+		Resource("custommetricssources").
+		Name(customMetricsSource.Name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(customMetricsSource).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Delete takes name of the customMetricsSource and deletes it. Returns an error if one occurs.
+func (c *customMetricsSources) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	return c.client.Delete().
+		Resource("custommetricssources").
+		Name(name).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *customMetricsSources) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	var timeout time.Duration
+	if listOpts.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOpts.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Resource("custommetricssources").
+		VersionedParams(&listOpts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// Patch applies the patch and returns the patched customMetricsSource.
+func (c *customMetricsSources) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1beta1.CustomMetricsSource, err error) {
+	result = &v1beta1.CustomMetricsSource{}
+	err = c.client.Patch(pt).
+		Resource("custommetricssources").
+		Name(name).
+		SubResource(subresources...).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(data).
+		Do(ctx).
+		Into(result)
+	return
+}
@@ -0,0 +1,122 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	"context"
+
+	v1beta1 "github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeCustomMetricsSources implements CustomMetricsSourceInterface
+type FakeCustomMetricsSources struct {
+	Fake *FakeMetricsrouterV1beta1
+}
+
+var custommetricssourcesResource = schema.GroupVersionResource{Group: "metricsrouter.io", Version: "v1beta1", Resource: "custommetricssources"}
+
+var custommetricssourcesKind = schema.GroupVersionKind{Group: "metricsrouter.io", Version: "v1beta1", Kind: "CustomMetricsSource"}
+
+// Get takes name of the customMetricsSource, and returns the corresponding customMetricsSource object, and an error if there is any.
+func (c *FakeCustomMetricsSources) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1beta1.CustomMetricsSource, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootGetAction(custommetricssourcesResource, name), &v1beta1.CustomMetricsSource{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta1.CustomMetricsSource), err
+}
+
+// List takes label and field selectors, and returns the list of CustomMetricsSources that match those selectors.
+func (c *FakeCustomMetricsSources) List(ctx context.Context, opts v1.ListOptions) (result *v1beta1.CustomMetricsSourceList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootListAction(custommetricssourcesResource, custommetricssourcesKind, opts), &v1beta1.CustomMetricsSourceList{})
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &v1beta1.CustomMetricsSourceList{ListMeta: obj.(*v1beta1.CustomMetricsSourceList).ListMeta}
+	for _, item := range obj.(*v1beta1.CustomMetricsSourceList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested customMetricsSources.
+func (c *FakeCustomMetricsSources) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewRootWatchAction(custommetricssourcesResource, opts))
+}
+
+// Create takes the representation of a customMetricsSource and creates it.  Returns the server's representation of the customMetricsSource, and an error, if there is any.
+func (c *FakeCustomMetricsSources) Create(ctx context.Context, customMetricsSource *v1beta1.CustomMetricsSource, opts v1.CreateOptions) (result *v1beta1.CustomMetricsSource, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootCreateAction(custommetricssourcesResource, customMetricsSource), &v1beta1.CustomMetricsSource{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta1.CustomMetricsSource), err
+}
+
+// Update takes the representation of a customMetricsSource and updates it. Returns the server's representation of the customMetricsSource, and an error, if there is any.
+func (c *FakeCustomMetricsSources) Update(ctx context.Context, customMetricsSource *v1beta1.CustomMetricsSource, opts v1.UpdateOptions) (result *v1beta1.CustomMetricsSource, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootUpdateAction(custommetricssourcesResource, customMetricsSource), &v1beta1.CustomMetricsSource{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta1.CustomMetricsSource), err
+}
+
+// Delete takes name of the customMetricsSource and deletes it. Returns an error if one occurs.
+func (c *FakeCustomMetricsSources) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewRootDeleteAction(custommetricssourcesResource, name), &v1beta1.CustomMetricsSource{})
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeCustomMetricsSources) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	action := testing.NewRootDeleteCollectionAction(custommetricssourcesResource, listOpts)
+
+	_, err := c.Fake.Invokes(action, &v1beta1.CustomMetricsSourceList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched customMetricsSource.
+func (c *FakeCustomMetricsSources) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1beta1.CustomMetricsSource, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootPatchSubresourceAction(custommetricssourcesResource, name, pt, data, subresources...), &v1beta1.CustomMetricsSource{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta1.CustomMetricsSource), err
+}
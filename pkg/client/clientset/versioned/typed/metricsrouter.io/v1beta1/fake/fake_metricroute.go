@@ -0,0 +1,122 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	"context"
+
+	v1beta1 "github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeMetricRoutes implements MetricRouteInterface
+type FakeMetricRoutes struct {
+	Fake *FakeMetricsrouterV1beta1
+}
+
+var metricroutesResource = schema.GroupVersionResource{Group: "metricsrouter.io", Version: "v1beta1", Resource: "metricroutes"}
+
+var metricroutesKind = schema.GroupVersionKind{Group: "metricsrouter.io", Version: "v1beta1", Kind: "MetricRoute"}
+
+// Get takes name of the metricRoute, and returns the corresponding metricRoute object, and an error if there is any.
+func (c *FakeMetricRoutes) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1beta1.MetricRoute, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootGetAction(metricroutesResource, name), &v1beta1.MetricRoute{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta1.MetricRoute), err
+}
+
+// List takes label and field selectors, and returns the list of MetricRoutes that match those selectors.
+func (c *FakeMetricRoutes) List(ctx context.Context, opts v1.ListOptions) (result *v1beta1.MetricRouteList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootListAction(metricroutesResource, metricroutesKind, opts), &v1beta1.MetricRouteList{})
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &v1beta1.MetricRouteList{ListMeta: obj.(*v1beta1.MetricRouteList).ListMeta}
+	for _, item := range obj.(*v1beta1.MetricRouteList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested metricRoutes.
+func (c *FakeMetricRoutes) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewRootWatchAction(metricroutesResource, opts))
+}
+
+// Create takes the representation of a metricRoute and creates it.  Returns the server's representation of the metricRoute, and an error, if there is any.
+func (c *FakeMetricRoutes) Create(ctx context.Context, metricRoute *v1beta1.MetricRoute, opts v1.CreateOptions) (result *v1beta1.MetricRoute, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootCreateAction(metricroutesResource, metricRoute), &v1beta1.MetricRoute{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta1.MetricRoute), err
+}
+
+// Update takes the representation of a metricRoute and updates it. Returns the server's representation of the metricRoute, and an error, if there is any.
+func (c *FakeMetricRoutes) Update(ctx context.Context, metricRoute *v1beta1.MetricRoute, opts v1.UpdateOptions) (result *v1beta1.MetricRoute, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootUpdateAction(metricroutesResource, metricRoute), &v1beta1.MetricRoute{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta1.MetricRoute), err
+}
+
+// Delete takes name of the metricRoute and deletes it. Returns an error if one occurs.
+func (c *FakeMetricRoutes) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewRootDeleteAction(metricroutesResource, name), &v1beta1.MetricRoute{})
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeMetricRoutes) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	action := testing.NewRootDeleteCollectionAction(metricroutesResource, listOpts)
+
+	_, err := c.Fake.Invokes(action, &v1beta1.MetricRouteList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched metricRoute.
+func (c *FakeMetricRoutes) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1beta1.MetricRoute, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootPatchSubresourceAction(metricroutesResource, name, pt, data, subresources...), &v1beta1.MetricRoute{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta1.MetricRoute), err
+}
@@ -0,0 +1,168 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	"context"
+	"time"
+
+	v1beta1 "github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+	scheme "github.com/arjunrn/custom-metrics-router/pkg/client/clientset/versioned/scheme"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// MetricRoutesGetter has a method to return a MetricRouteInterface.
+// A group's client should implement this interface.
+type MetricRoutesGetter interface {
+	MetricRoutes() MetricRouteInterface
+}
+
+// MetricRouteInterface has methods to work with MetricRoute resources.
+type MetricRouteInterface interface {
+	Create(ctx context.Context, metricRoute *v1beta1.MetricRoute, opts v1.CreateOptions) (*v1beta1.MetricRoute, error)
+	Update(ctx context.Context, metricRoute *v1beta1.MetricRoute, opts v1.UpdateOptions) (*v1beta1.MetricRoute, error)
+	Delete(ctx context.Context, name string, opts v1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error
+	Get(ctx context.Context, name string, opts v1.GetOptions) (*v1beta1.MetricRoute, error)
+	List(ctx context.Context, opts v1.ListOptions) (*v1beta1.MetricRouteList, error)
+	Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1beta1.MetricRoute, err error)
+	MetricRouteExpansion
+}
+
+// metricRoutes implements MetricRouteInterface
+type metricRoutes struct {
+	client rest.Interface
+}
+
+// newMetricRoutes returns a MetricRoutes
+func newMetricRoutes(c *MetricsrouterV1beta1Client) *metricRoutes {
+	return &metricRoutes{
+		client: c.RESTClient(),
+	}
+}
+
+// Get takes name of the metricRoute, and returns the corresponding metricRoute object, and an error if there is any.
+func (c *metricRoutes) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1beta1.MetricRoute, err error) {
+	result = &v1beta1.MetricRoute{}
+	err = c.client.Get().
+		Resource("metricroutes").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of MetricRoutes that match those selectors.
+func (c *metricRoutes) List(ctx context.Context, opts v1.ListOptions) (result *v1beta1.MetricRouteList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1beta1.MetricRouteList{}
+	err = c.client.Get().
+		Resource("metricroutes").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested metricRoutes.
+func (c *metricRoutes) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Resource("metricroutes").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch(ctx)
+}
+
+// Create takes the representation of a metricRoute and creates it.  Returns the server's representation of the metricRoute, and an error, if there is any.
+func (c *metricRoutes) Create(ctx context.Context, metricRoute *v1beta1.MetricRoute, opts v1.CreateOptions) (result *v1beta1.MetricRoute, err error) {
+	result = &v1beta1.MetricRoute{}
+	err = c.client.Post().
+		Resource("metricroutes").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(metricRoute).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Update takes the representation of a metricRoute and updates it. Returns the server's representation of the metricRoute, and an error, if there is any.
+func (c *metricRoutes) Update(ctx context.Context, metricRoute *v1beta1.MetricRoute, opts v1.UpdateOptions) (result *v1beta1.MetricRoute, err error) {
+	result = &v1beta1.MetricRoute{}
+	err = c.client.Put().
+		Resource("metricroutes").
+		Name(metricRoute.Name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(metricRoute).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Delete takes name of the metricRoute and deletes it. Returns an error if one occurs.
+func (c *metricRoutes) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	return c.client.Delete().
+		Resource("metricroutes").
+		Name(name).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *metricRoutes) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	var timeout time.Duration
+	if listOpts.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOpts.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Resource("metricroutes").
+		VersionedParams(&listOpts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// Patch applies the patch and returns the patched metricRoute.
+func (c *metricRoutes) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1beta1.MetricRoute, err error) {
+	result = &v1beta1.MetricRoute{}
+	err = c.client.Patch(pt).
+		Resource("metricroutes").
+		Name(name).
+		SubResource(subresources...).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(data).
+		Do(ctx).
+		Into(result)
+	return
+}
@@ -22,6 +22,7 @@ import (
 	"fmt"
 
 	v1alpha1 "github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1alpha1"
+	v1beta1 "github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
 	schema "k8s.io/apimachinery/pkg/runtime/schema"
 	cache "k8s.io/client-go/tools/cache"
 )
@@ -56,6 +57,12 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 	case v1alpha1.SchemeGroupVersion.WithResource("custommetricssources"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Metricsrouter().V1alpha1().CustomMetricsSources().Informer()}, nil
 
+		// Group=metricsrouter.io, Version=v1beta1
+	case v1beta1.SchemeGroupVersion.WithResource("custommetricssources"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Metricsrouter().V1beta1().CustomMetricsSources().Informer()}, nil
+	case v1beta1.SchemeGroupVersion.WithResource("metricroutes"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Metricsrouter().V1beta1().MetricRoutes().Informer()}, nil
+
 	}
 
 	return nil, fmt.Errorf("no informer found for %v", resource)
@@ -0,0 +1,89 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by informer-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	"context"
+	time "time"
+
+	metricsrouteriov1beta1 "github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+	versioned "github.com/arjunrn/custom-metrics-router/pkg/client/clientset/versioned"
+	internalinterfaces "github.com/arjunrn/custom-metrics-router/pkg/client/informers/externalversions/internalinterfaces"
+	v1beta1 "github.com/arjunrn/custom-metrics-router/pkg/client/listers/metricsrouter.io/v1beta1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	watch "k8s.io/apimachinery/pkg/watch"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// MetricRouteInformer provides access to a shared informer and lister for
+// MetricRoutes.
+type MetricRouteInformer interface {
+	Informer() cache.SharedIndexInformer
+	Lister() v1beta1.MetricRouteLister
+}
+
+type metricRouteInformer struct {
+	factory          internalinterfaces.SharedInformerFactory
+	tweakListOptions internalinterfaces.TweakListOptionsFunc
+}
+
+// NewMetricRouteInformer constructs a new informer for MetricRoute type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewMetricRouteInformer(client versioned.Interface, resyncPeriod time.Duration, indexers cache.Indexers) cache.SharedIndexInformer {
+	return NewFilteredMetricRouteInformer(client, resyncPeriod, indexers, nil)
+}
+
+// NewFilteredMetricRouteInformer constructs a new informer for MetricRoute type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewFilteredMetricRouteInformer(client versioned.Interface, resyncPeriod time.Duration, indexers cache.Indexers, tweakListOptions internalinterfaces.TweakListOptionsFunc) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.MetricsrouterV1beta1().MetricRoutes().List(context.TODO(), options)
+			},
+			WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.MetricsrouterV1beta1().MetricRoutes().Watch(context.TODO(), options)
+			},
+		},
+		&metricsrouteriov1beta1.MetricRoute{},
+		resyncPeriod,
+		indexers,
+	)
+}
+
+func (f *metricRouteInformer) defaultInformer(client versioned.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	return NewFilteredMetricRouteInformer(client, resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, f.tweakListOptions)
+}
+
+func (f *metricRouteInformer) Informer() cache.SharedIndexInformer {
+	return f.factory.InformerFor(&metricsrouteriov1beta1.MetricRoute{}, f.defaultInformer)
+}
+
+func (f *metricRouteInformer) Lister() v1beta1.MetricRouteLister {
+	return v1beta1.NewMetricRouteLister(f.Informer().GetIndexer())
+}
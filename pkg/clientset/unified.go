@@ -1,10 +1,12 @@
 package clientset
 
 import (
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 
 	"github.com/arjunrn/custom-metrics-router/pkg/client/clientset/versioned/typed/metricsrouter.io/v1alpha1"
+	"github.com/arjunrn/custom-metrics-router/pkg/client/clientset/versioned/typed/metricsrouter.io/v1beta1"
 
 	metricsRouter "github.com/arjunrn/custom-metrics-router/pkg/client/clientset/versioned"
 )
@@ -12,17 +14,29 @@ import (
 type Interface interface {
 	kubernetes.Interface
 	metricsRouter.Interface
+	// Dynamic returns a client for any resource. It is nil for client sets
+	// which weren't created from a config.
+	Dynamic() dynamic.Interface
 }
 
 type ClientSet struct {
 	kubernetes.Interface
 	metricsProvider metricsRouter.Interface
+	dynamic         dynamic.Interface
 }
 
 func (c *ClientSet) MetricsrouterV1alpha1() v1alpha1.MetricsrouterV1alpha1Interface {
 	return c.metricsProvider.MetricsrouterV1alpha1()
 }
 
+func (c *ClientSet) MetricsrouterV1beta1() v1beta1.MetricsrouterV1beta1Interface {
+	return c.metricsProvider.MetricsrouterV1beta1()
+}
+
+func (c *ClientSet) Dynamic() dynamic.Interface {
+	return c.dynamic
+}
+
 func NewForConfig(kubeConfig *rest.Config) (Interface, error) {
 	kubeClient, err := kubernetes.NewForConfig(kubeConfig)
 	if err != nil {
@@ -32,10 +46,18 @@ func NewForConfig(kubeConfig *rest.Config) (Interface, error) {
 	if err != nil {
 		return nil, err
 	}
-	return NewClientSet(kubeClient, metricsProvider), nil
+	dynamicClient, err := dynamic.NewForConfig(kubeConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &ClientSet{
+		Interface:       kubeClient,
+		metricsProvider: metricsProvider,
+		dynamic:         dynamicClient,
+	}, nil
 }
 
-func NewClientSet(kubeClient *kubernetes.Clientset, provider *metricsRouter.Clientset) Interface {
+func NewClientSet(kubeClient kubernetes.Interface, provider metricsRouter.Interface) Interface {
 	return &ClientSet{
 		Interface:       kubeClient,
 		metricsProvider: provider,
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilvalidation "k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/yaml"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+	"github.com/arjunrn/custom-metrics-router/pkg/validation"
+)
+
+const defaultResyncPeriod = time.Minute
+
+// Config is the routing configuration which replaces the CustomMetricsSource
+// and MetricRoute objects in clusters where the CRDs can't be installed.
+type Config struct {
+	// ResyncPeriod is the interval in which the metrics of all sources are
+	// discovered again. Defaults to 1m.
+	ResyncPeriod metav1.Duration `json:"resyncPeriod,omitempty"`
+	// DefaultAuthentication is used for sources without authentication.
+	DefaultAuthentication *v1beta1.Authentication `json:"defaultAuthentication,omitempty"`
+	Sources               []Source                `json:"sources"`
+	MetricRoutes          []MetricRoute           `json:"metricRoutes,omitempty"`
+}
+
+// Source has the same fields as a CustomMetricsSource.
+type Source struct {
+	Name                            string `json:"name"`
+	v1beta1.CustomMetricsSourceSpec `json:",inline"`
+}
+
+// MetricRoute has the same fields as a MetricRoute object.
+type MetricRoute struct {
+	Name                    string `json:"name"`
+	v1beta1.MetricRouteSpec `json:",inline"`
+}
+
+// Parse reads a config, sets its defaults and validates it. Unknown fields
+// are rejected, so that typos don't go unnoticed.
+func Parse(data []byte) (*Config, error) {
+	config := &Config{}
+	if err := yaml.UnmarshalStrict(data, config); err != nil {
+		return nil, err
+	}
+	setDefaults(config)
+	if allErrs := validate(config); len(allErrs) > 0 {
+		return nil, allErrs.ToAggregate()
+	}
+	return config, nil
+}
+
+func setDefaults(config *Config) {
+	if config.ResyncPeriod.Duration == 0 {
+		config.ResyncPeriod.Duration = defaultResyncPeriod
+	}
+	for i := range config.Sources {
+		if config.Sources[i].Backend.Authentication == nil && config.DefaultAuthentication != nil {
+			config.Sources[i].Backend.Authentication = config.DefaultAuthentication.DeepCopy()
+		}
+	}
+}
+
+func validate(config *Config) field.ErrorList {
+	var allErrs field.ErrorList
+	if config.ResyncPeriod.Duration < 0 {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("resyncPeriod"), config.ResyncPeriod, "must not be negative"))
+	}
+
+	sourceNames := make(map[string]struct{})
+	services := make(map[string]string)
+	for i := range config.Sources {
+		source := &config.Sources[i]
+		path := field.NewPath("sources").Index(i)
+		for _, msg := range utilvalidation.IsDNS1123Subdomain(source.Name) {
+			allErrs = append(allErrs, field.Invalid(path.Child("name"), source.Name, msg))
+		}
+		if _, ok := sourceNames[source.Name]; ok {
+			allErrs = append(allErrs, field.Duplicate(path.Child("name"), source.Name))
+		}
+		sourceNames[source.Name] = struct{}{}
+		allErrs = append(allErrs, validation.ValidateCustomMetricsSourceSpec(&source.CustomMetricsSourceSpec, path)...)
+
+		// routes are keyed by the service, so two sources can't share one.
+		service := source.Backend.Service.Namespace + "/" + source.Backend.Service.Name
+		if other, ok := services[service]; ok {
+			allErrs = append(allErrs, field.Duplicate(path.Child("backend", "service"), fmt.Sprintf("service is already used by source %s", other)))
+		}
+		services[service] = source.Name
+	}
+
+	routeNames := make(map[string]struct{})
+	for i := range config.MetricRoutes {
+		route := &config.MetricRoutes[i]
+		path := field.NewPath("metricRoutes").Index(i)
+		for _, msg := range utilvalidation.IsDNS1123Subdomain(route.Name) {
+			allErrs = append(allErrs, field.Invalid(path.Child("name"), route.Name, msg))
+		}
+		if _, ok := routeNames[route.Name]; ok {
+			allErrs = append(allErrs, field.Duplicate(path.Child("name"), route.Name))
+		}
+		routeNames[route.Name] = struct{}{}
+		allErrs = append(allErrs, validation.ValidateMetricRouteSpec(&route.MetricRouteSpec, path)...)
+	}
+	return allErrs
+}
+
+// CustomMetricsSources returns the sources of the config as objects.
+func (c *Config) CustomMetricsSources() []*v1beta1.CustomMetricsSource {
+	sources := make([]*v1beta1.CustomMetricsSource, 0, len(c.Sources))
+	for _, source := range c.Sources {
+		sources = append(sources, &v1beta1.CustomMetricsSource{
+			ObjectMeta: metav1.ObjectMeta{Name: source.Name},
+			Spec:       *source.CustomMetricsSourceSpec.DeepCopy(),
+		})
+	}
+	return sources
+}
+
+// MetricRouteObjects returns the metric routes of the config as objects.
+func (c *Config) MetricRouteObjects() []*v1beta1.MetricRoute {
+	metricRoutes := make([]*v1beta1.MetricRoute, 0, len(c.MetricRoutes))
+	for _, route := range c.MetricRoutes {
+		metricRoutes = append(metricRoutes, &v1beta1.MetricRoute{
+			ObjectMeta: metav1.ObjectMeta{Name: route.Name},
+			Spec:       *route.MetricRouteSpec.DeepCopy(),
+		})
+	}
+	return metricRoutes
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+)
+
+const validConfig = `
+defaultAuthentication:
+  mode: None
+sources:
+- name: prometheus
+  backend:
+    service:
+      namespace: monitoring
+      name: prometheus-adapter
+      port: 443
+  routing:
+    priority: 100
+    metricTypes: [CustomMetrics, ExternalMetrics]
+- name: keda
+  backend:
+    service:
+      namespace: keda
+      name: keda-metrics-apiserver
+      port: 443
+    authentication:
+      mode: ServiceAccount
+  routing:
+    priority: 50
+    metricTypes: [ExternalMetrics]
+metricRoutes:
+- name: queues
+  match:
+    metricType: ExternalMetrics
+    nameRegex: "queue_.*"
+  sources:
+  - name: keda
+`
+
+func TestParse(t *testing.T) {
+	config, err := Parse([]byte(validConfig))
+	require.NoError(t, err)
+	require.Equal(t, time.Minute, config.ResyncPeriod.Duration)
+	require.Len(t, config.Sources, 2)
+	require.Equal(t, v1beta1.AuthenticationMode(v1beta1.NoAuthentication), config.Sources[0].Backend.Authentication.Mode)
+	require.Equal(t, v1beta1.AuthenticationMode(v1beta1.ServiceAccountAuthentication), config.Sources[1].Backend.Authentication.Mode)
+
+	sources := config.CustomMetricsSources()
+	require.Equal(t, "prometheus", sources[0].Name)
+	require.Equal(t, "prometheus-adapter", sources[0].Spec.Backend.Service.Name)
+	metricRoutes := config.MetricRouteObjects()
+	require.Len(t, metricRoutes, 1)
+	require.Equal(t, "queues", metricRoutes[0].Name)
+	require.Equal(t, "keda", metricRoutes[0].Spec.Sources[0].Name)
+}
+
+func TestParseInvalid(t *testing.T) {
+	source := func(name, service string) string {
+		return `
+- name: ` + name + `
+  backend:
+    service: {namespace: monitoring, name: ` + service + `, port: 443}
+  routing:
+    priority: 1
+    metricTypes: [ExternalMetrics]`
+	}
+
+	for _, tc := range []struct {
+		name   string
+		config string
+	}{
+		{name: "unknown field", config: "sources:" + source("a", "a") + "\n    priorty: 1"},
+		{name: "negative resync period", config: "resyncPeriod: -1m\nsources:" + source("a", "a")},
+		{name: "invalid source", config: "sources:" + source("a", "a") + "\n    metricPatterns: [{metricType: ExternalMetrics, regex: '('}]"},
+		{name: "invalid source name", config: "sources:" + source("A", "a")},
+		{name: "duplicate source name", config: "sources:" + source("a", "a") + source("a", "b")},
+		{name: "duplicate service", config: "sources:" + source("a", "a") + source("b", "a")},
+		{name: "invalid metric route", config: "sources:" + source("a", "a") + "\nmetricRoutes:\n- name: r\n  match: {metricType: ExternalMetrics}\n  sources: []"},
+		{name: "duplicate metric route", config: "sources:" + source("a", "a") + "\nmetricRoutes:\n- {name: r, match: {metricType: ExternalMetrics}, sources: [{name: a}]}\n- {name: r, match: {metricType: ExternalMetrics}, sources: [{name: a}]}"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse([]byte(tc.config))
+			require.Error(t, err)
+		})
+	}
+}
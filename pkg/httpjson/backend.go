@@ -0,0 +1,51 @@
+package httpjson
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	"k8s.io/client-go/rest"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+	"github.com/arjunrn/custom-metrics-router/pkg/backend"
+	"github.com/arjunrn/custom-metrics-router/pkg/metricsclient"
+)
+
+var _ backend.Backend = &Client{}
+
+func init() {
+	backend.Register(v1beta1.HTTPBackendType, newBackend)
+}
+
+// newBackend returns a client which gets the documents of the external
+// metrics of a source from its service with its TLS config and credentials.
+func newBackend(source *v1beta1.CustomMetricsSource, deps backend.Dependencies) (backend.Backend, error) {
+	spec := &source.Spec.Backend
+	if spec.HTTP == nil {
+		return nil, fmt.Errorf("custom metrics source %s of type %s has no http backend", source.Name, v1beta1.HTTPBackendType)
+	}
+	options, err := metricsclient.SourceOptions(deps.KubeClient, source)
+	if err != nil {
+		return nil, err
+	}
+	host := fmt.Sprintf("%s.%s", options.Name, options.Namespace)
+	port := strconv.Itoa(int(options.Port))
+	config, err := metricsclient.InClusterConfig(host, port, options.InsecureSkipTLSVerify, options.CABundle, options.Authenticator, options.RequesterForwarding)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate rest config for %s: %v", host, err)
+	}
+	transport, err := rest.TransportFor(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transport: %v", err)
+	}
+	scheme := string(spec.HTTP.Scheme)
+	if scheme == "" {
+		scheme = v1beta1.HTTPScheme
+	}
+	client, err := NewClient(source.Name, scheme+"://"+net.JoinHostPort(host, port), transport, spec.HTTP)
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
@@ -0,0 +1,396 @@
+package httpjson
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/kubernetes-sigs/custom-metrics-apiserver/pkg/provider"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/jsonpath"
+	"k8s.io/klog"
+	"k8s.io/metrics/pkg/apis/custom_metrics"
+	"k8s.io/metrics/pkg/apis/external_metrics"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+)
+
+// DefaultTimeout is the timeout of requests of backends which don't set one.
+const DefaultTimeout = 10 * time.Second
+
+// PathData is passed to the path templates of the metrics.
+type PathData struct {
+	Namespace string
+	// Selector is the metric selector of the request, escaped for a query
+	// parameter, e.g. ?selector={{.Selector}}.
+	Selector string
+	// Labels are the labels which the metric selector requires to have a
+	// value, escaped for a path segment.
+	Labels map[string]string
+}
+
+type externalMetric struct {
+	path   *template.Template
+	items  *jsonPathTemplate
+	value  Extractor
+	labels map[string]Extractor
+}
+
+type cachedDocument struct {
+	document interface{}
+	expires  time.Time
+}
+
+// Client serves the external metrics of a source by extracting them from the
+// JSON documents of an HTTP API.
+type Client struct {
+	source          string
+	url             string
+	httpClient      *http.Client
+	cacheTTL        time.Duration
+	externalMetrics map[string]externalMetric
+
+	lock  sync.Mutex
+	cache map[string]cachedDocument
+}
+
+// NewClient returns a client for the HTTP API at baseURL.
+func NewClient(source, baseURL string, transport http.RoundTripper, backend *v1beta1.HTTPBackend) (*Client, error) {
+	timeout := DefaultTimeout
+	if backend.Timeout != nil {
+		timeout = backend.Timeout.Duration
+	}
+	client := &Client{
+		source:          source,
+		url:             strings.TrimSuffix(baseURL, "/"),
+		httpClient:      &http.Client{Transport: transport, Timeout: timeout},
+		externalMetrics: make(map[string]externalMetric),
+		cache:           make(map[string]cachedDocument),
+	}
+	if backend.CacheTTL != nil {
+		client.cacheTTL = backend.CacheTTL.Duration
+	}
+	for _, metric := range backend.ExternalMetrics {
+		parsed, err := parseExternalMetric(metric)
+		if err != nil {
+			return nil, fmt.Errorf("invalid external metric %s: %v", metric.Name, err)
+		}
+		client.externalMetrics[metric.Name] = parsed
+	}
+	return client, nil
+}
+
+func parseExternalMetric(metric v1beta1.HTTPExternalMetric) (externalMetric, error) {
+	var parsed externalMetric
+	var err error
+	if parsed.path, err = ParsePath(metric.Path); err != nil {
+		return parsed, fmt.Errorf("invalid path: %v", err)
+	}
+	if _, err = ParseItems(metric.Items); err != nil {
+		return parsed, fmt.Errorf("invalid items: %v", err)
+	}
+	if metric.Items != "" {
+		parsed.items = &jsonPathTemplate{name: "items", expression: metric.Items}
+	}
+	if parsed.value, err = ParseExtractor(metric.Value); err != nil {
+		return parsed, fmt.Errorf("invalid value: %v", err)
+	}
+	parsed.labels = make(map[string]Extractor, len(metric.Labels))
+	for name, expression := range metric.Labels {
+		if parsed.labels[name], err = ParseExtractor(expression); err != nil {
+			return parsed, fmt.Errorf("invalid label %s: %v", name, err)
+		}
+	}
+	return parsed, nil
+}
+
+// ParsePath parses the template of a path.
+func ParsePath(path string) (*template.Template, error) {
+	return template.New("path").Option("missingkey=error").Parse(path)
+}
+
+// ParseItems parses the JSONPath of the items of a document. It returns nil
+// for an empty path, which selects the whole document.
+func ParseItems(items string) (*jsonpath.JSONPath, error) {
+	if items == "" {
+		return nil, nil
+	}
+	parsed := jsonpath.New("items")
+	if err := parsed.Parse(items); err != nil {
+		return nil, err
+	}
+	return parsed, nil
+}
+
+// Extractor extracts a value from an item of a JSON document.
+type Extractor interface {
+	Extract(item interface{}) (string, error)
+}
+
+// ParseExtractor parses a Go template, which starts with {{, or a JSONPath
+// template otherwise.
+func ParseExtractor(expression string) (Extractor, error) {
+	if strings.HasPrefix(strings.TrimSpace(expression), "{{") {
+		parsed, err := template.New("extractor").Option("missingkey=error").Parse(expression)
+		if err != nil {
+			return nil, err
+		}
+		return templateExtractor{parsed}, nil
+	}
+	if err := jsonpath.New("extractor").Parse(expression); err != nil {
+		return nil, err
+	}
+	return jsonPathExtractor{&jsonPathTemplate{name: "extractor", expression: expression}}, nil
+}
+
+// jsonPathTemplate is parsed for every execution. A parsed JSONPath keeps
+// state between executions, which breaks ranges after the first one and
+// concurrent requests.
+type jsonPathTemplate struct {
+	name       string
+	expression string
+}
+
+func (t *jsonPathTemplate) parse() (*jsonpath.JSONPath, error) {
+	parsed := jsonpath.New(t.name)
+	if err := parsed.Parse(t.expression); err != nil {
+		return nil, err
+	}
+	return parsed, nil
+}
+
+func (t *jsonPathTemplate) FindResults(data interface{}) ([][]reflect.Value, error) {
+	parsed, err := t.parse()
+	if err != nil {
+		return nil, err
+	}
+	return parsed.FindResults(data)
+}
+
+func (t *jsonPathTemplate) Execute(w io.Writer, data interface{}) error {
+	parsed, err := t.parse()
+	if err != nil {
+		return err
+	}
+	return parsed.Execute(w, data)
+}
+
+type templateExtractor struct {
+	template *template.Template
+}
+
+func (e templateExtractor) Extract(item interface{}) (string, error) {
+	var out bytes.Buffer
+	if err := e.template.Execute(&out, item); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
+type jsonPathExtractor struct {
+	jsonPath *jsonPathTemplate
+}
+
+func (e jsonPathExtractor) Extract(item interface{}) (string, error) {
+	var out bytes.Buffer
+	if err := e.jsonPath.Execute(&out, item); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
+// Source returns the name of the source the client was created for.
+func (c *Client) Source() string {
+	return c.source
+}
+
+// ListCustomMetricInfos returns no metrics, HTTP backends only serve external
+// metrics.
+func (c *Client) ListCustomMetricInfos() (map[provider.CustomMetricInfo]struct{}, error) {
+	return map[provider.CustomMetricInfo]struct{}{}, nil
+}
+
+func (c *Client) ListExternalMetrics() (map[provider.ExternalMetricInfo]struct{}, error) {
+	infos := make(map[provider.ExternalMetricInfo]struct{}, len(c.externalMetrics))
+	for name := range c.externalMetrics {
+		infos[provider.ExternalMetricInfo{Metric: name}] = struct{}{}
+	}
+	return infos, nil
+}
+
+func (c *Client) GetMetricByName(name types.NamespacedName, info provider.CustomMetricInfo, metricSelector labels.Selector) (*custom_metrics.MetricValue, error) {
+	return nil, apierrors.NewNotFound(info.GroupResource, info.Metric)
+}
+
+func (c *Client) GetMetricBySelector(namespace string, selector labels.Selector, info provider.CustomMetricInfo, metricSelector labels.Selector) (*custom_metrics.MetricValueList, error) {
+	return nil, apierrors.NewNotFound(info.GroupResource, info.Metric)
+}
+
+func (c *Client) GetExternalMetric(name, namespace string, metricSelector labels.Selector) (*external_metrics.ExternalMetricValueList, error) {
+	metric, ok := c.externalMetrics[name]
+	if !ok {
+		return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "externalmetrics"}, name)
+	}
+	var path bytes.Buffer
+	if err := metric.path.Execute(&path, NewPathData(namespace, metricSelector)); err != nil {
+		// the path needs a label the metric selector doesn't require.
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("the metric selector doesn't fill in the path of %s: %v", name, err))
+	}
+	document, err := c.get(name, path.String())
+	if err != nil {
+		return nil, err
+	}
+	items := []interface{}{document}
+	if metric.items != nil {
+		results, err := metric.items.FindResults(document)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find the items of %s: %v", name, err)
+		}
+		items = nil
+		for _, result := range results {
+			for _, value := range result {
+				items = append(items, value.Interface())
+			}
+		}
+	}
+
+	selector := metric.extractedSelector(metricSelector)
+	now := metav1.Now()
+	list := &external_metrics.ExternalMetricValueList{}
+	for _, item := range items {
+		metricLabels := make(map[string]string, len(metric.labels))
+		for label, extractor := range metric.labels {
+			if metricLabels[label], err = extractor.Extract(item); err != nil {
+				return nil, fmt.Errorf("failed to extract label %s of %s: %v", label, name, err)
+			}
+		}
+		if !selector.Matches(labels.Set(metricLabels)) {
+			continue
+		}
+		raw, err := metric.value.Extract(item)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract the value of %s: %v", name, err)
+		}
+		value, err := resource.ParseQuantity(raw)
+		if err != nil {
+			klog.V(4).Infof("Skipping value %q of external metric %s of %s: %v", raw, name, c.source, err)
+			continue
+		}
+		list.Items = append(list.Items, external_metrics.ExternalMetricValue{
+			MetricName:   name,
+			MetricLabels: metricLabels,
+			Timestamp:    now,
+			Value:        value,
+		})
+	}
+	return list, nil
+}
+
+// extractedSelector returns the requirements of metricSelector on the labels
+// the metric extracts. Requirements on other labels can only be met by the
+// path, so they don't filter the items.
+func (m externalMetric) extractedSelector(metricSelector labels.Selector) labels.Selector {
+	requirements, _ := metricSelector.Requirements()
+	selector := labels.NewSelector()
+	for _, requirement := range requirements {
+		if _, ok := m.labels[requirement.Key()]; ok {
+			selector = selector.Add(requirement)
+		}
+	}
+	return selector
+}
+
+// NewPathData returns the data of the path templates for a request.
+func NewPathData(namespace string, metricSelector labels.Selector) PathData {
+	data := PathData{
+		Namespace: url.PathEscape(namespace),
+		Labels:    make(map[string]string),
+	}
+	if metricSelector.Empty() {
+		return data
+	}
+	data.Selector = url.QueryEscape(metricSelector.String())
+	requirements, _ := metricSelector.Requirements()
+	for _, requirement := range requirements {
+		switch requirement.Operator() {
+		case selection.Equals, selection.DoubleEquals, selection.In:
+			values := requirement.Values().List()
+			if len(values) == 1 {
+				data.Labels[requirement.Key()] = url.PathEscape(values[0])
+			}
+		}
+	}
+	return data
+}
+
+// get returns the document of metric at path, from the cache if it didn't
+// expire yet.
+func (c *Client) get(metric, path string) (interface{}, error) {
+	now := time.Now()
+	if c.cacheTTL > 0 {
+		c.lock.Lock()
+		cached, ok := c.cache[path]
+		c.lock.Unlock()
+		if ok && now.Before(cached.expires) {
+			return cached.document, nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.httpClient.Timeout)
+	defer cancel()
+	request, err := http.NewRequest(http.MethodGet, c.url+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Accept", "application/json")
+	response, err := c.httpClient.Do(request.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %v", path, err)
+	}
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response of %s: %v", path, err)
+	}
+	if response.StatusCode == http.StatusNotFound {
+		return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "externalmetrics"}, metric)
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("getting %s failed with status %d", path, response.StatusCode)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	// numbers are kept as written, so that they parse as exact quantities.
+	decoder.UseNumber()
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return nil, fmt.Errorf("invalid JSON response of %s: %v", path, err)
+	}
+
+	if c.cacheTTL > 0 {
+		c.lock.Lock()
+		for cachedPath, cached := range c.cache {
+			if !now.Before(cached.expires) {
+				delete(c.cache, cachedPath)
+			}
+		}
+		c.cache[path] = cachedDocument{document: document, expires: now.Add(c.cacheTTL)}
+		c.lock.Unlock()
+	}
+	return document, nil
+}
@@ -0,0 +1,189 @@
+package httpjson
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+)
+
+func newTestServer(t *testing.T, requests *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/queues":
+			require.Equal(t, "team-a", r.URL.Query().Get("namespace"))
+			w.Write([]byte(`{"queues": [{"name": "orders", "length": 12}, {"name": "billing", "length": 1.5}, {"name": "broken", "length": "n/a"}]}`))
+		case "/api/queues/orders":
+			w.Write([]byte(`{"name": "orders", "backlog": {"messages": "250m"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestGetExternalMetric(t *testing.T) {
+	var requests int32
+	server := newTestServer(t, &requests)
+	defer server.Close()
+
+	client, err := NewClient("queues", server.URL+"/api", http.DefaultTransport, &v1beta1.HTTPBackend{
+		ExternalMetrics: []v1beta1.HTTPExternalMetric{
+			{
+				Name:   "queue_length",
+				Path:   "/queues?namespace={{.Namespace}}",
+				Items:  "{.queues[*]}",
+				Value:  "{.length}",
+				Labels: map[string]string{"queue": "{.name}"},
+			},
+			{
+				Name:  "queue_backlog",
+				Path:  "/queues/{{.Labels.queue}}",
+				Value: "{{.backlog.messages}}",
+			},
+			{
+				Name:  "missing",
+				Path:  "/missing",
+				Value: "{.length}",
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name           string
+		metric         string
+		metricSelector labels.Selector
+		expected       map[string]int64
+		expectedError  func(error) bool
+	}{
+		{
+			name:           "items with labels",
+			metric:         "queue_length",
+			metricSelector: labels.Everything(),
+			expected:       map[string]int64{"orders": 12000, "billing": 1500},
+		},
+		{
+			name:           "items filtered by the metric selector",
+			metric:         "queue_length",
+			metricSelector: labels.SelectorFromSet(labels.Set{"queue": "orders"}),
+			expected:       map[string]int64{"orders": 12000},
+		},
+		{
+			name:           "go template of a document",
+			metric:         "queue_backlog",
+			metricSelector: labels.SelectorFromSet(labels.Set{"queue": "orders"}),
+			expected:       map[string]int64{"": 250},
+		},
+		{
+			name:           "metric selector without the label of the path",
+			metric:         "queue_backlog",
+			metricSelector: labels.Everything(),
+			expectedError:  apierrors.IsBadRequest,
+		},
+		{
+			name:           "document not found",
+			metric:         "missing",
+			metricSelector: labels.Everything(),
+			expectedError:  apierrors.IsNotFound,
+		},
+		{
+			name:           "unknown metric",
+			metric:         "unknown",
+			metricSelector: labels.Everything(),
+			expectedError:  apierrors.IsNotFound,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			list, err := client.GetExternalMetric(tc.metric, "team-a", tc.metricSelector)
+			if tc.expectedError != nil {
+				require.True(t, tc.expectedError(err), "%v", err)
+				return
+			}
+			require.NoError(t, err)
+			values := make(map[string]int64)
+			for _, item := range list.Items {
+				require.Equal(t, tc.metric, item.MetricName)
+				values[item.MetricLabels["queue"]] = item.Value.MilliValue()
+			}
+			require.Equal(t, tc.expected, values)
+		})
+	}
+}
+
+func TestCache(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		cacheTTL *metav1.Duration
+		expected int32
+	}{
+		{name: "without cache", expected: 2},
+		{name: "with cache", cacheTTL: &metav1.Duration{Duration: time.Minute}, expected: 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var requests int32
+			server := newTestServer(t, &requests)
+			defer server.Close()
+			client, err := NewClient("queues", server.URL+"/api", http.DefaultTransport, &v1beta1.HTTPBackend{
+				CacheTTL: tc.cacheTTL,
+				ExternalMetrics: []v1beta1.HTTPExternalMetric{
+					{Name: "queue_length", Path: "/queues?namespace={{.Namespace}}", Items: "{.queues[*]}", Value: "{.length}"},
+				},
+			})
+			require.NoError(t, err)
+			for i := 0; i < 2; i++ {
+				_, err := client.GetExternalMetric("queue_length", "team-a", labels.Everything())
+				require.NoError(t, err)
+			}
+			require.Equal(t, tc.expected, atomic.LoadInt32(&requests))
+		})
+	}
+}
+
+func TestNewPathData(t *testing.T) {
+	selector, err := labels.Parse("queue=orders,env in (prod),tier!=web,zone in (a,b)")
+	require.NoError(t, err)
+	data := NewPathData("team-a", selector)
+	require.Equal(t, "team-a", data.Namespace)
+	require.Equal(t, map[string]string{"queue": "orders", "env": "prod"}, data.Labels)
+	require.Equal(t, "env+in+%28prod%29%2Cqueue%3Dorders%2Ctier%21%3Dweb%2Czone+in+%28a%2Cb%29", data.Selector)
+}
+
+func TestConcurrentRequests(t *testing.T) {
+	var requests int32
+	server := newTestServer(t, &requests)
+	defer server.Close()
+	client, err := NewClient("queues", server.URL+"/api", http.DefaultTransport, &v1beta1.HTTPBackend{
+		ExternalMetrics: []v1beta1.HTTPExternalMetric{{
+			Name: "queue_length",
+			Path: "/queues?namespace={{.Namespace}}",
+			// a parsed JSONPath with a range only works once.
+			Value: "{range .queues[0:1]}{.length}{end}",
+		}},
+	})
+	require.NoError(t, err)
+
+	errs := make(chan error, 10)
+	for i := 0; i < cap(errs); i++ {
+		go func() {
+			list, err := client.GetExternalMetric("queue_length", "team-a", labels.Everything())
+			if err == nil && (len(list.Items) != 1 || list.Items[0].Value.Value() != 12) {
+				err = fmt.Errorf("unexpected values %v", list.Items)
+			}
+			errs <- err
+		}()
+	}
+	for i := 0; i < cap(errs); i++ {
+		require.NoError(t, <-errs)
+	}
+}
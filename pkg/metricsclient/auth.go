@@ -0,0 +1,243 @@
+package metricsclient
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+	"golang.org/x/oauth2"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/transport"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+)
+
+const (
+	requestTimeout = 10 * time.Second
+	// impersonatorTokenExpiration is the lifetime of the tokens of the
+	// impersonator service account, the shortest the TokenRequest API allows.
+	impersonatorTokenExpiration int64 = 600
+	// secretTokenRefresh is how often the token of a Secret is read again, so
+	// that a rotated token is sent without recreating the source.
+	secretTokenRefresh = time.Minute
+)
+
+var (
+	impersonatorServiceAccount = pflag.String("impersonator-service-account", "custom-metrics/custom-metrics-router-impersonator",
+		"<namespace>/<name> of the service account whose short-lived tokens are sent to backends which impersonate users")
+	impersonationBackends = pflag.StringSlice("impersonation-backends", nil,
+		"<namespace>/<name> of the backend Services which are trusted with tokens of the impersonator service account. "+
+			"Sources of other Services can't use the authentication mode or the requester forwarding Impersonation")
+)
+
+// Authenticator adds the credentials for a backend to its rest config.
+type Authenticator interface {
+	Configure(config *rest.Config) error
+}
+
+// NewAuthenticator returns the Authenticator for the authentication settings of
+// a source whose backend is service. A nil auth falls back to the router's
+// service account token. Secrets and service accounts of other namespaces are
+// refused, and so is impersonation for backends which aren't trusted with it.
+func NewAuthenticator(kubeClient kubernetes.Interface, service v1beta1.ServiceReference, auth *v1beta1.Authentication) (Authenticator, error) {
+	namespace := service.Namespace
+	if auth == nil {
+		return serviceAccountAuthenticator{}, nil
+	}
+	switch auth.Mode {
+	case v1beta1.NoAuthentication:
+		return noAuthenticator{}, nil
+	case v1beta1.ServiceAccountAuthentication:
+		return serviceAccountAuthenticator{}, nil
+	case v1beta1.SecretTokenAuthentication:
+		if auth.SecretToken == nil {
+			return nil, fmt.Errorf("secretToken must be set for authentication mode %s", auth.Mode)
+		}
+		if auth.SecretToken.Namespace != namespace {
+			return nil, fmt.Errorf("secret %s/%s isn't in the namespace %s of the backend", auth.SecretToken.Namespace, auth.SecretToken.Name, namespace)
+		}
+		return &secretTokenAuthenticator{
+			tokenSource: oauth2.ReuseTokenSource(nil, &secretTokenSource{kubeClient: kubeClient, selector: *auth.SecretToken}),
+		}, nil
+	case v1beta1.ProjectedTokenAuthentication:
+		if auth.ProjectedToken == nil {
+			return nil, fmt.Errorf("projectedToken must be set for authentication mode %s", auth.Mode)
+		}
+		if sa := auth.ProjectedToken.ServiceAccount; sa.Namespace != namespace {
+			return nil, fmt.Errorf("service account %s/%s isn't in the namespace %s of the backend", sa.Namespace, sa.Name, namespace)
+		}
+		return &projectedTokenAuthenticator{
+			tokenSource: oauth2.ReuseTokenSource(nil, &projectedTokenSource{
+				kubeClient: kubeClient,
+				token:      *auth.ProjectedToken,
+			}),
+		}, nil
+	case v1beta1.ImpersonationAuthentication:
+		if auth.Impersonation == nil {
+			return nil, fmt.Errorf("impersonation must be set for authentication mode %s", auth.Mode)
+		}
+		if !trustedWithImpersonation(service) {
+			return nil, fmt.Errorf("backend %s/%s isn't trusted with impersonation, see --impersonation-backends", service.Namespace, service.Name)
+		}
+		return newImpersonationAuthenticator(kubeClient, *auth.Impersonation)
+	default:
+		return nil, fmt.Errorf("unknown authentication mode %q", auth.Mode)
+	}
+}
+
+// trustedWithImpersonation returns whether the cluster admin allowed a backend
+// to receive the tokens of the impersonator service account, which the API
+// server accepts as well. The authors of sources can't allow it.
+func trustedWithImpersonation(service v1beta1.ServiceReference) bool {
+	for _, backend := range *impersonationBackends {
+		if backend == service.Namespace+"/"+service.Name {
+			return true
+		}
+	}
+	return false
+}
+
+type noAuthenticator struct{}
+
+func (noAuthenticator) Configure(*rest.Config) error {
+	return nil
+}
+
+type serviceAccountAuthenticator struct{}
+
+func (serviceAccountAuthenticator) Configure(config *rest.Config) error {
+	token, err := ioutil.ReadFile(*tokenFile)
+	if err != nil {
+		return err
+	}
+	config.BearerToken = string(token)
+	config.BearerTokenFile = *tokenFile
+	return nil
+}
+
+type secretTokenAuthenticator struct {
+	tokenSource oauth2.TokenSource
+}
+
+func (a *secretTokenAuthenticator) Configure(config *rest.Config) error {
+	if _, err := a.tokenSource.Token(); err != nil {
+		return err
+	}
+	config.Wrap(transport.TokenSourceWrapTransport(a.tokenSource))
+	return nil
+}
+
+// secretTokenSource reads the token from a Secret. Its tokens expire after
+// secretTokenRefresh, so that the Secret is read again.
+type secretTokenSource struct {
+	kubeClient kubernetes.Interface
+	selector   v1beta1.SecretKeySelector
+}
+
+func (s *secretTokenSource) Token() (*oauth2.Token, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	secret, err := s.kubeClient.CoreV1().Secrets(s.selector.Namespace).Get(ctx, s.selector.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret %s/%s: %v", s.selector.Namespace, s.selector.Name, err)
+	}
+	token, ok := secret.Data[s.selector.Key]
+	if !ok {
+		return nil, fmt.Errorf("secret %s/%s has no key %s", s.selector.Namespace, s.selector.Name, s.selector.Key)
+	}
+	return &oauth2.Token{
+		AccessToken: string(token),
+		TokenType:   "Bearer",
+		Expiry:      time.Now().Add(secretTokenRefresh),
+	}, nil
+}
+
+type projectedTokenAuthenticator struct {
+	tokenSource oauth2.TokenSource
+}
+
+func (a *projectedTokenAuthenticator) Configure(config *rest.Config) error {
+	// fetch a token up front so that a misconfigured source fails when it is
+	// added instead of on the first metrics request.
+	if _, err := a.tokenSource.Token(); err != nil {
+		return err
+	}
+	config.Wrap(transport.TokenSourceWrapTransport(a.tokenSource))
+	return nil
+}
+
+type projectedTokenSource struct {
+	kubeClient kubernetes.Interface
+	token      v1beta1.ProjectedToken
+}
+
+func (s *projectedTokenSource) Token() (*oauth2.Token, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	sa := s.token.ServiceAccount
+	request := &authenticationv1.TokenRequest{
+		Spec: authenticationv1.TokenRequestSpec{
+			ExpirationSeconds: s.token.ExpirationSeconds,
+		},
+	}
+	// tokens without an audience are meant for the API server.
+	if s.token.Audience != "" {
+		request.Spec.Audiences = []string{s.token.Audience}
+	}
+	response, err := s.kubeClient.CoreV1().ServiceAccounts(sa.Namespace).CreateToken(ctx, sa.Name, request, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to request token for service account %s/%s: %v", sa.Namespace, sa.Name, err)
+	}
+	return &oauth2.Token{
+		AccessToken: response.Status.Token,
+		TokenType:   "Bearer",
+		Expiry:      response.Status.ExpirationTimestamp.Time,
+	}, nil
+}
+
+// impersonationAuthenticator sends short-lived tokens of the impersonator
+// service account instead of the router's own token, which may not
+// impersonate.
+type impersonationAuthenticator struct {
+	tokenSource   oauth2.TokenSource
+	impersonation v1beta1.Impersonation
+}
+
+func newImpersonationAuthenticator(kubeClient kubernetes.Interface, impersonation v1beta1.Impersonation) (Authenticator, error) {
+	parts := strings.Split(*impersonatorServiceAccount, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("--impersonator-service-account must be <namespace>/<name>, got %q", *impersonatorServiceAccount)
+	}
+	expiration := impersonatorTokenExpiration
+	return &impersonationAuthenticator{
+		tokenSource: oauth2.ReuseTokenSource(nil, &projectedTokenSource{
+			kubeClient: kubeClient,
+			token: v1beta1.ProjectedToken{
+				ServiceAccount:    v1beta1.ServiceAccountReference{Namespace: parts[0], Name: parts[1]},
+				ExpirationSeconds: &expiration,
+			},
+		}),
+		impersonation: impersonation,
+	}, nil
+}
+
+func (a *impersonationAuthenticator) Configure(config *rest.Config) error {
+	if _, err := a.tokenSource.Token(); err != nil {
+		return err
+	}
+	config.Wrap(transport.TokenSourceWrapTransport(a.tokenSource))
+	if a.impersonation.UserName != "" {
+		config.Impersonate = rest.ImpersonationConfig{
+			UserName: a.impersonation.UserName,
+			Groups:   a.impersonation.Groups,
+			Extra:    a.impersonation.Extra,
+		}
+	}
+	return nil
+}
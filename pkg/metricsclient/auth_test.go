@@ -0,0 +1,190 @@
+package metricsclient
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+)
+
+var adapter = v1beta1.ServiceReference{Namespace: "team-a", Name: "adapter"}
+
+func TestNewAuthenticator(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		auth          *v1beta1.Authentication
+		expectedError bool
+	}{
+		{name: "default"},
+		{name: "none", auth: &v1beta1.Authentication{Mode: v1beta1.NoAuthentication}},
+		{name: "missing secret", auth: &v1beta1.Authentication{Mode: v1beta1.SecretTokenAuthentication}, expectedError: true},
+		{name: "missing projected token", auth: &v1beta1.Authentication{Mode: v1beta1.ProjectedTokenAuthentication}, expectedError: true},
+		{name: "missing impersonation", auth: &v1beta1.Authentication{Mode: v1beta1.ImpersonationAuthentication}, expectedError: true},
+		{
+			name:          "untrusted impersonation",
+			auth:          &v1beta1.Authentication{Mode: v1beta1.ImpersonationAuthentication, Impersonation: &v1beta1.Impersonation{UserName: "metrics-reader"}},
+			expectedError: true,
+		},
+		{name: "unknown", auth: &v1beta1.Authentication{Mode: "Basic"}, expectedError: true},
+		{
+			name: "secret in another namespace",
+			auth: &v1beta1.Authentication{
+				Mode:        v1beta1.SecretTokenAuthentication,
+				SecretToken: &v1beta1.SecretKeySelector{Namespace: "kube-system", Name: "admin-token", Key: "token"},
+			},
+			expectedError: true,
+		},
+		{
+			name: "service account in another namespace",
+			auth: &v1beta1.Authentication{
+				Mode: v1beta1.ProjectedTokenAuthentication,
+				ProjectedToken: &v1beta1.ProjectedToken{
+					ServiceAccount: v1beta1.ServiceAccountReference{Namespace: "kube-system", Name: "admin"},
+					Audience:       "adapter",
+				},
+			},
+			expectedError: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewAuthenticator(fake.NewSimpleClientset(), adapter, tc.auth)
+			if tc.expectedError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestSourceOptionsImpersonation(t *testing.T) {
+	source := &v1beta1.CustomMetricsSource{Spec: v1beta1.CustomMetricsSourceSpec{Backend: v1beta1.Backend{
+		Service:             adapter,
+		RequesterForwarding: v1beta1.ImpersonationRequesterForwarding,
+	}}}
+	// backends only receive the impersonator's tokens when the cluster admin
+	// trusts them.
+	_, err := SourceOptions(fake.NewSimpleClientset(), source)
+	require.Error(t, err)
+
+	*impersonationBackends = []string{"team-a/adapter"}
+	defer func() { *impersonationBackends = nil }()
+	_, err = SourceOptions(fake.NewSimpleClientset(), source)
+	require.NoError(t, err)
+
+	// sources with their own credentials may forward requesters.
+	*impersonationBackends = nil
+	source.Spec.Backend.Authentication = &v1beta1.Authentication{Mode: v1beta1.NoAuthentication}
+	_, err = SourceOptions(fake.NewSimpleClientset(), source)
+	require.NoError(t, err)
+}
+
+func TestSecretTokenAuthenticator(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "adapter-token", Namespace: "team-a"},
+		Data:       map[string][]byte{"token": []byte("secret-token")},
+	}
+	client := fake.NewSimpleClientset(secret)
+	authenticator, err := NewAuthenticator(client, adapter, &v1beta1.Authentication{
+		Mode:        v1beta1.SecretTokenAuthentication,
+		SecretToken: &v1beta1.SecretKeySelector{Namespace: "team-a", Name: "adapter-token", Key: "token"},
+	})
+	require.NoError(t, err)
+	config := &rest.Config{}
+	require.NoError(t, authenticator.Configure(config))
+	require.NotNil(t, config.WrapTransport)
+	require.Empty(t, config.BearerToken)
+
+	// a rotated token is read again.
+	source := &secretTokenSource{kubeClient: client, selector: v1beta1.SecretKeySelector{Namespace: "team-a", Name: "adapter-token", Key: "token"}}
+	token, err := source.Token()
+	require.NoError(t, err)
+	require.Equal(t, "secret-token", token.AccessToken)
+	secret.Data["token"] = []byte("rotated-token")
+	_, err = client.CoreV1().Secrets("team-a").Update(context.Background(), secret, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	token, err = source.Token()
+	require.NoError(t, err)
+	require.Equal(t, "rotated-token", token.AccessToken)
+
+	authenticator, err = NewAuthenticator(client, adapter, &v1beta1.Authentication{
+		Mode:        v1beta1.SecretTokenAuthentication,
+		SecretToken: &v1beta1.SecretKeySelector{Namespace: "team-a", Name: "adapter-token", Key: "missing"},
+	})
+	require.NoError(t, err)
+	require.Error(t, authenticator.Configure(&rest.Config{}))
+}
+
+func TestProjectedTokenAuthenticator(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	requests := 0
+	client.PrependReactor("create", "serviceaccounts", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		request := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenRequest)
+		require.Equal(t, []string{"adapter"}, request.Spec.Audiences)
+		requests++
+		return true, &authenticationv1.TokenRequest{
+			Status: authenticationv1.TokenRequestStatus{
+				Token:               "projected-token",
+				ExpirationTimestamp: metav1.NewTime(time.Now().Add(time.Hour)),
+			},
+		}, nil
+	})
+	authenticator, err := NewAuthenticator(client, v1beta1.ServiceReference{Namespace: "custom-metrics", Name: "adapter"}, &v1beta1.Authentication{
+		Mode: v1beta1.ProjectedTokenAuthentication,
+		ProjectedToken: &v1beta1.ProjectedToken{
+			ServiceAccount: v1beta1.ServiceAccountReference{Namespace: "custom-metrics", Name: "custom-metrics-router"},
+			Audience:       "adapter",
+		},
+	})
+	require.NoError(t, err)
+	config := &rest.Config{}
+	require.NoError(t, authenticator.Configure(config))
+	require.NotNil(t, config.WrapTransport)
+	require.Empty(t, config.BearerToken)
+
+	token, err := authenticator.(*projectedTokenAuthenticator).tokenSource.Token()
+	require.NoError(t, err)
+	require.Equal(t, "projected-token", token.AccessToken)
+	require.Equal(t, 1, requests)
+}
+
+func TestImpersonationAuthenticator(t *testing.T) {
+	*impersonationBackends = []string{"team-a/adapter"}
+	defer func() { *impersonationBackends = nil }()
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "serviceaccounts", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		create := action.(k8stesting.CreateAction)
+		require.Equal(t, "custom-metrics", create.GetNamespace())
+		request := create.GetObject().(*authenticationv1.TokenRequest)
+		require.Empty(t, request.Spec.Audiences)
+		require.Equal(t, impersonatorTokenExpiration, *request.Spec.ExpirationSeconds)
+		return true, &authenticationv1.TokenRequest{
+			Status: authenticationv1.TokenRequestStatus{
+				Token:               "impersonator-token",
+				ExpirationTimestamp: metav1.NewTime(time.Now().Add(10 * time.Minute)),
+			},
+		}, nil
+	})
+	authenticator, err := NewAuthenticator(client, adapter, &v1beta1.Authentication{
+		Mode:          v1beta1.ImpersonationAuthentication,
+		Impersonation: &v1beta1.Impersonation{UserName: "metrics-reader"},
+	})
+	require.NoError(t, err)
+	config := &rest.Config{}
+	require.NoError(t, authenticator.Configure(config))
+	// the router's own token is never sent.
+	require.Empty(t, config.BearerToken)
+	require.Empty(t, config.BearerTokenFile)
+	require.NotNil(t, config.WrapTransport)
+	require.Equal(t, "metrics-reader", config.Impersonate.UserName)
+}
@@ -0,0 +1,40 @@
+package metricsclient
+
+import (
+	"net/http"
+	"net/url"
+
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apiserver/pkg/authentication/user"
+)
+
+const (
+	remoteUserHeader        = "X-Remote-User"
+	remoteGroupHeader       = "X-Remote-Group"
+	remoteExtraHeaderPrefix = "X-Remote-Extra-"
+)
+
+// frontProxyRoundTripper sets the request header authentication headers which
+// the kube-aggregator uses to tell an extension apiserver who made a request.
+type frontProxyRoundTripper struct {
+	requester user.Info
+	delegate  http.RoundTripper
+}
+
+func (rt *frontProxyRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = utilnet.CloneRequest(req)
+	req.Header.Set(remoteUserHeader, rt.requester.GetName())
+	for _, group := range rt.requester.GetGroups() {
+		req.Header.Add(remoteGroupHeader, group)
+	}
+	for key, values := range rt.requester.GetExtra() {
+		for _, value := range values {
+			req.Header.Add(remoteExtraHeaderPrefix+url.PathEscape(key), value)
+		}
+	}
+	return rt.delegate.RoundTrip(req)
+}
+
+func (rt *frontProxyRoundTripper) WrappedRoundTripper() http.RoundTripper {
+	return rt.delegate
+}
@@ -0,0 +1,37 @@
+package metricsclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apiserver/pkg/authentication/user"
+)
+
+func TestFrontProxyRoundTripper(t *testing.T) {
+	var headers http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &frontProxyRoundTripper{
+		requester: &user.DefaultInfo{
+			Name:   "jane",
+			Groups: []string{"team-a", "system:authenticated"},
+			Extra:  map[string][]string{"example.org/scope": {"metrics"}},
+		},
+		delegate: http.DefaultTransport,
+	}}
+	request, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	response, err := client.Do(request)
+	require.NoError(t, err)
+	response.Body.Close()
+
+	require.Equal(t, "jane", headers.Get("X-Remote-User"))
+	require.Equal(t, []string{"team-a", "system:authenticated"}, headers["X-Remote-Group"])
+	require.Equal(t, "metrics", headers.Get("X-Remote-Extra-Example.org%2fscope"))
+	require.Empty(t, request.Header.Get("X-Remote-User"))
+}
@@ -2,8 +2,8 @@ package metricsclient
 
 import (
 	"fmt"
-	"io/ioutil"
 	"net"
+	"net/http"
 	"strconv"
 	"strings"
 
@@ -14,9 +14,12 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apiserver/pkg/authentication/user"
 	"k8s.io/client-go/discovery"
 	cachedDiscovery "k8s.io/client-go/discovery/cached"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/transport"
 	certutil "k8s.io/client-go/util/cert"
 	"k8s.io/klog"
 	"k8s.io/metrics/pkg/apis/custom_metrics"
@@ -24,36 +27,105 @@ import (
 	"k8s.io/metrics/pkg/apis/custom_metrics/v1beta2"
 	"k8s.io/metrics/pkg/apis/external_metrics"
 	externalMetricsAPI "k8s.io/metrics/pkg/apis/external_metrics/v1beta1"
+	rmClient "k8s.io/metrics/pkg/client/clientset/versioned/typed/metrics/v1beta1"
 	cmClient "k8s.io/metrics/pkg/client/custom_metrics"
 	emClient "k8s.io/metrics/pkg/client/external_metrics"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+	"github.com/arjunrn/custom-metrics-router/pkg/backend"
 )
 
 var (
 	tokenFile  = pflag.String("token-file", "/var/run/secrets/kubernetes.io/serviceaccount/token", "path to token file")
 	rootCAFile = pflag.String("root-ca-faile", "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt", "path to root CA file")
+
+	proxyClientCertFile = pflag.String("proxy-client-cert-file", "", "client certificate presented to backends which receive the requester as front-proxy headers")
+	proxyClientKeyFile  = pflag.String("proxy-client-key-file", "", "private key for --proxy-client-cert-file")
 )
 
+// Options describe how to reach and authenticate to a backend service.
+type Options struct {
+	// Source is the name of the CustomMetricsSource the backend belongs to.
+	Source                string
+	Name                  string
+	Namespace             string
+	Port                  int32
+	InsecureSkipTLSVerify bool
+	// CABundle verifies the serving certificate of the backend instead of the
+	// CA of the router's service account when it is set.
+	CABundle            []byte
+	Authenticator       Authenticator
+	RequesterForwarding v1beta1.RequesterForwarding
+}
+
+var _ backend.ResourceMetricsBackend = &Client{}
+var _ backend.RequesterForwarder = &Client{}
+
+func init() {
+	backend.Register(v1beta1.MetricsAPIBackendType, func(source *v1beta1.CustomMetricsSource, deps backend.Dependencies) (backend.Backend, error) {
+		options, err := SourceOptions(deps.KubeClient, source)
+		if err != nil {
+			return nil, err
+		}
+		client, err := NewClient(options, deps.Mapper)
+		if err != nil {
+			return nil, err
+		}
+		return client, nil
+	})
+}
+
+// SourceOptions returns the options for the backend of a source.
+func SourceOptions(kubeClient kubernetes.Interface, source *v1beta1.CustomMetricsSource) (Options, error) {
+	spec := &source.Spec.Backend
+	auth := spec.Authentication
+	if spec.RequesterForwarding == v1beta1.ImpersonationRequesterForwarding &&
+		(auth == nil || auth.Mode == v1beta1.ServiceAccountAuthentication) {
+		// the router's own token may not impersonate, so requesters are
+		// forwarded with a token of the impersonator service account, which
+		// only trusted backends receive.
+		auth = &v1beta1.Authentication{Mode: v1beta1.ImpersonationAuthentication, Impersonation: &v1beta1.Impersonation{}}
+	}
+	authenticator, err := NewAuthenticator(kubeClient, spec.Service, auth)
+	if err != nil {
+		return Options{}, fmt.Errorf("invalid authentication for custom metrics source %s: %v", source.Name, err)
+	}
+	return Options{
+		Source:                source.Name,
+		Name:                  spec.Service.Name,
+		Namespace:             spec.Service.Namespace,
+		Port:                  spec.Service.Port,
+		InsecureSkipTLSVerify: spec.TLS.InsecureSkipVerify,
+		CABundle:              spec.TLS.CABundle,
+		Authenticator:         authenticator,
+		RequesterForwarding:   spec.RequesterForwarding,
+	}, nil
+}
+
 type Client struct {
 	customMetricsClient   cmClient.CustomMetricsClient
 	externalMetricsClient emClient.ExternalMetricsClient
+	resourceMetricsClient rmClient.MetricsV1beta1Interface
 	discoveryClient       discovery.CachedDiscoveryInterface
+	apiVersionsGetter     cmClient.AvailableAPIsGetter
 	mapper                meta.RESTMapper
+	source                string
 	namespace             string
 	name                  string
+	host                  string
+	transport             http.RoundTripper
+	requesterForwarding   v1beta1.RequesterForwarding
 }
 
-// InClusterConfig returns a config object which uses the service account
-// kubernetes gives to pods. It's intended for clients that expect to be
-// running inside a pod running on kubernetes. It will return ErrNotInCluster
-// if called from a process not running in a kubernetes environment.
-func InClusterConfig(host, port string, insecure bool) (*rest.Config, error) {
-	token, err := ioutil.ReadFile(*tokenFile)
-	if err != nil {
-		return nil, err
-	}
+// InClusterConfig returns a config object for a backend reachable from inside
+// the cluster. The credentials sent to the backend are added by the
+// Authenticator of the source.
+func InClusterConfig(host, port string, insecure bool, caBundle []byte, authenticator Authenticator, requesterForwarding v1beta1.RequesterForwarding) (*rest.Config, error) {
 	var tlsClientConfig rest.TLSClientConfig
 	if insecure {
 		tlsClientConfig.Insecure = true
+	} else if len(caBundle) > 0 {
+		tlsClientConfig.CAData = caBundle
 	} else {
 		if _, err := certutil.NewPool(*rootCAFile); err != nil {
 			klog.Errorf("Expected to load root CA config from %s, but got err: %v", *rootCAFile, err)
@@ -62,19 +134,33 @@ func InClusterConfig(host, port string, insecure bool) (*rest.Config, error) {
 		}
 	}
 
-	return &rest.Config{
+	config := &rest.Config{
 		// TODO: switch to using cluster DNS.
 		Host: "https://" + net.JoinHostPort(host, port),
 		// Host:            "https://localhost:6443",
 		TLSClientConfig: tlsClientConfig,
-		BearerToken:     string(token),
-		BearerTokenFile: *tokenFile,
-	}, nil
+	}
+	if err := authenticator.Configure(config); err != nil {
+		return nil, err
+	}
+	switch requesterForwarding {
+	case v1beta1.ImpersonationRequesterForwarding:
+		if config.Impersonate.UserName != "" {
+			return nil, fmt.Errorf("requester forwarding %s can't be combined with a fixed impersonated user", requesterForwarding)
+		}
+	case v1beta1.FrontProxyRequesterForwarding:
+		if *proxyClientCertFile == "" || *proxyClientKeyFile == "" {
+			return nil, fmt.Errorf("requester forwarding %s requires --proxy-client-cert-file and --proxy-client-key-file", requesterForwarding)
+		}
+		config.TLSClientConfig.CertFile = *proxyClientCertFile
+		config.TLSClientConfig.KeyFile = *proxyClientKeyFile
+	}
+	return config, nil
 }
 
-func NewClient(insecureTLSSkipVerify bool, name, namespace string, port int32, mapper meta.RESTMapper) (*Client, error) {
-	host := fmt.Sprintf("%s.%s", name, namespace)
-	config, err := InClusterConfig(host, strconv.Itoa(int(port)), insecureTLSSkipVerify)
+func NewClient(options Options, mapper meta.RESTMapper) (*Client, error) {
+	host := fmt.Sprintf("%s.%s", options.Name, options.Namespace)
+	config, err := InClusterConfig(host, strconv.Itoa(int(options.Port)), options.InsecureSkipTLSVerify, options.CABundle, options.Authenticator, options.RequesterForwarding)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate rest config for %s: %v", host, err)
 	}
@@ -83,22 +169,81 @@ func NewClient(insecureTLSSkipVerify bool, name, namespace string, port int32, m
 		return nil, fmt.Errorf("failed to create discovery client: %v", err)
 	}
 	cachedClient := cachedDiscovery.NewMemCacheClient(discoveryClient)
-	customMetricsClient := cmClient.NewForConfig(config, mapper, cmClient.NewAvailableAPIsGetter(discoveryClient))
+	apiVersionsGetter := cmClient.NewAvailableAPIsGetter(discoveryClient)
+	customMetricsClient := cmClient.NewForConfig(config, mapper, apiVersionsGetter)
 	externalMetricsClient, err := emClient.NewForConfig(config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create external metrics client: %v", err)
 	}
+	resourceMetricsClient, err := rmClient.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resource metrics client: %v", err)
+	}
+	// the transport is kept so that clients for forwarded requesters share
+	// connections and credentials with this client.
+	transport, err := rest.TransportFor(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transport: %v", err)
+	}
 
 	return &Client{
-		name:                  name,
-		namespace:             namespace,
+		source:                options.Source,
+		name:                  options.Name,
+		namespace:             options.Namespace,
+		host:                  config.Host,
 		customMetricsClient:   customMetricsClient,
 		externalMetricsClient: externalMetricsClient,
+		resourceMetricsClient: resourceMetricsClient,
 		discoveryClient:       cachedClient,
+		apiVersionsGetter:     apiVersionsGetter,
 		mapper:                mapper,
+		transport:             transport,
+		requesterForwarding:   options.RequesterForwarding,
 	}, err
 }
 
+// Source returns the name of the CustomMetricsSource the client was created for.
+func (c *Client) Source() string {
+	return c.source
+}
+
+// WithRequester returns a client which passes requester on to the backend
+// according to the requester forwarding of the source. The client itself is
+// returned when forwarding is disabled or the requester is unknown.
+func (c *Client) WithRequester(requester user.Info) (backend.Backend, error) {
+	if requester == nil {
+		return c, nil
+	}
+	var rt http.RoundTripper
+	switch c.requesterForwarding {
+	case v1beta1.ImpersonationRequesterForwarding:
+		// extra fields aren't forwarded, because the impersonator may only
+		// impersonate the users and groups it was granted.
+		rt = transport.NewImpersonatingRoundTripper(transport.ImpersonationConfig{
+			UserName: requester.GetName(),
+			Groups:   requester.GetGroups(),
+		}, c.transport)
+	case v1beta1.FrontProxyRequesterForwarding:
+		rt = &frontProxyRoundTripper{requester: requester, delegate: c.transport}
+	default:
+		return c, nil
+	}
+	config := &rest.Config{Host: c.host, Transport: rt}
+	externalMetricsClient, err := emClient.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create external metrics client: %v", err)
+	}
+	resourceMetricsClient, err := rmClient.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resource metrics client: %v", err)
+	}
+	client := *c
+	client.customMetricsClient = cmClient.NewForConfig(config, c.mapper, c.apiVersionsGetter)
+	client.externalMetricsClient = externalMetricsClient
+	client.resourceMetricsClient = resourceMetricsClient
+	return &client, nil
+}
+
 func (c *Client) ListCustomMetricInfos() (map[provider.CustomMetricInfo]struct{}, error) {
 	resources, err := c.discoveryClient.ServerResourcesForGroupVersion(customMetricsAPI.SchemeGroupVersion.String())
 	if err != nil {
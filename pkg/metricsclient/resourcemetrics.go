@@ -0,0 +1,75 @@
+package metricsclient
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/metrics/pkg/apis/metrics"
+	resourceMetricsAPI "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+)
+
+// ListResourceMetrics returns the resources, nodes and pods, for which the
+// backend serves resource metrics.
+func (c *Client) ListResourceMetrics() (map[string]struct{}, error) {
+	resources, err := c.discoveryClient.ServerResourcesForGroupVersion(resourceMetricsAPI.SchemeGroupVersion.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get resource for %s: %v", resourceMetricsAPI.SchemeGroupVersion, err)
+	}
+	infos := make(map[string]struct{})
+	for _, r := range resources.APIResources {
+		infos[r.Name] = struct{}{}
+	}
+	return infos, nil
+}
+
+// The resource metrics methods return the errors of the backend unchanged, so
+// that e.g. a missing pod is reported as NotFound to the requester.
+
+func (c *Client) GetNodeMetrics(name string) (*metrics.NodeMetrics, error) {
+	result, err := c.resourceMetricsClient.NodeMetricses().Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	out := &metrics.NodeMetrics{}
+	if err := resourceMetricsAPI.Convert_v1beta1_NodeMetrics_To_metrics_NodeMetrics(result, out, nil); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *Client) ListNodeMetrics(options metav1.ListOptions) (*metrics.NodeMetricsList, error) {
+	result, err := c.resourceMetricsClient.NodeMetricses().List(context.TODO(), options)
+	if err != nil {
+		return nil, err
+	}
+	out := &metrics.NodeMetricsList{}
+	if err := resourceMetricsAPI.Convert_v1beta1_NodeMetricsList_To_metrics_NodeMetricsList(result, out, nil); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *Client) GetPodMetrics(namespace, name string) (*metrics.PodMetrics, error) {
+	result, err := c.resourceMetricsClient.PodMetricses(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	out := &metrics.PodMetrics{}
+	if err := resourceMetricsAPI.Convert_v1beta1_PodMetrics_To_metrics_PodMetrics(result, out, nil); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *Client) ListPodMetrics(namespace string, options metav1.ListOptions) (*metrics.PodMetricsList, error) {
+	result, err := c.resourceMetricsClient.PodMetricses(namespace).List(context.TODO(), options)
+	if err != nil {
+		return nil, err
+	}
+	out := &metrics.PodMetricsList{}
+	if err := resourceMetricsAPI.Convert_v1beta1_PodMetricsList_To_metrics_PodMetricsList(result, out, nil); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,172 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	apiregistrationv1 "k8s.io/kube-aggregator/pkg/apis/apiregistration/v1"
+	apiregistrationclient "k8s.io/kube-aggregator/pkg/client/clientset_generated/clientset/typed/apiregistration/v1"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+	mrclient "github.com/arjunrn/custom-metrics-router/pkg/client/clientset/versioned/typed/metricsrouter.io/v1beta1"
+	"github.com/arjunrn/custom-metrics-router/pkg/validation"
+)
+
+// metricTypes are the metric APIs which are migrated to the router.
+var metricTypes = map[string]v1beta1.MetricType{
+	"custom.metrics.k8s.io":   v1beta1.CustomMetricsType,
+	"external.metrics.k8s.io": v1beta1.ExternalMetricsType,
+}
+
+const defaultPort = 443
+
+// Options configure a migration.
+type Options struct {
+	// Router is the Service of the router which the APIServices are pointed at.
+	Router apiregistrationv1.ServiceReference
+	// RouterCABundle verifies the router. The APIServices skip the TLS
+	// verification of the router when it is empty.
+	RouterCABundle []byte
+	// DryRun only prints the planned changes.
+	DryRun bool
+	Out    io.Writer
+}
+
+// Migrate creates a CustomMetricsSource for every backend which serves the
+// custom or external metrics APIService and then points the APIServices at
+// the router. Backends which already have a source are skipped.
+func Migrate(ctx context.Context, apiServices apiregistrationclient.APIServiceInterface, sources mrclient.CustomMetricsSourceInterface, options Options) error {
+	apiServiceList, err := apiServices.List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list APIServices: %v", err)
+	}
+	sourceList, err := sources.List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list custom metrics sources: %v", err)
+	}
+	existing := make(map[string]string)
+	for _, source := range sourceList.Items {
+		service := source.Spec.Backend.Service
+		existing[service.Namespace+"/"+service.Name] = source.Name
+	}
+
+	var planned []*v1beta1.CustomMetricsSource
+	var repointed []apiregistrationv1.APIService
+	backends := make(map[string]*v1beta1.CustomMetricsSource)
+	for _, apiService := range apiServiceList.Items {
+		metricType, ok := metricTypes[apiService.Spec.Group]
+		if !ok || apiService.Spec.Service == nil || isRouter(apiService.Spec.Service, options.Router) {
+			continue
+		}
+		repointed = append(repointed, apiService)
+
+		service := apiService.Spec.Service
+		key := service.Namespace + "/" + service.Name
+		if name, ok := existing[key]; ok {
+			fmt.Fprintf(options.Out, "Service %s of APIService %s is already used by custom metrics source %s\n", key, apiService.Name, name)
+			continue
+		}
+		source, ok := backends[key]
+		if !ok {
+			source = sourceForAPIService(&apiService)
+			backends[key] = source
+			planned = append(planned, source)
+		}
+		if !hasMetricType(source, metricType) {
+			source.Spec.Routing.MetricTypes = append(source.Spec.Routing.MetricTypes, metricType)
+		}
+	}
+
+	for _, source := range planned {
+		if allErrs := validation.ValidateCustomMetricsSourceSpec(&source.Spec, field.NewPath("spec")); len(allErrs) > 0 {
+			return fmt.Errorf("invalid custom metrics source %s: %v", source.Name, allErrs.ToAggregate())
+		}
+	}
+	if len(repointed) == 0 {
+		fmt.Fprintln(options.Out, "No metrics APIServices to migrate")
+		return nil
+	}
+
+	// the sources are created first, so that the router can serve the
+	// metrics once the APIServices point at it.
+	for _, source := range planned {
+		fmt.Fprintf(options.Out, "Creating custom metrics source %s for service %s/%s:%d with metric types %s\n",
+			source.Name, source.Spec.Backend.Service.Namespace, source.Spec.Backend.Service.Name,
+			source.Spec.Backend.Service.Port, joinMetricTypes(source.Spec.Routing.MetricTypes))
+		if options.DryRun {
+			continue
+		}
+		if _, err := sources.Create(ctx, source, metav1.CreateOptions{}); err != nil {
+			if errors.IsAlreadyExists(err) {
+				return fmt.Errorf("custom metrics source %s already exists for another service, create it with another name and migrate again", source.Name)
+			}
+			return fmt.Errorf("failed to create custom metrics source %s: %v", source.Name, err)
+		}
+	}
+	for i := range repointed {
+		apiService := &repointed[i]
+		fmt.Fprintf(options.Out, "Pointing APIService %s from service %s/%s at the router %s/%s\n", apiService.Name,
+			apiService.Spec.Service.Namespace, apiService.Spec.Service.Name, options.Router.Namespace, options.Router.Name)
+		if options.DryRun {
+			continue
+		}
+		router := options.Router
+		apiService.Spec.Service = &router
+		apiService.Spec.CABundle = options.RouterCABundle
+		apiService.Spec.InsecureSkipTLSVerify = len(options.RouterCABundle) == 0
+		if _, err := apiServices.Update(ctx, apiService, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to update APIService %s: %v", apiService.Name, err)
+		}
+	}
+	return nil
+}
+
+// sourceForAPIService returns a source with the service and the TLS settings
+// of an APIService. The source is named after the service.
+func sourceForAPIService(apiService *apiregistrationv1.APIService) *v1beta1.CustomMetricsSource {
+	service := apiService.Spec.Service
+	port := int32(defaultPort)
+	if service.Port != nil {
+		port = *service.Port
+	}
+	return &v1beta1.CustomMetricsSource{
+		ObjectMeta: metav1.ObjectMeta{Name: service.Name},
+		Spec: v1beta1.CustomMetricsSourceSpec{
+			Backend: v1beta1.Backend{
+				Service: v1beta1.ServiceReference{Namespace: service.Namespace, Name: service.Name, Port: port},
+				TLS: v1beta1.TLSConfig{
+					InsecureSkipVerify: apiService.Spec.InsecureSkipTLSVerify,
+					CABundle:           apiService.Spec.CABundle,
+				},
+			},
+		},
+	}
+}
+
+func isRouter(service *apiregistrationv1.ServiceReference, router apiregistrationv1.ServiceReference) bool {
+	return service.Namespace == router.Namespace && service.Name == router.Name
+}
+
+func hasMetricType(source *v1beta1.CustomMetricsSource, metricType v1beta1.MetricType) bool {
+	for _, t := range source.Spec.Routing.MetricTypes {
+		if t == metricType {
+			return true
+		}
+	}
+	return false
+}
+
+func joinMetricTypes(metricTypes []v1beta1.MetricType) string {
+	names := make([]string, 0, len(metricTypes))
+	for _, metricType := range metricTypes {
+		names = append(names, string(metricType))
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,88 @@
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apiregistrationv1 "k8s.io/kube-aggregator/pkg/apis/apiregistration/v1"
+	aggregatorfake "k8s.io/kube-aggregator/pkg/client/clientset_generated/clientset/fake"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+	"github.com/arjunrn/custom-metrics-router/pkg/client/clientset/versioned/fake"
+)
+
+func testAPIService(group, namespace, name string, port *int32) *apiregistrationv1.APIService {
+	return &apiregistrationv1.APIService{
+		ObjectMeta: metav1.ObjectMeta{Name: "v1beta1." + group},
+		Spec: apiregistrationv1.APIServiceSpec{
+			Group:                 group,
+			Version:               "v1beta1",
+			Service:               &apiregistrationv1.ServiceReference{Namespace: namespace, Name: name, Port: port},
+			InsecureSkipTLSVerify: true,
+		},
+	}
+}
+
+var router = apiregistrationv1.ServiceReference{Namespace: "custom-metrics", Name: "custom-metrics-router"}
+
+func TestMigrate(t *testing.T) {
+	port := int32(6443)
+	aggregatorClient := aggregatorfake.NewSimpleClientset(
+		testAPIService("custom.metrics.k8s.io", "monitoring", "prometheus-adapter", &port),
+		testAPIService("external.metrics.k8s.io", "monitoring", "prometheus-adapter", &port),
+		testAPIService("metrics.k8s.io", "kube-system", "metrics-server", nil),
+	)
+	client := fake.NewSimpleClientset()
+	apiServices := aggregatorClient.ApiregistrationV1().APIServices()
+	sources := client.MetricsrouterV1beta1().CustomMetricsSources()
+
+	out := &bytes.Buffer{}
+	require.NoError(t, Migrate(context.Background(), apiServices, sources, Options{Router: router, DryRun: true, Out: out}))
+	require.Contains(t, out.String(), "Creating custom metrics source prometheus-adapter for service monitoring/prometheus-adapter:6443 with metric types CustomMetrics,ExternalMetrics")
+	require.Contains(t, out.String(), "Pointing APIService v1beta1.custom.metrics.k8s.io from service monitoring/prometheus-adapter")
+	sourceList, err := sources.List(context.Background(), metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Empty(t, sourceList.Items)
+
+	require.NoError(t, Migrate(context.Background(), apiServices, sources, Options{Router: router, Out: &bytes.Buffer{}}))
+	source, err := sources.Get(context.Background(), "prometheus-adapter", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, v1beta1.ServiceReference{Namespace: "monitoring", Name: "prometheus-adapter", Port: 6443}, source.Spec.Backend.Service)
+	require.True(t, source.Spec.Backend.TLS.InsecureSkipVerify)
+	require.Equal(t, []v1beta1.MetricType{v1beta1.CustomMetricsType, v1beta1.ExternalMetricsType}, source.Spec.Routing.MetricTypes)
+	for _, name := range []string{"v1beta1.custom.metrics.k8s.io", "v1beta1.external.metrics.k8s.io"} {
+		apiService, err := apiServices.Get(context.Background(), name, metav1.GetOptions{})
+		require.NoError(t, err)
+		require.Equal(t, router, *apiService.Spec.Service)
+	}
+	apiService, err := apiServices.Get(context.Background(), "v1beta1.metrics.k8s.io", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "metrics-server", apiService.Spec.Service.Name)
+
+	// a second migration has nothing left to do.
+	out.Reset()
+	require.NoError(t, Migrate(context.Background(), apiServices, sources, Options{Router: router, Out: out}))
+	require.Equal(t, "No metrics APIServices to migrate\n", out.String())
+}
+
+func TestMigrateExistingSource(t *testing.T) {
+	aggregatorClient := aggregatorfake.NewSimpleClientset(testAPIService("external.metrics.k8s.io", "keda", "keda-metrics-apiserver", nil))
+	existing := &v1beta1.CustomMetricsSource{ObjectMeta: metav1.ObjectMeta{Name: "keda"}}
+	existing.Spec.Backend.Service = v1beta1.ServiceReference{Namespace: "keda", Name: "keda-metrics-apiserver", Port: 443}
+	client := fake.NewSimpleClientset(existing)
+	apiServices := aggregatorClient.ApiregistrationV1().APIServices()
+	sources := client.MetricsrouterV1beta1().CustomMetricsSources()
+
+	out := &bytes.Buffer{}
+	require.NoError(t, Migrate(context.Background(), apiServices, sources, Options{Router: router, Out: out}))
+	require.Contains(t, out.String(), "already used by custom metrics source keda")
+	sourceList, err := sources.List(context.Background(), metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, sourceList.Items, 1)
+	apiService, err := apiServices.Get(context.Background(), "v1beta1.external.metrics.k8s.io", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, router, *apiService.Spec.Service)
+}
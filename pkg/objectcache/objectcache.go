@@ -0,0 +1,124 @@
+package objectcache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+)
+
+const (
+	// DefaultIdleTimeout is how long the informer of a resource keeps running
+	// by default after it was last read.
+	DefaultIdleTimeout = 10 * time.Minute
+	// syncTimeout is how long a request waits for the cache of a resource
+	// which is read for the first time.
+	syncTimeout = 10 * time.Second
+	// listTimeout is how long the list which checks the access of the router
+	// to a resource may take.
+	listTimeout = 5 * time.Second
+	// sweepInterval is how often idle informers are stopped.
+	sweepInterval = time.Minute
+)
+
+type entry struct {
+	informer informers.GenericInformer
+	stopCh   chan struct{}
+	lastUsed time.Time
+}
+
+// Cache keeps an informer for every resource read by the object field
+// backends. It outlives the backends, which are created again on every resync
+// of their source, and stops the informers of resources no backend has read
+// for the idle timeout, e.g. after their source was deleted.
+type Cache struct {
+	client      dynamic.Interface
+	idleTimeout time.Duration
+	lock        sync.Mutex
+	informers   map[schema.GroupVersionResource]*entry
+	now         func() time.Time
+}
+
+// New returns a cache of the objects which client can read.
+func New(client dynamic.Interface, idleTimeout time.Duration) *Cache {
+	return &Cache{
+		client:      client,
+		idleTimeout: idleTimeout,
+		informers:   make(map[schema.GroupVersionResource]*entry),
+		now:         time.Now,
+	}
+}
+
+// Informer returns the synced informer of a resource. The informer is started
+// by the first request for the resource. The resource is listed once before,
+// so that a request fails right away when the router may not read it instead
+// of waiting for an informer which retries forever.
+func (c *Cache) Informer(resource schema.GroupVersionResource) (informers.GenericInformer, error) {
+	c.lock.Lock()
+	_, ok := c.informers[resource]
+	c.lock.Unlock()
+	if !ok {
+		ctx, cancel := context.WithTimeout(context.Background(), listTimeout)
+		_, err := c.client.Resource(resource).List(ctx, metav1.ListOptions{Limit: 1})
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %v", resource, err)
+		}
+	}
+
+	c.lock.Lock()
+	e, ok := c.informers[resource]
+	if !ok {
+		e = &entry{
+			informer: dynamicinformer.NewFilteredDynamicInformer(c.client, resource, metav1.NamespaceAll, 0,
+				cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, nil),
+			stopCh: make(chan struct{}),
+		}
+		c.informers[resource] = e
+		go e.informer.Informer().Run(e.stopCh)
+		klog.V(2).Infof("Started the informer of %s", resource)
+	}
+	e.lastUsed = c.now()
+	c.lock.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), syncTimeout)
+	defer cancel()
+	if !cache.WaitForCacheSync(ctx.Done(), e.informer.Informer().HasSynced) {
+		return nil, fmt.Errorf("the cache of %s isn't synced", resource)
+	}
+	return e.informer, nil
+}
+
+// Run stops idle informers until stopCh is closed, then stops all of them.
+func (c *Cache) Run(stopCh <-chan struct{}) {
+	wait.Until(c.stopIdle, sweepInterval, stopCh)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for resource, e := range c.informers {
+		close(e.stopCh)
+		delete(c.informers, resource)
+	}
+}
+
+// stopIdle stops the informers which weren't read for the idle timeout.
+func (c *Cache) stopIdle() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	cutoff := c.now().Add(-c.idleTimeout)
+	for resource, e := range c.informers {
+		if e.lastUsed.Before(cutoff) {
+			close(e.stopCh)
+			delete(c.informers, resource)
+			klog.V(2).Infof("Stopped the informer of %s which wasn't read since %s", resource, e.lastUsed)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package objectcache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var (
+	queues  = schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "queues"}
+	secrets = schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+)
+
+func TestCache(t *testing.T) {
+	queue := &unstructured.Unstructured{}
+	queue.SetAPIVersion("example.com/v1")
+	queue.SetKind("Queue")
+	queue.SetNamespace("shop")
+	queue.SetName("orders")
+	client := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), queue)
+	client.PrependReactor("list", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(secrets.GroupResource(), "", nil)
+	})
+	now := time.Now()
+	c := New(client, time.Minute)
+	c.now = func() time.Time { return now }
+
+	informer, err := c.Informer(queues)
+	require.NoError(t, err)
+	objects, err := informer.Lister().ByNamespace("shop").List(labels.Everything())
+	require.NoError(t, err)
+	require.Len(t, objects, 1)
+
+	// the informer of a resource the router may not read isn't started.
+	start := time.Now()
+	_, err = c.Informer(secrets)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "forbidden")
+	require.True(t, time.Since(start) < syncTimeout)
+	require.NotContains(t, c.informers, secrets)
+
+	// informers which were read recently are kept.
+	now = now.Add(30 * time.Second)
+	c.stopIdle()
+	require.Contains(t, c.informers, queues)
+
+	now = now.Add(2 * time.Minute)
+	c.stopIdle()
+	require.Empty(t, c.informers)
+}
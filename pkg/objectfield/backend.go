@@ -0,0 +1,31 @@
+package objectfield
+
+import (
+	"fmt"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+	"github.com/arjunrn/custom-metrics-router/pkg/backend"
+)
+
+var _ backend.Backend = &Client{}
+
+func init() {
+	backend.Register(v1beta1.ObjectFieldBackendType, newBackend)
+}
+
+// newBackend returns a client which reads the objects from the object cache of
+// the router.
+func newBackend(source *v1beta1.CustomMetricsSource, deps backend.Dependencies) (backend.Backend, error) {
+	spec := &source.Spec.Backend
+	if spec.ObjectField == nil {
+		return nil, fmt.Errorf("custom metrics source %s of type %s has no object field backend", source.Name, v1beta1.ObjectFieldBackendType)
+	}
+	if deps.Objects == nil || deps.Mapper == nil {
+		return nil, fmt.Errorf("custom metrics source %s of type %s requires an object cache and a mapper", source.Name, v1beta1.ObjectFieldBackendType)
+	}
+	client, err := NewClient(source.Name, deps.Objects, deps.Mapper, spec.ObjectField)
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
@@ -0,0 +1,213 @@
+package objectfield
+
+import (
+	"fmt"
+
+	"github.com/kubernetes-sigs/custom-metrics-apiserver/pkg/provider"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+	"k8s.io/metrics/pkg/apis/custom_metrics"
+	"k8s.io/metrics/pkg/apis/external_metrics"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+	"github.com/arjunrn/custom-metrics-router/pkg/httpjson"
+	"github.com/arjunrn/custom-metrics-router/pkg/objectcache"
+)
+
+type customMetric struct {
+	v1beta1.ObjectFieldMetric
+	groupResource schema.GroupResource
+	field         httpjson.Extractor
+}
+
+// Client serves custom metrics from fields of the objects in an object cache.
+type Client struct {
+	source        string
+	objects       *objectcache.Cache
+	mapper        meta.RESTMapper
+	customMetrics []customMetric
+}
+
+// NewClient returns a client which resolves the resources of the metrics with
+// mapper.
+func NewClient(source string, objects *objectcache.Cache, mapper meta.RESTMapper, backend *v1beta1.ObjectFieldBackend) (*Client, error) {
+	client := &Client{
+		source:  source,
+		objects: objects,
+		mapper:  mapper,
+	}
+	for _, metric := range backend.Metrics {
+		field, err := httpjson.ParseExtractor(metric.Field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid field of custom metric %s: %v", metric.Name, err)
+		}
+		client.customMetrics = append(client.customMetrics, customMetric{
+			ObjectFieldMetric: metric,
+			groupResource:     schema.ParseGroupResource(metric.Resource),
+			field:             field,
+		})
+	}
+	return client, nil
+}
+
+// Source returns the name of the source the client was created for.
+func (c *Client) Source() string {
+	return c.source
+}
+
+func (c *Client) ListCustomMetricInfos() (map[provider.CustomMetricInfo]struct{}, error) {
+	infos := make(map[provider.CustomMetricInfo]struct{}, len(c.customMetrics))
+	for _, metric := range c.customMetrics {
+		gvr, err := c.resolve(metric.groupResource)
+		if err != nil {
+			return nil, err
+		}
+		infos[provider.CustomMetricInfo{GroupResource: gvr.GroupResource(), Namespaced: metric.Namespaced, Metric: metric.Name}] = struct{}{}
+	}
+	return infos, nil
+}
+
+// ListExternalMetrics returns no metrics, object field backends only serve
+// custom metrics.
+func (c *Client) ListExternalMetrics() (map[provider.ExternalMetricInfo]struct{}, error) {
+	return map[provider.ExternalMetricInfo]struct{}{}, nil
+}
+
+func (c *Client) GetExternalMetric(name, namespace string, metricSelector labels.Selector) (*external_metrics.ExternalMetricValueList, error) {
+	return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "externalmetrics"}, name)
+}
+
+func (c *Client) GetMetricByName(name types.NamespacedName, info provider.CustomMetricInfo, metricSelector labels.Selector) (*custom_metrics.MetricValue, error) {
+	metric, gvr, err := c.customMetric(info)
+	if err != nil {
+		return nil, err
+	}
+	if !metricSelector.Matches(labels.Set{}) {
+		return nil, apierrors.NewNotFound(info.GroupResource, name.Name)
+	}
+	informer, err := c.objects.Informer(gvr)
+	if err != nil {
+		return nil, err
+	}
+	var object runtime.Object
+	if metric.Namespaced {
+		object, err = informer.Lister().ByNamespace(name.Namespace).Get(name.Name)
+	} else {
+		object, err = informer.Lister().Get(name.Name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	value, err := c.value(metric, gvr, object)
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return nil, apierrors.NewNotFound(info.GroupResource, name.Name)
+	}
+	return value, nil
+}
+
+func (c *Client) GetMetricBySelector(namespace string, selector labels.Selector, info provider.CustomMetricInfo, metricSelector labels.Selector) (*custom_metrics.MetricValueList, error) {
+	metric, gvr, err := c.customMetric(info)
+	if err != nil {
+		return nil, err
+	}
+	list := &custom_metrics.MetricValueList{}
+	if !metricSelector.Matches(labels.Set{}) {
+		return list, nil
+	}
+	informer, err := c.objects.Informer(gvr)
+	if err != nil {
+		return nil, err
+	}
+	var objects []runtime.Object
+	if metric.Namespaced {
+		objects, err = informer.Lister().ByNamespace(namespace).List(selector)
+	} else {
+		objects, err = informer.Lister().List(selector)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, object := range objects {
+		value, err := c.value(metric, gvr, object)
+		if err != nil {
+			return nil, err
+		}
+		if value != nil {
+			list.Items = append(list.Items, *value)
+		}
+	}
+	return list, nil
+}
+
+// value returns the value of the metric of an object, or nil when the object
+// doesn't have the field.
+func (c *Client) value(metric *customMetric, gvr schema.GroupVersionResource, object runtime.Object) (*custom_metrics.MetricValue, error) {
+	u, ok := object.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("unexpected object %T in the cache of %s", object, gvr)
+	}
+	raw, err := metric.field.Extract(u.Object)
+	if err != nil {
+		klog.V(4).Infof("Object %s/%s of %s has no field %s for %s: %v", u.GetNamespace(), u.GetName(), gvr, metric.Field, c.source, err)
+		return nil, nil
+	}
+	quantity, err := resource.ParseQuantity(raw)
+	if err != nil {
+		klog.V(4).Infof("Skipping value %q of object %s/%s of %s for %s: %v", raw, u.GetNamespace(), u.GetName(), gvr, c.source, err)
+		return nil, nil
+	}
+	kind, err := c.mapper.KindFor(gvr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the kind of %s: %v", gvr, err)
+	}
+	return &custom_metrics.MetricValue{
+		DescribedObject: custom_metrics.ObjectReference{
+			Kind:            kind.Kind,
+			APIVersion:      kind.GroupVersion().String(),
+			Namespace:       u.GetNamespace(),
+			Name:            u.GetName(),
+			UID:             u.GetUID(),
+			ResourceVersion: u.GetResourceVersion(),
+		},
+		Metric:    custom_metrics.MetricIdentifier{Name: metric.Name},
+		Timestamp: metav1.Now(),
+		Value:     quantity,
+	}, nil
+}
+
+func (c *Client) customMetric(info provider.CustomMetricInfo) (*customMetric, schema.GroupVersionResource, error) {
+	for i := range c.customMetrics {
+		metric := &c.customMetrics[i]
+		if metric.Name != info.Metric || metric.Namespaced != info.Namespaced {
+			continue
+		}
+		gvr, err := c.resolve(metric.groupResource)
+		if err != nil {
+			return nil, schema.GroupVersionResource{}, err
+		}
+		if gvr.GroupResource() == info.GroupResource {
+			return metric, gvr, nil
+		}
+	}
+	return nil, schema.GroupVersionResource{}, apierrors.NewNotFound(schema.GroupResource{Resource: "custommetrics"}, info.Metric)
+}
+
+// resolve returns the preferred version of a resource.
+func (c *Client) resolve(groupResource schema.GroupResource) (schema.GroupVersionResource, error) {
+	gvr, err := c.mapper.ResourceFor(groupResource.WithVersion(""))
+	if err != nil {
+		return schema.GroupVersionResource{}, fmt.Errorf("failed to resolve resource %s: %v", groupResource, err)
+	}
+	return gvr, nil
+}
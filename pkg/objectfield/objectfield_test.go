@@ -0,0 +1,135 @@
+package objectfield
+
+import (
+	"testing"
+
+	"github.com/kubernetes-sigs/custom-metrics-apiserver/pkg/provider"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+	"github.com/arjunrn/custom-metrics-router/pkg/objectcache"
+)
+
+var (
+	queues        = schema.GroupResource{Group: "example.com", Resource: "queues"}
+	clusterQueues = schema.GroupResource{Group: "example.com", Resource: "clusterqueues"}
+)
+
+func testObject(kind, namespace, name string, labels map[string]string, status map[string]interface{}) *unstructured.Unstructured {
+	object := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	object.SetAPIVersion("example.com/v1")
+	object.SetKind(kind)
+	object.SetNamespace(namespace)
+	object.SetName(name)
+	object.SetLabels(labels)
+	if status != nil {
+		object.Object["status"] = status
+	}
+	return object
+}
+
+func TestClient(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{{Group: "example.com", Version: "v1"}})
+	mapper.Add(schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Queue"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "ClusterQueue"}, meta.RESTScopeRoot)
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(),
+		testObject("Queue", "shop", "orders", map[string]string{"app": "shop"}, map[string]interface{}{"backlog": int64(42)}),
+		testObject("Queue", "shop", "billing", map[string]string{"app": "shop"}, map[string]interface{}{"backlog": "1500m"}),
+		testObject("Queue", "shop", "new", map[string]string{"app": "shop"}, nil),
+		testObject("Queue", "shop", "returns", map[string]string{"app": "returns"}, map[string]interface{}{"backlog": int64(3)}),
+		testObject("Queue", "other", "orders", map[string]string{"app": "shop"}, map[string]interface{}{"backlog": int64(5)}),
+		testObject("ClusterQueue", "", "shared", nil, map[string]interface{}{"backlog": 2.5}),
+	)
+	client, err := NewClient("queues", objectcache.New(dynamicClient, objectcache.DefaultIdleTimeout), mapper, &v1beta1.ObjectFieldBackend{Metrics: []v1beta1.ObjectFieldMetric{
+		{Name: "backlog", Resource: "queues.example.com", Namespaced: true, Field: "{.status.backlog}"},
+		{Name: "backlog", Resource: "clusterqueues.example.com", Field: "{{.status.backlog}}"},
+	}})
+	require.NoError(t, err)
+
+	infos, err := client.ListCustomMetricInfos()
+	require.NoError(t, err)
+	require.Equal(t, map[provider.CustomMetricInfo]struct{}{
+		{GroupResource: queues, Namespaced: true, Metric: "backlog"}:         {},
+		{GroupResource: clusterQueues, Namespaced: false, Metric: "backlog"}: {},
+	}, infos)
+
+	for _, tc := range []struct {
+		name           string
+		info           provider.CustomMetricInfo
+		namespace      string
+		selector       labels.Selector
+		metricSelector labels.Selector
+		expected       map[string]int64
+		expectedError  func(error) bool
+	}{
+		{
+			name:           "namespaced objects",
+			info:           provider.CustomMetricInfo{GroupResource: queues, Namespaced: true, Metric: "backlog"},
+			namespace:      "shop",
+			selector:       labels.SelectorFromSet(labels.Set{"app": "shop"}),
+			metricSelector: labels.Everything(),
+			expected:       map[string]int64{"orders": 42000, "billing": 1500},
+		},
+		{
+			name:           "cluster scoped objects",
+			info:           provider.CustomMetricInfo{GroupResource: clusterQueues, Metric: "backlog"},
+			selector:       labels.Everything(),
+			metricSelector: labels.Everything(),
+			expected:       map[string]int64{"shared": 2500},
+		},
+		{
+			name:           "metric selector",
+			info:           provider.CustomMetricInfo{GroupResource: queues, Namespaced: true, Metric: "backlog"},
+			namespace:      "shop",
+			selector:       labels.Everything(),
+			metricSelector: labels.SelectorFromSet(labels.Set{"queue": "orders"}),
+			expected:       map[string]int64{},
+		},
+		{
+			name:           "unknown metric",
+			info:           provider.CustomMetricInfo{GroupResource: queues, Namespaced: true, Metric: "length"},
+			namespace:      "shop",
+			selector:       labels.Everything(),
+			metricSelector: labels.Everything(),
+			expectedError:  apierrors.IsNotFound,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			list, err := client.GetMetricBySelector(tc.namespace, tc.selector, tc.info, tc.metricSelector)
+			if tc.expectedError != nil {
+				require.True(t, tc.expectedError(err), "%v", err)
+				return
+			}
+			require.NoError(t, err)
+			values := make(map[string]int64)
+			for _, item := range list.Items {
+				require.Equal(t, "backlog", item.Metric.Name)
+				require.Equal(t, "example.com/v1", item.DescribedObject.APIVersion)
+				require.Equal(t, tc.namespace, item.DescribedObject.Namespace)
+				values[item.DescribedObject.Name] = item.Value.MilliValue()
+			}
+			require.Equal(t, tc.expected, values)
+		})
+	}
+
+	t.Run("by name", func(t *testing.T) {
+		info := provider.CustomMetricInfo{GroupResource: queues, Namespaced: true, Metric: "backlog"}
+		value, err := client.GetMetricByName(types.NamespacedName{Namespace: "other", Name: "orders"}, info, labels.Everything())
+		require.NoError(t, err)
+		require.Equal(t, "Queue", value.DescribedObject.Kind)
+		require.Equal(t, int64(5), value.Value.Value())
+
+		_, err = client.GetMetricByName(types.NamespacedName{Namespace: "shop", Name: "new"}, info, labels.Everything())
+		require.True(t, apierrors.IsNotFound(err), "%v", err)
+		_, err = client.GetMetricByName(types.NamespacedName{Namespace: "shop", Name: "missing"}, info, labels.Everything())
+		require.True(t, apierrors.IsNotFound(err), "%v", err)
+	})
+}
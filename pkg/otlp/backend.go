@@ -0,0 +1,88 @@
+package otlp
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+	"github.com/arjunrn/custom-metrics-router/pkg/backend"
+	"github.com/arjunrn/custom-metrics-router/pkg/prometheus"
+	"github.com/arjunrn/custom-metrics-router/pkg/remotewrite"
+)
+
+const (
+	// DefaultNamespaceAttribute is the attribute with the namespace of the
+	// objects of custom metrics.
+	DefaultNamespaceAttribute = "k8s.namespace.name"
+	// objectAttributeFormat is formatted with the singular name of a resource
+	// to the attribute with the names of its objects, e.g. k8s.pod.name.
+	objectAttributeFormat = "k8s.%s.name"
+)
+
+func init() {
+	backend.Register(v1beta1.OTLPBackendType, newBackend)
+}
+
+// newBackend returns a client which reads the data points which the OTLP
+// receiver of the router received.
+func newBackend(source *v1beta1.CustomMetricsSource, deps backend.Dependencies) (backend.Backend, error) {
+	spec := &source.Spec.Backend
+	if spec.OTLP == nil {
+		return nil, fmt.Errorf("custom metrics source %s of type %s has no OTLP backend", source.Name, v1beta1.OTLPBackendType)
+	}
+	if deps.Mapper == nil {
+		return nil, fmt.Errorf("custom metrics source %s of type %s requires a mapper", source.Name, v1beta1.OTLPBackendType)
+	}
+	if deps.OTLPSeries == nil {
+		return nil, fmt.Errorf("custom metrics source %s of type %s requires the OTLP receiver", source.Name, v1beta1.OTLPBackendType)
+	}
+	var objects prometheus.ObjectLister
+	if deps.KubeClient != nil {
+		objects = prometheus.NewObjectLister(deps.KubeClient.Discovery().RESTClient())
+	}
+	customMetrics, externalMetrics := Metrics(spec.OTLP)
+	return remotewrite.NewClient(source.Name, deps.OTLPSeries, deps.Mapper, objects, customMetrics, externalMetrics), nil
+}
+
+// Metrics returns the metrics of an OTLP backend. Metrics without a mapping of
+// their labels have no labels, because the resource attributes would make the
+// labels of every metric differ.
+func Metrics(backend *v1beta1.OTLPBackend) ([]remotewrite.CustomMetric, []remotewrite.ExternalMetric) {
+	customMetrics := make([]remotewrite.CustomMetric, 0, len(backend.CustomMetrics))
+	for _, metric := range backend.CustomMetrics {
+		namespaceAttribute := metric.NamespaceAttribute
+		if namespaceAttribute == "" {
+			namespaceAttribute = DefaultNamespaceAttribute
+		}
+		customMetrics = append(customMetrics, remotewrite.CustomMetric{
+			Name:              metric.Name,
+			Series:            metric.Metric,
+			MatchLabels:       metric.MatchAttributes,
+			Resource:          schema.ParseGroupResource(metric.Resource),
+			Namespaced:        metric.Namespaced,
+			NamespaceLabel:    namespaceAttribute,
+			ObjectLabel:       metric.ObjectAttribute,
+			ObjectLabelFormat: objectAttributeFormat,
+			Labels:            labelMapping(metric.Labels),
+		})
+	}
+	externalMetrics := make([]remotewrite.ExternalMetric, 0, len(backend.ExternalMetrics))
+	for _, metric := range backend.ExternalMetrics {
+		externalMetrics = append(externalMetrics, remotewrite.ExternalMetric{
+			Name:           metric.Name,
+			Series:         metric.Metric,
+			MatchLabels:    metric.MatchAttributes,
+			NamespaceLabel: metric.NamespaceAttribute,
+			Labels:         labelMapping(metric.Labels),
+		})
+	}
+	return customMetrics, externalMetrics
+}
+
+func labelMapping(mapping map[string]string) map[string]string {
+	if mapping == nil {
+		return map[string]string{}
+	}
+	return mapping
+}
@@ -0,0 +1,73 @@
+package otlp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kubernetes-sigs/custom-metrics-apiserver/pkg/provider"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+	"github.com/arjunrn/custom-metrics-router/pkg/otlp/metricspb"
+	"github.com/arjunrn/custom-metrics-router/pkg/remotewrite"
+	"github.com/arjunrn/custom-metrics-router/pkg/series"
+)
+
+func TestMetrics(t *testing.T) {
+	now := time.Now()
+	store := series.NewStore(DefaultMaxSeries, DefaultMaxAge)
+	gauge := func(name string, dataPoints ...*metricspb.NumberDataPoint) *metricspb.Metric {
+		return &metricspb.Metric{Name: name, Data: &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{DataPoints: dataPoints}}}
+	}
+	Append(store, exportRequest(keyValues("k8s.namespace.name", "shop", "k8s.pod.name", "worker-1", "host.name", "node-1"),
+		gauge("worker.jobs", dataPoint(3, now, "job.state", "pending"), dataPoint(2, now, "job.state", "running")),
+	), "")
+	Append(store, exportRequest(keyValues("k8s.namespace.name", "shop", "k8s.pod.name", "worker-2", "host.name", "node-2"),
+		gauge("worker.jobs", dataPoint(5, now, "job.state", "pending")),
+	), "")
+	Append(store, exportRequest(keyValues("service.name", "broker"),
+		gauge("messaging.queue.messages",
+			dataPoint(12, now, "messaging.destination.name", "orders"),
+			dataPoint(4, now, "messaging.destination.name", "billing"),
+		),
+	), "")
+
+	customMetrics, externalMetrics := Metrics(&v1beta1.OTLPBackend{
+		CustomMetrics: []v1beta1.OTLPCustomMetric{
+			{Name: "jobs", Metric: "worker.jobs", Resource: "pods", Namespaced: true, Labels: map[string]string{"state": "job.state"}},
+		},
+		ExternalMetrics: []v1beta1.OTLPExternalMetric{
+			{Name: "queue_messages", Metric: "messaging.queue.messages", Labels: map[string]string{"queue": "messaging.destination.name"}},
+			{Name: "messages", Metric: "messaging.queue.messages"},
+		},
+	})
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{{Version: "v1"}})
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Pod"}, meta.RESTScopeNamespace)
+	client := remotewrite.NewClient("collector", store, mapper, nil, customMetrics, externalMetrics)
+
+	info := provider.CustomMetricInfo{GroupResource: schema.GroupResource{Resource: "pods"}, Namespaced: true, Metric: "jobs"}
+	value, err := client.GetMetricByName(types.NamespacedName{Namespace: "shop", Name: "worker-1"}, info, labels.Everything())
+	require.NoError(t, err)
+	require.Equal(t, int64(5), value.Value.Value())
+	value, err = client.GetMetricByName(types.NamespacedName{Namespace: "shop", Name: "worker-1"}, info, labels.SelectorFromSet(labels.Set{"state": "running"}))
+	require.NoError(t, err)
+	require.Equal(t, int64(2), value.Value.Value())
+	list, err := client.GetMetricBySelector("shop", labels.Everything(), info, labels.Everything())
+	require.NoError(t, err)
+	require.Len(t, list.Items, 2)
+
+	external, err := client.GetExternalMetric("queue_messages", "shop", labels.SelectorFromSet(labels.Set{"queue": "orders"}))
+	require.NoError(t, err)
+	require.Len(t, external.Items, 1)
+	require.Equal(t, map[string]string{"queue": "orders"}, external.Items[0].MetricLabels)
+	require.Equal(t, int64(12), external.Items[0].Value.Value())
+	// metrics without a mapping of their labels have none.
+	external, err = client.GetExternalMetric("messages", "shop", labels.Everything())
+	require.NoError(t, err)
+	require.Len(t, external.Items, 2)
+	require.Empty(t, external.Items[0].MetricLabels)
+}
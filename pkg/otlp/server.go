@@ -0,0 +1,304 @@
+package otlp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/klog"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/authorization"
+	"github.com/arjunrn/custom-metrics-router/pkg/otlp/metricspb"
+	"github.com/arjunrn/custom-metrics-router/pkg/series"
+)
+
+const (
+	// MetricsPath is the path OTLP/HTTP metrics requests are received at.
+	MetricsPath = "/v1/metrics"
+	// SourceName is the virtual resource in the metrics.metricsrouter.io
+	// group which senders must be allowed to create.
+	SourceName = "otlp"
+	// maxRequestBytes limits the compressed and the decompressed size of a
+	// request.
+	maxRequestBytes = 10 * 1024 * 1024
+	// DefaultMaxSeries is how many series the receiver keeps by default.
+	DefaultMaxSeries = 100000
+	// DefaultMaxAge is how long a series is kept by default after its latest
+	// data point.
+	DefaultMaxAge = 5 * time.Minute
+	// flagNoRecordedValue in the flags of a data point marks the end of a
+	// series.
+	flagNoRecordedValue = 1
+
+	protobufContentType = "application/x-protobuf"
+	jsonContentType     = "application/json"
+)
+
+// Server receives OTLP/HTTP metrics requests in the binary protobuf or the
+// JSON encoding from clients which authenticate with a bearer token of the
+// Kubernetes API server. The user of the token must be allowed to create otlp
+// in the metrics.metricsrouter.io group, either in all namespaces or in the
+// namespace of every data point, which is its k8s.namespace.name attribute.
+// The series of a user who is only allowed in some namespaces are only served
+// in their namespace.
+type Server struct {
+	store         *series.Store
+	authenticator authorization.Authenticator
+	authorizer    authorization.Authorizer
+	mux           *http.ServeMux
+}
+
+func NewServer(store *series.Store, authenticator authorization.Authenticator, authorizer authorization.Authorizer) *Server {
+	s := &Server{store: store, authenticator: authenticator, authorizer: authorizer, mux: http.NewServeMux()}
+	s.mux.HandleFunc(MetricsPath, s.export)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Run receives OTLP requests with TLS on address until stopCh is closed.
+func (s *Server) Run(address, certFile, keyFile string, stopCh <-chan struct{}) error {
+	server := &http.Server{Addr: address, Handler: s}
+	go func() {
+		<-stopCh
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			utilruntime.HandleError(fmt.Errorf("failed to shut down OTLP server: %v", err))
+		}
+	}()
+	klog.Infof("Receiving OTLP metrics requests on %s", address)
+	if err := server.ListenAndServeTLS(certFile, keyFile); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) export(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	contentType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || (contentType != protobufContentType && contentType != jsonContentType) {
+		http.Error(w, fmt.Sprintf("content type must be %s or %s", protobufContentType, jsonContentType), http.StatusUnsupportedMediaType)
+		return
+	}
+	requester, err := s.authenticator.AuthenticateRequest(r)
+	if err != nil {
+		klog.Errorf("Failed to authenticate OTLP request: %v", err)
+		http.Error(w, "authentication failed", http.StatusInternalServerError)
+		return
+	}
+	if requester == nil {
+		http.Error(w, "a valid bearer token is required", http.StatusUnauthorized)
+		return
+	}
+
+	data, err := readBody(w, r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to read request: %v", err), http.StatusBadRequest)
+		return
+	}
+	request := &metricspb.ExportMetricsServiceRequest{}
+	if contentType == jsonContentType {
+		unmarshaler := jsonpb.Unmarshaler{AllowUnknownFields: true}
+		err = unmarshaler.Unmarshal(bytes.NewReader(data), request)
+	} else {
+		err = proto.Unmarshal(data, request)
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to decode export request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	namespaceAttribute, status, msg := s.authorize(requester, request)
+	if status != http.StatusOK {
+		http.Error(w, msg, status)
+		return
+	}
+	response := &metricspb.ExportMetricsServiceResponse{}
+	if dropped := Append(s.store, request, namespaceAttribute); dropped > 0 {
+		klog.V(2).Infof("Dropped %d data points of new series of %s because the OTLP store is full", dropped, requester.GetName())
+		response.PartialSuccess = &metricspb.ExportMetricsPartialSuccess{
+			RejectedDataPoints: int64(dropped),
+			ErrorMessage:       "the store of the router is full",
+		}
+	}
+	var body []byte
+	if contentType == jsonContentType {
+		var buffer bytes.Buffer
+		err = (&jsonpb.Marshaler{}).Marshal(&buffer, response)
+		body = buffer.Bytes()
+	} else {
+		body, err = proto.Marshal(response)
+	}
+	if err != nil {
+		klog.Errorf("Failed to encode OTLP response: %v", err)
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	if _, err := w.Write(body); err != nil {
+		klog.V(2).Infof("Failed to write OTLP response: %v", err)
+	}
+}
+
+// authorize checks that the requester may export the data points of a
+// request. It returns the attribute with the namespaces the requester was
+// authorized for, which is empty when it may export in all namespaces, or the
+// status and message of the error.
+func (s *Server) authorize(requester user.Info, request *metricspb.ExportMetricsServiceRequest) (string, int, string) {
+	allowed, reason, err := s.authorizer.Authorize(authorization.Attributes{User: requester, Verb: "create", Source: SourceName})
+	if err != nil {
+		klog.Errorf("Failed to authorize OTLP request: %v", err)
+		return "", http.StatusInternalServerError, "authorization failed"
+	}
+	if allowed {
+		return "", http.StatusOK, ""
+	}
+	namespaces := make(map[string]struct{})
+	forEachDataPoint(request, func(labels map[string]string, _ *metricspb.NumberDataPoint) {
+		namespaces[labels[DefaultNamespaceAttribute]] = struct{}{}
+	})
+	if _, ok := namespaces[""]; ok {
+		return "", http.StatusForbidden, fmt.Sprintf("user %q may not send data points without the attribute %s: %s", requester.GetName(), DefaultNamespaceAttribute, reason)
+	}
+	for namespace := range namespaces {
+		allowed, reason, err := s.authorizer.Authorize(authorization.Attributes{User: requester, Verb: "create", Namespace: namespace, Source: SourceName})
+		if err != nil {
+			klog.Errorf("Failed to authorize OTLP request for namespace %s: %v", namespace, err)
+			return "", http.StatusInternalServerError, "authorization failed"
+		}
+		if !allowed {
+			return "", http.StatusForbidden, fmt.Sprintf("user %q may not send data points of namespace %s: %s", requester.GetName(), namespace, reason)
+		}
+	}
+	return DefaultNamespaceAttribute, http.StatusOK, ""
+}
+
+// readBody returns the body of a request, which may be gzip compressed.
+func readBody(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	var body io.Reader = http.MaxBytesReader(w, r.Body, maxRequestBytes)
+	switch encoding := r.Header.Get("Content-Encoding"); encoding {
+	case "", "identity":
+	case "gzip":
+		reader, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+		body = reader
+	default:
+		return nil, fmt.Errorf("unsupported content encoding %s", encoding)
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(body, maxRequestBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxRequestBytes {
+		return nil, fmt.Errorf("request is larger than 10MiB")
+	}
+	return data, nil
+}
+
+// Append stores the gauge and cumulative sum data points of an export request
+// as series named by the metric with the attributes of the data point and its
+// resource as labels. The namespace of a series is the value of its
+// namespaceAttribute, which is empty when the sender was authorized for all
+// namespaces. It returns the number of data points which were dropped because
+// the store is full.
+func Append(store *series.Store, request *metricspb.ExportMetricsServiceRequest, namespaceAttribute string) int {
+	now := time.Now()
+	dropped := 0
+	forEachDataPoint(request, func(labels map[string]string, dataPoint *metricspb.NumberDataPoint) {
+		timestamp := now
+		if dataPoint.TimeUnixNano != 0 {
+			timestamp = time.Unix(0, int64(dataPoint.TimeUnixNano))
+		}
+		if dataPoint.Flags&flagNoRecordedValue != 0 {
+			store.Remove(labels, timestamp)
+			return
+		}
+		var value float64
+		switch v := dataPoint.Value.(type) {
+		case *metricspb.NumberDataPoint_AsDouble:
+			value = v.AsDouble
+		case *metricspb.NumberDataPoint_AsInt:
+			value = float64(v.AsInt)
+		default:
+			return
+		}
+		namespace := ""
+		if namespaceAttribute != "" {
+			namespace = labels[namespaceAttribute]
+		}
+		if !store.Set(namespace, labels, value, timestamp) {
+			dropped++
+		}
+	})
+	return dropped
+}
+
+// forEachDataPoint calls f with the labels of every gauge and cumulative sum
+// data point of an export request.
+func forEachDataPoint(request *metricspb.ExportMetricsServiceRequest, f func(labels map[string]string, dataPoint *metricspb.NumberDataPoint)) {
+	for _, resourceMetrics := range request.ResourceMetrics {
+		resourceAttributes := attributes(nil, resourceMetrics.GetResource().GetAttributes())
+		for _, scopeMetrics := range resourceMetrics.ScopeMetrics {
+			for _, metric := range scopeMetrics.Metrics {
+				var dataPoints []*metricspb.NumberDataPoint
+				switch data := metric.Data.(type) {
+				case *metricspb.Metric_Gauge:
+					dataPoints = data.Gauge.GetDataPoints()
+				case *metricspb.Metric_Sum:
+					// the values of delta sums can't be used without
+					// accumulating them.
+					if data.Sum.GetAggregationTemporality() == metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA {
+						continue
+					}
+					dataPoints = data.Sum.GetDataPoints()
+				}
+				for _, dataPoint := range dataPoints {
+					labels := attributes(resourceAttributes, dataPoint.Attributes)
+					labels[series.MetricNameLabel] = metric.Name
+					f(labels, dataPoint)
+				}
+			}
+		}
+	}
+}
+
+// attributes returns a copy of base with the given attributes as strings.
+func attributes(base map[string]string, pairs []*metricspb.KeyValue) map[string]string {
+	attributes := make(map[string]string, len(base)+len(pairs)+1)
+	for key, value := range base {
+		attributes[key] = value
+	}
+	for _, pair := range pairs {
+		switch value := pair.GetValue().GetValue().(type) {
+		case *metricspb.AnyValue_StringValue:
+			attributes[pair.Key] = value.StringValue
+		case *metricspb.AnyValue_BoolValue:
+			attributes[pair.Key] = strconv.FormatBool(value.BoolValue)
+		case *metricspb.AnyValue_IntValue:
+			attributes[pair.Key] = strconv.FormatInt(value.IntValue, 10)
+		case *metricspb.AnyValue_DoubleValue:
+			attributes[pair.Key] = strconv.FormatFloat(value.DoubleValue, 'g', -1, 64)
+		}
+	}
+	return attributes
+}
@@ -0,0 +1,217 @@
+package otlp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/authorization"
+	"github.com/arjunrn/custom-metrics-router/pkg/otlp/metricspb"
+	"github.com/arjunrn/custom-metrics-router/pkg/series"
+)
+
+// keyValues returns attributes from alternating keys and string values.
+func keyValues(pairs ...string) []*metricspb.KeyValue {
+	var attributes []*metricspb.KeyValue
+	for i := 0; i < len(pairs); i += 2 {
+		attributes = append(attributes, &metricspb.KeyValue{
+			Key:   pairs[i],
+			Value: &metricspb.AnyValue{Value: &metricspb.AnyValue_StringValue{StringValue: pairs[i+1]}},
+		})
+	}
+	return attributes
+}
+
+func dataPoint(value float64, timestamp time.Time, attributes ...string) *metricspb.NumberDataPoint {
+	return &metricspb.NumberDataPoint{
+		Attributes:   keyValues(attributes...),
+		TimeUnixNano: uint64(timestamp.UnixNano()),
+		Value:        &metricspb.NumberDataPoint_AsDouble{AsDouble: value},
+	}
+}
+
+func exportRequest(resource []*metricspb.KeyValue, metrics ...*metricspb.Metric) *metricspb.ExportMetricsServiceRequest {
+	return &metricspb.ExportMetricsServiceRequest{ResourceMetrics: []*metricspb.ResourceMetrics{{
+		Resource:     &metricspb.Resource{Attributes: resource},
+		ScopeMetrics: []*metricspb.ScopeMetrics{{Metrics: metrics}},
+	}}}
+}
+
+func values(store *series.Store, name, label string) map[string]float64 {
+	values := make(map[string]float64)
+	for _, series := range store.Select(name, nil) {
+		values[series.Labels[label]] = series.Value
+	}
+	return values
+}
+
+func TestAppend(t *testing.T) {
+	now := time.Now()
+	store := series.NewStore(3, DefaultMaxAge)
+	resource := keyValues("k8s.namespace.name", "shop", "k8s.pod.name", "worker-1")
+
+	dropped := Append(store, exportRequest(resource,
+		&metricspb.Metric{Name: "worker.jobs", Data: &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{DataPoints: []*metricspb.NumberDataPoint{
+			dataPoint(3, now, "job.state", "pending"),
+			{
+				Attributes:   keyValues("job.state", "running"),
+				TimeUnixNano: uint64(now.UnixNano()),
+				Value:        &metricspb.NumberDataPoint_AsInt{AsInt: 2},
+			},
+		}}}},
+		&metricspb.Metric{Name: "worker.processed", Data: &metricspb.Metric_Sum{Sum: &metricspb.Sum{
+			AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+			DataPoints:             []*metricspb.NumberDataPoint{dataPoint(40, now)},
+		}}},
+		// delta sums are ignored.
+		&metricspb.Metric{Name: "worker.failed", Data: &metricspb.Metric_Sum{Sum: &metricspb.Sum{
+			AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA,
+			DataPoints:             []*metricspb.NumberDataPoint{dataPoint(1, now)},
+		}}},
+	), "")
+	require.Equal(t, 0, dropped)
+	require.Equal(t, map[string]float64{"pending": 3, "running": 2}, values(store, "worker.jobs", "job.state"))
+	require.Empty(t, values(store, "worker.failed", "job.state"))
+
+	series := store.Select("worker.processed", nil)
+	require.Len(t, series, 1)
+	require.Equal(t, map[string]string{
+		"__name__":           "worker.processed",
+		"k8s.namespace.name": "shop",
+		"k8s.pod.name":       "worker-1",
+	}, series[0].Labels)
+
+	// a data point without a recorded value ends its series and the store is
+	// full for new series.
+	dropped = Append(store, exportRequest(resource,
+		&metricspb.Metric{Name: "worker.jobs", Data: &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{DataPoints: []*metricspb.NumberDataPoint{
+			{Attributes: keyValues("job.state", "running"), TimeUnixNano: uint64(now.UnixNano()), Flags: flagNoRecordedValue},
+			dataPoint(5, now, "job.state", "failed"),
+		}}}},
+	), "")
+	require.Equal(t, 0, dropped)
+	require.Equal(t, map[string]float64{"pending": 3, "failed": 5}, values(store, "worker.jobs", "job.state"))
+	dropped = Append(store, exportRequest(resource,
+		&metricspb.Metric{Name: "worker.jobs", Data: &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{DataPoints: []*metricspb.NumberDataPoint{
+			dataPoint(1, now, "job.state", "retried"),
+		}}}},
+	), "")
+	require.Equal(t, 1, dropped)
+}
+
+func TestServer(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+		switch review.Spec.Token {
+		case "collector-token":
+			review.Status.Authenticated = true
+			review.Status.User.Username = "system:serviceaccount:monitoring:collector"
+		case "other-token":
+			review.Status.Authenticated = true
+			review.Status.User.Username = "system:serviceaccount:team-a:default"
+		}
+		return true, review, nil
+	})
+	client.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		attributes := review.Spec.ResourceAttributes
+		// the collector may export data points of all namespaces and team-a
+		// only its own.
+		allowedUser := review.Spec.User == "system:serviceaccount:monitoring:collector" ||
+			review.Spec.User == "system:serviceaccount:team-a:default" && attributes.Namespace == "team-a"
+		review.Status.Allowed = allowedUser &&
+			attributes.Verb == "create" && attributes.Group == authorization.MetricsGroup && attributes.Resource == SourceName
+		return true, review, nil
+	})
+	store := series.NewStore(DefaultMaxSeries, DefaultMaxAge)
+	server := NewServer(
+		store,
+		authorization.NewTokenReviewAuthenticator(client.AuthenticationV1().TokenReviews(), time.Minute, time.Minute),
+		authorization.NewSubjectAccessReviewAuthorizer(client.AuthorizationV1().SubjectAccessReviews(), time.Minute, time.Minute),
+	)
+
+	data, err := proto.Marshal(exportRequest(keyValues("k8s.pod.name", "worker-1"),
+		&metricspb.Metric{Name: "worker.jobs", Data: &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{DataPoints: []*metricspb.NumberDataPoint{
+			dataPoint(12, time.Now()),
+		}}}},
+	))
+	require.NoError(t, err)
+	encode := func(namespace, pod string) []byte {
+		data, err := proto.Marshal(exportRequest(keyValues("k8s.pod.name", pod),
+			&metricspb.Metric{Name: "worker.jobs", Data: &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{DataPoints: []*metricspb.NumberDataPoint{
+				dataPoint(3, time.Now(), "k8s.namespace.name", namespace),
+			}}}},
+		))
+		require.NoError(t, err)
+		return data
+	}
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	_, err = writer.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	json := []byte(`{"resourceMetrics": [{
+		"resource": {"attributes": [{"key": "k8s.pod.name", "value": {"stringValue": "worker-2"}}]},
+		"scopeMetrics": [{
+			"scope": {"name": "worker"},
+			"metrics": [{"name": "worker.jobs", "unit": "1", "gauge": {"dataPoints": [{"timeUnixNano": "1601553600000000000", "asInt": "7"}]}}]
+		}]
+	}]}`)
+
+	for _, tc := range []struct {
+		name        string
+		token       string
+		contentType string
+		encoding    string
+		body        []byte
+		expected    int
+	}{
+		{name: "without token", contentType: "application/x-protobuf", body: data, expected: http.StatusUnauthorized},
+		{name: "without namespace", token: "other-token", contentType: "application/x-protobuf", body: data, expected: http.StatusForbidden},
+		{name: "forbidden namespace", token: "other-token", contentType: "application/x-protobuf", body: encode("team-b", "worker-3"), expected: http.StatusForbidden},
+		{name: "allowed namespace", token: "other-token", contentType: "application/x-protobuf", body: encode("team-a", "worker-4"), expected: http.StatusOK},
+		{name: "unsupported content type", token: "collector-token", contentType: "text/plain", body: data, expected: http.StatusUnsupportedMediaType},
+		{name: "invalid JSON", token: "collector-token", contentType: "application/json", body: data, expected: http.StatusBadRequest},
+		{name: "gzip compressed protobuf", token: "collector-token", contentType: "application/x-protobuf", encoding: "gzip", body: compressed.Bytes(), expected: http.StatusOK},
+		{name: "JSON", token: "collector-token", contentType: "application/json", body: json, expected: http.StatusOK},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, MetricsPath, bytes.NewReader(tc.body))
+			request.Header.Set("Content-Type", tc.contentType)
+			if tc.encoding != "" {
+				request.Header.Set("Content-Encoding", tc.encoding)
+			}
+			if tc.token != "" {
+				request.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, request)
+			require.Equal(t, tc.expected, recorder.Code, recorder.Body.String())
+			if tc.expected == http.StatusOK {
+				require.Equal(t, tc.contentType, recorder.Header().Get("Content-Type"))
+			}
+		})
+	}
+
+	require.Equal(t, map[string]float64{"worker-1": 12, "worker-2": 7, "worker-4": 3}, values(store, "worker.jobs", "k8s.pod.name"))
+	// the series of a sender authorized for a namespace record it.
+	for _, series := range store.Select("worker.jobs", nil) {
+		if series.Labels["k8s.pod.name"] == "worker-4" {
+			require.Equal(t, "team-a", series.Namespace)
+		} else {
+			require.Empty(t, series.Namespace)
+		}
+	}
+}
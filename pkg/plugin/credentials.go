@@ -0,0 +1,67 @@
+package plugin
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+
+	"k8s.io/client-go/rest"
+)
+
+// headerMetadata returns the credentials which the authenticator of a source
+// configured as gRPC metadata. They are taken from the headers which the
+// wrappers of the rest config add to an HTTP request, so that every
+// authentication mode works the same way as for the other backends.
+type headerMetadata struct {
+	roundTripper http.RoundTripper
+}
+
+func newHeaderMetadata(config *rest.Config) (*headerMetadata, error) {
+	roundTripper, err := rest.HTTPWrappersForConfig(config, headerRecorder{})
+	if err != nil {
+		return nil, err
+	}
+	return &headerMetadata{roundTripper: roundTripper}, nil
+}
+
+// pairs returns the credentials as alternating keys and values. Headers with
+// several values, e.g. Impersonate-Group, have a pair for every value.
+func (m *headerMetadata) pairs(ctx context.Context) ([]string, error) {
+	request, err := http.NewRequest(http.MethodPost, "https://plugin/", nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := m.roundTripper.RoundTrip(request.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(response.Header))
+	for name := range response.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var pairs []string
+	for _, name := range names {
+		key := strings.ToLower(name)
+		if key != "authorization" && !strings.HasPrefix(key, "impersonate-") {
+			continue
+		}
+		for _, value := range response.Header[name] {
+			pairs = append(pairs, key, value)
+		}
+	}
+	return pairs, nil
+}
+
+// headerRecorder answers every request with its own headers.
+type headerRecorder struct{}
+
+func (headerRecorder) RoundTrip(request *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     request.Header,
+		Body:       http.NoBody,
+		Request:    request,
+	}, nil
+}
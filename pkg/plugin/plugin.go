@@ -0,0 +1,309 @@
+package plugin
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kubernetes-sigs/custom-metrics-apiserver/pkg/provider"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"k8s.io/metrics/pkg/apis/custom_metrics"
+	"k8s.io/metrics/pkg/apis/external_metrics"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+	"github.com/arjunrn/custom-metrics-router/pkg/backend"
+	"github.com/arjunrn/custom-metrics-router/pkg/metricsclient"
+	pluginv1 "github.com/arjunrn/custom-metrics-router/pkg/plugin/v1"
+)
+
+const requestTimeout = 10 * time.Second
+
+var _ backend.Backend = &Client{}
+
+func init() {
+	backend.Register(v1beta1.PluginBackendType, newBackend)
+}
+
+// Client serves the metrics of a source by calling a metrics plugin.
+type Client struct {
+	source      string
+	client      pluginv1.MetricsPluginClient
+	callOptions []grpc.CallOption
+	// headers are the credentials of the source, which are only sent over
+	// TLS.
+	headers *headerMetadata
+}
+
+// NewClient returns a client for the plugin behind conn. The call options are
+// added to every call, e.g. credentials.
+func NewClient(source string, conn *grpc.ClientConn, callOptions ...grpc.CallOption) *Client {
+	return &Client{
+		source:      source,
+		client:      pluginv1.NewMetricsPluginClient(conn),
+		callOptions: callOptions,
+	}
+}
+
+// newBackend connects to the plugin of a source over its Unix socket or the
+// port of its service.
+func newBackend(source *v1beta1.CustomMetricsSource, deps backend.Dependencies) (backend.Backend, error) {
+	spec := &source.Spec.Backend
+	plugin := spec.Plugin
+	if plugin == nil {
+		plugin = &v1beta1.PluginBackend{}
+	}
+	if plugin.Socket != "" {
+		conn, err := dial(connKey{target: plugin.Socket, socket: true}, nil)
+		if err != nil {
+			return nil, err
+		}
+		return NewClient(source.Name, conn), nil
+	}
+
+	options, err := metricsclient.SourceOptions(deps.KubeClient, source)
+	if err != nil {
+		return nil, err
+	}
+	host := fmt.Sprintf("%s.%s", options.Name, options.Namespace)
+	target := net.JoinHostPort(host, strconv.Itoa(int(options.Port)))
+	if plugin.Plaintext {
+		conn, err := dial(connKey{target: target}, nil)
+		if err != nil {
+			return nil, err
+		}
+		return NewClient(source.Name, conn), nil
+	}
+
+	config, err := metricsclient.InClusterConfig(host, strconv.Itoa(int(options.Port)), options.InsecureSkipTLSVerify, options.CABundle, options.Authenticator, options.RequesterForwarding)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate rest config for %s: %v", host, err)
+	}
+	tlsConfig, err := rest.TLSConfigFor(config)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{}
+	}
+	headers, err := newHeaderMetadata(config)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := dial(connKey{
+		target:   target,
+		tls:      true,
+		insecure: options.InsecureSkipTLSVerify,
+		caBundle: string(options.CABundle),
+	}, credentials.NewTLS(tlsConfig))
+	if err != nil {
+		return nil, err
+	}
+	client := NewClient(source.Name, conn)
+	client.headers = headers
+	return client, nil
+}
+
+// connKey identifies the connections which can be shared by backends.
+type connKey struct {
+	target   string
+	socket   bool
+	tls      bool
+	insecure bool
+	caBundle string
+}
+
+var (
+	connsLock sync.Mutex
+	// conns are shared by the backends of a plugin, because its backend is
+	// created again on every resync of its source. They stay open until the
+	// router exits.
+	conns = make(map[connKey]*grpc.ClientConn)
+)
+
+// dial returns the connection for key. Connections are established in the
+// background, so dialing doesn't fail when the plugin isn't up yet.
+func dial(key connKey, transportCredentials credentials.TransportCredentials) (*grpc.ClientConn, error) {
+	connsLock.Lock()
+	defer connsLock.Unlock()
+	if conn, ok := conns[key]; ok {
+		return conn, nil
+	}
+	var options []grpc.DialOption
+	if transportCredentials != nil {
+		options = append(options, grpc.WithTransportCredentials(transportCredentials))
+	} else {
+		options = append(options, grpc.WithInsecure())
+	}
+	if key.socket {
+		options = append(options,
+			grpc.WithAuthority("localhost"),
+			grpc.WithContextDialer(func(ctx context.Context, path string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", path)
+			}))
+	}
+	conn, err := grpc.Dial(key.target, options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial plugin %s: %v", key.target, err)
+	}
+	conns[key] = conn
+	return conn, nil
+}
+
+func (c *Client) Source() string {
+	return c.source
+}
+
+// context returns the context of a call, which carries the credentials of the
+// source as metadata.
+func (c *Client) context() (context.Context, context.CancelFunc, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	if c.headers == nil {
+		return ctx, cancel, nil
+	}
+	pairs, err := c.headers.pairs(ctx)
+	if err != nil {
+		cancel()
+		return nil, nil, fmt.Errorf("failed to get the credentials for plugin %s: %v", c.source, err)
+	}
+	return metadata.AppendToOutgoingContext(ctx, pairs...), cancel, nil
+}
+
+func (c *Client) listMetrics() (*pluginv1.ListMetricsResponse, error) {
+	ctx, cancel, err := c.context()
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+	return c.client.ListMetrics(ctx, &pluginv1.ListMetricsRequest{}, c.callOptions...)
+}
+
+func (c *Client) ListCustomMetricInfos() (map[provider.CustomMetricInfo]struct{}, error) {
+	response, err := c.listMetrics()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list metrics of plugin %s: %v", c.source, err)
+	}
+	infos := make(map[provider.CustomMetricInfo]struct{}, len(response.GetCustomMetrics()))
+	for _, info := range response.GetCustomMetrics() {
+		infos[info.ToInfo()] = struct{}{}
+	}
+	return infos, nil
+}
+
+func (c *Client) ListExternalMetrics() (map[provider.ExternalMetricInfo]struct{}, error) {
+	response, err := c.listMetrics()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list metrics of plugin %s: %v", c.source, err)
+	}
+	infos := make(map[provider.ExternalMetricInfo]struct{}, len(response.GetExternalMetrics()))
+	for _, info := range response.GetExternalMetrics() {
+		infos[info.ToInfo()] = struct{}{}
+	}
+	return infos, nil
+}
+
+func (c *Client) GetMetricByName(name types.NamespacedName, info provider.CustomMetricInfo, metricSelector labels.Selector) (*custom_metrics.MetricValue, error) {
+	ctx, cancel, err := c.context()
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+	response, err := c.client.GetMetricByName(ctx, &pluginv1.GetMetricByNameRequest{
+		Info:           pluginv1.CustomMetricInfoFrom(info),
+		Namespace:      name.Namespace,
+		Name:           name.Name,
+		MetricSelector: metricSelector.String(),
+	}, c.callOptions...)
+	if err != nil {
+		return nil, pluginv1.APIError(err, info.GroupResource, info.Metric)
+	}
+	value, err := response.ToMetricValue(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := setIdentifier(&value.Metric, info, metricSelector); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func (c *Client) GetMetricBySelector(namespace string, selector labels.Selector, info provider.CustomMetricInfo, metricSelector labels.Selector) (*custom_metrics.MetricValueList, error) {
+	ctx, cancel, err := c.context()
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+	response, err := c.client.GetMetricBySelector(ctx, &pluginv1.GetMetricBySelectorRequest{
+		Info:           pluginv1.CustomMetricInfoFrom(info),
+		Namespace:      namespace,
+		Selector:       selector.String(),
+		MetricSelector: metricSelector.String(),
+	}, c.callOptions...)
+	if err != nil {
+		return nil, pluginv1.APIError(err, info.GroupResource, info.Metric)
+	}
+	list, err := response.ToMetricValueList(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	for i := range list.Items {
+		if err := setIdentifier(&list.Items[i].Metric, info, metricSelector); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+func (c *Client) GetExternalMetric(name, namespace string, metricSelector labels.Selector) (*external_metrics.ExternalMetricValueList, error) {
+	ctx, cancel, err := c.context()
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+	response, err := c.client.GetExternalMetric(ctx, &pluginv1.GetExternalMetricRequest{
+		Info:           &pluginv1.ExternalMetricInfo{Metric: name},
+		Namespace:      namespace,
+		MetricSelector: metricSelector.String(),
+	}, c.callOptions...)
+	if err != nil {
+		return nil, pluginv1.APIError(err, schema.GroupResource{Resource: "externalmetrics"}, name)
+	}
+	list, err := response.ToExternalMetricValueList(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	for i := range list.Items {
+		if list.Items[i].MetricName == "" {
+			list.Items[i].MetricName = name
+		}
+	}
+	return list, nil
+}
+
+// setIdentifier fills in the name of the metric if the plugin left it out and
+// the metric selector of the request.
+func setIdentifier(identifier *custom_metrics.MetricIdentifier, info provider.CustomMetricInfo, metricSelector labels.Selector) error {
+	if identifier.Name == "" {
+		identifier.Name = info.Metric
+	}
+	if metricSelector.Empty() {
+		return nil
+	}
+	selector, err := metav1.ParseToLabelSelector(metricSelector.String())
+	if err != nil {
+		return err
+	}
+	identifier.Selector = selector
+	return nil
+}
@@ -0,0 +1,171 @@
+package plugin
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kubernetes-sigs/custom-metrics-apiserver/pkg/provider"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"k8s.io/metrics/pkg/apis/custom_metrics"
+	"k8s.io/metrics/pkg/apis/external_metrics"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+	"github.com/arjunrn/custom-metrics-router/pkg/backend"
+	"github.com/arjunrn/custom-metrics-router/pkg/plugin/sdk"
+)
+
+var (
+	requests   = provider.CustomMetricInfo{GroupResource: schema.GroupResource{Resource: "pods"}, Namespaced: true, Metric: "http_requests"}
+	queueDepth = provider.ExternalMetricInfo{Metric: "queue_depth"}
+	sampledAt  = time.Unix(1600000000, 0)
+)
+
+type fakeProvider struct{}
+
+func (fakeProvider) ListMetrics(context.Context) ([]provider.CustomMetricInfo, []provider.ExternalMetricInfo, error) {
+	return []provider.CustomMetricInfo{requests}, []provider.ExternalMetricInfo{queueDepth}, nil
+}
+
+func (fakeProvider) GetMetricByName(_ context.Context, name types.NamespacedName, info provider.CustomMetricInfo, _ labels.Selector) (*custom_metrics.MetricValue, error) {
+	if name.Name != "web-0" {
+		return nil, apierrors.NewNotFound(info.GroupResource, name.Name)
+	}
+	return &custom_metrics.MetricValue{
+		DescribedObject: custom_metrics.ObjectReference{APIVersion: "v1", Kind: "Pod", Namespace: name.Namespace, Name: name.Name},
+		Timestamp:       metav1.NewTime(sampledAt),
+		Value:           resource.MustParse("1500m"),
+	}, nil
+}
+
+func (fakeProvider) GetMetricBySelector(_ context.Context, namespace string, selector labels.Selector, info provider.CustomMetricInfo, _ labels.Selector) (*custom_metrics.MetricValueList, error) {
+	if selector.String() != "app=web" {
+		return &custom_metrics.MetricValueList{}, nil
+	}
+	list := &custom_metrics.MetricValueList{}
+	for _, name := range []string{"web-0", "web-1"} {
+		list.Items = append(list.Items, custom_metrics.MetricValue{
+			DescribedObject: custom_metrics.ObjectReference{APIVersion: "v1", Kind: "Pod", Namespace: namespace, Name: name},
+			Metric:          custom_metrics.MetricIdentifier{Name: info.Metric},
+			Value:           resource.MustParse("2"),
+		})
+	}
+	return list, nil
+}
+
+func (fakeProvider) GetExternalMetric(_ context.Context, _ string, metricSelector labels.Selector, _ provider.ExternalMetricInfo) (*external_metrics.ExternalMetricValueList, error) {
+	queue, ok := metricSelector.RequiresExactMatch("queue")
+	if !ok {
+		return nil, apierrors.NewBadRequest("the metric selector must select a queue")
+	}
+	window := int64(60)
+	return &external_metrics.ExternalMetricValueList{Items: []external_metrics.ExternalMetricValue{{
+		MetricLabels:  map[string]string{"queue": queue},
+		Timestamp:     metav1.NewTime(sampledAt),
+		WindowSeconds: &window,
+		Value:         resource.MustParse("42"),
+	}}}, nil
+}
+
+func TestClient(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "plugin.sock")
+	listener, err := sdk.Listen("unix://" + socket)
+	require.NoError(t, err)
+	tcpListener, err := sdk.Listen("127.0.0.1:0")
+	require.NoError(t, err)
+	for _, l := range []net.Listener{listener, tcpListener} {
+		server := sdk.NewServer(fakeProvider{})
+		go server.Serve(l)
+		defer server.Stop()
+	}
+
+	socketBackend, err := backend.New(&v1beta1.CustomMetricsSource{
+		ObjectMeta: metav1.ObjectMeta{Name: "sidecar"},
+		Spec: v1beta1.CustomMetricsSourceSpec{Backend: v1beta1.Backend{
+			Type:    v1beta1.PluginBackendType,
+			Service: v1beta1.ServiceReference{Namespace: "metrics", Name: "sidecar"},
+			Plugin:  &v1beta1.PluginBackend{Socket: socket},
+		}},
+	}, backend.Dependencies{})
+	require.NoError(t, err)
+	conn, err := grpc.Dial(tcpListener.Addr().String(), grpc.WithInsecure())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	for _, tc := range []struct {
+		name    string
+		backend backend.Backend
+	}{
+		{name: "unix socket", backend: socketBackend},
+		{name: "tcp", backend: NewClient("sidecar", conn)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, "sidecar", tc.backend.Source())
+			customMetricInfos, err := tc.backend.ListCustomMetricInfos()
+			require.NoError(t, err)
+			require.Equal(t, map[provider.CustomMetricInfo]struct{}{requests: {}}, customMetricInfos)
+			externalMetricInfos, err := tc.backend.ListExternalMetrics()
+			require.NoError(t, err)
+			require.Equal(t, map[provider.ExternalMetricInfo]struct{}{queueDepth: {}}, externalMetricInfos)
+
+			value, err := tc.backend.GetMetricByName(types.NamespacedName{Namespace: "default", Name: "web-0"}, requests, labels.Everything())
+			require.NoError(t, err)
+			require.Equal(t, "http_requests", value.Metric.Name)
+			require.Equal(t, "web-0", value.DescribedObject.Name)
+			require.True(t, sampledAt.Equal(value.Timestamp.Time))
+			require.Equal(t, int64(1500), value.Value.MilliValue())
+
+			_, err = tc.backend.GetMetricByName(types.NamespacedName{Namespace: "default", Name: "web-9"}, requests, labels.Everything())
+			require.True(t, apierrors.IsNotFound(err), "%v", err)
+
+			list, err := tc.backend.GetMetricBySelector("default", labels.SelectorFromSet(labels.Set{"app": "web"}), requests, labels.SelectorFromSet(labels.Set{"verb": "GET"}))
+			require.NoError(t, err)
+			require.Len(t, list.Items, 2)
+			require.Equal(t, "web-1", list.Items[1].DescribedObject.Name)
+			require.Equal(t, map[string]string{"verb": "GET"}, list.Items[1].Metric.Selector.MatchLabels)
+			require.False(t, list.Items[1].Timestamp.IsZero())
+
+			externalValues, err := tc.backend.GetExternalMetric("queue_depth", "default", labels.SelectorFromSet(labels.Set{"queue": "orders"}))
+			require.NoError(t, err)
+			require.Len(t, externalValues.Items, 1)
+			require.Equal(t, "queue_depth", externalValues.Items[0].MetricName)
+			require.Equal(t, map[string]string{"queue": "orders"}, externalValues.Items[0].MetricLabels)
+			require.Equal(t, int64(60), *externalValues.Items[0].WindowSeconds)
+			require.Equal(t, int64(42), externalValues.Items[0].Value.Value())
+
+			_, err = tc.backend.GetExternalMetric("queue_depth", "default", labels.Everything())
+			require.True(t, apierrors.IsBadRequest(err), "%v", err)
+		})
+	}
+}
+
+func TestHeaderMetadata(t *testing.T) {
+	headers, err := newHeaderMetadata(&rest.Config{
+		BearerToken: "secret",
+		Impersonate: rest.ImpersonationConfig{UserName: "metrics-reader", Groups: []string{"team-a", "team-b"}},
+	})
+	require.NoError(t, err)
+	client := &Client{source: "plugin", headers: headers}
+	ctx, cancel, err := client.context()
+	require.NoError(t, err)
+	defer cancel()
+	// every group is sent.
+	md, ok := metadata.FromOutgoingContext(ctx)
+	require.True(t, ok)
+	require.Equal(t, metadata.MD{
+		"authorization":     {"Bearer secret"},
+		"impersonate-user":  {"metrics-reader"},
+		"impersonate-group": {"team-a", "team-b"},
+	}, md)
+}
@@ -0,0 +1,135 @@
+// Package sdk serves metrics plugins, which the router calls for the sources
+// of the backend type Plugin.
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/kubernetes-sigs/custom-metrics-apiserver/pkg/provider"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/metrics/pkg/apis/custom_metrics"
+	"k8s.io/metrics/pkg/apis/external_metrics"
+
+	pluginv1 "github.com/arjunrn/custom-metrics-router/pkg/plugin/v1"
+)
+
+// Provider serves the metrics of a plugin. The methods mirror the metrics
+// providers of custom-metrics-apiserver. Errors of the apimachinery errors
+// package, e.g. NotFound and BadRequest, are passed on to the router.
+type Provider interface {
+	ListMetrics(ctx context.Context) ([]provider.CustomMetricInfo, []provider.ExternalMetricInfo, error)
+	GetMetricByName(ctx context.Context, name types.NamespacedName, info provider.CustomMetricInfo, metricSelector labels.Selector) (*custom_metrics.MetricValue, error)
+	GetMetricBySelector(ctx context.Context, namespace string, selector labels.Selector, info provider.CustomMetricInfo, metricSelector labels.Selector) (*custom_metrics.MetricValueList, error)
+	GetExternalMetric(ctx context.Context, namespace string, metricSelector labels.Selector, info provider.ExternalMetricInfo) (*external_metrics.ExternalMetricValueList, error)
+}
+
+// Serve serves p on address until the server fails. See Listen for the forms
+// of address.
+func Serve(address string, p Provider, options ...grpc.ServerOption) error {
+	listener, err := Listen(address)
+	if err != nil {
+		return err
+	}
+	return NewServer(p, options...).Serve(listener)
+}
+
+// NewServer returns a gRPC server which serves p. TLS is configured with the
+// option grpc.Creds.
+func NewServer(p Provider, options ...grpc.ServerOption) *grpc.Server {
+	server := grpc.NewServer(options...)
+	pluginv1.RegisterMetricsPluginServer(server, &pluginServer{provider: p})
+	return server
+}
+
+// Listen listens on a Unix socket for addresses of the form unix:///path and
+// on a TCP port otherwise, e.g. for :9443. A socket left behind by a previous
+// run is removed.
+func Listen(address string) (net.Listener, error) {
+	if !strings.HasPrefix(address, "unix://") {
+		return net.Listen("tcp", address)
+	}
+	path := strings.TrimPrefix(address, "unix://")
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to remove socket %s: %v", path, err)
+	}
+	return net.Listen("unix", path)
+}
+
+type pluginServer struct {
+	provider Provider
+}
+
+func (s *pluginServer) ListMetrics(ctx context.Context, _ *pluginv1.ListMetricsRequest) (*pluginv1.ListMetricsResponse, error) {
+	customMetricInfos, externalMetricInfos, err := s.provider.ListMetrics(ctx)
+	if err != nil {
+		return nil, pluginv1.Status(err)
+	}
+	response := &pluginv1.ListMetricsResponse{
+		CustomMetrics:   make([]*pluginv1.CustomMetricInfo, 0, len(customMetricInfos)),
+		ExternalMetrics: make([]*pluginv1.ExternalMetricInfo, 0, len(externalMetricInfos)),
+	}
+	for _, info := range customMetricInfos {
+		response.CustomMetrics = append(response.CustomMetrics, pluginv1.CustomMetricInfoFrom(info))
+	}
+	for _, info := range externalMetricInfos {
+		response.ExternalMetrics = append(response.ExternalMetrics, pluginv1.ExternalMetricInfoFrom(info))
+	}
+	return response, nil
+}
+
+func (s *pluginServer) GetMetricByName(ctx context.Context, request *pluginv1.GetMetricByNameRequest) (*pluginv1.MetricValue, error) {
+	metricSelector, err := parseSelector(request.GetMetricSelector())
+	if err != nil {
+		return nil, err
+	}
+	name := types.NamespacedName{Namespace: request.GetNamespace(), Name: request.GetName()}
+	value, err := s.provider.GetMetricByName(ctx, name, request.GetInfo().ToInfo(), metricSelector)
+	if err != nil {
+		return nil, pluginv1.Status(err)
+	}
+	return pluginv1.MetricValueFrom(value)
+}
+
+func (s *pluginServer) GetMetricBySelector(ctx context.Context, request *pluginv1.GetMetricBySelectorRequest) (*pluginv1.MetricValueList, error) {
+	selector, err := parseSelector(request.GetSelector())
+	if err != nil {
+		return nil, err
+	}
+	metricSelector, err := parseSelector(request.GetMetricSelector())
+	if err != nil {
+		return nil, err
+	}
+	list, err := s.provider.GetMetricBySelector(ctx, request.GetNamespace(), selector, request.GetInfo().ToInfo(), metricSelector)
+	if err != nil {
+		return nil, pluginv1.Status(err)
+	}
+	return pluginv1.MetricValueListFrom(list)
+}
+
+func (s *pluginServer) GetExternalMetric(ctx context.Context, request *pluginv1.GetExternalMetricRequest) (*pluginv1.ExternalMetricValueList, error) {
+	metricSelector, err := parseSelector(request.GetMetricSelector())
+	if err != nil {
+		return nil, err
+	}
+	list, err := s.provider.GetExternalMetric(ctx, request.GetNamespace(), metricSelector, request.GetInfo().ToInfo())
+	if err != nil {
+		return nil, pluginv1.Status(err)
+	}
+	return pluginv1.ExternalMetricValueListFrom(list)
+}
+
+func parseSelector(selector string) (labels.Selector, error) {
+	parsed, err := labels.Parse(selector)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return parsed, nil
+}
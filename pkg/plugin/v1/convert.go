@@ -0,0 +1,226 @@
+package v1
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/kubernetes-sigs/custom-metrics-apiserver/pkg/provider"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/metrics/pkg/apis/custom_metrics"
+	"k8s.io/metrics/pkg/apis/external_metrics"
+)
+
+// CustomMetricInfoFrom converts the info of a custom metric to its message.
+func CustomMetricInfoFrom(info provider.CustomMetricInfo) *CustomMetricInfo {
+	return &CustomMetricInfo{
+		Group:      info.GroupResource.Group,
+		Resource:   info.GroupResource.Resource,
+		Namespaced: info.Namespaced,
+		Metric:     info.Metric,
+	}
+}
+
+// ToInfo converts the message to the info of a custom metric.
+func (m *CustomMetricInfo) ToInfo() provider.CustomMetricInfo {
+	return provider.CustomMetricInfo{
+		GroupResource: schema.GroupResource{Group: m.GetGroup(), Resource: m.GetResource()},
+		Namespaced:    m.GetNamespaced(),
+		Metric:        m.GetMetric(),
+	}
+}
+
+// ExternalMetricInfoFrom converts the info of an external metric to its
+// message.
+func ExternalMetricInfoFrom(info provider.ExternalMetricInfo) *ExternalMetricInfo {
+	return &ExternalMetricInfo{Metric: info.Metric}
+}
+
+// ToInfo converts the message to the info of an external metric.
+func (m *ExternalMetricInfo) ToInfo() provider.ExternalMetricInfo {
+	return provider.ExternalMetricInfo{Metric: m.GetMetric()}
+}
+
+// MetricValueFrom converts a value of a custom metric to its message.
+func MetricValueFrom(value *custom_metrics.MetricValue) (*MetricValue, error) {
+	m := &MetricValue{
+		DescribedObject: &ObjectReference{
+			ApiVersion: value.DescribedObject.APIVersion,
+			Kind:       value.DescribedObject.Kind,
+			Namespace:  value.DescribedObject.Namespace,
+			Name:       value.DescribedObject.Name,
+		},
+		Metric: value.Metric.Name,
+		Value:  value.Value.String(),
+	}
+	if value.WindowSeconds != nil {
+		m.WindowSeconds = *value.WindowSeconds
+	}
+	if !value.Timestamp.IsZero() {
+		sampledAt, err := ptypes.TimestampProto(value.Timestamp.Time)
+		if err != nil {
+			return nil, err
+		}
+		m.Timestamp = sampledAt
+	}
+	return m, nil
+}
+
+// ToMetricValue converts the message to a value of a custom metric. Values
+// without a timestamp get now.
+func (m *MetricValue) ToMetricValue(now time.Time) (*custom_metrics.MetricValue, error) {
+	object := m.GetDescribedObject()
+	value := &custom_metrics.MetricValue{
+		DescribedObject: custom_metrics.ObjectReference{
+			APIVersion: object.GetApiVersion(),
+			Kind:       object.GetKind(),
+			Namespace:  object.GetNamespace(),
+			Name:       object.GetName(),
+		},
+		Metric: custom_metrics.MetricIdentifier{Name: m.GetMetric()},
+	}
+	var err error
+	value.Timestamp, value.WindowSeconds, value.Value, err = convertSample(m.GetTimestamp(), m.GetWindowSeconds(), m.GetValue(), now)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value of metric %s of %s %s: %v", m.GetMetric(), object.GetKind(), object.GetName(), err)
+	}
+	return value, nil
+}
+
+// MetricValueListFrom converts values of a custom metric to their message.
+func MetricValueListFrom(list *custom_metrics.MetricValueList) (*MetricValueList, error) {
+	m := &MetricValueList{Items: make([]*MetricValue, 0, len(list.Items))}
+	for i := range list.Items {
+		item, err := MetricValueFrom(&list.Items[i])
+		if err != nil {
+			return nil, err
+		}
+		m.Items = append(m.Items, item)
+	}
+	return m, nil
+}
+
+// ToMetricValueList converts the message to values of a custom metric.
+func (m *MetricValueList) ToMetricValueList(now time.Time) (*custom_metrics.MetricValueList, error) {
+	list := &custom_metrics.MetricValueList{Items: make([]custom_metrics.MetricValue, 0, len(m.GetItems()))}
+	for _, item := range m.GetItems() {
+		value, err := item.ToMetricValue(now)
+		if err != nil {
+			return nil, err
+		}
+		list.Items = append(list.Items, *value)
+	}
+	return list, nil
+}
+
+// ExternalMetricValueListFrom converts values of an external metric to their
+// message.
+func ExternalMetricValueListFrom(list *external_metrics.ExternalMetricValueList) (*ExternalMetricValueList, error) {
+	m := &ExternalMetricValueList{Items: make([]*ExternalMetricValue, 0, len(list.Items))}
+	for _, value := range list.Items {
+		item := &ExternalMetricValue{
+			Metric: value.MetricName,
+			Labels: value.MetricLabels,
+			Value:  value.Value.String(),
+		}
+		if value.WindowSeconds != nil {
+			item.WindowSeconds = *value.WindowSeconds
+		}
+		if !value.Timestamp.IsZero() {
+			sampledAt, err := ptypes.TimestampProto(value.Timestamp.Time)
+			if err != nil {
+				return nil, err
+			}
+			item.Timestamp = sampledAt
+		}
+		m.Items = append(m.Items, item)
+	}
+	return m, nil
+}
+
+// ToExternalMetricValueList converts the message to values of an external
+// metric. Values without a timestamp get now.
+func (m *ExternalMetricValueList) ToExternalMetricValueList(now time.Time) (*external_metrics.ExternalMetricValueList, error) {
+	list := &external_metrics.ExternalMetricValueList{Items: make([]external_metrics.ExternalMetricValue, 0, len(m.GetItems()))}
+	for _, item := range m.GetItems() {
+		value := external_metrics.ExternalMetricValue{
+			MetricName:   item.GetMetric(),
+			MetricLabels: item.GetLabels(),
+		}
+		var err error
+		value.Timestamp, value.WindowSeconds, value.Value, err = convertSample(item.GetTimestamp(), item.GetWindowSeconds(), item.GetValue(), now)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of external metric %s: %v", item.GetMetric(), err)
+		}
+		list.Items = append(list.Items, value)
+	}
+	return list, nil
+}
+
+func convertSample(sampledAt *timestamp.Timestamp, windowSeconds int64, value string, now time.Time) (metav1.Time, *int64, resource.Quantity, error) {
+	quantity, err := resource.ParseQuantity(value)
+	if err != nil {
+		return metav1.Time{}, nil, resource.Quantity{}, err
+	}
+	sampled := now
+	if sampledAt != nil {
+		sampled, err = ptypes.Timestamp(sampledAt)
+		if err != nil {
+			return metav1.Time{}, nil, resource.Quantity{}, err
+		}
+	}
+	var window *int64
+	if windowSeconds > 0 {
+		window = &windowSeconds
+	}
+	return metav1.NewTime(sampled), window, quantity, nil
+}
+
+// Status converts an error of a metrics provider to a gRPC status error.
+func Status(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	code := codes.Unknown
+	switch {
+	case apierrors.IsNotFound(err):
+		code = codes.NotFound
+	case apierrors.IsBadRequest(err):
+		code = codes.InvalidArgument
+	case apierrors.IsForbidden(err):
+		code = codes.PermissionDenied
+	case apierrors.IsServiceUnavailable(err):
+		code = codes.Unavailable
+	}
+	return status.Error(code, err.Error())
+}
+
+// APIError converts a gRPC status error of a request for a metric of
+// groupResource to an API error.
+func APIError(err error, groupResource schema.GroupResource, metric string) error {
+	s, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	switch s.Code() {
+	case codes.NotFound:
+		return apierrors.NewNotFound(groupResource, metric)
+	case codes.InvalidArgument:
+		return apierrors.NewBadRequest(s.Message())
+	case codes.PermissionDenied:
+		return apierrors.NewForbidden(groupResource, metric, fmt.Errorf("%s", s.Message()))
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return apierrors.NewServiceUnavailable(s.Message())
+	default:
+		return apierrors.NewInternalError(fmt.Errorf("%s", s.Message()))
+	}
+}
@@ -0,0 +1,942 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: pkg/plugin/v1/plugin.proto
+
+package v1
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	timestamp "github.com/golang/protobuf/ptypes/timestamp"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type ListMetricsRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListMetricsRequest) Reset()         { *m = ListMetricsRequest{} }
+func (m *ListMetricsRequest) String() string { return proto.CompactTextString(m) }
+func (*ListMetricsRequest) ProtoMessage()    {}
+func (*ListMetricsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bc7cbddb9f34e4be, []int{0}
+}
+
+func (m *ListMetricsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListMetricsRequest.Unmarshal(m, b)
+}
+func (m *ListMetricsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListMetricsRequest.Marshal(b, m, deterministic)
+}
+func (m *ListMetricsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListMetricsRequest.Merge(m, src)
+}
+func (m *ListMetricsRequest) XXX_Size() int {
+	return xxx_messageInfo_ListMetricsRequest.Size(m)
+}
+func (m *ListMetricsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListMetricsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListMetricsRequest proto.InternalMessageInfo
+
+type ListMetricsResponse struct {
+	CustomMetrics        []*CustomMetricInfo   `protobuf:"bytes,1,rep,name=custom_metrics,json=customMetrics,proto3" json:"custom_metrics,omitempty"`
+	ExternalMetrics      []*ExternalMetricInfo `protobuf:"bytes,2,rep,name=external_metrics,json=externalMetrics,proto3" json:"external_metrics,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
+	XXX_unrecognized     []byte                `json:"-"`
+	XXX_sizecache        int32                 `json:"-"`
+}
+
+func (m *ListMetricsResponse) Reset()         { *m = ListMetricsResponse{} }
+func (m *ListMetricsResponse) String() string { return proto.CompactTextString(m) }
+func (*ListMetricsResponse) ProtoMessage()    {}
+func (*ListMetricsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bc7cbddb9f34e4be, []int{1}
+}
+
+func (m *ListMetricsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListMetricsResponse.Unmarshal(m, b)
+}
+func (m *ListMetricsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListMetricsResponse.Marshal(b, m, deterministic)
+}
+func (m *ListMetricsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListMetricsResponse.Merge(m, src)
+}
+func (m *ListMetricsResponse) XXX_Size() int {
+	return xxx_messageInfo_ListMetricsResponse.Size(m)
+}
+func (m *ListMetricsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListMetricsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListMetricsResponse proto.InternalMessageInfo
+
+func (m *ListMetricsResponse) GetCustomMetrics() []*CustomMetricInfo {
+	if m != nil {
+		return m.CustomMetrics
+	}
+	return nil
+}
+
+func (m *ListMetricsResponse) GetExternalMetrics() []*ExternalMetricInfo {
+	if m != nil {
+		return m.ExternalMetrics
+	}
+	return nil
+}
+
+// CustomMetricInfo is a metric which describes objects of a resource, e.g.
+// the resource pods or the group apps and the resource deployments.
+type CustomMetricInfo struct {
+	Group                string   `protobuf:"bytes,1,opt,name=group,proto3" json:"group,omitempty"`
+	Resource             string   `protobuf:"bytes,2,opt,name=resource,proto3" json:"resource,omitempty"`
+	Namespaced           bool     `protobuf:"varint,3,opt,name=namespaced,proto3" json:"namespaced,omitempty"`
+	Metric               string   `protobuf:"bytes,4,opt,name=metric,proto3" json:"metric,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CustomMetricInfo) Reset()         { *m = CustomMetricInfo{} }
+func (m *CustomMetricInfo) String() string { return proto.CompactTextString(m) }
+func (*CustomMetricInfo) ProtoMessage()    {}
+func (*CustomMetricInfo) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bc7cbddb9f34e4be, []int{2}
+}
+
+func (m *CustomMetricInfo) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CustomMetricInfo.Unmarshal(m, b)
+}
+func (m *CustomMetricInfo) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CustomMetricInfo.Marshal(b, m, deterministic)
+}
+func (m *CustomMetricInfo) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CustomMetricInfo.Merge(m, src)
+}
+func (m *CustomMetricInfo) XXX_Size() int {
+	return xxx_messageInfo_CustomMetricInfo.Size(m)
+}
+func (m *CustomMetricInfo) XXX_DiscardUnknown() {
+	xxx_messageInfo_CustomMetricInfo.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CustomMetricInfo proto.InternalMessageInfo
+
+func (m *CustomMetricInfo) GetGroup() string {
+	if m != nil {
+		return m.Group
+	}
+	return ""
+}
+
+func (m *CustomMetricInfo) GetResource() string {
+	if m != nil {
+		return m.Resource
+	}
+	return ""
+}
+
+func (m *CustomMetricInfo) GetNamespaced() bool {
+	if m != nil {
+		return m.Namespaced
+	}
+	return false
+}
+
+func (m *CustomMetricInfo) GetMetric() string {
+	if m != nil {
+		return m.Metric
+	}
+	return ""
+}
+
+type ExternalMetricInfo struct {
+	Metric               string   `protobuf:"bytes,1,opt,name=metric,proto3" json:"metric,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ExternalMetricInfo) Reset()         { *m = ExternalMetricInfo{} }
+func (m *ExternalMetricInfo) String() string { return proto.CompactTextString(m) }
+func (*ExternalMetricInfo) ProtoMessage()    {}
+func (*ExternalMetricInfo) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bc7cbddb9f34e4be, []int{3}
+}
+
+func (m *ExternalMetricInfo) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ExternalMetricInfo.Unmarshal(m, b)
+}
+func (m *ExternalMetricInfo) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ExternalMetricInfo.Marshal(b, m, deterministic)
+}
+func (m *ExternalMetricInfo) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExternalMetricInfo.Merge(m, src)
+}
+func (m *ExternalMetricInfo) XXX_Size() int {
+	return xxx_messageInfo_ExternalMetricInfo.Size(m)
+}
+func (m *ExternalMetricInfo) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExternalMetricInfo.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExternalMetricInfo proto.InternalMessageInfo
+
+func (m *ExternalMetricInfo) GetMetric() string {
+	if m != nil {
+		return m.Metric
+	}
+	return ""
+}
+
+type GetMetricByNameRequest struct {
+	Info      *CustomMetricInfo `protobuf:"bytes,1,opt,name=info,proto3" json:"info,omitempty"`
+	Namespace string            `protobuf:"bytes,2,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Name      string            `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	// metric_selector is a label selector of the metric, e.g. "verb=GET".
+	MetricSelector       string   `protobuf:"bytes,4,opt,name=metric_selector,json=metricSelector,proto3" json:"metric_selector,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetMetricByNameRequest) Reset()         { *m = GetMetricByNameRequest{} }
+func (m *GetMetricByNameRequest) String() string { return proto.CompactTextString(m) }
+func (*GetMetricByNameRequest) ProtoMessage()    {}
+func (*GetMetricByNameRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bc7cbddb9f34e4be, []int{4}
+}
+
+func (m *GetMetricByNameRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetMetricByNameRequest.Unmarshal(m, b)
+}
+func (m *GetMetricByNameRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetMetricByNameRequest.Marshal(b, m, deterministic)
+}
+func (m *GetMetricByNameRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetMetricByNameRequest.Merge(m, src)
+}
+func (m *GetMetricByNameRequest) XXX_Size() int {
+	return xxx_messageInfo_GetMetricByNameRequest.Size(m)
+}
+func (m *GetMetricByNameRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetMetricByNameRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetMetricByNameRequest proto.InternalMessageInfo
+
+func (m *GetMetricByNameRequest) GetInfo() *CustomMetricInfo {
+	if m != nil {
+		return m.Info
+	}
+	return nil
+}
+
+func (m *GetMetricByNameRequest) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *GetMetricByNameRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *GetMetricByNameRequest) GetMetricSelector() string {
+	if m != nil {
+		return m.MetricSelector
+	}
+	return ""
+}
+
+type GetMetricBySelectorRequest struct {
+	Info      *CustomMetricInfo `protobuf:"bytes,1,opt,name=info,proto3" json:"info,omitempty"`
+	Namespace string            `protobuf:"bytes,2,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	// selector is a label selector of the objects.
+	Selector             string   `protobuf:"bytes,3,opt,name=selector,proto3" json:"selector,omitempty"`
+	MetricSelector       string   `protobuf:"bytes,4,opt,name=metric_selector,json=metricSelector,proto3" json:"metric_selector,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetMetricBySelectorRequest) Reset()         { *m = GetMetricBySelectorRequest{} }
+func (m *GetMetricBySelectorRequest) String() string { return proto.CompactTextString(m) }
+func (*GetMetricBySelectorRequest) ProtoMessage()    {}
+func (*GetMetricBySelectorRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bc7cbddb9f34e4be, []int{5}
+}
+
+func (m *GetMetricBySelectorRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetMetricBySelectorRequest.Unmarshal(m, b)
+}
+func (m *GetMetricBySelectorRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetMetricBySelectorRequest.Marshal(b, m, deterministic)
+}
+func (m *GetMetricBySelectorRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetMetricBySelectorRequest.Merge(m, src)
+}
+func (m *GetMetricBySelectorRequest) XXX_Size() int {
+	return xxx_messageInfo_GetMetricBySelectorRequest.Size(m)
+}
+func (m *GetMetricBySelectorRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetMetricBySelectorRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetMetricBySelectorRequest proto.InternalMessageInfo
+
+func (m *GetMetricBySelectorRequest) GetInfo() *CustomMetricInfo {
+	if m != nil {
+		return m.Info
+	}
+	return nil
+}
+
+func (m *GetMetricBySelectorRequest) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *GetMetricBySelectorRequest) GetSelector() string {
+	if m != nil {
+		return m.Selector
+	}
+	return ""
+}
+
+func (m *GetMetricBySelectorRequest) GetMetricSelector() string {
+	if m != nil {
+		return m.MetricSelector
+	}
+	return ""
+}
+
+type GetExternalMetricRequest struct {
+	Info                 *ExternalMetricInfo `protobuf:"bytes,1,opt,name=info,proto3" json:"info,omitempty"`
+	Namespace            string              `protobuf:"bytes,2,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	MetricSelector       string              `protobuf:"bytes,3,opt,name=metric_selector,json=metricSelector,proto3" json:"metric_selector,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}            `json:"-"`
+	XXX_unrecognized     []byte              `json:"-"`
+	XXX_sizecache        int32               `json:"-"`
+}
+
+func (m *GetExternalMetricRequest) Reset()         { *m = GetExternalMetricRequest{} }
+func (m *GetExternalMetricRequest) String() string { return proto.CompactTextString(m) }
+func (*GetExternalMetricRequest) ProtoMessage()    {}
+func (*GetExternalMetricRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bc7cbddb9f34e4be, []int{6}
+}
+
+func (m *GetExternalMetricRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetExternalMetricRequest.Unmarshal(m, b)
+}
+func (m *GetExternalMetricRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetExternalMetricRequest.Marshal(b, m, deterministic)
+}
+func (m *GetExternalMetricRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetExternalMetricRequest.Merge(m, src)
+}
+func (m *GetExternalMetricRequest) XXX_Size() int {
+	return xxx_messageInfo_GetExternalMetricRequest.Size(m)
+}
+func (m *GetExternalMetricRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetExternalMetricRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetExternalMetricRequest proto.InternalMessageInfo
+
+func (m *GetExternalMetricRequest) GetInfo() *ExternalMetricInfo {
+	if m != nil {
+		return m.Info
+	}
+	return nil
+}
+
+func (m *GetExternalMetricRequest) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *GetExternalMetricRequest) GetMetricSelector() string {
+	if m != nil {
+		return m.MetricSelector
+	}
+	return ""
+}
+
+// ObjectReference is the object a custom metric describes.
+type ObjectReference struct {
+	ApiVersion           string   `protobuf:"bytes,1,opt,name=api_version,json=apiVersion,proto3" json:"api_version,omitempty"`
+	Kind                 string   `protobuf:"bytes,2,opt,name=kind,proto3" json:"kind,omitempty"`
+	Namespace            string   `protobuf:"bytes,3,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Name                 string   `protobuf:"bytes,4,opt,name=name,proto3" json:"name,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ObjectReference) Reset()         { *m = ObjectReference{} }
+func (m *ObjectReference) String() string { return proto.CompactTextString(m) }
+func (*ObjectReference) ProtoMessage()    {}
+func (*ObjectReference) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bc7cbddb9f34e4be, []int{7}
+}
+
+func (m *ObjectReference) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ObjectReference.Unmarshal(m, b)
+}
+func (m *ObjectReference) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ObjectReference.Marshal(b, m, deterministic)
+}
+func (m *ObjectReference) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ObjectReference.Merge(m, src)
+}
+func (m *ObjectReference) XXX_Size() int {
+	return xxx_messageInfo_ObjectReference.Size(m)
+}
+func (m *ObjectReference) XXX_DiscardUnknown() {
+	xxx_messageInfo_ObjectReference.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ObjectReference proto.InternalMessageInfo
+
+func (m *ObjectReference) GetApiVersion() string {
+	if m != nil {
+		return m.ApiVersion
+	}
+	return ""
+}
+
+func (m *ObjectReference) GetKind() string {
+	if m != nil {
+		return m.Kind
+	}
+	return ""
+}
+
+func (m *ObjectReference) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *ObjectReference) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+type MetricValue struct {
+	DescribedObject *ObjectReference `protobuf:"bytes,1,opt,name=described_object,json=describedObject,proto3" json:"described_object,omitempty"`
+	Metric          string           `protobuf:"bytes,2,opt,name=metric,proto3" json:"metric,omitempty"`
+	// timestamp defaults to the time the router received the value.
+	Timestamp *timestamp.Timestamp `protobuf:"bytes,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	// window_seconds is the window of a rate, if the value is one.
+	WindowSeconds int64 `protobuf:"varint,4,opt,name=window_seconds,json=windowSeconds,proto3" json:"window_seconds,omitempty"`
+	// value is a Kubernetes quantity, e.g. "42" or "1500m".
+	Value                string   `protobuf:"bytes,5,opt,name=value,proto3" json:"value,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MetricValue) Reset()         { *m = MetricValue{} }
+func (m *MetricValue) String() string { return proto.CompactTextString(m) }
+func (*MetricValue) ProtoMessage()    {}
+func (*MetricValue) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bc7cbddb9f34e4be, []int{8}
+}
+
+func (m *MetricValue) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MetricValue.Unmarshal(m, b)
+}
+func (m *MetricValue) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MetricValue.Marshal(b, m, deterministic)
+}
+func (m *MetricValue) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MetricValue.Merge(m, src)
+}
+func (m *MetricValue) XXX_Size() int {
+	return xxx_messageInfo_MetricValue.Size(m)
+}
+func (m *MetricValue) XXX_DiscardUnknown() {
+	xxx_messageInfo_MetricValue.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MetricValue proto.InternalMessageInfo
+
+func (m *MetricValue) GetDescribedObject() *ObjectReference {
+	if m != nil {
+		return m.DescribedObject
+	}
+	return nil
+}
+
+func (m *MetricValue) GetMetric() string {
+	if m != nil {
+		return m.Metric
+	}
+	return ""
+}
+
+func (m *MetricValue) GetTimestamp() *timestamp.Timestamp {
+	if m != nil {
+		return m.Timestamp
+	}
+	return nil
+}
+
+func (m *MetricValue) GetWindowSeconds() int64 {
+	if m != nil {
+		return m.WindowSeconds
+	}
+	return 0
+}
+
+func (m *MetricValue) GetValue() string {
+	if m != nil {
+		return m.Value
+	}
+	return ""
+}
+
+type MetricValueList struct {
+	Items                []*MetricValue `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *MetricValueList) Reset()         { *m = MetricValueList{} }
+func (m *MetricValueList) String() string { return proto.CompactTextString(m) }
+func (*MetricValueList) ProtoMessage()    {}
+func (*MetricValueList) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bc7cbddb9f34e4be, []int{9}
+}
+
+func (m *MetricValueList) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MetricValueList.Unmarshal(m, b)
+}
+func (m *MetricValueList) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MetricValueList.Marshal(b, m, deterministic)
+}
+func (m *MetricValueList) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MetricValueList.Merge(m, src)
+}
+func (m *MetricValueList) XXX_Size() int {
+	return xxx_messageInfo_MetricValueList.Size(m)
+}
+func (m *MetricValueList) XXX_DiscardUnknown() {
+	xxx_messageInfo_MetricValueList.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MetricValueList proto.InternalMessageInfo
+
+func (m *MetricValueList) GetItems() []*MetricValue {
+	if m != nil {
+		return m.Items
+	}
+	return nil
+}
+
+type ExternalMetricValue struct {
+	Metric string            `protobuf:"bytes,1,opt,name=metric,proto3" json:"metric,omitempty"`
+	Labels map[string]string `protobuf:"bytes,2,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// timestamp defaults to the time the router received the value.
+	Timestamp *timestamp.Timestamp `protobuf:"bytes,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	// window_seconds is the window of a rate, if the value is one.
+	WindowSeconds int64 `protobuf:"varint,4,opt,name=window_seconds,json=windowSeconds,proto3" json:"window_seconds,omitempty"`
+	// value is a Kubernetes quantity, e.g. "42" or "1500m".
+	Value                string   `protobuf:"bytes,5,opt,name=value,proto3" json:"value,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ExternalMetricValue) Reset()         { *m = ExternalMetricValue{} }
+func (m *ExternalMetricValue) String() string { return proto.CompactTextString(m) }
+func (*ExternalMetricValue) ProtoMessage()    {}
+func (*ExternalMetricValue) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bc7cbddb9f34e4be, []int{10}
+}
+
+func (m *ExternalMetricValue) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ExternalMetricValue.Unmarshal(m, b)
+}
+func (m *ExternalMetricValue) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ExternalMetricValue.Marshal(b, m, deterministic)
+}
+func (m *ExternalMetricValue) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExternalMetricValue.Merge(m, src)
+}
+func (m *ExternalMetricValue) XXX_Size() int {
+	return xxx_messageInfo_ExternalMetricValue.Size(m)
+}
+func (m *ExternalMetricValue) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExternalMetricValue.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExternalMetricValue proto.InternalMessageInfo
+
+func (m *ExternalMetricValue) GetMetric() string {
+	if m != nil {
+		return m.Metric
+	}
+	return ""
+}
+
+func (m *ExternalMetricValue) GetLabels() map[string]string {
+	if m != nil {
+		return m.Labels
+	}
+	return nil
+}
+
+func (m *ExternalMetricValue) GetTimestamp() *timestamp.Timestamp {
+	if m != nil {
+		return m.Timestamp
+	}
+	return nil
+}
+
+func (m *ExternalMetricValue) GetWindowSeconds() int64 {
+	if m != nil {
+		return m.WindowSeconds
+	}
+	return 0
+}
+
+func (m *ExternalMetricValue) GetValue() string {
+	if m != nil {
+		return m.Value
+	}
+	return ""
+}
+
+type ExternalMetricValueList struct {
+	Items                []*ExternalMetricValue `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}               `json:"-"`
+	XXX_unrecognized     []byte                 `json:"-"`
+	XXX_sizecache        int32                  `json:"-"`
+}
+
+func (m *ExternalMetricValueList) Reset()         { *m = ExternalMetricValueList{} }
+func (m *ExternalMetricValueList) String() string { return proto.CompactTextString(m) }
+func (*ExternalMetricValueList) ProtoMessage()    {}
+func (*ExternalMetricValueList) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bc7cbddb9f34e4be, []int{11}
+}
+
+func (m *ExternalMetricValueList) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ExternalMetricValueList.Unmarshal(m, b)
+}
+func (m *ExternalMetricValueList) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ExternalMetricValueList.Marshal(b, m, deterministic)
+}
+func (m *ExternalMetricValueList) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExternalMetricValueList.Merge(m, src)
+}
+func (m *ExternalMetricValueList) XXX_Size() int {
+	return xxx_messageInfo_ExternalMetricValueList.Size(m)
+}
+func (m *ExternalMetricValueList) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExternalMetricValueList.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExternalMetricValueList proto.InternalMessageInfo
+
+func (m *ExternalMetricValueList) GetItems() []*ExternalMetricValue {
+	if m != nil {
+		return m.Items
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*ListMetricsRequest)(nil), "metricsrouter.plugin.v1.ListMetricsRequest")
+	proto.RegisterType((*ListMetricsResponse)(nil), "metricsrouter.plugin.v1.ListMetricsResponse")
+	proto.RegisterType((*CustomMetricInfo)(nil), "metricsrouter.plugin.v1.CustomMetricInfo")
+	proto.RegisterType((*ExternalMetricInfo)(nil), "metricsrouter.plugin.v1.ExternalMetricInfo")
+	proto.RegisterType((*GetMetricByNameRequest)(nil), "metricsrouter.plugin.v1.GetMetricByNameRequest")
+	proto.RegisterType((*GetMetricBySelectorRequest)(nil), "metricsrouter.plugin.v1.GetMetricBySelectorRequest")
+	proto.RegisterType((*GetExternalMetricRequest)(nil), "metricsrouter.plugin.v1.GetExternalMetricRequest")
+	proto.RegisterType((*ObjectReference)(nil), "metricsrouter.plugin.v1.ObjectReference")
+	proto.RegisterType((*MetricValue)(nil), "metricsrouter.plugin.v1.MetricValue")
+	proto.RegisterType((*MetricValueList)(nil), "metricsrouter.plugin.v1.MetricValueList")
+	proto.RegisterType((*ExternalMetricValue)(nil), "metricsrouter.plugin.v1.ExternalMetricValue")
+	proto.RegisterMapType((map[string]string)(nil), "metricsrouter.plugin.v1.ExternalMetricValue.LabelsEntry")
+	proto.RegisterType((*ExternalMetricValueList)(nil), "metricsrouter.plugin.v1.ExternalMetricValueList")
+}
+
+func init() { proto.RegisterFile("pkg/plugin/v1/plugin.proto", fileDescriptor_bc7cbddb9f34e4be) }
+
+var fileDescriptor_bc7cbddb9f34e4be = []byte{
+	// 776 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xc4, 0x56, 0xdd, 0x4e, 0xdb, 0x48,
+	0x14, 0x96, 0x93, 0x80, 0xc8, 0x89, 0x20, 0xd9, 0x01, 0x41, 0x64, 0xad, 0x16, 0x64, 0xed, 0x6a,
+	0xb3, 0x5a, 0xb0, 0x37, 0x70, 0xc3, 0x8f, 0x56, 0x2b, 0xb1, 0x42, 0x68, 0x25, 0xd8, 0x22, 0x53,
+	0x71, 0x51, 0xa9, 0x8a, 0x1c, 0xe7, 0x24, 0x18, 0x62, 0x8f, 0x3b, 0x33, 0x0e, 0x20, 0xf5, 0x69,
+	0xfa, 0x06, 0xed, 0x03, 0xb4, 0x0f, 0xd1, 0x27, 0xe9, 0x1b, 0x54, 0x99, 0x99, 0x24, 0x76, 0x88,
+	0xab, 0xe4, 0xa6, 0xbd, 0x9b, 0x73, 0x7c, 0x7e, 0xbe, 0xf3, 0x33, 0xdf, 0x18, 0xcc, 0xf8, 0xbe,
+	0xe7, 0xc4, 0xfd, 0xa4, 0x17, 0x44, 0xce, 0xa0, 0xa9, 0x4f, 0x76, 0xcc, 0xa8, 0xa0, 0x64, 0x2b,
+	0x44, 0xc1, 0x02, 0x9f, 0x33, 0x9a, 0x08, 0x64, 0xb6, 0xfe, 0x36, 0x68, 0x9a, 0xdb, 0x3d, 0x4a,
+	0x7b, 0x7d, 0x74, 0xa4, 0x59, 0x3b, 0xe9, 0x3a, 0x22, 0x08, 0x91, 0x0b, 0x2f, 0x8c, 0x95, 0xa7,
+	0xb5, 0x01, 0xe4, 0x22, 0xe0, 0xe2, 0x52, 0xf9, 0xbb, 0xf8, 0x26, 0x41, 0x2e, 0xac, 0x4f, 0x06,
+	0xac, 0x67, 0xd4, 0x3c, 0xa6, 0x11, 0x47, 0x72, 0x05, 0x6b, 0x7e, 0xc2, 0x05, 0x0d, 0x5b, 0x3a,
+	0x61, 0xdd, 0xd8, 0x29, 0x36, 0x2a, 0xfb, 0x7f, 0xd8, 0x39, 0x00, 0xec, 0x7f, 0xa5, 0xb9, 0x8a,
+	0xf3, 0x5f, 0xd4, 0xa5, 0xee, 0xaa, 0x9f, 0xd2, 0x70, 0x72, 0x03, 0x35, 0x7c, 0x14, 0xc8, 0x22,
+	0xaf, 0x3f, 0x8e, 0x59, 0x90, 0x31, 0xff, 0xcc, 0x8d, 0x79, 0xa6, 0x1d, 0x52, 0x51, 0xab, 0x98,
+	0xd1, 0x71, 0xeb, 0x2d, 0xd4, 0xa6, 0x53, 0x93, 0x0d, 0x58, 0xea, 0x31, 0x9a, 0xc4, 0x75, 0x63,
+	0xc7, 0x68, 0x94, 0x5d, 0x25, 0x10, 0x13, 0x56, 0x18, 0x72, 0x9a, 0x30, 0x1f, 0xeb, 0x05, 0xf9,
+	0x61, 0x2c, 0x93, 0x5f, 0x00, 0x22, 0x2f, 0x44, 0x1e, 0x7b, 0x3e, 0x76, 0xea, 0xc5, 0x1d, 0xa3,
+	0xb1, 0xe2, 0xa6, 0x34, 0x64, 0x13, 0x96, 0x15, 0xc8, 0x7a, 0x49, 0x7a, 0x6a, 0xc9, 0xda, 0x05,
+	0xf2, 0x1c, 0x64, 0xca, 0xda, 0xc8, 0x58, 0x7f, 0x30, 0x60, 0xf3, 0x1c, 0x75, 0xb3, 0x4f, 0x9f,
+	0xfe, 0xf7, 0x42, 0xd4, 0x83, 0x20, 0x7f, 0x43, 0x29, 0x88, 0xba, 0x54, 0x3a, 0x2c, 0xd4, 0x66,
+	0xe9, 0x46, 0x7e, 0x86, 0xf2, 0x18, 0xad, 0x2e, 0x6e, 0xa2, 0x20, 0x04, 0x4a, 0x43, 0x41, 0xd6,
+	0x55, 0x76, 0xe5, 0x99, 0xfc, 0x0e, 0x55, 0x95, 0xa3, 0xc5, 0xb1, 0x8f, 0xbe, 0xa0, 0x4c, 0x97,
+	0xb6, 0xa6, 0xd4, 0xd7, 0x5a, 0x6b, 0x7d, 0x34, 0xc0, 0x4c, 0x81, 0x1e, 0xe9, 0xbf, 0x0b, 0x70,
+	0x13, 0x56, 0xc6, 0xe8, 0x14, 0xf8, 0xb1, 0x3c, 0x7f, 0x01, 0xef, 0x0c, 0xa8, 0x9f, 0xa3, 0xc8,
+	0xce, 0x69, 0x04, 0xff, 0x9f, 0x0c, 0xfc, 0x85, 0x56, 0x71, 0x9e, 0x02, 0x66, 0x80, 0x2c, 0xce,
+	0x04, 0xf9, 0x08, 0xd5, 0x17, 0xed, 0x3b, 0xf4, 0x85, 0x8b, 0x5d, 0x64, 0x18, 0xf9, 0x48, 0xb6,
+	0xa1, 0xe2, 0xc5, 0x41, 0x6b, 0x80, 0x8c, 0x07, 0x34, 0xd2, 0xab, 0x04, 0x5e, 0x1c, 0xdc, 0x28,
+	0xcd, 0x70, 0xac, 0xf7, 0x41, 0xd4, 0xd1, 0x59, 0xe5, 0x39, 0x0b, 0xa7, 0x98, 0xb7, 0x08, 0xa5,
+	0xc9, 0x22, 0x58, 0x5f, 0x0c, 0xa8, 0xa8, 0xaa, 0x6e, 0xbc, 0x7e, 0x82, 0xe4, 0x1a, 0x6a, 0x1d,
+	0xe4, 0x3e, 0x0b, 0xda, 0xd8, 0x69, 0x51, 0x89, 0x49, 0x77, 0xa7, 0x91, 0xdb, 0x9d, 0x29, 0xe8,
+	0x6e, 0x75, 0x1c, 0x41, 0x7d, 0x49, 0xdd, 0x88, 0x42, 0xfa, 0x46, 0x90, 0x43, 0x28, 0x8f, 0x89,
+	0x4a, 0xc2, 0xad, 0xec, 0x9b, 0xb6, 0xa2, 0x32, 0x7b, 0x44, 0x65, 0xf6, 0xcb, 0x91, 0x85, 0x3b,
+	0x31, 0x26, 0xbf, 0xc1, 0xda, 0x43, 0x10, 0x75, 0xe8, 0x43, 0x8b, 0xa3, 0x4f, 0xa3, 0x0e, 0x97,
+	0x45, 0x15, 0xdd, 0x55, 0xa5, 0xbd, 0x56, 0xca, 0x21, 0x15, 0x0c, 0x86, 0x65, 0xd5, 0x97, 0x14,
+	0x15, 0x48, 0xc1, 0xba, 0x84, 0x6a, 0xaa, 0xe4, 0x21, 0x01, 0x92, 0x63, 0x58, 0x0a, 0x04, 0x86,
+	0x23, 0xa2, 0xfb, 0x35, 0xb7, 0xd6, 0x94, 0xa3, 0xab, 0x5c, 0xac, 0xf7, 0x05, 0x58, 0xcf, 0x2e,
+	0x88, 0x6a, 0x65, 0x0e, 0x0f, 0x90, 0x2b, 0x58, 0xee, 0x7b, 0x6d, 0xec, 0x8f, 0x18, 0xf0, 0x70,
+	0xce, 0xb5, 0x93, 0x51, 0xed, 0x0b, 0xe9, 0x7a, 0x16, 0x09, 0xf6, 0xe4, 0xea, 0x38, 0x3f, 0xa8,
+	0x8f, 0xe6, 0x11, 0x54, 0x52, 0x68, 0x48, 0x0d, 0x8a, 0xf7, 0xf8, 0xa4, 0x8b, 0x1d, 0x1e, 0x27,
+	0x6e, 0x85, 0x94, 0xdb, 0x71, 0xe1, 0xd0, 0xb0, 0x5e, 0xc3, 0xd6, 0x8c, 0xe2, 0xe4, 0x28, 0x4e,
+	0xb3, 0xa3, 0xd8, 0x5d, 0xa4, 0x3b, 0x7a, 0x24, 0xfb, 0x9f, 0x8b, 0xb0, 0xaa, 0xd4, 0xfc, 0x4a,
+	0xda, 0x93, 0x5b, 0xa8, 0xa4, 0x5e, 0x3a, 0x92, 0x7f, 0xd5, 0x9f, 0x3f, 0x93, 0xe6, 0xee, 0x7c,
+	0xc6, 0xfa, 0xf1, 0xbc, 0x85, 0xea, 0x14, 0xcb, 0x13, 0x27, 0x37, 0xc0, 0xec, 0xf7, 0xc0, 0x9c,
+	0x6b, 0xff, 0x88, 0x80, 0xf5, 0x19, 0xd4, 0x4c, 0x0e, 0xe6, 0xc9, 0x36, 0x45, 0xe4, 0x66, 0x63,
+	0x9e, 0x8c, 0x72, 0x3e, 0x8f, 0xf0, 0xd3, 0x33, 0x3e, 0x25, 0xcd, 0x6f, 0xe5, 0x9c, 0xc9, 0xbd,
+	0xe6, 0x5f, 0x8b, 0x0c, 0x76, 0x98, 0xf9, 0xf4, 0xe4, 0xd5, 0x51, 0x2f, 0x10, 0xb7, 0x49, 0xdb,
+	0xf6, 0x69, 0xe8, 0x78, 0xec, 0x2e, 0x89, 0x58, 0xe4, 0xa8, 0x1f, 0x8d, 0x3d, 0x1d, 0x6c, 0x4f,
+	0x45, 0x73, 0x32, 0x7f, 0x51, 0x27, 0x83, 0x66, 0x7b, 0x59, 0x5e, 0x84, 0x83, 0xaf, 0x03, 0x00,
+	0x1f, 0xe4, 0xfe, 0x1a, 0x60, 0x09, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// MetricsPluginClient is the client API for MetricsPlugin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type MetricsPluginClient interface {
+	// ListMetrics returns the metrics which the plugin serves.
+	ListMetrics(ctx context.Context, in *ListMetricsRequest, opts ...grpc.CallOption) (*ListMetricsResponse, error)
+	GetMetricByName(ctx context.Context, in *GetMetricByNameRequest, opts ...grpc.CallOption) (*MetricValue, error)
+	GetMetricBySelector(ctx context.Context, in *GetMetricBySelectorRequest, opts ...grpc.CallOption) (*MetricValueList, error)
+	GetExternalMetric(ctx context.Context, in *GetExternalMetricRequest, opts ...grpc.CallOption) (*ExternalMetricValueList, error)
+}
+
+type metricsPluginClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewMetricsPluginClient(cc *grpc.ClientConn) MetricsPluginClient {
+	return &metricsPluginClient{cc}
+}
+
+func (c *metricsPluginClient) ListMetrics(ctx context.Context, in *ListMetricsRequest, opts ...grpc.CallOption) (*ListMetricsResponse, error) {
+	out := new(ListMetricsResponse)
+	err := c.cc.Invoke(ctx, "/metricsrouter.plugin.v1.MetricsPlugin/ListMetrics", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metricsPluginClient) GetMetricByName(ctx context.Context, in *GetMetricByNameRequest, opts ...grpc.CallOption) (*MetricValue, error) {
+	out := new(MetricValue)
+	err := c.cc.Invoke(ctx, "/metricsrouter.plugin.v1.MetricsPlugin/GetMetricByName", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metricsPluginClient) GetMetricBySelector(ctx context.Context, in *GetMetricBySelectorRequest, opts ...grpc.CallOption) (*MetricValueList, error) {
+	out := new(MetricValueList)
+	err := c.cc.Invoke(ctx, "/metricsrouter.plugin.v1.MetricsPlugin/GetMetricBySelector", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metricsPluginClient) GetExternalMetric(ctx context.Context, in *GetExternalMetricRequest, opts ...grpc.CallOption) (*ExternalMetricValueList, error) {
+	out := new(ExternalMetricValueList)
+	err := c.cc.Invoke(ctx, "/metricsrouter.plugin.v1.MetricsPlugin/GetExternalMetric", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MetricsPluginServer is the server API for MetricsPlugin service.
+type MetricsPluginServer interface {
+	// ListMetrics returns the metrics which the plugin serves.
+	ListMetrics(context.Context, *ListMetricsRequest) (*ListMetricsResponse, error)
+	GetMetricByName(context.Context, *GetMetricByNameRequest) (*MetricValue, error)
+	GetMetricBySelector(context.Context, *GetMetricBySelectorRequest) (*MetricValueList, error)
+	GetExternalMetric(context.Context, *GetExternalMetricRequest) (*ExternalMetricValueList, error)
+}
+
+// UnimplementedMetricsPluginServer can be embedded to have forward compatible implementations.
+type UnimplementedMetricsPluginServer struct {
+}
+
+func (*UnimplementedMetricsPluginServer) ListMetrics(ctx context.Context, req *ListMetricsRequest) (*ListMetricsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListMetrics not implemented")
+}
+func (*UnimplementedMetricsPluginServer) GetMetricByName(ctx context.Context, req *GetMetricByNameRequest) (*MetricValue, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMetricByName not implemented")
+}
+func (*UnimplementedMetricsPluginServer) GetMetricBySelector(ctx context.Context, req *GetMetricBySelectorRequest) (*MetricValueList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMetricBySelector not implemented")
+}
+func (*UnimplementedMetricsPluginServer) GetExternalMetric(ctx context.Context, req *GetExternalMetricRequest) (*ExternalMetricValueList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetExternalMetric not implemented")
+}
+
+func RegisterMetricsPluginServer(s *grpc.Server, srv MetricsPluginServer) {
+	s.RegisterService(&_MetricsPlugin_serviceDesc, srv)
+}
+
+func _MetricsPlugin_ListMetrics_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListMetricsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetricsPluginServer).ListMetrics(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/metricsrouter.plugin.v1.MetricsPlugin/ListMetrics",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetricsPluginServer).ListMetrics(ctx, req.(*ListMetricsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetricsPlugin_GetMetricByName_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetMetricByNameRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetricsPluginServer).GetMetricByName(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/metricsrouter.plugin.v1.MetricsPlugin/GetMetricByName",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetricsPluginServer).GetMetricByName(ctx, req.(*GetMetricByNameRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetricsPlugin_GetMetricBySelector_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetMetricBySelectorRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetricsPluginServer).GetMetricBySelector(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/metricsrouter.plugin.v1.MetricsPlugin/GetMetricBySelector",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetricsPluginServer).GetMetricBySelector(ctx, req.(*GetMetricBySelectorRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetricsPlugin_GetExternalMetric_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetExternalMetricRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetricsPluginServer).GetExternalMetric(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/metricsrouter.plugin.v1.MetricsPlugin/GetExternalMetric",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetricsPluginServer).GetExternalMetric(ctx, req.(*GetExternalMetricRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _MetricsPlugin_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "metricsrouter.plugin.v1.MetricsPlugin",
+	HandlerType: (*MetricsPluginServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListMetrics",
+			Handler:    _MetricsPlugin_ListMetrics_Handler,
+		},
+		{
+			MethodName: "GetMetricByName",
+			Handler:    _MetricsPlugin_GetMetricByName_Handler,
+		},
+		{
+			MethodName: "GetMetricBySelector",
+			Handler:    _MetricsPlugin_GetMetricBySelector_Handler,
+		},
+		{
+			MethodName: "GetExternalMetric",
+			Handler:    _MetricsPlugin_GetExternalMetric_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "pkg/plugin/v1/plugin.proto",
+}
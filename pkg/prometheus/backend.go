@@ -0,0 +1,55 @@
+package prometheus
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	"k8s.io/client-go/rest"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+	"github.com/arjunrn/custom-metrics-router/pkg/backend"
+	"github.com/arjunrn/custom-metrics-router/pkg/metricsclient"
+)
+
+var _ backend.Backend = &Client{}
+
+func init() {
+	backend.Register(v1beta1.PrometheusBackendType, newBackend)
+}
+
+// newBackend returns a client which queries the Prometheus API of the service
+// of a source with its TLS config and credentials.
+func newBackend(source *v1beta1.CustomMetricsSource, deps backend.Dependencies) (backend.Backend, error) {
+	spec := &source.Spec.Backend
+	if spec.Prometheus == nil {
+		return nil, fmt.Errorf("custom metrics source %s of type %s has no prometheus backend", source.Name, v1beta1.PrometheusBackendType)
+	}
+	options, err := metricsclient.SourceOptions(deps.KubeClient, source)
+	if err != nil {
+		return nil, err
+	}
+	host := fmt.Sprintf("%s.%s", options.Name, options.Namespace)
+	port := strconv.Itoa(int(options.Port))
+	config, err := metricsclient.InClusterConfig(host, port, options.InsecureSkipTLSVerify, options.CABundle, options.Authenticator, options.RequesterForwarding)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate rest config for %s: %v", host, err)
+	}
+	transport, err := rest.TransportFor(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transport: %v", err)
+	}
+	scheme := string(spec.Prometheus.Scheme)
+	if scheme == "" {
+		scheme = v1beta1.HTTPScheme
+	}
+	var objects ObjectLister
+	if deps.KubeClient != nil {
+		objects = NewObjectLister(deps.KubeClient.Discovery().RESTClient())
+	}
+	client, err := NewClient(source.Name, scheme+"://"+net.JoinHostPort(host, port)+spec.Prometheus.PathPrefix, transport, spec.Prometheus, deps.Mapper, objects)
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
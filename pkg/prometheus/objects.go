@@ -0,0 +1,55 @@
+package prometheus
+
+import (
+	"context"
+	"encoding/json"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+)
+
+// ObjectLister lists the names of the objects which a label selector selects.
+type ObjectLister interface {
+	ListNames(resource schema.GroupVersionResource, namespace string, selector labels.Selector) ([]string, error)
+}
+
+type objectLister struct {
+	client rest.Interface
+}
+
+// NewObjectLister returns a lister which lists the metadata of any resource
+// with a client for the root of the API server, e.g. the discovery client.
+func NewObjectLister(client rest.Interface) ObjectLister {
+	return &objectLister{client: client}
+}
+
+func (l *objectLister) ListNames(resource schema.GroupVersionResource, namespace string, selector labels.Selector) ([]string, error) {
+	path := []string{"/apis", resource.Group, resource.Version}
+	if resource.Group == "" {
+		path = []string{"/api", resource.Version}
+	}
+	if namespace != "" {
+		path = append(path, "namespaces", namespace)
+	}
+	path = append(path, resource.Resource)
+	data, err := l.client.Get().
+		AbsPath(path...).
+		Param("labelSelector", selector.String()).
+		SetHeader("Accept", "application/json;as=PartialObjectMetadataList;v=v1;g=meta.k8s.io,application/json").
+		Do(context.TODO()).
+		Raw()
+	if err != nil {
+		return nil, err
+	}
+	var list metav1.PartialObjectMetadataList
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+	names := make([]string, len(list.Items))
+	for i, item := range list.Items {
+		names[i] = item.Name
+	}
+	return names, nil
+}
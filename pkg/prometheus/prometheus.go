@@ -0,0 +1,515 @@
+package prometheus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/kubernetes-sigs/custom-metrics-apiserver/pkg/provider"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/klog"
+	"k8s.io/metrics/pkg/apis/custom_metrics"
+	"k8s.io/metrics/pkg/apis/external_metrics"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+)
+
+const requestTimeout = 10 * time.Second
+
+// QueryData is passed to the query templates of the metrics.
+type QueryData struct {
+	// Namespace is the namespace of the request. It is escaped for a double
+	// quoted PromQL string, e.g. namespace="{{.Namespace}}".
+	Namespace string
+	// Names is a regular expression which matches the names of the requested
+	// objects. It is escaped for a double quoted PromQL string, e.g.
+	// pod=~"{{.Names}}".
+	Names string
+	// LabelMatchers is the metric selector of the request as comma separated
+	// PromQL label matchers, e.g. `job="api",env!="dev"`.
+	LabelMatchers string
+}
+
+type customMetric struct {
+	v1beta1.PrometheusCustomMetric
+	groupResource schema.GroupResource
+	query         *template.Template
+}
+
+type externalMetric struct {
+	v1beta1.PrometheusExternalMetric
+	query *template.Template
+}
+
+// Client serves the metrics of a source by evaluating their PromQL queries
+// with the HTTP API of Prometheus.
+type Client struct {
+	source          string
+	url             string
+	httpClient      *http.Client
+	mapper          meta.RESTMapper
+	objects         ObjectLister
+	customMetrics   []customMetric
+	externalMetrics map[string]externalMetric
+}
+
+// NewClient returns a client for the Prometheus API at baseURL. The objects
+// are listed for metric requests with a label selector.
+func NewClient(source, baseURL string, transport http.RoundTripper, backend *v1beta1.PrometheusBackend, mapper meta.RESTMapper, objects ObjectLister) (*Client, error) {
+	client := &Client{
+		source:          source,
+		url:             strings.TrimSuffix(baseURL, "/"),
+		httpClient:      &http.Client{Transport: transport, Timeout: requestTimeout},
+		mapper:          mapper,
+		objects:         objects,
+		externalMetrics: make(map[string]externalMetric),
+	}
+	for _, metric := range backend.CustomMetrics {
+		query, err := ParseQuery(metric.Query)
+		if err != nil {
+			return nil, fmt.Errorf("invalid query of custom metric %s: %v", metric.Name, err)
+		}
+		client.customMetrics = append(client.customMetrics, customMetric{
+			PrometheusCustomMetric: metric,
+			groupResource:          schema.ParseGroupResource(metric.Resource),
+			query:                  query,
+		})
+	}
+	for _, metric := range backend.ExternalMetrics {
+		query, err := ParseQuery(metric.Query)
+		if err != nil {
+			return nil, fmt.Errorf("invalid query of external metric %s: %v", metric.Name, err)
+		}
+		client.externalMetrics[metric.Name] = externalMetric{PrometheusExternalMetric: metric, query: query}
+	}
+	return client, nil
+}
+
+// ParseQuery parses the template of a query.
+func ParseQuery(query string) (*template.Template, error) {
+	return template.New("query").Option("missingkey=error").Parse(query)
+}
+
+// Source returns the name of the source the client was created for.
+func (c *Client) Source() string {
+	return c.source
+}
+
+// ListCustomMetricInfos returns the custom metrics of the source. Their
+// resources are resolved like the resources of discovered metrics.
+func (c *Client) ListCustomMetricInfos() (map[provider.CustomMetricInfo]struct{}, error) {
+	infos := make(map[provider.CustomMetricInfo]struct{}, len(c.customMetrics))
+	for _, metric := range c.customMetrics {
+		groupResource, err := c.resolve(metric.groupResource)
+		if err != nil {
+			return nil, err
+		}
+		infos[provider.CustomMetricInfo{GroupResource: groupResource, Namespaced: metric.Namespaced, Metric: metric.Name}] = struct{}{}
+	}
+	return infos, nil
+}
+
+func (c *Client) ListExternalMetrics() (map[provider.ExternalMetricInfo]struct{}, error) {
+	infos := make(map[provider.ExternalMetricInfo]struct{}, len(c.externalMetrics))
+	for name := range c.externalMetrics {
+		infos[provider.ExternalMetricInfo{Metric: name}] = struct{}{}
+	}
+	return infos, nil
+}
+
+func (c *Client) GetMetricByName(name types.NamespacedName, info provider.CustomMetricInfo, metricSelector labels.Selector) (*custom_metrics.MetricValue, error) {
+	values, err := c.queryObjects(name.Namespace, []string{name.Name}, info, metricSelector)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, apierrors.NewNotFound(info.GroupResource, name.Name)
+	}
+	return &values[0], nil
+}
+
+func (c *Client) GetMetricBySelector(namespace string, selector labels.Selector, info provider.CustomMetricInfo, metricSelector labels.Selector) (*custom_metrics.MetricValueList, error) {
+	// the objects are only listed when the selector restricts them.
+	var names []string
+	if !selector.Empty() {
+		if c.objects == nil {
+			return nil, fmt.Errorf("label selectors aren't supported without an object lister")
+		}
+		resource, err := c.versionedResource(info.GroupResource)
+		if err != nil {
+			return nil, err
+		}
+		names, err = c.objects.ListNames(resource, namespace, selector)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %v", info.GroupResource, err)
+		}
+		if len(names) == 0 {
+			return &custom_metrics.MetricValueList{}, nil
+		}
+	}
+	values, err := c.queryObjects(namespace, names, info, metricSelector)
+	if err != nil {
+		return nil, err
+	}
+	return &custom_metrics.MetricValueList{Items: values}, nil
+}
+
+// queryObjects evaluates the query of a custom metric for the objects with
+// the given names, or for all objects when names is nil.
+func (c *Client) queryObjects(namespace string, names []string, info provider.CustomMetricInfo, metricSelector labels.Selector) ([]custom_metrics.MetricValue, error) {
+	metric, err := c.customMetric(info)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateNamespace(namespace); err != nil {
+		return nil, err
+	}
+	matchers, err := LabelMatchers(metricSelector)
+	if err != nil {
+		return nil, apierrors.NewBadRequest(err.Error())
+	}
+	data := QueryData{Namespace: escape(namespace), Names: ".*", LabelMatchers: matchers}
+	if names != nil {
+		quoted := make([]string, len(names))
+		for i, name := range names {
+			if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+				return nil, apierrors.NewBadRequest(fmt.Sprintf("invalid name %q: %s", name, strings.Join(errs, ", ")))
+			}
+			quoted[i] = regexp.QuoteMeta(name)
+		}
+		data.Names = escape(strings.Join(quoted, "|"))
+	}
+	samples, err := c.query(metric.query, data)
+	if err != nil {
+		return nil, err
+	}
+
+	objectLabel := metric.ObjectLabel
+	if objectLabel == "" {
+		objectLabel, err = c.singular(info.GroupResource)
+		if err != nil {
+			return nil, err
+		}
+	}
+	kind, err := c.kind(info.GroupResource)
+	if err != nil {
+		return nil, err
+	}
+	var identifier custom_metrics.MetricIdentifier
+	identifier.Name = info.Metric
+	if !metricSelector.Empty() {
+		identifier.Selector, err = metav1.ParseToLabelSelector(metricSelector.String())
+		if err != nil {
+			return nil, apierrors.NewBadRequest(err.Error())
+		}
+	}
+	wanted := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		wanted[name] = struct{}{}
+	}
+	var values []custom_metrics.MetricValue
+	for _, s := range samples {
+		name := s.Metric[objectLabel]
+		if _, ok := wanted[name]; name == "" || (names != nil && !ok) {
+			continue
+		}
+		value, ok := quantity(s.Value)
+		if !ok {
+			continue
+		}
+		object := custom_metrics.ObjectReference{Kind: kind.Kind, APIVersion: kind.GroupVersion().String(), Name: name}
+		if info.Namespaced {
+			object.Namespace = namespace
+		}
+		values = append(values, custom_metrics.MetricValue{
+			DescribedObject: object,
+			Metric:          identifier,
+			Timestamp:       s.Value.timestamp,
+			Value:           *value,
+		})
+	}
+	return values, nil
+}
+
+func (c *Client) GetExternalMetric(name, namespace string, selector labels.Selector) (*external_metrics.ExternalMetricValueList, error) {
+	metric, ok := c.externalMetrics[name]
+	if !ok {
+		return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "externalmetrics"}, name)
+	}
+	if err := validateNamespace(namespace); err != nil {
+		return nil, err
+	}
+	matchers, err := LabelMatchers(selector)
+	if err != nil {
+		return nil, apierrors.NewBadRequest(err.Error())
+	}
+	samples, err := c.query(metric.query, QueryData{Namespace: escape(namespace), LabelMatchers: matchers})
+	if err != nil {
+		return nil, err
+	}
+	list := &external_metrics.ExternalMetricValueList{}
+	for _, s := range samples {
+		value, ok := quantity(s.Value)
+		if !ok {
+			continue
+		}
+		metricLabels := make(map[string]string, len(s.Metric))
+		for label, value := range s.Metric {
+			if label != "__name__" {
+				metricLabels[label] = value
+			}
+		}
+		list.Items = append(list.Items, external_metrics.ExternalMetricValue{
+			MetricName:   name,
+			MetricLabels: metricLabels,
+			Timestamp:    s.Value.timestamp,
+			Value:        *value,
+		})
+	}
+	return list, nil
+}
+
+func (c *Client) customMetric(info provider.CustomMetricInfo) (*customMetric, error) {
+	for i := range c.customMetrics {
+		metric := &c.customMetrics[i]
+		if metric.Name != info.Metric || metric.Namespaced != info.Namespaced {
+			continue
+		}
+		groupResource, err := c.resolve(metric.groupResource)
+		if err != nil {
+			return nil, err
+		}
+		if groupResource == info.GroupResource {
+			return metric, nil
+		}
+	}
+	return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "custommetrics"}, info.Metric)
+}
+
+// query evaluates an instant query and returns its results. A scalar result
+// is returned as a single sample without labels.
+func (c *Client) query(query *template.Template, data QueryData) ([]sample, error) {
+	var promQL bytes.Buffer
+	if err := query.Execute(&promQL, data); err != nil {
+		return nil, fmt.Errorf("failed to execute query template: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	request, err := http.NewRequest(http.MethodGet, c.url+"/api/v1/query?"+url.Values{"query": {promQL.String()}}.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := c.httpClient.Do(request.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query prometheus: %v", err)
+	}
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prometheus response: %v", err)
+	}
+	var result queryResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("invalid prometheus response with status %d: %v", response.StatusCode, err)
+	}
+	if result.Status != "success" {
+		return nil, fmt.Errorf("prometheus query %q failed: %s: %s", promQL.String(), result.ErrorType, result.Error)
+	}
+	klog.V(4).Infof("Prometheus query %q returned a %s", promQL.String(), result.Data.ResultType)
+
+	switch result.Data.ResultType {
+	case "vector":
+		var samples []sample
+		if err := json.Unmarshal(result.Data.Result, &samples); err != nil {
+			return nil, fmt.Errorf("invalid prometheus vector: %v", err)
+		}
+		return samples, nil
+	case "scalar":
+		var value samplePair
+		if err := json.Unmarshal(result.Data.Result, &value); err != nil {
+			return nil, fmt.Errorf("invalid prometheus scalar: %v", err)
+		}
+		return []sample{{Value: value}}, nil
+	default:
+		return nil, fmt.Errorf("prometheus query %q returned a %s instead of a vector", promQL.String(), result.Data.ResultType)
+	}
+}
+
+func (c *Client) resolve(groupResource schema.GroupResource) (schema.GroupResource, error) {
+	if c.mapper == nil {
+		return groupResource, nil
+	}
+	resource, err := c.mapper.ResourceFor(groupResource.WithVersion(""))
+	if err != nil {
+		return schema.GroupResource{}, fmt.Errorf("failed to resolve resource %s: %v", groupResource, err)
+	}
+	return resource.GroupResource(), nil
+}
+
+func (c *Client) versionedResource(groupResource schema.GroupResource) (schema.GroupVersionResource, error) {
+	if c.mapper == nil {
+		return schema.GroupVersionResource{}, fmt.Errorf("failed to resolve resource %s without a mapper", groupResource)
+	}
+	resource, err := c.mapper.ResourceFor(groupResource.WithVersion(""))
+	if err != nil {
+		return schema.GroupVersionResource{}, fmt.Errorf("failed to resolve resource %s: %v", groupResource, err)
+	}
+	return resource, nil
+}
+
+func (c *Client) singular(groupResource schema.GroupResource) (string, error) {
+	if c.mapper == nil {
+		return strings.TrimSuffix(groupResource.Resource, "s"), nil
+	}
+	singular, err := c.mapper.ResourceSingularizer(groupResource.Resource)
+	if err != nil {
+		return "", fmt.Errorf("failed to singularize %s: %v", groupResource.Resource, err)
+	}
+	return singular, nil
+}
+
+func (c *Client) kind(groupResource schema.GroupResource) (schema.GroupVersionKind, error) {
+	if c.mapper == nil {
+		return schema.GroupVersionKind{Group: groupResource.Group, Kind: groupResource.Resource}, nil
+	}
+	kind, err := c.mapper.KindFor(groupResource.WithVersion(""))
+	if err != nil {
+		return schema.GroupVersionKind{}, fmt.Errorf("failed to get the kind of %s: %v", groupResource, err)
+	}
+	return kind, nil
+}
+
+// LabelMatchers returns a selector as comma separated PromQL label matchers.
+func LabelMatchers(selector labels.Selector) (string, error) {
+	if selector == nil {
+		return "", nil
+	}
+	requirements, selectable := selector.Requirements()
+	if !selectable {
+		return "", fmt.Errorf("metric selector %q selects nothing", selector)
+	}
+	matchers := make([]string, 0, len(requirements))
+	for _, requirement := range requirements {
+		values := requirement.Values().List()
+		var matcher string
+		switch requirement.Operator() {
+		case selection.Equals, selection.DoubleEquals:
+			matcher = "=" + strconv.Quote(values[0])
+		case selection.NotEquals:
+			matcher = "!=" + strconv.Quote(values[0])
+		case selection.In:
+			matcher = "=~" + strconv.Quote(quoteValues(values))
+		case selection.NotIn:
+			matcher = "!~" + strconv.Quote(quoteValues(values))
+		case selection.Exists:
+			matcher = `!=""`
+		case selection.DoesNotExist:
+			matcher = `=""`
+		default:
+			return "", fmt.Errorf("operator %s of metric selector %q isn't supported", requirement.Operator(), selector)
+		}
+		matchers = append(matchers, requirement.Key()+matcher)
+	}
+	return strings.Join(matchers, ","), nil
+}
+
+// validateNamespace rejects namespaces which can't exist, so that they can't
+// change the queries.
+func validateNamespace(namespace string) error {
+	if namespace == "" {
+		return nil
+	}
+	if errs := validation.IsDNS1123Label(namespace); len(errs) > 0 {
+		return apierrors.NewBadRequest(fmt.Sprintf("invalid namespace %q: %s", namespace, strings.Join(errs, ", ")))
+	}
+	return nil
+}
+
+// escape returns a value escaped for a double quoted PromQL string, whose
+// escape sequences are the ones of Go.
+func escape(value string) string {
+	quoted := strconv.Quote(value)
+	return quoted[1 : len(quoted)-1]
+}
+
+func quoteValues(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = regexp.QuoteMeta(value)
+	}
+	return strings.Join(quoted, "|")
+}
+
+// quantity converts the value of a sample. NaN and infinite values can't be
+// represented and are skipped.
+func quantity(pair samplePair) (*resource.Quantity, bool) {
+	if math.IsNaN(pair.value) || math.IsInf(pair.value, 0) {
+		return nil, false
+	}
+	return resource.NewMilliQuantity(int64(math.Round(pair.value*1000)), resource.DecimalSI), true
+}
+
+type queryResponse struct {
+	Status    string `json:"status"`
+	ErrorType string `json:"errorType"`
+	Error     string `json:"error"`
+	Data      struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+type sample struct {
+	Metric map[string]string `json:"metric"`
+	Value  samplePair        `json:"value"`
+}
+
+// samplePair is the [<unix time>, "<value>"] array of the Prometheus API.
+type samplePair struct {
+	timestamp metav1.Time
+	value     float64
+}
+
+func (p *samplePair) UnmarshalJSON(data []byte) error {
+	var pair []interface{}
+	if err := json.Unmarshal(data, &pair); err != nil {
+		return err
+	}
+	if len(pair) != 2 {
+		return fmt.Errorf("expected a timestamp and a value, got %s", data)
+	}
+	timestamp, ok := pair[0].(float64)
+	if !ok {
+		return fmt.Errorf("invalid timestamp %v", pair[0])
+	}
+	value, ok := pair[1].(string)
+	if !ok {
+		return fmt.Errorf("invalid value %v", pair[1])
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fmt.Errorf("invalid value %q: %v", value, err)
+	}
+	seconds, fraction := math.Modf(timestamp)
+	p.timestamp = metav1.NewTime(time.Unix(int64(seconds), int64(fraction*1e9)))
+	p.value = parsed
+	return nil
+}
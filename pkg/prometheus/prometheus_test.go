@@ -0,0 +1,171 @@
+package prometheus
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kubernetes-sigs/custom-metrics-apiserver/pkg/provider"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+)
+
+type fakeObjectLister map[string][]string
+
+func (l fakeObjectLister) ListNames(resource schema.GroupVersionResource, namespace string, selector labels.Selector) ([]string, error) {
+	return l[selector.String()], nil
+}
+
+// testPrometheus answers instant queries with the results in responses.
+func testPrometheus(t *testing.T, responses map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/prometheus/api/v1/query", r.URL.Path)
+		result, ok := responses[r.URL.Query().Get("query")]
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"status":"error","errorType":"bad_data","error":"unexpected query"}`)
+			return
+		}
+		fmt.Fprintf(w, `{"status":"success","data":%s}`, result)
+	}))
+}
+
+func TestClient(t *testing.T) {
+	server := testPrometheus(t, map[string]string{
+		`sum(rate(http_requests_total{namespace="apps",pod=~"web-1"}[2m])) by (pod)`: `{"resultType":"vector","result":[
+			{"metric":{"pod":"web-1"},"value":[1600000000.5,"2.5"]}]}`,
+		`sum(rate(http_requests_total{namespace="apps",pod=~".*"}[2m])) by (pod)`: `{"resultType":"vector","result":[
+			{"metric":{"pod":"web-1"},"value":[1600000000,"2.5"]},
+			{"metric":{"pod":"web-2"},"value":[1600000000,"NaN"]},
+			{"metric":{"pod":"api-1"},"value":[1600000000,"1"]}]}`,
+		`sum(rate(http_requests_total{namespace="apps",pod=~"web-1|web-2"}[2m])) by (pod)`: `{"resultType":"vector","result":[
+			{"metric":{"pod":"web-1"},"value":[1600000000,"2.5"]},
+			{"metric":{"pod":"web-3"},"value":[1600000000,"4"]}]}`,
+		`sum(rate(http_requests_total{namespace="apps",pod=~"web\\.1"}[2m])) by (pod)`: `{"resultType":"vector","result":[
+			{"metric":{"pod":"web.1"},"value":[1600000000,"3"]}]}`,
+		`queue_depth{ env!="dev",queue="orders" }`: `{"resultType":"vector","result":[
+			{"metric":{"__name__":"queue_depth","queue":"orders"},"value":[1600000000,"42"]}]}`,
+		`scalar(sum(queue_depth))`: `{"resultType":"scalar","result":[1600000000,"0.25"]}`,
+	})
+	defer server.Close()
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Pod"}, meta.RESTScopeNamespace)
+	client, err := NewClient("prometheus", server.URL+"/prometheus", http.DefaultTransport, &v1beta1.PrometheusBackend{
+		CustomMetrics: []v1beta1.PrometheusCustomMetric{{
+			Name:       "http_requests",
+			Resource:   "pods",
+			Namespaced: true,
+			Query:      `sum(rate(http_requests_total{namespace="{{.Namespace}}",pod=~"{{.Names}}"}[2m])) by (pod)`,
+		}},
+		ExternalMetrics: []v1beta1.PrometheusExternalMetric{
+			{Name: "queue_depth", Query: `queue_depth{ {{.LabelMatchers}} }`},
+			{Name: "total_queue_depth", Query: `scalar(sum(queue_depth))`},
+			{Name: "broken", Query: `broken`},
+		},
+	}, mapper, fakeObjectLister{"app=web": {"web-1", "web-2"}})
+	require.NoError(t, err)
+
+	requests := provider.CustomMetricInfo{GroupResource: schema.GroupResource{Resource: "pods"}, Namespaced: true, Metric: "http_requests"}
+	infos, err := client.ListCustomMetricInfos()
+	require.NoError(t, err)
+	require.Equal(t, map[provider.CustomMetricInfo]struct{}{requests: {}}, infos)
+	externalInfos, err := client.ListExternalMetrics()
+	require.NoError(t, err)
+	require.Len(t, externalInfos, 3)
+
+	value, err := client.GetMetricByName(types.NamespacedName{Namespace: "apps", Name: "web-1"}, requests, labels.Everything())
+	require.NoError(t, err)
+	require.Equal(t, "web-1", value.DescribedObject.Name)
+	require.Equal(t, "apps", value.DescribedObject.Namespace)
+	require.Equal(t, "Pod", value.DescribedObject.Kind)
+	require.Equal(t, "v1", value.DescribedObject.APIVersion)
+	require.Equal(t, "http_requests", value.Metric.Name)
+	require.Equal(t, int64(1600000000500), value.Timestamp.UnixNano()/1e6)
+	require.True(t, resource.MustParse("2500m").Equal(value.Value))
+
+	// names are escaped for the regular expression and the string.
+	value, err = client.GetMetricByName(types.NamespacedName{Namespace: "apps", Name: "web.1"}, requests, labels.Everything())
+	require.NoError(t, err)
+	require.Equal(t, "web.1", value.DescribedObject.Name)
+
+	// names and namespaces which can't exist are rejected before they are
+	// put into a query.
+	_, err = client.GetMetricByName(types.NamespacedName{Namespace: "apps", Name: `x"}) or vector(1) or (up{pod=~"`}, requests, labels.Everything())
+	require.True(t, apierrors.IsBadRequest(err), "%v", err)
+	_, err = client.GetMetricBySelector(`apps"}`, labels.Everything(), requests, labels.Everything())
+	require.True(t, apierrors.IsBadRequest(err), "%v", err)
+	_, err = client.GetExternalMetric("queue_depth", `apps"}`, labels.Everything())
+	require.True(t, apierrors.IsBadRequest(err), "%v", err)
+
+	// all objects are queried without a selector, and NaN values are skipped.
+	list, err := client.GetMetricBySelector("apps", labels.Everything(), requests, labels.Everything())
+	require.NoError(t, err)
+	require.Len(t, list.Items, 2)
+	require.Equal(t, "web-1", list.Items[0].DescribedObject.Name)
+	require.Equal(t, "api-1", list.Items[1].DescribedObject.Name)
+
+	// only the selected objects are returned.
+	list, err = client.GetMetricBySelector("apps", labels.SelectorFromSet(labels.Set{"app": "web"}), requests, labels.Everything())
+	require.NoError(t, err)
+	require.Len(t, list.Items, 1)
+	require.Equal(t, "web-1", list.Items[0].DescribedObject.Name)
+
+	list, err = client.GetMetricBySelector("apps", labels.SelectorFromSet(labels.Set{"app": "db"}), requests, labels.Everything())
+	require.NoError(t, err)
+	require.Empty(t, list.Items)
+
+	selector, err := labels.Parse("queue=orders,env!=dev")
+	require.NoError(t, err)
+	external, err := client.GetExternalMetric("queue_depth", "apps", selector)
+	require.NoError(t, err)
+	require.Len(t, external.Items, 1)
+	require.Equal(t, map[string]string{"queue": "orders"}, external.Items[0].MetricLabels)
+	require.True(t, resource.MustParse("42").Equal(external.Items[0].Value))
+
+	external, err = client.GetExternalMetric("total_queue_depth", "apps", labels.Everything())
+	require.NoError(t, err)
+	require.Len(t, external.Items, 1)
+	require.True(t, resource.MustParse("250m").Equal(external.Items[0].Value))
+
+	_, err = client.GetExternalMetric("broken", "apps", labels.Everything())
+	require.Error(t, err)
+	_, err = client.GetExternalMetric("unknown", "apps", labels.Everything())
+	require.Error(t, err)
+}
+
+func TestEscape(t *testing.T) {
+	require.Equal(t, `x\"}) or vector(1)`, escape(`x"}) or vector(1)`))
+	require.Equal(t, `web\\.1`, escape(`web\.1`))
+}
+
+func TestLabelMatchers(t *testing.T) {
+	for selector, expected := range map[string]string{
+		"":                     "",
+		"app=web":              `app="web"`,
+		"app!=web":             `app!="web"`,
+		"app in (api,web.v1)":  `app=~"api|web\\.v1"`,
+		"app notin (api)":      `app!~"api"`,
+		"app":                  `app!=""`,
+		"!app":                 `app=""`,
+		"app=web,env in (a,b)": `app="web",env=~"a|b"`,
+	} {
+		parsed, err := labels.Parse(selector)
+		require.NoError(t, err)
+		matchers, err := LabelMatchers(parsed)
+		require.NoError(t, err)
+		require.Equal(t, expected, matchers, selector)
+	}
+	parsed, err := labels.Parse("replicas>1")
+	require.NoError(t, err)
+	_, err = LabelMatchers(parsed)
+	require.Error(t, err)
+}
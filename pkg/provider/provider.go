@@ -1,60 +1,196 @@
 package provider
 
 import (
+	"context"
+	"errors"
 	"fmt"
 
 	"github.com/kubernetes-sigs/custom-metrics-apiserver/pkg/provider"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/klog"
 	"k8s.io/metrics/pkg/apis/custom_metrics"
 	"k8s.io/metrics/pkg/apis/external_metrics"
+	"k8s.io/metrics/pkg/apis/metrics"
 
+	"github.com/arjunrn/custom-metrics-router/pkg/authorization"
+	"github.com/arjunrn/custom-metrics-router/pkg/backend"
 	"github.com/arjunrn/custom-metrics-router/pkg/routes"
 )
 
+// ResourceMetricsProvider serves the nodes and pods of metrics.k8s.io.
+type ResourceMetricsProvider interface {
+	GetNodeMetrics(name string) (*metrics.NodeMetrics, error)
+	ListNodeMetrics(options metav1.ListOptions) (*metrics.NodeMetricsList, error)
+	GetPodMetrics(namespace, name string) (*metrics.PodMetrics, error)
+	ListPodMetrics(namespace string, options metav1.ListOptions) (*metrics.PodMetricsList, error)
+}
+
 type FullMetricsProvider interface {
 	provider.CustomMetricsProvider
 	provider.ExternalMetricsProvider
+	ResourceMetricsProvider
+	// ForRequest returns a provider which serves the request in ctx.
+	ForRequest(ctx context.Context) FullMetricsProvider
 }
 
 type routedMetricsProvider struct {
 	customMetricRoutes *routes.Routes
+	authorizer         authorization.Authorizer
+	requester          user.Info
 }
 
-func NewRoutedProvider(customMetricRoutes *routes.Routes) FullMetricsProvider {
+func NewRoutedProvider(customMetricRoutes *routes.Routes, authorizer authorization.Authorizer) FullMetricsProvider {
 	return &routedMetricsProvider{
 		customMetricRoutes: customMetricRoutes,
+		authorizer:         authorizer,
+	}
+}
+
+func (r routedMetricsProvider) ForRequest(ctx context.Context) FullMetricsProvider {
+	r.requester, _ = request.UserFrom(ctx)
+	return r
+}
+
+// forRequester checks that the requester may read metric in namespace from
+// metricsBackend and returns the backend which should serve the request.
+func (r routedMetricsProvider) forRequester(metricsBackend backend.Backend, namespace, metric string) (backend.Backend, error) {
+	allowed, reason, err := r.authorizer.Authorize(authorization.Attributes{
+		User:      r.requester,
+		Namespace: namespace,
+		Source:    metricsBackend.Source(),
+		Metric:    metric,
+	})
+	if err != nil {
+		return nil, apierrors.NewInternalError(err)
+	}
+	if !allowed {
+		resource := schema.GroupResource{Group: authorization.MetricsGroup, Resource: metricsBackend.Source()}
+		return nil, apierrors.NewForbidden(resource, metric, errors.New(reason))
 	}
+	if forwarder, ok := metricsBackend.(backend.RequesterForwarder); ok {
+		return forwarder.WithRequester(r.requester)
+	}
+	return metricsBackend, nil
 }
 
 func (r routedMetricsProvider) GetMetricByName(name types.NamespacedName, info provider.CustomMetricInfo, metricSelector labels.Selector) (*custom_metrics.MetricValue, error) {
-	backend, err := r.customMetricRoutes.GetMetricsBackend(info)
+	metricsBackend, err := r.customMetricRoutes.GetMetricsBackend(info, name.Namespace)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get metrics backend: %v", err)
 	}
-	return backend.GetMetricByName(name, info, metricSelector)
+	metricsBackend, err = r.forRequester(metricsBackend, name.Namespace, info.Metric)
+	if err != nil {
+		return nil, err
+	}
+	return metricsBackend.GetMetricByName(name, info, metricSelector)
 }
 
 func (r routedMetricsProvider) GetMetricBySelector(namespace string, selector labels.Selector, info provider.CustomMetricInfo, metricSelector labels.Selector) (*custom_metrics.MetricValueList, error) {
-	backend, err := r.customMetricRoutes.GetMetricsBackend(info)
+	metricsBackend, err := r.customMetricRoutes.GetMetricsBackend(info, namespace)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get backend: %v", err)
 	}
-	return backend.GetMetricBySelector(namespace, selector, info, metricSelector)
+	metricsBackend, err = r.forRequester(metricsBackend, namespace, info.Metric)
+	if err != nil {
+		return nil, err
+	}
+	return metricsBackend.GetMetricBySelector(namespace, selector, info, metricSelector)
 }
 
 func (r routedMetricsProvider) ListAllMetrics() []provider.CustomMetricInfo {
 	return r.customMetricRoutes.ListAllCustomMetrics()
 }
 
+// GetExternalMetric asks the backends for an external metric in order until
+// one of them has values for namespace, so that a source without samples for
+// the namespace, e.g. the pushed metrics, doesn't hide the others.
 func (r routedMetricsProvider) GetExternalMetric(namespace string, metricSelector labels.Selector, info provider.ExternalMetricInfo) (*external_metrics.ExternalMetricValueList, error) {
-	backend, err := r.customMetricRoutes.GetExternalMetricsBackend(info)
+	backends, err := r.customMetricRoutes.GetExternalMetricsBackends(info, namespace)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get backend for external metric %s: %v", info.Metric, err)
 	}
-	return backend.GetExternalMetric(info.Metric, namespace, metricSelector)
+	for _, metricsBackend := range backends {
+		metricsBackend, err = r.forRequester(metricsBackend, namespace, info.Metric)
+		if err != nil {
+			return nil, err
+		}
+		var values *external_metrics.ExternalMetricValueList
+		values, err = metricsBackend.GetExternalMetric(info.Metric, namespace, metricSelector)
+		if !apierrors.IsNotFound(err) {
+			return values, err
+		}
+	}
+	return nil, err
 }
 
 func (r routedMetricsProvider) ListAllExternalMetrics() []provider.ExternalMetricInfo {
 	return r.customMetricRoutes.ListAllExternalMetrics()
 }
+
+// withResourceMetricsBackends calls fn with the backends for resource in
+// order until one of them succeeds or fails with an error which another
+// backend wouldn't fix.
+func (r routedMetricsProvider) withResourceMetricsBackends(resource, namespace string, fn func(backend.ResourceMetricsBackend) error) error {
+	backends, err := r.customMetricRoutes.GetResourceMetricsBackends(resource, namespace)
+	if err != nil {
+		return apierrors.NewServiceUnavailable(err.Error())
+	}
+	for _, resourceBackend := range backends {
+		var metricsBackend backend.Backend
+		metricsBackend, err = r.forRequester(resourceBackend, namespace, resource)
+		if err != nil {
+			return err
+		}
+		if forwarded, ok := metricsBackend.(backend.ResourceMetricsBackend); ok {
+			resourceBackend = forwarded
+		}
+		err = fn(resourceBackend)
+		if err == nil || apierrors.IsNotFound(err) || apierrors.IsBadRequest(err) {
+			return err
+		}
+		klog.Warningf("Resource metrics backend %s failed to serve %s: %v", resourceBackend.Source(), resource, err)
+	}
+	return err
+}
+
+func (r routedMetricsProvider) GetNodeMetrics(name string) (*metrics.NodeMetrics, error) {
+	var result *metrics.NodeMetrics
+	err := r.withResourceMetricsBackends("nodes", "", func(resourceBackend backend.ResourceMetricsBackend) (err error) {
+		result, err = resourceBackend.GetNodeMetrics(name)
+		return err
+	})
+	return result, err
+}
+
+func (r routedMetricsProvider) ListNodeMetrics(options metav1.ListOptions) (*metrics.NodeMetricsList, error) {
+	var result *metrics.NodeMetricsList
+	err := r.withResourceMetricsBackends("nodes", "", func(resourceBackend backend.ResourceMetricsBackend) (err error) {
+		result, err = resourceBackend.ListNodeMetrics(options)
+		return err
+	})
+	return result, err
+}
+
+func (r routedMetricsProvider) GetPodMetrics(namespace, name string) (*metrics.PodMetrics, error) {
+	var result *metrics.PodMetrics
+	err := r.withResourceMetricsBackends("pods", namespace, func(resourceBackend backend.ResourceMetricsBackend) (err error) {
+		result, err = resourceBackend.GetPodMetrics(namespace, name)
+		return err
+	})
+	return result, err
+}
+
+func (r routedMetricsProvider) ListPodMetrics(namespace string, options metav1.ListOptions) (*metrics.PodMetricsList, error) {
+	var result *metrics.PodMetricsList
+	err := r.withResourceMetricsBackends("pods", namespace, func(resourceBackend backend.ResourceMetricsBackend) (err error) {
+		result, err = resourceBackend.ListPodMetrics(namespace, options)
+		return err
+	})
+	return result, err
+}
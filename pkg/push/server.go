@@ -0,0 +1,174 @@
+package push
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	pathvalidation "k8s.io/apimachinery/pkg/api/validation/path"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/klog"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/authorization"
+)
+
+// PushPath is the prefix of the paths samples are pushed to, followed by the
+// namespace of the metrics, e.g. /push/namespaces/team-a.
+const PushPath = "/push/namespaces/"
+
+const maxRequestBytes = 1024 * 1024
+
+// PushRequest is the body of a push.
+type PushRequest struct {
+	Samples []PushedSample `json:"samples"`
+}
+
+// PushedSample is a value of an external metric. Samples without a TTL are
+// served for the default TTL of the store.
+type PushedSample struct {
+	Metric string            `json:"metric"`
+	Labels map[string]string `json:"labels,omitempty"`
+	Value  resource.Quantity `json:"value"`
+	TTL    *metav1.Duration  `json:"ttl,omitempty"`
+}
+
+// ValidateSamples checks the samples of a push.
+func ValidateSamples(samples []PushedSample, maxTTL time.Duration) field.ErrorList {
+	allErrs := field.ErrorList{}
+	samplesPath := field.NewPath("samples")
+	if len(samples) == 0 {
+		allErrs = append(allErrs, field.Required(samplesPath, "at least one sample is required"))
+	}
+	for i, sample := range samples {
+		samplePath := samplesPath.Index(i)
+		if sample.Metric == "" {
+			allErrs = append(allErrs, field.Required(samplePath.Child("metric"), ""))
+		}
+		for _, msg := range pathvalidation.IsValidPathSegmentName(sample.Metric) {
+			allErrs = append(allErrs, field.Invalid(samplePath.Child("metric"), sample.Metric, msg))
+		}
+		for key, value := range sample.Labels {
+			labelPath := samplePath.Child("labels").Key(key)
+			for _, msg := range validation.IsQualifiedName(key) {
+				allErrs = append(allErrs, field.Invalid(labelPath, key, msg))
+			}
+			for _, msg := range validation.IsValidLabelValue(value) {
+				allErrs = append(allErrs, field.Invalid(labelPath, value, msg))
+			}
+		}
+		if ttl := sample.TTL; ttl != nil && (ttl.Duration <= 0 || ttl.Duration > maxTTL) {
+			allErrs = append(allErrs, field.Invalid(samplePath.Child("ttl"), ttl.Duration.String(), fmt.Sprintf("must be greater than 0 and at most %s", maxTTL)))
+		}
+	}
+	return allErrs
+}
+
+// Server accepts pushed samples from clients which authenticate with a bearer
+// token of the Kubernetes API server. The user of the token must be allowed
+// to create the metric in the metrics.metricsrouter.io group.
+type Server struct {
+	store         *Store
+	authenticator authorization.Authenticator
+	authorizer    authorization.Authorizer
+	mux           *http.ServeMux
+}
+
+func NewServer(store *Store, authenticator authorization.Authenticator, authorizer authorization.Authorizer) *Server {
+	s := &Server{store: store, authenticator: authenticator, authorizer: authorizer, mux: http.NewServeMux()}
+	s.mux.HandleFunc(PushPath, s.push)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Run serves pushes with TLS on address until stopCh is closed.
+func (s *Server) Run(address, certFile, keyFile string, stopCh <-chan struct{}) error {
+	server := &http.Server{Addr: address, Handler: s}
+	go func() {
+		<-stopCh
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			utilruntime.HandleError(fmt.Errorf("failed to shut down push server: %v", err))
+		}
+	}()
+	klog.Infof("Accepting pushed metrics on %s", address)
+	if err := server.ListenAndServeTLS(certFile, keyFile); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) push(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	namespace := strings.TrimPrefix(r.URL.Path, PushPath)
+	if msgs := validation.IsDNS1123Label(namespace); len(msgs) > 0 {
+		http.Error(w, fmt.Sprintf("invalid namespace %q: %s", namespace, strings.Join(msgs, ", ")), http.StatusNotFound)
+		return
+	}
+	requester, err := s.authenticator.AuthenticateRequest(r)
+	if err != nil {
+		klog.Errorf("Failed to authenticate push: %v", err)
+		http.Error(w, "authentication failed", http.StatusInternalServerError)
+		return
+	}
+	if requester == nil {
+		http.Error(w, "a valid bearer token is required", http.StatusUnauthorized)
+		return
+	}
+
+	request := PushRequest{}
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil {
+		http.Error(w, fmt.Sprintf("failed to decode push: %v", err), http.StatusBadRequest)
+		return
+	}
+	if allErrs := ValidateSamples(request.Samples, s.store.maxTTL); len(allErrs) > 0 {
+		http.Error(w, allErrs.ToAggregate().Error(), http.StatusBadRequest)
+		return
+	}
+
+	checked := make(map[string]struct{})
+	for _, sample := range request.Samples {
+		if _, ok := checked[sample.Metric]; ok {
+			continue
+		}
+		checked[sample.Metric] = struct{}{}
+		allowed, reason, err := s.authorizer.Authorize(authorization.Attributes{
+			User:      requester,
+			Verb:      "create",
+			Namespace: namespace,
+			Source:    SourceName,
+			Metric:    sample.Metric,
+		})
+		if err != nil {
+			klog.Errorf("Failed to authorize push of %s/%s: %v", namespace, sample.Metric, err)
+			http.Error(w, "authorization failed", http.StatusInternalServerError)
+			return
+		}
+		if !allowed {
+			http.Error(w, fmt.Sprintf("user %q may not push metric %s in namespace %s: %s", requester.GetName(), sample.Metric, namespace, reason), http.StatusForbidden)
+			return
+		}
+	}
+
+	if dropped := s.store.Push(namespace, request.Samples); dropped > 0 {
+		klog.V(2).Infof("Dropped %d new samples of %s because the push store is full", dropped, requester.GetName())
+		http.Error(w, fmt.Sprintf("%d of %d samples were dropped because the store is full", dropped, len(request.Samples)), http.StatusTooManyRequests)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
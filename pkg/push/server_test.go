@@ -0,0 +1,133 @@
+package push
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/authorization"
+)
+
+func TestServer(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+		if review.Spec.Token == "batch-token" {
+			review.Status.Authenticated = true
+			review.Status.User.Username = "system:serviceaccount:team-a:batch"
+		}
+		return true, review, nil
+	})
+	client.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		attributes := review.Spec.ResourceAttributes
+		review.Status.Allowed = attributes.Verb == "create" && attributes.Group == authorization.MetricsGroup &&
+			attributes.Resource == SourceName && attributes.Namespace == "team-a" && attributes.Name == "jobs_pending"
+		return true, review, nil
+	})
+	store := NewStore(time.Minute, time.Hour, DefaultMaxSamples, nil)
+	authorizer := authorization.NewSubjectAccessReviewAuthorizer(client.AuthorizationV1().SubjectAccessReviews(), time.Minute, time.Minute)
+	server := NewServer(store, authorization.NewTokenReviewAuthenticator(client.AuthenticationV1().TokenReviews(), time.Minute, time.Minute), authorizer)
+
+	for _, tc := range []struct {
+		name     string
+		method   string
+		path     string
+		token    string
+		body     string
+		expected int
+	}{
+		{
+			name:     "push",
+			path:     "/push/namespaces/team-a",
+			token:    "batch-token",
+			body:     `{"samples": [{"metric": "jobs_pending", "labels": {"queue": "orders"}, "value": "12", "ttl": "10m"}]}`,
+			expected: http.StatusNoContent,
+		},
+		{
+			name:     "wrong method",
+			method:   http.MethodGet,
+			path:     "/push/namespaces/team-a",
+			token:    "batch-token",
+			expected: http.StatusMethodNotAllowed,
+		},
+		{
+			name:     "without token",
+			path:     "/push/namespaces/team-a",
+			body:     `{"samples": [{"metric": "jobs_pending", "value": "12"}]}`,
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name:     "invalid token",
+			path:     "/push/namespaces/team-a",
+			token:    "other-token",
+			body:     `{"samples": [{"metric": "jobs_pending", "value": "12"}]}`,
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name:     "forbidden namespace",
+			path:     "/push/namespaces/team-b",
+			token:    "batch-token",
+			body:     `{"samples": [{"metric": "jobs_pending", "value": "12"}]}`,
+			expected: http.StatusForbidden,
+		},
+		{
+			name:     "forbidden metric",
+			path:     "/push/namespaces/team-a",
+			token:    "batch-token",
+			body:     `{"samples": [{"metric": "jobs_pending", "value": "12"}, {"metric": "jobs_running", "value": "1"}]}`,
+			expected: http.StatusForbidden,
+		},
+		{
+			name:     "ttl above the maximum",
+			path:     "/push/namespaces/team-a",
+			token:    "batch-token",
+			body:     `{"samples": [{"metric": "jobs_pending", "value": "12", "ttl": "2h"}]}`,
+			expected: http.StatusBadRequest,
+		},
+		{
+			name:     "invalid label",
+			path:     "/push/namespaces/team-a",
+			token:    "batch-token",
+			body:     `{"samples": [{"metric": "jobs_pending", "labels": {"queue": "a/b"}, "value": "12"}]}`,
+			expected: http.StatusBadRequest,
+		},
+		{
+			name:     "invalid namespace",
+			path:     "/push/namespaces/team-a/other",
+			token:    "batch-token",
+			body:     `{"samples": [{"metric": "jobs_pending", "value": "12"}]}`,
+			expected: http.StatusNotFound,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			method := tc.method
+			if method == "" {
+				method = http.MethodPost
+			}
+			request := httptest.NewRequest(method, tc.path, strings.NewReader(tc.body))
+			if tc.token != "" {
+				request.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, request)
+			require.Equal(t, tc.expected, recorder.Code, recorder.Body.String())
+		})
+	}
+
+	list, err := store.GetExternalMetric("jobs_pending", "team-a", labels.Everything())
+	require.NoError(t, err)
+	require.Len(t, list.Items, 1)
+	require.Equal(t, map[string]string{"queue": "orders"}, list.Items[0].MetricLabels)
+	require.Equal(t, int64(12), list.Items[0].Value.Value())
+}
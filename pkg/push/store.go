@@ -0,0 +1,439 @@
+package push
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kubernetes-sigs/custom-metrics-apiserver/pkg/provider"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog"
+	"k8s.io/metrics/pkg/apis/custom_metrics"
+	"k8s.io/metrics/pkg/apis/external_metrics"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/backend"
+)
+
+// SourceName is the name under which pushed metrics are routed and
+// authorized.
+const SourceName = "push"
+
+const (
+	// DefaultTTL is how long samples without a TTL are served.
+	DefaultTTL = 5 * time.Minute
+	// DefaultMaxTTL is the longest TTL a sample may have.
+	DefaultMaxTTL = time.Hour
+	// DefaultMaxSamples is how many samples the store keeps by default.
+	DefaultMaxSamples = 10000
+	// sweepInterval is how often expired samples are removed and the samples
+	// are persisted.
+	sweepInterval = 30 * time.Second
+)
+
+// configMapKey is the key of the samples in a ConfigMap.
+const configMapKey = "samples.json"
+
+// Sample is a pushed value of an external metric.
+type Sample struct {
+	Namespace string            `json:"namespace"`
+	Metric    string            `json:"metric"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Value     resource.Quantity `json:"value"`
+	Timestamp metav1.Time       `json:"timestamp"`
+	Expires   metav1.Time       `json:"expires"`
+}
+
+func (s *Sample) key() string {
+	return s.Namespace + "/" + s.Metric + "/" + labels.Set(s.Labels).String()
+}
+
+// metricKey identifies the samples of a metric in a namespace.
+type metricKey struct {
+	namespace string
+	metric    string
+}
+
+// Persistence keeps the samples across restarts of the router.
+type Persistence interface {
+	// Load returns no samples when none were saved yet.
+	Load() ([]Sample, error)
+	Save(samples []Sample) error
+}
+
+var _ backend.Backend = &Store{}
+
+// Store keeps at most a bounded number of pushed samples in memory and serves
+// them as external metrics until they expire.
+type Store struct {
+	lock sync.RWMutex
+	// samples are keyed by their labels.
+	samples     map[metricKey]map[string]Sample
+	count       int
+	metrics     map[string]int
+	defaultTTL  time.Duration
+	maxTTL      time.Duration
+	maxSamples  int
+	persistence Persistence
+	dirty       bool
+	onChange    func()
+	now         func() time.Time
+}
+
+// NewStore returns a store which keeps at most maxSamples samples and saves
+// them to persistence unless it is nil.
+func NewStore(defaultTTL, maxTTL time.Duration, maxSamples int, persistence Persistence) *Store {
+	return &Store{
+		samples:     make(map[metricKey]map[string]Sample),
+		metrics:     make(map[string]int),
+		defaultTTL:  defaultTTL,
+		maxTTL:      maxTTL,
+		maxSamples:  maxSamples,
+		persistence: persistence,
+		onChange:    func() {},
+		now:         time.Now,
+	}
+}
+
+// SetOnChange sets the function which is called after the names of the
+// metrics in the store changed, e.g. to route them again.
+func (s *Store) SetOnChange(onChange func()) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.onChange = onChange
+}
+
+// Load adds the samples of the persistence which didn't expire yet.
+func (s *Store) Load() error {
+	if s.persistence == nil {
+		return nil
+	}
+	samples, err := s.persistence.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load pushed samples: %v", err)
+	}
+	now := s.now()
+	s.lock.Lock()
+	changed := false
+	for _, sample := range samples {
+		if sample.Expires.Time.After(now) {
+			_, newMetric := s.set(sample)
+			changed = newMetric || changed
+		}
+	}
+	onChange := s.onChange
+	s.lock.Unlock()
+	if changed {
+		onChange()
+	}
+	return nil
+}
+
+// Push replaces the samples with the same namespace, metric and labels. The
+// samples must have been validated with ValidateSamples. It returns the number
+// of samples which were dropped because the store is full.
+func (s *Store) Push(namespace string, pushed []PushedSample) int {
+	now := s.now()
+	s.lock.Lock()
+	changed := false
+	dropped := 0
+	for _, p := range pushed {
+		ttl := s.defaultTTL
+		if p.TTL != nil {
+			ttl = p.TTL.Duration
+		}
+		stored, newMetric := s.set(Sample{
+			Namespace: namespace,
+			Metric:    p.Metric,
+			Labels:    p.Labels,
+			Value:     p.Value,
+			Timestamp: metav1.NewTime(now),
+			Expires:   metav1.NewTime(now.Add(ttl)),
+		})
+		if !stored {
+			dropped++
+		}
+		changed = newMetric || changed
+	}
+	s.dirty = true
+	onChange := s.onChange
+	s.lock.Unlock()
+	if changed {
+		onChange()
+	}
+	return dropped
+}
+
+// set stores a sample and returns whether it was stored and whether its
+// metric is new. Samples of new series are dropped when the store is full.
+// The lock must be held.
+func (s *Store) set(sample Sample) (bool, bool) {
+	key := metricKey{namespace: sample.Namespace, metric: sample.Metric}
+	series := labels.Set(sample.Labels).String()
+	samples, ok := s.samples[key]
+	if _, exists := samples[series]; exists {
+		samples[series] = sample
+		return true, false
+	}
+	if s.count >= s.maxSamples {
+		return false, false
+	}
+	if !ok {
+		samples = make(map[string]Sample)
+		s.samples[key] = samples
+	}
+	samples[series] = sample
+	s.count++
+	s.metrics[sample.Metric]++
+	return true, s.metrics[sample.Metric] == 1
+}
+
+// Expire removes the samples which expired.
+func (s *Store) Expire() {
+	now := s.now()
+	s.lock.Lock()
+	changed := false
+	for key, samples := range s.samples {
+		for series, sample := range samples {
+			if sample.Expires.Time.After(now) {
+				continue
+			}
+			delete(samples, series)
+			s.count--
+			s.dirty = true
+			s.metrics[sample.Metric]--
+			if s.metrics[sample.Metric] == 0 {
+				delete(s.metrics, sample.Metric)
+				changed = true
+			}
+		}
+		if len(samples) == 0 {
+			delete(s.samples, key)
+		}
+	}
+	onChange := s.onChange
+	s.lock.Unlock()
+	if changed {
+		onChange()
+	}
+}
+
+// Save persists the samples when they changed since they were last saved.
+func (s *Store) Save() error {
+	if s.persistence == nil {
+		return nil
+	}
+	s.lock.Lock()
+	if !s.dirty {
+		s.lock.Unlock()
+		return nil
+	}
+	samples := make([]Sample, 0, s.count)
+	for _, metricSamples := range s.samples {
+		for _, sample := range metricSamples {
+			samples = append(samples, sample)
+		}
+	}
+	s.dirty = false
+	s.lock.Unlock()
+
+	sort.Slice(samples, func(i, j int) bool {
+		return samples[i].key() < samples[j].key()
+	})
+	if err := s.persistence.Save(samples); err != nil {
+		s.lock.Lock()
+		s.dirty = true
+		s.lock.Unlock()
+		return fmt.Errorf("failed to save pushed samples: %v", err)
+	}
+	return nil
+}
+
+// Run removes expired samples and persists the samples until stopCh is
+// closed.
+func (s *Store) Run(stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+	klog.Infof("Starting pushed metrics store")
+	defer klog.Infof("Shutting down pushed metrics store")
+
+	wait.Until(func() {
+		s.Expire()
+		if err := s.Save(); err != nil {
+			utilruntime.HandleError(err)
+		}
+	}, sweepInterval, stopCh)
+}
+
+// Source returns the name of the pushed metrics source.
+func (s *Store) Source() string {
+	return SourceName
+}
+
+// ListCustomMetricInfos returns no metrics, only external metrics can be
+// pushed.
+func (s *Store) ListCustomMetricInfos() (map[provider.CustomMetricInfo]struct{}, error) {
+	return map[provider.CustomMetricInfo]struct{}{}, nil
+}
+
+func (s *Store) ListExternalMetrics() (map[provider.ExternalMetricInfo]struct{}, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	infos := make(map[provider.ExternalMetricInfo]struct{}, len(s.metrics))
+	for metric := range s.metrics {
+		infos[provider.ExternalMetricInfo{Metric: metric}] = struct{}{}
+	}
+	return infos, nil
+}
+
+func (s *Store) GetMetricByName(name types.NamespacedName, info provider.CustomMetricInfo, metricSelector labels.Selector) (*custom_metrics.MetricValue, error) {
+	return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "custommetrics"}, info.Metric)
+}
+
+func (s *Store) GetMetricBySelector(namespace string, selector labels.Selector, info provider.CustomMetricInfo, metricSelector labels.Selector) (*custom_metrics.MetricValueList, error) {
+	return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "custommetrics"}, info.Metric)
+}
+
+// GetExternalMetric returns the samples of the metric in the namespace which
+// match the selector and didn't expire yet. A namespace without samples of the
+// metric isn't served, so that requests fail over to the other sources of the
+// metric.
+func (s *Store) GetExternalMetric(name, namespace string, metricSelector labels.Selector) (*external_metrics.ExternalMetricValueList, error) {
+	now := s.now()
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	found := false
+	list := &external_metrics.ExternalMetricValueList{}
+	for _, sample := range s.samples[metricKey{namespace: namespace, metric: name}] {
+		if !sample.Expires.Time.After(now) {
+			continue
+		}
+		found = true
+		if !metricSelector.Matches(labels.Set(sample.Labels)) {
+			continue
+		}
+		list.Items = append(list.Items, external_metrics.ExternalMetricValue{
+			MetricName:   name,
+			MetricLabels: sample.Labels,
+			Timestamp:    sample.Timestamp,
+			Value:        sample.Value,
+		})
+	}
+	if !found {
+		return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "externalmetrics"}, name)
+	}
+	return list, nil
+}
+
+// ConfigMapPersistence keeps the samples in a ConfigMap, so that they are
+// shared by the replicas of the router after a restart. Replicas merge their
+// samples with those which the others saved.
+type ConfigMapPersistence struct {
+	Client    kubernetes.Interface
+	Namespace string
+	Name      string
+}
+
+func (p *ConfigMapPersistence) Load() ([]Sample, error) {
+	configMap, err := p.Client.CoreV1().ConfigMaps(p.Namespace).Get(context.TODO(), p.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return configMapSamples(configMap)
+}
+
+// Save merges the samples with the samples in the ConfigMap which didn't
+// expire yet, so that replicas which received different pushes don't
+// overwrite each other. Of two samples of the same series the newer one is
+// kept. A conflicting update is retried with the samples of the update which
+// won.
+func (p *ConfigMapPersistence) Save(samples []Sample) error {
+	configMaps := p.Client.CoreV1().ConfigMaps(p.Namespace)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMap, err := configMaps.Get(context.TODO(), p.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			data, err := json.Marshal(samples)
+			if err != nil {
+				return err
+			}
+			_, err = configMaps.Create(context.TODO(), &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: p.Namespace, Name: p.Name},
+				Data:       map[string]string{configMapKey: string(data)},
+			}, metav1.CreateOptions{})
+			if apierrors.IsAlreadyExists(err) {
+				// another replica created it, retry with its samples.
+				return apierrors.NewConflict(corev1.Resource("configmaps"), p.Name, err)
+			}
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		saved, err := configMapSamples(configMap)
+		if err != nil {
+			// samples which can't be read are replaced.
+			klog.Warningf("Replacing the samples of configmap %s/%s: %v", p.Namespace, p.Name, err)
+		}
+		data, err := json.Marshal(mergeSamples(samples, saved, time.Now()))
+		if err != nil {
+			return err
+		}
+		configMap = configMap.DeepCopy()
+		configMap.Data = map[string]string{configMapKey: string(data)}
+		_, err = configMaps.Update(context.TODO(), configMap, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+func configMapSamples(configMap *corev1.ConfigMap) ([]Sample, error) {
+	data := configMap.Data[configMapKey]
+	if data == "" {
+		return nil, nil
+	}
+	var samples []Sample
+	if err := json.Unmarshal([]byte(data), &samples); err != nil {
+		return nil, fmt.Errorf("invalid pushed samples: %v", err)
+	}
+	return samples, nil
+}
+
+// mergeSamples adds the saved samples which didn't expire to the samples,
+// unless the samples have a newer sample of their series.
+func mergeSamples(samples, saved []Sample, now time.Time) []Sample {
+	merged := make(map[string]Sample, len(samples)+len(saved))
+	for _, sample := range samples {
+		merged[sample.key()] = sample
+	}
+	for _, sample := range saved {
+		if !sample.Expires.Time.After(now) {
+			continue
+		}
+		key := sample.key()
+		if existing, ok := merged[key]; ok && !sample.Timestamp.After(existing.Timestamp.Time) {
+			continue
+		}
+		merged[key] = sample
+	}
+	result := make([]Sample, 0, len(merged))
+	for _, sample := range merged {
+		result = append(result, sample)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].key() < result[j].key()
+	})
+	return result
+}
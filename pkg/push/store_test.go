@@ -0,0 +1,144 @@
+package push
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kubernetes-sigs/custom-metrics-apiserver/pkg/provider"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestStore(t *testing.T) {
+	now := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	store := NewStore(time.Minute, time.Hour, DefaultMaxSamples, nil)
+	store.now = func() time.Time { return now }
+	changes := 0
+	store.SetOnChange(func() { changes++ })
+
+	store.Push("team-a", []PushedSample{
+		{Metric: "jobs_pending", Labels: map[string]string{"queue": "orders"}, Value: resource.MustParse("12")},
+		{Metric: "jobs_pending", Labels: map[string]string{"queue": "billing"}, Value: resource.MustParse("3"), TTL: &metav1.Duration{Duration: 10 * time.Minute}},
+	})
+	store.Push("team-b", []PushedSample{
+		{Metric: "jobs_pending", Labels: map[string]string{"queue": "orders"}, Value: resource.MustParse("7")},
+	})
+	// replaces the value of the series.
+	store.Push("team-a", []PushedSample{
+		{Metric: "jobs_pending", Labels: map[string]string{"queue": "orders"}, Value: resource.MustParse("13")},
+	})
+	require.Equal(t, 1, changes)
+
+	infos, err := store.ListExternalMetrics()
+	require.NoError(t, err)
+	require.Equal(t, map[provider.ExternalMetricInfo]struct{}{{Metric: "jobs_pending"}: {}}, infos)
+
+	values := func(namespace string, selector labels.Selector) map[string]int64 {
+		list, err := store.GetExternalMetric("jobs_pending", namespace, selector)
+		require.NoError(t, err)
+		values := make(map[string]int64)
+		for _, item := range list.Items {
+			values[item.MetricLabels["queue"]] = item.Value.Value()
+		}
+		return values
+	}
+	require.Equal(t, map[string]int64{"orders": 13, "billing": 3}, values("team-a", labels.Everything()))
+	require.Equal(t, map[string]int64{"billing": 3}, values("team-a", labels.SelectorFromSet(labels.Set{"queue": "billing"})))
+	require.Equal(t, map[string]int64{"orders": 7}, values("team-b", labels.Everything()))
+	_, err = store.GetExternalMetric("jobs_running", "team-a", labels.Everything())
+	require.True(t, apierrors.IsNotFound(err), "%v", err)
+	// namespaces without samples fail over to other sources.
+	_, err = store.GetExternalMetric("jobs_pending", "team-c", labels.Everything())
+	require.True(t, apierrors.IsNotFound(err), "%v", err)
+
+	// expired samples aren't served before they are removed.
+	now = now.Add(2 * time.Minute)
+	require.Equal(t, map[string]int64{"billing": 3}, values("team-a", labels.Everything()))
+	_, err = store.GetExternalMetric("jobs_pending", "team-b", labels.Everything())
+	require.True(t, apierrors.IsNotFound(err), "%v", err)
+	store.Expire()
+	require.Equal(t, 1, changes)
+	require.Equal(t, 1, store.count)
+
+	now = now.Add(10 * time.Minute)
+	store.Expire()
+	require.Equal(t, 2, changes)
+	infos, err = store.ListExternalMetrics()
+	require.NoError(t, err)
+	require.Empty(t, infos)
+}
+
+func TestConfigMapPersistence(t *testing.T) {
+	now := time.Now()
+	persistence := &ConfigMapPersistence{Client: fake.NewSimpleClientset(), Namespace: "custom-metrics", Name: "pushed-metrics"}
+	store := NewStore(time.Minute, time.Hour, DefaultMaxSamples, persistence)
+	store.Push("team-a", []PushedSample{
+		{Metric: "jobs_pending", Value: resource.MustParse("12")},
+		{Metric: "jobs_running", Value: resource.MustParse("2")},
+	})
+	require.NoError(t, store.Save())
+
+	restored := NewStore(time.Minute, time.Hour, DefaultMaxSamples, persistence)
+	restored.now = func() time.Time { return now.Add(30 * time.Second) }
+	require.NoError(t, restored.Load())
+	infos, err := restored.ListExternalMetrics()
+	require.NoError(t, err)
+	require.Len(t, infos, 2)
+	list, err := restored.GetExternalMetric("jobs_pending", "team-a", labels.Everything())
+	require.NoError(t, err)
+	require.Len(t, list.Items, 1)
+	require.Equal(t, int64(12), list.Items[0].Value.Value())
+
+	expired := NewStore(time.Minute, time.Hour, DefaultMaxSamples, persistence)
+	expired.now = func() time.Time { return now.Add(2 * time.Minute) }
+	require.NoError(t, expired.Load())
+	require.Empty(t, expired.samples)
+}
+
+func TestStoreMaxSamples(t *testing.T) {
+	store := NewStore(time.Minute, time.Hour, 2, nil)
+	require.Equal(t, 0, store.Push("team-a", []PushedSample{
+		{Metric: "jobs_pending", Labels: map[string]string{"queue": "orders"}, Value: resource.MustParse("12")},
+		{Metric: "jobs_pending", Labels: map[string]string{"queue": "billing"}, Value: resource.MustParse("3")},
+	}))
+	// samples of known series replace their values while new series are
+	// dropped.
+	require.Equal(t, 1, store.Push("team-a", []PushedSample{
+		{Metric: "jobs_pending", Labels: map[string]string{"queue": "orders"}, Value: resource.MustParse("13")},
+		{Metric: "jobs_running", Value: resource.MustParse("1")},
+	}))
+	list, err := store.GetExternalMetric("jobs_pending", "team-a", labels.SelectorFromSet(labels.Set{"queue": "orders"}))
+	require.NoError(t, err)
+	require.Len(t, list.Items, 1)
+	require.Equal(t, int64(13), list.Items[0].Value.Value())
+	_, err = store.GetExternalMetric("jobs_running", "team-a", labels.Everything())
+	require.True(t, apierrors.IsNotFound(err), "%v", err)
+}
+
+func TestConfigMapPersistenceReplicas(t *testing.T) {
+	persistence := &ConfigMapPersistence{Client: fake.NewSimpleClientset(), Namespace: "custom-metrics", Name: "pushed-metrics"}
+	first := NewStore(time.Minute, time.Hour, DefaultMaxSamples, persistence)
+	second := NewStore(time.Minute, time.Hour, DefaultMaxSamples, persistence)
+	second.now = func() time.Time { return time.Now().Add(time.Second) }
+	first.Push("team-a", []PushedSample{
+		{Metric: "jobs_pending", Value: resource.MustParse("12")},
+		{Metric: "jobs_running", Value: resource.MustParse("2")},
+	})
+	second.Push("team-a", []PushedSample{{Metric: "jobs_pending", Value: resource.MustParse("14")}})
+	second.Push("team-b", []PushedSample{{Metric: "jobs_pending", Value: resource.MustParse("5")}})
+	require.NoError(t, first.Save())
+	require.NoError(t, second.Save())
+
+	// the samples of both replicas are kept and the newer sample wins.
+	restored := NewStore(time.Minute, time.Hour, DefaultMaxSamples, persistence)
+	require.NoError(t, restored.Load())
+	require.Equal(t, 3, restored.count)
+	list, err := restored.GetExternalMetric("jobs_pending", "team-a", labels.Everything())
+	require.NoError(t, err)
+	require.Len(t, list.Items, 1)
+	require.Equal(t, int64(14), list.Items[0].Value.Value())
+}
@@ -0,0 +1,75 @@
+package rates
+
+import (
+	"sync"
+	"time"
+)
+
+// staleAfter is how long the samples of a series which isn't scraped anymore,
+// e.g. of a deleted pod, are kept.
+const staleAfter = 10 * time.Minute
+
+type sample struct {
+	time  time.Time
+	value float64
+}
+
+// Tracker keeps the recent samples of series to compute their rates. It
+// outlives the scrape backends, which are created again on every resync of
+// their source, so that their rates don't start over.
+type Tracker struct {
+	lock      sync.Mutex
+	series    map[string][]sample
+	lastSweep time.Time
+}
+
+// NewTracker returns an empty tracker.
+func NewTracker() *Tracker {
+	return &Tracker{series: make(map[string][]sample)}
+}
+
+// Observe adds a sample to a series and returns its per-second rate over at
+// least window. The newest sample older than window is kept, so that a series
+// has a rate from its second sample on. A decreasing value is a reset of a
+// counter.
+func (t *Tracker) Observe(key string, now time.Time, value float64, window time.Duration) (float64, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.sweep(now)
+
+	samples := append(t.series[key], sample{time: now, value: value})
+	cutoff := now.Add(-window)
+	for len(samples) > 2 && !samples[1].time.After(cutoff) {
+		samples = samples[1:]
+	}
+	t.series[key] = samples
+	if len(samples) < 2 {
+		return 0, false
+	}
+	var increase float64
+	for i := 1; i < len(samples); i++ {
+		delta := samples[i].value - samples[i-1].value
+		if delta < 0 {
+			delta = samples[i].value
+		}
+		increase += delta
+	}
+	elapsed := samples[len(samples)-1].time.Sub(samples[0].time).Seconds()
+	if elapsed <= 0 {
+		return 0, false
+	}
+	return increase / elapsed, true
+}
+
+// sweep drops stale series at most once a minute.
+func (t *Tracker) sweep(now time.Time) {
+	if now.Sub(t.lastSweep) < time.Minute {
+		return
+	}
+	t.lastSweep = now
+	for key, samples := range t.series {
+		if now.Sub(samples[len(samples)-1].time) > staleAfter {
+			delete(t.series, key)
+		}
+	}
+}
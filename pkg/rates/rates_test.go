@@ -0,0 +1,45 @@
+package rates
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracker(t *testing.T) {
+	start := time.Unix(1600000000, 0)
+	for _, tc := range []struct {
+		name     string
+		samples  []float64
+		interval time.Duration
+		window   time.Duration
+		expected float64
+		ok       bool
+	}{
+		{name: "single sample", samples: []float64{10}, interval: 15 * time.Second, window: time.Minute},
+		{name: "increasing", samples: []float64{0, 15, 30, 45}, interval: 15 * time.Second, window: time.Minute, expected: 1, ok: true},
+		{name: "counter reset", samples: []float64{100, 130, 15}, interval: 15 * time.Second, window: time.Minute, expected: 1.5, ok: true},
+		{name: "samples older than the window", samples: []float64{0, 100, 130, 160}, interval: 30 * time.Second, window: 30 * time.Second, expected: 1, ok: true},
+		{name: "window shorter than the interval", samples: []float64{0, 30}, interval: 30 * time.Second, window: 10 * time.Second, expected: 1, ok: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tracker := NewTracker()
+			var rate float64
+			var ok bool
+			for i, value := range tc.samples {
+				rate, ok = tracker.Observe("series", start.Add(time.Duration(i)*tc.interval), value, tc.window)
+			}
+			require.Equal(t, tc.ok, ok)
+			require.InDelta(t, tc.expected, rate, 1e-9)
+		})
+	}
+
+	t.Run("stale series", func(t *testing.T) {
+		tracker := NewTracker()
+		tracker.Observe("old", start, 1, time.Minute)
+		tracker.Observe("new", start.Add(staleAfter+time.Minute), 1, time.Minute)
+		require.NotContains(t, tracker.series, "old")
+		require.Contains(t, tracker.series, "new")
+	})
+}
@@ -0,0 +1,36 @@
+package remotewrite
+
+import (
+	"fmt"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+	"github.com/arjunrn/custom-metrics-router/pkg/backend"
+	"github.com/arjunrn/custom-metrics-router/pkg/prometheus"
+)
+
+var _ backend.Backend = &Client{}
+
+func init() {
+	backend.Register(v1beta1.RemoteWriteBackendType, newBackend)
+}
+
+// newBackend returns a client which reads the series which the remote-write
+// receiver of the router received.
+func newBackend(source *v1beta1.CustomMetricsSource, deps backend.Dependencies) (backend.Backend, error) {
+	spec := &source.Spec.Backend
+	if spec.RemoteWrite == nil {
+		return nil, fmt.Errorf("custom metrics source %s of type %s has no remote-write backend", source.Name, v1beta1.RemoteWriteBackendType)
+	}
+	if deps.Mapper == nil {
+		return nil, fmt.Errorf("custom metrics source %s of type %s requires a mapper", source.Name, v1beta1.RemoteWriteBackendType)
+	}
+	if deps.RemoteWriteSeries == nil {
+		return nil, fmt.Errorf("custom metrics source %s of type %s requires the remote-write receiver", source.Name, v1beta1.RemoteWriteBackendType)
+	}
+	var objects prometheus.ObjectLister
+	if deps.KubeClient != nil {
+		objects = prometheus.NewObjectLister(deps.KubeClient.Discovery().RESTClient())
+	}
+	customMetrics, externalMetrics := RemoteWriteMetrics(spec.RemoteWrite)
+	return NewClient(source.Name, deps.RemoteWriteSeries, deps.Mapper, objects, customMetrics, externalMetrics), nil
+}
@@ -0,0 +1,239 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: pkg/remotewrite/prompb/remote.proto
+
+// The subset of the messages of the Prometheus remote-write protocol which the
+// router reads. The field numbers match prompb of Prometheus, so fields the
+// router doesn't know, e.g. metadata and exemplars, are skipped.
+
+package prompb
+
+import (
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type WriteRequest struct {
+	Timeseries           []*TimeSeries `protobuf:"bytes,1,rep,name=timeseries,proto3" json:"timeseries,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *WriteRequest) Reset()         { *m = WriteRequest{} }
+func (m *WriteRequest) String() string { return proto.CompactTextString(m) }
+func (*WriteRequest) ProtoMessage()    {}
+func (*WriteRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9f2d8d1caca5a37f, []int{0}
+}
+
+func (m *WriteRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_WriteRequest.Unmarshal(m, b)
+}
+func (m *WriteRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_WriteRequest.Marshal(b, m, deterministic)
+}
+func (m *WriteRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WriteRequest.Merge(m, src)
+}
+func (m *WriteRequest) XXX_Size() int {
+	return xxx_messageInfo_WriteRequest.Size(m)
+}
+func (m *WriteRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_WriteRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WriteRequest proto.InternalMessageInfo
+
+func (m *WriteRequest) GetTimeseries() []*TimeSeries {
+	if m != nil {
+		return m.Timeseries
+	}
+	return nil
+}
+
+type TimeSeries struct {
+	// labels include the metric name as __name__.
+	Labels               []*Label  `protobuf:"bytes,1,rep,name=labels,proto3" json:"labels,omitempty"`
+	Samples              []*Sample `protobuf:"bytes,2,rep,name=samples,proto3" json:"samples,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *TimeSeries) Reset()         { *m = TimeSeries{} }
+func (m *TimeSeries) String() string { return proto.CompactTextString(m) }
+func (*TimeSeries) ProtoMessage()    {}
+func (*TimeSeries) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9f2d8d1caca5a37f, []int{1}
+}
+
+func (m *TimeSeries) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TimeSeries.Unmarshal(m, b)
+}
+func (m *TimeSeries) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TimeSeries.Marshal(b, m, deterministic)
+}
+func (m *TimeSeries) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TimeSeries.Merge(m, src)
+}
+func (m *TimeSeries) XXX_Size() int {
+	return xxx_messageInfo_TimeSeries.Size(m)
+}
+func (m *TimeSeries) XXX_DiscardUnknown() {
+	xxx_messageInfo_TimeSeries.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TimeSeries proto.InternalMessageInfo
+
+func (m *TimeSeries) GetLabels() []*Label {
+	if m != nil {
+		return m.Labels
+	}
+	return nil
+}
+
+func (m *TimeSeries) GetSamples() []*Sample {
+	if m != nil {
+		return m.Samples
+	}
+	return nil
+}
+
+type Label struct {
+	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Value                string   `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Label) Reset()         { *m = Label{} }
+func (m *Label) String() string { return proto.CompactTextString(m) }
+func (*Label) ProtoMessage()    {}
+func (*Label) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9f2d8d1caca5a37f, []int{2}
+}
+
+func (m *Label) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Label.Unmarshal(m, b)
+}
+func (m *Label) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Label.Marshal(b, m, deterministic)
+}
+func (m *Label) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Label.Merge(m, src)
+}
+func (m *Label) XXX_Size() int {
+	return xxx_messageInfo_Label.Size(m)
+}
+func (m *Label) XXX_DiscardUnknown() {
+	xxx_messageInfo_Label.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Label proto.InternalMessageInfo
+
+func (m *Label) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Label) GetValue() string {
+	if m != nil {
+		return m.Value
+	}
+	return ""
+}
+
+type Sample struct {
+	Value float64 `protobuf:"fixed64,1,opt,name=value,proto3" json:"value,omitempty"`
+	// timestamp in milliseconds since the epoch.
+	Timestamp            int64    `protobuf:"varint,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Sample) Reset()         { *m = Sample{} }
+func (m *Sample) String() string { return proto.CompactTextString(m) }
+func (*Sample) ProtoMessage()    {}
+func (*Sample) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9f2d8d1caca5a37f, []int{3}
+}
+
+func (m *Sample) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Sample.Unmarshal(m, b)
+}
+func (m *Sample) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Sample.Marshal(b, m, deterministic)
+}
+func (m *Sample) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Sample.Merge(m, src)
+}
+func (m *Sample) XXX_Size() int {
+	return xxx_messageInfo_Sample.Size(m)
+}
+func (m *Sample) XXX_DiscardUnknown() {
+	xxx_messageInfo_Sample.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Sample proto.InternalMessageInfo
+
+func (m *Sample) GetValue() float64 {
+	if m != nil {
+		return m.Value
+	}
+	return 0
+}
+
+func (m *Sample) GetTimestamp() int64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
+func init() {
+	proto.RegisterType((*WriteRequest)(nil), "prometheus.WriteRequest")
+	proto.RegisterType((*TimeSeries)(nil), "prometheus.TimeSeries")
+	proto.RegisterType((*Label)(nil), "prometheus.Label")
+	proto.RegisterType((*Sample)(nil), "prometheus.Sample")
+}
+
+func init() {
+	proto.RegisterFile("pkg/remotewrite/prompb/remote.proto", fileDescriptor_9f2d8d1caca5a37f)
+}
+
+var fileDescriptor_9f2d8d1caca5a37f = []byte{
+	// 266 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x90, 0x31, 0x4f, 0xc3, 0x30,
+	0x10, 0x85, 0x95, 0x96, 0x16, 0xf5, 0x60, 0xc1, 0x42, 0x28, 0x03, 0x43, 0x15, 0x96, 0x22, 0xd1,
+	0x44, 0x80, 0xc4, 0x02, 0x13, 0x43, 0xc5, 0xc0, 0xe4, 0x22, 0x21, 0xb1, 0x39, 0xd1, 0xa9, 0x35,
+	0xc4, 0xb1, 0x39, 0x9f, 0xe1, 0xef, 0xa3, 0x38, 0xa9, 0x92, 0x81, 0xc9, 0xf6, 0xfb, 0xbe, 0x77,
+	0x92, 0x0f, 0xae, 0xdc, 0xd7, 0xae, 0x20, 0x34, 0x96, 0xf1, 0x97, 0x34, 0x63, 0xe1, 0xc8, 0x1a,
+	0x57, 0xf6, 0x51, 0xee, 0xc8, 0xb2, 0x15, 0xd0, 0x86, 0xc8, 0x7b, 0x0c, 0x3e, 0xdb, 0xc0, 0xe9,
+	0x7b, 0x2b, 0x4a, 0xfc, 0x0e, 0xe8, 0x59, 0x3c, 0x00, 0xb0, 0x36, 0xe8, 0x91, 0x34, 0xfa, 0x34,
+	0x59, 0x4e, 0x57, 0x27, 0x77, 0x17, 0xf9, 0x50, 0xc8, 0xdf, 0xb4, 0xc1, 0x6d, 0xa4, 0x72, 0x64,
+	0x66, 0x08, 0x30, 0x10, 0x71, 0x0d, 0xf3, 0x5a, 0x95, 0x58, 0x1f, 0x26, 0x9c, 0x8d, 0x27, 0xbc,
+	0xb6, 0x44, 0xf6, 0x82, 0xb8, 0x81, 0x63, 0xaf, 0x8c, 0xab, 0xd1, 0xa7, 0x93, 0xe8, 0x8a, 0xb1,
+	0xbb, 0x8d, 0x48, 0x1e, 0x94, 0xec, 0x16, 0x66, 0xb1, 0x2e, 0x04, 0x1c, 0x35, 0xca, 0x60, 0x9a,
+	0x2c, 0x93, 0xd5, 0x42, 0xc6, 0xbb, 0x38, 0x87, 0xd9, 0x8f, 0xaa, 0x03, 0xa6, 0x93, 0x18, 0x76,
+	0x8f, 0xec, 0x09, 0xe6, 0xdd, 0x94, 0x81, 0xb7, 0xa5, 0xa4, 0xe7, 0xe2, 0x12, 0x16, 0xf1, 0x1f,
+	0xac, 0x8c, 0x8b, 0xcd, 0xa9, 0x1c, 0x82, 0xe7, 0x97, 0x8f, 0xcd, 0x4e, 0xf3, 0x3e, 0x94, 0x79,
+	0x65, 0x4d, 0xa1, 0xe8, 0x33, 0x34, 0xd4, 0x14, 0x55, 0xf0, 0x6c, 0xcd, 0xda, 0x20, 0x93, 0xae,
+	0xfc, 0x9a, 0x6c, 0x60, 0xa4, 0xe2, 0xff, 0xdd, 0x3f, 0x76, 0x47, 0x39, 0x8f, 0xcb, 0xbf, 0xff,
+	0x1b, 0x00, 0xb8, 0xd7, 0xf1, 0x24, 0xa3, 0x01, 0x00, 0x00,
+}
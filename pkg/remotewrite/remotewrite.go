@@ -0,0 +1,359 @@
+package remotewrite
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/kubernetes-sigs/custom-metrics-apiserver/pkg/provider"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/metrics/pkg/apis/custom_metrics"
+	"k8s.io/metrics/pkg/apis/external_metrics"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+	"github.com/arjunrn/custom-metrics-router/pkg/prometheus"
+	"github.com/arjunrn/custom-metrics-router/pkg/series"
+)
+
+// DefaultNamespaceLabel is the label with the namespace of the objects of
+// custom metrics.
+const DefaultNamespaceLabel = "namespace"
+
+// CustomMetric maps series of a store to a custom metric.
+type CustomMetric struct {
+	Name string
+	// Series is the name of the selected series, which must have the labels
+	// of MatchLabels.
+	Series      string
+	MatchLabels map[string]string
+	Resource    schema.GroupResource
+	Namespaced  bool
+	// NamespaceLabel is the label of the series with the namespace of the
+	// object.
+	NamespaceLabel string
+	// ObjectLabel is the label of the series with the name of the object.
+	// When it is empty, ObjectLabelFormat, e.g. k8s.%s.name, is formatted
+	// with the singular name of the resource, or the singular name is used
+	// without a format.
+	ObjectLabel       string
+	ObjectLabelFormat string
+	// Labels maps the labels of the metric to labels of the series. Without
+	// it the metric has the labels of the series besides the name, the
+	// namespace and the object.
+	Labels map[string]string
+}
+
+// ExternalMetric maps series of a store to an external metric.
+type ExternalMetric struct {
+	Name        string
+	Series      string
+	MatchLabels map[string]string
+	// NamespaceLabel is the label of the series with the namespace in which
+	// the series is served. Without it the series are served in every
+	// namespace.
+	NamespaceLabel string
+	// Labels maps the labels of the metric to labels of the series. Without
+	// it the metric has the labels of the series besides the name.
+	Labels map[string]string
+}
+
+// RemoteWriteMetrics returns the metrics of a remote-write backend.
+func RemoteWriteMetrics(backend *v1beta1.RemoteWriteBackend) ([]CustomMetric, []ExternalMetric) {
+	customMetrics := make([]CustomMetric, 0, len(backend.CustomMetrics))
+	for _, metric := range backend.CustomMetrics {
+		namespaceLabel := metric.NamespaceLabel
+		if namespaceLabel == "" {
+			namespaceLabel = DefaultNamespaceLabel
+		}
+		customMetrics = append(customMetrics, CustomMetric{
+			Name:           metric.Name,
+			Series:         metric.Series,
+			MatchLabels:    metric.MatchLabels,
+			Resource:       schema.ParseGroupResource(metric.Resource),
+			Namespaced:     metric.Namespaced,
+			NamespaceLabel: namespaceLabel,
+			ObjectLabel:    metric.ObjectLabel,
+		})
+	}
+	externalMetrics := make([]ExternalMetric, 0, len(backend.ExternalMetrics))
+	for _, metric := range backend.ExternalMetrics {
+		externalMetrics = append(externalMetrics, ExternalMetric{
+			Name:           metric.Name,
+			Series:         metric.Series,
+			MatchLabels:    metric.MatchLabels,
+			NamespaceLabel: metric.NamespaceLabel,
+		})
+	}
+	return customMetrics, externalMetrics
+}
+
+// Client serves the metrics of a source from the received series of a store.
+type Client struct {
+	source          string
+	store           *series.Store
+	mapper          meta.RESTMapper
+	objects         prometheus.ObjectLister
+	customMetrics   []CustomMetric
+	externalMetrics map[string]ExternalMetric
+}
+
+// NewClient returns a client which reads the series from store. The objects
+// are listed for metric requests with a label selector.
+func NewClient(source string, store *series.Store, mapper meta.RESTMapper, objects prometheus.ObjectLister, customMetrics []CustomMetric, externalMetrics []ExternalMetric) *Client {
+	client := &Client{
+		source:          source,
+		store:           store,
+		mapper:          mapper,
+		objects:         objects,
+		customMetrics:   customMetrics,
+		externalMetrics: make(map[string]ExternalMetric, len(externalMetrics)),
+	}
+	for _, metric := range externalMetrics {
+		client.externalMetrics[metric.Name] = metric
+	}
+	return client
+}
+
+// Source returns the name of the source the client was created for.
+func (c *Client) Source() string {
+	return c.source
+}
+
+// ListCustomMetricInfos returns the custom metrics of the source. Their
+// resources are resolved like the resources of discovered metrics.
+func (c *Client) ListCustomMetricInfos() (map[provider.CustomMetricInfo]struct{}, error) {
+	infos := make(map[provider.CustomMetricInfo]struct{}, len(c.customMetrics))
+	for _, metric := range c.customMetrics {
+		gvr, err := c.resolve(metric.Resource)
+		if err != nil {
+			return nil, err
+		}
+		infos[provider.CustomMetricInfo{GroupResource: gvr.GroupResource(), Namespaced: metric.Namespaced, Metric: metric.Name}] = struct{}{}
+	}
+	return infos, nil
+}
+
+func (c *Client) ListExternalMetrics() (map[provider.ExternalMetricInfo]struct{}, error) {
+	infos := make(map[provider.ExternalMetricInfo]struct{}, len(c.externalMetrics))
+	for name := range c.externalMetrics {
+		infos[provider.ExternalMetricInfo{Metric: name}] = struct{}{}
+	}
+	return infos, nil
+}
+
+func (c *Client) GetMetricByName(name types.NamespacedName, info provider.CustomMetricInfo, metricSelector labels.Selector) (*custom_metrics.MetricValue, error) {
+	values, err := c.objectValues(name.Namespace, []string{name.Name}, info, metricSelector)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, apierrors.NewNotFound(info.GroupResource, name.Name)
+	}
+	return &values[0], nil
+}
+
+func (c *Client) GetMetricBySelector(namespace string, selector labels.Selector, info provider.CustomMetricInfo, metricSelector labels.Selector) (*custom_metrics.MetricValueList, error) {
+	// the objects are only listed when the selector restricts them.
+	var names []string
+	if !selector.Empty() {
+		if c.objects == nil {
+			return nil, fmt.Errorf("label selectors aren't supported without an object lister")
+		}
+		gvr, err := c.resolve(info.GroupResource)
+		if err != nil {
+			return nil, err
+		}
+		names, err = c.objects.ListNames(gvr, namespace, selector)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %v", info.GroupResource, err)
+		}
+		if len(names) == 0 {
+			return &custom_metrics.MetricValueList{}, nil
+		}
+	}
+	values, err := c.objectValues(namespace, names, info, metricSelector)
+	if err != nil {
+		return nil, err
+	}
+	return &custom_metrics.MetricValueList{Items: values}, nil
+}
+
+// objectValues sums the series of the objects with the given names, or of all
+// objects when names is nil.
+func (c *Client) objectValues(namespace string, names []string, info provider.CustomMetricInfo, metricSelector labels.Selector) ([]custom_metrics.MetricValue, error) {
+	metric, gvr, err := c.customMetric(info)
+	if err != nil {
+		return nil, err
+	}
+	namespaceLabel := metric.NamespaceLabel
+	objectLabel := metric.ObjectLabel
+	if objectLabel == "" {
+		singular, err := c.mapper.ResourceSingularizer(gvr.Resource)
+		if err != nil {
+			return nil, fmt.Errorf("failed to singularize %s: %v", gvr.Resource, err)
+		}
+		objectLabel = singular
+		if metric.ObjectLabelFormat != "" {
+			objectLabel = fmt.Sprintf(metric.ObjectLabelFormat, singular)
+		}
+	}
+	kind, err := c.mapper.KindFor(gvr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the kind of %s: %v", gvr, err)
+	}
+	var identifier custom_metrics.MetricIdentifier
+	identifier.Name = info.Metric
+	if !metricSelector.Empty() {
+		identifier.Selector, err = metav1.ParseToLabelSelector(metricSelector.String())
+		if err != nil {
+			return nil, apierrors.NewBadRequest(err.Error())
+		}
+	}
+	wanted := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		wanted[name] = struct{}{}
+	}
+
+	sums := make(map[string]float64)
+	timestamps := make(map[string]time.Time)
+	var order []string
+	for _, series := range c.store.Select(metric.Series, metric.MatchLabels) {
+		if metric.Namespaced && (series.Labels[namespaceLabel] != namespace || !servedIn(&series, namespace)) {
+			continue
+		}
+		// series of cluster-scoped objects require a sender authorized for
+		// all namespaces.
+		if !metric.Namespaced && series.Namespace != "" {
+			continue
+		}
+		name := series.Labels[objectLabel]
+		if _, ok := wanted[name]; name == "" || (names != nil && !ok) {
+			continue
+		}
+		metricLabels := metricLabels(series.Labels, metric.Labels, namespaceLabel, objectLabel)
+		if !metricSelector.Matches(labels.Set(metricLabels)) || !valid(series.Value) {
+			continue
+		}
+		if _, ok := sums[name]; !ok {
+			order = append(order, name)
+		}
+		sums[name] += series.Value
+		if series.Timestamp.After(timestamps[name]) {
+			timestamps[name] = series.Timestamp
+		}
+	}
+	values := make([]custom_metrics.MetricValue, 0, len(order))
+	for _, name := range order {
+		object := custom_metrics.ObjectReference{Kind: kind.Kind, APIVersion: kind.GroupVersion().String(), Name: name}
+		if metric.Namespaced {
+			object.Namespace = namespace
+		}
+		values = append(values, custom_metrics.MetricValue{
+			DescribedObject: object,
+			Metric:          identifier,
+			Timestamp:       metav1.NewTime(timestamps[name]),
+			Value:           quantity(sums[name]),
+		})
+	}
+	return values, nil
+}
+
+// GetExternalMetric returns a value for every series of the metric which
+// matches the selector.
+func (c *Client) GetExternalMetric(name, namespace string, metricSelector labels.Selector) (*external_metrics.ExternalMetricValueList, error) {
+	metric, ok := c.externalMetrics[name]
+	if !ok {
+		return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "externalmetrics"}, name)
+	}
+	list := &external_metrics.ExternalMetricValueList{}
+	for _, series := range c.store.Select(metric.Series, metric.MatchLabels) {
+		if metric.NamespaceLabel != "" && series.Labels[metric.NamespaceLabel] != namespace || !servedIn(&series, namespace) {
+			continue
+		}
+		metricLabels := metricLabels(series.Labels, metric.Labels)
+		if !metricSelector.Matches(labels.Set(metricLabels)) || !valid(series.Value) {
+			continue
+		}
+		list.Items = append(list.Items, external_metrics.ExternalMetricValue{
+			MetricName:   name,
+			MetricLabels: metricLabels,
+			Timestamp:    metav1.NewTime(series.Timestamp),
+			Value:        quantity(series.Value),
+		})
+	}
+	return list, nil
+}
+
+// servedIn is true when a series may be served in namespace, which is the
+// case when its sender was authorized for the namespace or all namespaces.
+// Senders can't name the namespace of another team in the labels of a series.
+func servedIn(series *series.Series, namespace string) bool {
+	return series.Namespace == "" || series.Namespace == namespace
+}
+
+func (c *Client) customMetric(info provider.CustomMetricInfo) (*CustomMetric, schema.GroupVersionResource, error) {
+	for i := range c.customMetrics {
+		metric := &c.customMetrics[i]
+		if metric.Name != info.Metric || metric.Namespaced != info.Namespaced {
+			continue
+		}
+		gvr, err := c.resolve(metric.Resource)
+		if err != nil {
+			return nil, schema.GroupVersionResource{}, err
+		}
+		if gvr.GroupResource() == info.GroupResource {
+			return metric, gvr, nil
+		}
+	}
+	return nil, schema.GroupVersionResource{}, apierrors.NewNotFound(schema.GroupResource{Resource: "custommetrics"}, info.Metric)
+}
+
+// resolve returns the preferred version of a resource.
+func (c *Client) resolve(groupResource schema.GroupResource) (schema.GroupVersionResource, error) {
+	gvr, err := c.mapper.ResourceFor(groupResource.WithVersion(""))
+	if err != nil {
+		return schema.GroupVersionResource{}, fmt.Errorf("failed to resolve resource %s: %v", groupResource, err)
+	}
+	return gvr, nil
+}
+
+// metricLabels returns the labels of a metric for the labels of a series.
+// Without a mapping these are the labels of the series without the name of
+// the metric and the excluded labels.
+func metricLabels(seriesLabels, mapping map[string]string, excluded ...string) map[string]string {
+	if mapping != nil {
+		metricLabels := make(map[string]string, len(mapping))
+		for label, seriesLabel := range mapping {
+			if value, ok := seriesLabels[seriesLabel]; ok {
+				metricLabels[label] = value
+			}
+		}
+		return metricLabels
+	}
+	metricLabels := make(map[string]string, len(seriesLabels))
+	for label, value := range seriesLabels {
+		if !strings.HasPrefix(label, "__") {
+			metricLabels[label] = value
+		}
+	}
+	for _, label := range excluded {
+		delete(metricLabels, label)
+	}
+	return metricLabels
+}
+
+// valid returns whether a value can be represented as a quantity.
+func valid(value float64) bool {
+	return !math.IsNaN(value) && !math.IsInf(value, 0)
+}
+
+func quantity(value float64) resource.Quantity {
+	return *resource.NewMilliQuantity(int64(math.Round(value*1000)), resource.DecimalSI)
+}
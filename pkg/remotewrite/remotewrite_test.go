@@ -0,0 +1,141 @@
+package remotewrite
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kubernetes-sigs/custom-metrics-apiserver/pkg/provider"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+	"github.com/arjunrn/custom-metrics-router/pkg/remotewrite/prompb"
+	"github.com/arjunrn/custom-metrics-router/pkg/series"
+)
+
+var pods = schema.GroupResource{Resource: "pods"}
+
+type fakeObjectLister map[string][]string
+
+func (l fakeObjectLister) ListNames(resource schema.GroupVersionResource, namespace string, selector labels.Selector) ([]string, error) {
+	return l[selector.String()], nil
+}
+
+func TestClient(t *testing.T) {
+	now := time.Now()
+	store := series.NewStore(DefaultMaxSeries, DefaultMaxAge)
+	Append(store, &prompb.WriteRequest{Timeseries: []*prompb.TimeSeries{
+		timeSeries(3, now, "__name__", "worker_jobs", "namespace", "shop", "pod", "worker-1", "state", "pending", "job", "workers"),
+		timeSeries(2, now, "__name__", "worker_jobs", "namespace", "shop", "pod", "worker-1", "state", "running", "job", "workers"),
+		timeSeries(5, now, "__name__", "worker_jobs", "namespace", "shop", "pod", "worker-2", "state", "pending", "job", "workers"),
+		timeSeries(7, now, "__name__", "worker_jobs", "namespace", "other", "pod", "worker-1", "state", "pending", "job", "workers"),
+		timeSeries(9, now, "__name__", "worker_jobs", "namespace", "shop", "pod", "worker-3", "state", "pending", "job", "other"),
+		timeSeries(12, now, "__name__", "queue_messages", "queue", "orders", "tenant", "shop"),
+		timeSeries(4, now, "__name__", "queue_messages", "queue", "billing", "tenant", "shop"),
+		timeSeries(6, now, "__name__", "queue_messages", "queue", "orders", "tenant", "other"),
+	}}, "")
+
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{{Version: "v1"}})
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Pod"}, meta.RESTScopeNamespace)
+	objects := fakeObjectLister{"app=worker": {"worker-2"}}
+	customMetrics, externalMetrics := RemoteWriteMetrics(&v1beta1.RemoteWriteBackend{
+		CustomMetrics: []v1beta1.RemoteWriteCustomMetric{
+			{Name: "jobs", Series: "worker_jobs", MatchLabels: map[string]string{"job": "workers"}, Resource: "pods", Namespaced: true},
+		},
+		ExternalMetrics: []v1beta1.RemoteWriteExternalMetric{
+			{Name: "queue_messages", Series: "queue_messages", NamespaceLabel: "tenant"},
+		},
+	})
+	externalMetrics = append(externalMetrics, ExternalMetric{
+		Name:        "orders_messages",
+		Series:      "queue_messages",
+		MatchLabels: map[string]string{"queue": "orders"},
+		Labels:      map[string]string{"customer": "tenant"},
+	})
+	client := NewClient("agents", store, mapper, objects, customMetrics, externalMetrics)
+
+	infos, err := client.ListCustomMetricInfos()
+	require.NoError(t, err)
+	require.Equal(t, map[provider.CustomMetricInfo]struct{}{{GroupResource: pods, Namespaced: true, Metric: "jobs"}: {}}, infos)
+
+	info := provider.CustomMetricInfo{GroupResource: pods, Namespaced: true, Metric: "jobs"}
+	for _, tc := range []struct {
+		name           string
+		selector       labels.Selector
+		metricSelector labels.Selector
+		expected       map[string]int64
+	}{
+		{
+			name:           "sum of the series of every pod",
+			selector:       labels.Everything(),
+			metricSelector: labels.Everything(),
+			expected:       map[string]int64{"worker-1": 5, "worker-2": 5},
+		},
+		{
+			name:           "metric selector",
+			selector:       labels.Everything(),
+			metricSelector: labels.SelectorFromSet(labels.Set{"state": "pending"}),
+			expected:       map[string]int64{"worker-1": 3, "worker-2": 5},
+		},
+		{
+			name:           "label selector of the pods",
+			selector:       labels.SelectorFromSet(labels.Set{"app": "worker"}),
+			metricSelector: labels.Everything(),
+			expected:       map[string]int64{"worker-2": 5},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			list, err := client.GetMetricBySelector("shop", tc.selector, info, tc.metricSelector)
+			require.NoError(t, err)
+			values := make(map[string]int64)
+			for _, item := range list.Items {
+				require.Equal(t, "Pod", item.DescribedObject.Kind)
+				require.Equal(t, "shop", item.DescribedObject.Namespace)
+				values[item.DescribedObject.Name] = item.Value.Value()
+			}
+			require.Equal(t, tc.expected, values)
+		})
+	}
+
+	value, err := client.GetMetricByName(types.NamespacedName{Namespace: "other", Name: "worker-1"}, info, labels.Everything())
+	require.NoError(t, err)
+	require.Equal(t, int64(7), value.Value.Value())
+	_, err = client.GetMetricByName(types.NamespacedName{Namespace: "shop", Name: "worker-3"}, info, labels.Everything())
+	require.True(t, apierrors.IsNotFound(err), "%v", err)
+
+	list, err := client.GetExternalMetric("queue_messages", "shop", labels.SelectorFromSet(labels.Set{"queue": "orders"}))
+	require.NoError(t, err)
+	require.Len(t, list.Items, 1)
+	require.Equal(t, map[string]string{"queue": "orders", "tenant": "shop"}, list.Items[0].MetricLabels)
+	require.Equal(t, int64(12), list.Items[0].Value.Value())
+	list, err = client.GetExternalMetric("queue_messages", "shop", labels.Everything())
+	require.NoError(t, err)
+	require.Len(t, list.Items, 2)
+	list, err = client.GetExternalMetric("orders_messages", "other", labels.SelectorFromSet(labels.Set{"customer": "other"}))
+	require.NoError(t, err)
+	require.Len(t, list.Items, 1)
+	require.Equal(t, map[string]string{"customer": "other"}, list.Items[0].MetricLabels)
+	require.Equal(t, int64(6), list.Items[0].Value.Value())
+	_, err = client.GetExternalMetric("jobs", "shop", labels.Everything())
+	require.True(t, apierrors.IsNotFound(err), "%v", err)
+
+	// the series of a sender authorized for a namespace are only served in
+	// that namespace, whatever their other labels say.
+	Append(store, &prompb.WriteRequest{Timeseries: []*prompb.TimeSeries{
+		timeSeries(20, now, "__name__", "queue_messages", "queue", "returns", "tenant", "other", "namespace", "shop"),
+		timeSeries(30, now, "__name__", "queue_messages", "queue", "orders", "namespace", "shop"),
+	}}, DefaultNamespaceLabel)
+	list, err = client.GetExternalMetric("queue_messages", "other", labels.SelectorFromSet(labels.Set{"queue": "returns"}))
+	require.NoError(t, err)
+	require.Empty(t, list.Items)
+	list, err = client.GetExternalMetric("orders_messages", "other", labels.Everything())
+	require.NoError(t, err)
+	require.Len(t, list.Items, 2)
+	list, err = client.GetExternalMetric("orders_messages", "shop", labels.Everything())
+	require.NoError(t, err)
+	require.Len(t, list.Items, 3)
+}
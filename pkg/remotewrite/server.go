@@ -0,0 +1,208 @@
+package remotewrite
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/snappy"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/klog"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/authorization"
+	"github.com/arjunrn/custom-metrics-router/pkg/remotewrite/prompb"
+	"github.com/arjunrn/custom-metrics-router/pkg/series"
+)
+
+const (
+	// WritePath is the path remote-write requests are received at.
+	WritePath = "/api/v1/write"
+	// SourceName is the virtual resource in the metrics.metricsrouter.io
+	// group which senders must be allowed to create.
+	SourceName = "remote-write"
+	// maxRequestBytes limits the compressed and the decompressed size of a
+	// request.
+	maxRequestBytes = 10 * 1024 * 1024
+	// DefaultMaxSeries is how many series the receiver keeps by default.
+	DefaultMaxSeries = 100000
+	// DefaultMaxAge is how long a series is kept by default after its latest
+	// sample.
+	DefaultMaxAge = 5 * time.Minute
+	// staleNaN marks the end of a series in the remote-write protocol.
+	staleNaN uint64 = 0x7ff0000000000002
+)
+
+// Server receives Prometheus remote-write requests from clients which
+// authenticate with a bearer token of the Kubernetes API server. The user of
+// the token must be allowed to create remote-write in the
+// metrics.metricsrouter.io group, either in all namespaces or in the namespace
+// of every series, which is its namespace label. The series of a user who is
+// only allowed in some namespaces are only served in their namespace.
+type Server struct {
+	store         *series.Store
+	authenticator authorization.Authenticator
+	authorizer    authorization.Authorizer
+	mux           *http.ServeMux
+}
+
+func NewServer(store *series.Store, authenticator authorization.Authenticator, authorizer authorization.Authorizer) *Server {
+	s := &Server{store: store, authenticator: authenticator, authorizer: authorizer, mux: http.NewServeMux()}
+	s.mux.HandleFunc(WritePath, s.write)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Run receives remote-write requests with TLS on address until stopCh is
+// closed.
+func (s *Server) Run(address, certFile, keyFile string, stopCh <-chan struct{}) error {
+	server := &http.Server{Addr: address, Handler: s}
+	go func() {
+		<-stopCh
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			utilruntime.HandleError(fmt.Errorf("failed to shut down remote-write server: %v", err))
+		}
+	}()
+	klog.Infof("Receiving Prometheus remote-write requests on %s", address)
+	if err := server.ListenAndServeTLS(certFile, keyFile); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) write(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	requester, err := s.authenticator.AuthenticateRequest(r)
+	if err != nil {
+		klog.Errorf("Failed to authenticate remote-write request: %v", err)
+		http.Error(w, "authentication failed", http.StatusInternalServerError)
+		return
+	}
+	if requester == nil {
+		http.Error(w, "a valid bearer token is required", http.StatusUnauthorized)
+		return
+	}
+
+	compressed, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to read request: %v", err), http.StatusBadRequest)
+		return
+	}
+	if length, err := snappy.DecodedLen(compressed); err != nil || length > maxRequestBytes {
+		http.Error(w, "request must be snappy compressed and at most 10MiB", http.StatusBadRequest)
+		return
+	}
+	data, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to decompress request: %v", err), http.StatusBadRequest)
+		return
+	}
+	request := &prompb.WriteRequest{}
+	if err := proto.Unmarshal(data, request); err != nil {
+		http.Error(w, fmt.Sprintf("failed to decode write request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	namespaceLabel, status, msg := s.authorize(requester, request)
+	if status != http.StatusOK {
+		http.Error(w, msg, status)
+		return
+	}
+	if dropped := Append(s.store, request, namespaceLabel); dropped > 0 {
+		klog.V(2).Infof("Dropped %d new series of %s because the remote-write store is full", dropped, requester.GetName())
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// authorize checks that the requester may write the series of a request. It
+// returns the label with the namespaces the requester was authorized for,
+// which is empty when it may write in all namespaces, or the status and
+// message of the error.
+func (s *Server) authorize(requester user.Info, request *prompb.WriteRequest) (string, int, string) {
+	allowed, reason, err := s.authorizer.Authorize(authorization.Attributes{User: requester, Verb: "create", Source: SourceName})
+	if err != nil {
+		klog.Errorf("Failed to authorize remote-write request: %v", err)
+		return "", http.StatusInternalServerError, "authorization failed"
+	}
+	if allowed {
+		return "", http.StatusOK, ""
+	}
+	checked := make(map[string]struct{})
+	for _, timeSeries := range request.Timeseries {
+		namespace := ""
+		for _, label := range timeSeries.Labels {
+			if label.Name == DefaultNamespaceLabel {
+				namespace = label.Value
+			}
+		}
+		if _, ok := checked[namespace]; ok {
+			continue
+		}
+		checked[namespace] = struct{}{}
+		if namespace == "" {
+			return "", http.StatusForbidden, fmt.Sprintf("user %q may not send series without the label %s: %s", requester.GetName(), DefaultNamespaceLabel, reason)
+		}
+		allowed, reason, err := s.authorizer.Authorize(authorization.Attributes{User: requester, Verb: "create", Namespace: namespace, Source: SourceName})
+		if err != nil {
+			klog.Errorf("Failed to authorize remote-write request for namespace %s: %v", namespace, err)
+			return "", http.StatusInternalServerError, "authorization failed"
+		}
+		if !allowed {
+			return "", http.StatusForbidden, fmt.Sprintf("user %q may not send series of namespace %s: %s", requester.GetName(), namespace, reason)
+		}
+	}
+	return DefaultNamespaceLabel, http.StatusOK, ""
+}
+
+// Append stores the latest samples of the series of a write request. The
+// namespace of a series is the value of its namespaceLabel, which is empty
+// when the sender was authorized for all namespaces. It returns the number of
+// series which were dropped because the store is full.
+func Append(store *series.Store, request *prompb.WriteRequest, namespaceLabel string) int {
+	dropped := 0
+	for _, timeSeries := range request.Timeseries {
+		latest := latestSample(timeSeries.Samples)
+		if latest == nil {
+			continue
+		}
+		labels := make(map[string]string, len(timeSeries.Labels))
+		for _, pair := range timeSeries.Labels {
+			labels[pair.Name] = pair.Value
+		}
+		timestamp := time.Unix(0, latest.Timestamp*int64(time.Millisecond))
+		if math.Float64bits(latest.Value) == staleNaN {
+			store.Remove(labels, timestamp)
+			continue
+		}
+		namespace := ""
+		if namespaceLabel != "" {
+			namespace = labels[namespaceLabel]
+		}
+		if !store.Set(namespace, labels, latest.Value, timestamp) {
+			dropped++
+		}
+	}
+	return dropped
+}
+
+func latestSample(samples []*prompb.Sample) *prompb.Sample {
+	var latest *prompb.Sample
+	for _, sample := range samples {
+		if latest == nil || sample.Timestamp >= latest.Timestamp {
+			latest = sample
+		}
+	}
+	return latest
+}
@@ -0,0 +1,158 @@
+package remotewrite
+
+import (
+	"bytes"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/require"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/authorization"
+	"github.com/arjunrn/custom-metrics-router/pkg/remotewrite/prompb"
+	"github.com/arjunrn/custom-metrics-router/pkg/series"
+)
+
+// timeSeries returns a series with alternating label names and values.
+func timeSeries(value float64, timestamp time.Time, labels ...string) *prompb.TimeSeries {
+	series := &prompb.TimeSeries{
+		Samples: []*prompb.Sample{{Value: value, Timestamp: timestamp.UnixNano() / int64(time.Millisecond)}},
+	}
+	for i := 0; i < len(labels); i += 2 {
+		series.Labels = append(series.Labels, &prompb.Label{Name: labels[i], Value: labels[i+1]})
+	}
+	return series
+}
+
+func TestAppend(t *testing.T) {
+	now := time.Now()
+	store := series.NewStore(3, time.Minute)
+
+	dropped := Append(store, &prompb.WriteRequest{Timeseries: []*prompb.TimeSeries{
+		timeSeries(1, now.Add(-time.Second), "__name__", "jobs_pending", "queue", "orders", "namespace", "shop"),
+		timeSeries(2, now.Add(-time.Second), "queue", "billing", "__name__", "jobs_pending", "namespace", "shop"),
+		// series without samples are ignored.
+		{Labels: []*prompb.Label{{Name: "__name__", Value: "jobs_running"}}},
+	}}, DefaultNamespaceLabel)
+	require.Equal(t, 0, dropped)
+
+	dropped = Append(store, &prompb.WriteRequest{Timeseries: []*prompb.TimeSeries{
+		// the latest sample of a series is kept.
+		{
+			Labels: []*prompb.Label{{Name: "__name__", Value: "jobs_pending"}, {Name: "queue", Value: "orders"}, {Name: "namespace", Value: "shop"}},
+			Samples: []*prompb.Sample{
+				{Value: 5, Timestamp: now.UnixNano() / int64(time.Millisecond)},
+				{Value: 4, Timestamp: now.Add(-time.Second).UnixNano() / int64(time.Millisecond)},
+			},
+		},
+		timeSeries(6, now, "__name__", "jobs_failed", "queue", "orders", "namespace", "shop"),
+		timeSeries(7, now, "__name__", "jobs_failed", "queue", "billing", "namespace", "shop"),
+	}}, "")
+	require.Equal(t, 1, dropped)
+
+	values := func(name string) map[string]float64 {
+		values := make(map[string]float64)
+		for _, series := range store.Select(name, nil) {
+			values[series.Labels["queue"]+"/"+series.Namespace] = series.Value
+		}
+		return values
+	}
+	// the namespace of a series is the one its latest sender was authorized
+	// for.
+	require.Equal(t, map[string]float64{"orders/": 5, "billing/shop": 2}, values("jobs_pending"))
+	require.Equal(t, map[string]float64{"orders/": 6}, values("jobs_failed"))
+
+	// a stale marker ends a series.
+	Append(store, &prompb.WriteRequest{Timeseries: []*prompb.TimeSeries{
+		timeSeries(math.Float64frombits(staleNaN), now, "__name__", "jobs_pending", "queue", "billing", "namespace", "shop"),
+	}}, DefaultNamespaceLabel)
+	require.Equal(t, map[string]float64{"orders/": 5}, values("jobs_pending"))
+}
+
+func TestServer(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+		switch review.Spec.Token {
+		case "agent-token":
+			review.Status.Authenticated = true
+			review.Status.User.Username = "system:serviceaccount:monitoring:agent"
+		case "other-token":
+			review.Status.Authenticated = true
+			review.Status.User.Username = "system:serviceaccount:team-a:default"
+		}
+		return true, review, nil
+	})
+	client.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		attributes := review.Spec.ResourceAttributes
+		// the agent may write series of all namespaces and team-a only its own.
+		allowedUser := review.Spec.User == "system:serviceaccount:monitoring:agent" ||
+			review.Spec.User == "system:serviceaccount:team-a:default" && attributes.Namespace == "team-a"
+		review.Status.Allowed = allowedUser &&
+			attributes.Verb == "create" && attributes.Group == authorization.MetricsGroup && attributes.Resource == SourceName
+		return true, review, nil
+	})
+	store := series.NewStore(DefaultMaxSeries, DefaultMaxAge)
+	server := NewServer(
+		store,
+		authorization.NewTokenReviewAuthenticator(client.AuthenticationV1().TokenReviews(), time.Minute, time.Minute),
+		authorization.NewSubjectAccessReviewAuthorizer(client.AuthorizationV1().SubjectAccessReviews(), time.Minute, time.Minute),
+	)
+
+	encode := func(series ...*prompb.TimeSeries) []byte {
+		data, err := proto.Marshal(&prompb.WriteRequest{Timeseries: series})
+		require.NoError(t, err)
+		return data
+	}
+	data := encode(timeSeries(12, time.Now(), "__name__", "jobs_pending", "queue", "orders"))
+	compressed := snappy.Encode(nil, data)
+	teamA := snappy.Encode(nil, encode(timeSeries(3, time.Now(), "__name__", "jobs_running", "namespace", "team-a")))
+	teamB := snappy.Encode(nil, encode(
+		timeSeries(3, time.Now(), "__name__", "jobs_running", "namespace", "team-a"),
+		timeSeries(4, time.Now(), "__name__", "jobs_running", "namespace", "team-b"),
+	))
+
+	for _, tc := range []struct {
+		name     string
+		token    string
+		body     []byte
+		expected int
+	}{
+		{name: "without token", body: compressed, expected: http.StatusUnauthorized},
+		{name: "forbidden", token: "other-token", body: compressed, expected: http.StatusForbidden},
+		{name: "uncompressed", token: "agent-token", body: data, expected: http.StatusBadRequest},
+		{name: "write", token: "agent-token", body: compressed, expected: http.StatusNoContent},
+		{name: "series of an allowed namespace", token: "other-token", body: teamA, expected: http.StatusNoContent},
+		{name: "series of another namespace", token: "other-token", body: teamB, expected: http.StatusForbidden},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, WritePath, bytes.NewReader(tc.body))
+			request.Header.Set("Content-Encoding", "snappy")
+			request.Header.Set("Content-Type", "application/x-protobuf")
+			if tc.token != "" {
+				request.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, request)
+			require.Equal(t, tc.expected, recorder.Code, recorder.Body.String())
+		})
+	}
+
+	series := store.Select("jobs_pending", nil)
+	require.Len(t, series, 1)
+	require.Equal(t, float64(12), series[0].Value)
+	require.Empty(t, series[0].Namespace)
+	series = store.Select("jobs_running", nil)
+	require.Len(t, series, 1)
+	require.Equal(t, "team-a", series[0].Namespace)
+}
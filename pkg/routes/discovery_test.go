@@ -0,0 +1,62 @@
+package routes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kubernetes-sigs/custom-metrics-apiserver/pkg/provider"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+	"github.com/arjunrn/custom-metrics-router/pkg/metricsclient"
+)
+
+func TestDiscoveryGracePeriod(t *testing.T) {
+	authenticator, err := metricsclient.NewAuthenticator(nil, v1beta1.ServiceReference{}, &v1beta1.Authentication{Mode: v1beta1.NoAuthentication})
+	require.NoError(t, err)
+	// the backend doesn't exist, so its discovery always fails.
+	client, err := metricsclient.NewClient(metricsclient.Options{Source: "flaky", Name: "flaky", Namespace: "metrics.invalid", Port: 443, Authenticator: authenticator}, nil)
+	require.NoError(t, err)
+	routing := ServiceRouting{
+		Priority:        1,
+		ExternalMetrics: true,
+		StaticMetrics:   &v1beta1.StaticMetrics{ExternalMetrics: []string{"queue_depth"}},
+	}
+	key := serviceKey{Name: "flaky", Namespace: "metrics.invalid"}
+	expire := func(r *Routes) {
+		properties := r.serviceProperties[key]
+		properties.updated = time.Now().Add(-time.Hour)
+		r.serviceProperties[key] = properties
+	}
+
+	r := New(nil)
+	require.NoError(t, r.SeedService("flaky", "metrics.invalid", client, routing, nil, map[provider.ExternalMetricInfo]struct{}{
+		{Metric: "queue_depth"}: {},
+		{Metric: "stream_lag"}:  {},
+	}, nil))
+
+	// the previous routes are kept as a whole during the grace period.
+	require.Error(t, r.AddService("flaky", "metrics.invalid", client, routing))
+	require.ElementsMatch(t, []provider.ExternalMetricInfo{{Metric: "queue_depth"}, {Metric: "stream_lag"}}, r.ListAllExternalMetrics())
+
+	// afterwards only the static metrics are routed.
+	expire(r)
+	require.NoError(t, r.AddService("flaky", "metrics.invalid", client, routing))
+	require.Equal(t, []provider.ExternalMetricInfo{{Metric: "queue_depth"}}, r.ListAllExternalMetrics())
+
+	// services without static metrics are removed.
+	routing.StaticMetrics = nil
+	require.Error(t, r.AddService("flaky", "metrics.invalid", client, routing))
+	require.Equal(t, []provider.ExternalMetricInfo{{Metric: "queue_depth"}}, r.ListAllExternalMetrics())
+	expire(r)
+	require.Error(t, r.AddService("flaky", "metrics.invalid", client, routing))
+	require.Empty(t, r.ListAllExternalMetrics())
+	require.NotContains(t, r.serviceProperties, key)
+
+	// without a grace period the routes are kept until discovery succeeds.
+	r.SetDiscoveryGracePeriod(0)
+	require.NoError(t, r.SeedService("flaky", "metrics.invalid", client, routing, nil, map[provider.ExternalMetricInfo]struct{}{{Metric: "stream_lag"}: {}}, nil))
+	expire(r)
+	require.Error(t, r.AddService("flaky", "metrics.invalid", client, routing))
+	require.Equal(t, []provider.ExternalMetricInfo{{Metric: "stream_lag"}}, r.ListAllExternalMetrics())
+}
@@ -0,0 +1,177 @@
+package routes
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	corelisters "k8s.io/client-go/listers/core/v1"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+)
+
+// metricRoute is a MetricRoute which is ready to be matched against requests.
+type metricRoute struct {
+	name              string
+	metricType        v1beta1.MetricType
+	metric            *regexp.Regexp
+	groupResource     *schema.GroupResource
+	namespaceSelector labels.Selector
+	sources           []string
+	strategy          v1beta1.RouteStrategy
+}
+
+func compileMetricRoute(route *v1beta1.MetricRoute) (*metricRoute, error) {
+	match := route.Spec.Match
+	compiled := &metricRoute{
+		name:       route.Name,
+		metricType: match.MetricType,
+		strategy:   route.Spec.Strategy,
+	}
+	if compiled.strategy == "" {
+		compiled.strategy = v1beta1.FailoverRouteStrategy
+	}
+	if compiled.strategy != v1beta1.FailoverRouteStrategy && compiled.strategy != v1beta1.StrictRouteStrategy {
+		return nil, fmt.Errorf("unknown strategy %q", compiled.strategy)
+	}
+	if len(route.Spec.Sources) == 0 {
+		return nil, fmt.Errorf("at least one source must be set")
+	}
+	for _, source := range route.Spec.Sources {
+		compiled.sources = append(compiled.sources, source.Name)
+	}
+
+	switch {
+	case match.Name != "" && match.NameRegex != "":
+		return nil, fmt.Errorf("name and nameRegex can't be combined")
+	case match.Name != "":
+		compiled.metric = regexp.MustCompile("^" + regexp.QuoteMeta(match.Name) + "$")
+	case match.NameRegex != "":
+		metric, err := regexp.Compile("^(?:" + match.NameRegex + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid nameRegex: %v", err)
+		}
+		compiled.metric = metric
+	}
+	if match.GroupResource != "" {
+		if match.MetricType != v1beta1.CustomMetricsType {
+			return nil, fmt.Errorf("groupResource can only be set for custom metrics")
+		}
+		groupResource := schema.ParseGroupResource(match.GroupResource)
+		compiled.groupResource = &groupResource
+	}
+	if match.NamespaceSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(match.NamespaceSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid namespaceSelector: %v", err)
+		}
+		compiled.namespaceSelector = selector
+	}
+	return compiled, nil
+}
+
+// SetMetricRoutes replaces the MetricRoutes which are consulted before the
+// priority of the sources. Routes which are invalid are skipped and reported
+// in the returned error.
+func (r *Routes) SetMetricRoutes(metricRoutes []*v1beta1.MetricRoute) error {
+	var errs []error
+	compiled := make([]*metricRoute, 0, len(metricRoutes))
+	for _, route := range metricRoutes {
+		c, err := compileMetricRoute(route)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("metric route %s: %v", route.Name, err))
+			continue
+		}
+		compiled = append(compiled, c)
+	}
+	sort.Slice(compiled, func(i, j int) bool {
+		return compiled[i].name < compiled[j].name
+	})
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.metricRoutes = compiled
+	return utilerrors.NewAggregate(errs)
+}
+
+// SetNamespaceLister sets the lister used to evaluate the namespace selectors
+// of MetricRoutes. Routes with a namespace selector never match without it.
+func (r *Routes) SetNamespaceLister(lister corelisters.NamespaceLister) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.namespaces = lister
+}
+
+// matchMetricRoute returns the first route for the metric or nil when no route
+// matches. groupResource is nil for external metrics.
+func (r *Routes) matchMetricRoute(metricType v1beta1.MetricType, metric string, groupResource *schema.GroupResource, namespace string) *metricRoute {
+	for _, route := range r.metricRoutes {
+		if route.metricType != metricType {
+			continue
+		}
+		if route.metric != nil && !route.metric.MatchString(metric) {
+			continue
+		}
+		if route.groupResource != nil && (groupResource == nil || *route.groupResource != *groupResource) {
+			continue
+		}
+		if route.namespaceSelector != nil && !r.namespaceMatches(route.namespaceSelector, namespace) {
+			continue
+		}
+		return route
+	}
+	return nil
+}
+
+func (r *Routes) namespaceMatches(selector labels.Selector, namespace string) bool {
+	if namespace == "" || r.namespaces == nil {
+		return false
+	}
+	ns, err := r.namespaces.Get(namespace)
+	if err != nil {
+		return false
+	}
+	return selector.Matches(labels.Set(ns.Labels))
+}
+
+// routeOrder returns the services in the order of the sources of route. With
+// the Failover strategy the other services follow by priority.
+func (r *Routes) routeOrder(services *MetricServiceList, route *metricRoute) MetricServiceList {
+	var ordered MetricServiceList
+	used := make(map[serviceKey]bool)
+	for _, source := range route.sources {
+		for _, service := range *services {
+			key := serviceKey{Name: service.Name, Namespace: service.Namespace}
+			if !used[key] && r.sourceName(key) == source {
+				ordered = append(ordered, service)
+				used[key] = true
+			}
+		}
+	}
+	if route.strategy == v1beta1.StrictRouteStrategy {
+		return ordered
+	}
+	for _, service := range *services {
+		if !used[serviceKey{Name: service.Name, Namespace: service.Namespace}] {
+			ordered = append(ordered, service)
+		}
+	}
+	return ordered
+}
+
+// bestService returns the service which should serve a metric. The sources of
+// route are preferred in their order over the priority of the services.
+func (r *Routes) bestService(services *MetricServiceList, route *metricRoute) (*MetricsAPIService, error) {
+	if route == nil {
+		return services.GetBestMetricService()
+	}
+	ordered := r.routeOrder(services, route)
+	if len(ordered) == 0 {
+		return nil, fmt.Errorf("none of the sources of metric route %s serves the metric", route.name)
+	}
+	return &ordered[0], nil
+}
@@ -0,0 +1,297 @@
+package routes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kubernetes-sigs/custom-metrics-apiserver/pkg/provider"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+	"github.com/arjunrn/custom-metrics-router/pkg/backend"
+	"github.com/arjunrn/custom-metrics-router/pkg/metricsclient"
+)
+
+func addTestService(t *testing.T, r *Routes, source string, priority int, customMetricInfos []provider.CustomMetricInfo, externalMetricInfos []provider.ExternalMetricInfo) {
+	authenticator, err := metricsclient.NewAuthenticator(nil, v1beta1.ServiceReference{}, &v1beta1.Authentication{Mode: v1beta1.NoAuthentication})
+	require.NoError(t, err)
+	client, err := metricsclient.NewClient(metricsclient.Options{
+		Source:        source,
+		Name:          source,
+		Namespace:     "metrics",
+		Port:          443,
+		Authenticator: authenticator,
+	}, nil)
+	require.NoError(t, err)
+
+	for _, info := range customMetricInfos {
+		if _, ok := r.customMetrics[info]; !ok {
+			r.customMetrics[info] = NewMetricServiceList()
+		}
+		r.customMetrics[info].AddService(source, "metrics", time.Unix(1, 0), priority)
+	}
+	for _, info := range externalMetricInfos {
+		if _, ok := r.externalMetrics[info]; !ok {
+			r.externalMetrics[info] = NewMetricServiceList()
+		}
+		r.externalMetrics[info].AddService(source, "metrics", time.Unix(1, 0), priority)
+	}
+	r.serviceProperties[serviceKey{Name: source, Namespace: "metrics"}] = ServiceProperties{priority: priority, backend: client}
+}
+
+func testMetricRoute(name string, match v1beta1.MetricMatch, strategy v1beta1.RouteStrategy, sources ...string) *v1beta1.MetricRoute {
+	route := &v1beta1.MetricRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       v1beta1.MetricRouteSpec{Match: match, Strategy: strategy},
+	}
+	for _, source := range sources {
+		route.Spec.Sources = append(route.Spec.Sources, v1beta1.SourceReference{Name: source})
+	}
+	return route
+}
+
+func TestMetricRoutes(t *testing.T) {
+	requests := provider.CustomMetricInfo{GroupResource: schema.GroupResource{Resource: "pods"}, Namespaced: true, Metric: "http_requests"}
+	deploymentRequests := provider.CustomMetricInfo{GroupResource: schema.GroupResource{Group: "apps", Resource: "deployments"}, Namespaced: true, Metric: "http_requests"}
+	queueDepth := provider.ExternalMetricInfo{Metric: "queue_depth"}
+
+	for _, tc := range []struct {
+		name          string
+		metricRoutes  []*v1beta1.MetricRoute
+		info          interface{}
+		namespace     string
+		expected      string
+		expectedError bool
+	}{
+		{
+			name:      "priority without routes",
+			info:      requests,
+			namespace: "team-a",
+			expected:  "low",
+		},
+		{
+			name: "route by name",
+			metricRoutes: []*v1beta1.MetricRoute{
+				testMetricRoute("requests", v1beta1.MetricMatch{MetricType: v1beta1.CustomMetricsType, Name: "http_requests"}, "", "high"),
+			},
+			info:      requests,
+			namespace: "team-a",
+			expected:  "high",
+		},
+		{
+			name: "route by regex",
+			metricRoutes: []*v1beta1.MetricRoute{
+				testMetricRoute("queues", v1beta1.MetricMatch{MetricType: v1beta1.ExternalMetricsType, NameRegex: "queue_.*"}, "", "high"),
+			},
+			info:      queueDepth,
+			namespace: "team-a",
+			expected:  "high",
+		},
+		{
+			name: "other metric type",
+			metricRoutes: []*v1beta1.MetricRoute{
+				testMetricRoute("queues", v1beta1.MetricMatch{MetricType: v1beta1.CustomMetricsType, NameRegex: "queue_.*"}, "", "high"),
+			},
+			info:      queueDepth,
+			namespace: "team-a",
+			expected:  "low",
+		},
+		{
+			name: "group resource",
+			metricRoutes: []*v1beta1.MetricRoute{
+				testMetricRoute("deployments", v1beta1.MetricMatch{MetricType: v1beta1.CustomMetricsType, GroupResource: "deployments.apps"}, "", "high"),
+			},
+			info:      deploymentRequests,
+			namespace: "team-a",
+			expected:  "high",
+		},
+		{
+			name: "other group resource",
+			metricRoutes: []*v1beta1.MetricRoute{
+				testMetricRoute("deployments", v1beta1.MetricMatch{MetricType: v1beta1.CustomMetricsType, GroupResource: "deployments.apps"}, "", "high"),
+			},
+			info:      requests,
+			namespace: "team-a",
+			expected:  "low",
+		},
+		{
+			name: "namespace selector",
+			metricRoutes: []*v1beta1.MetricRoute{
+				testMetricRoute("team-a", v1beta1.MetricMatch{
+					MetricType:        v1beta1.CustomMetricsType,
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+				}, "", "high"),
+			},
+			info:      requests,
+			namespace: "team-a",
+			expected:  "high",
+		},
+		{
+			name: "namespace selector of other namespace",
+			metricRoutes: []*v1beta1.MetricRoute{
+				testMetricRoute("team-a", v1beta1.MetricMatch{
+					MetricType:        v1beta1.CustomMetricsType,
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+				}, "", "high"),
+			},
+			info:      requests,
+			namespace: "team-b",
+			expected:  "low",
+		},
+		{
+			name: "sources in order",
+			metricRoutes: []*v1beta1.MetricRoute{
+				testMetricRoute("requests", v1beta1.MetricMatch{MetricType: v1beta1.CustomMetricsType}, "", "missing", "high", "low"),
+			},
+			info:      requests,
+			namespace: "team-a",
+			expected:  "high",
+		},
+		{
+			name: "lowest route name wins",
+			metricRoutes: []*v1beta1.MetricRoute{
+				testMetricRoute("b", v1beta1.MetricMatch{MetricType: v1beta1.CustomMetricsType}, "", "low"),
+				testMetricRoute("a", v1beta1.MetricMatch{MetricType: v1beta1.CustomMetricsType}, "", "high"),
+			},
+			info:      requests,
+			namespace: "team-a",
+			expected:  "high",
+		},
+		{
+			name: "failover to priority",
+			metricRoutes: []*v1beta1.MetricRoute{
+				testMetricRoute("requests", v1beta1.MetricMatch{MetricType: v1beta1.CustomMetricsType}, v1beta1.FailoverRouteStrategy, "missing"),
+			},
+			info:      requests,
+			namespace: "team-a",
+			expected:  "low",
+		},
+		{
+			name: "strict",
+			metricRoutes: []*v1beta1.MetricRoute{
+				testMetricRoute("requests", v1beta1.MetricMatch{MetricType: v1beta1.CustomMetricsType}, v1beta1.StrictRouteStrategy, "missing"),
+			},
+			info:          requests,
+			namespace:     "team-a",
+			expectedError: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := New(nil)
+			addTestService(t, r, "low", 10, []provider.CustomMetricInfo{requests, deploymentRequests}, []provider.ExternalMetricInfo{queueDepth})
+			addTestService(t, r, "high", 100, []provider.CustomMetricInfo{requests, deploymentRequests}, []provider.ExternalMetricInfo{queueDepth})
+
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			require.NoError(t, indexer.Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: map[string]string{"team": "a"}}}))
+			require.NoError(t, indexer.Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-b", Labels: map[string]string{"team": "b"}}}))
+			r.SetNamespaceLister(corelisters.NewNamespaceLister(indexer))
+			require.NoError(t, r.SetMetricRoutes(tc.metricRoutes))
+
+			var client backend.Backend
+			var err error
+			switch info := tc.info.(type) {
+			case provider.CustomMetricInfo:
+				client, err = r.GetMetricsBackend(info, tc.namespace)
+			case provider.ExternalMetricInfo:
+				var clients []backend.Backend
+				clients, err = r.GetExternalMetricsBackends(info, tc.namespace)
+				if err == nil {
+					client = clients[0]
+				}
+			}
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, client.Source())
+		})
+	}
+}
+
+func TestSetMetricRoutesSkipsInvalidRoutes(t *testing.T) {
+	r := New(nil)
+	err := r.SetMetricRoutes([]*v1beta1.MetricRoute{
+		testMetricRoute("regex", v1beta1.MetricMatch{MetricType: v1beta1.CustomMetricsType, NameRegex: "("}, "", "a"),
+		testMetricRoute("external", v1beta1.MetricMatch{MetricType: v1beta1.ExternalMetricsType, GroupResource: "pods"}, "", "a"),
+		testMetricRoute("valid", v1beta1.MetricMatch{MetricType: v1beta1.CustomMetricsType}, "", "a"),
+	})
+	require.Error(t, err)
+	require.Len(t, r.metricRoutes, 1)
+	require.Equal(t, "valid", r.metricRoutes[0].name)
+}
+
+func TestResourceMetricsBackends(t *testing.T) {
+	r := New(nil)
+	addTestService(t, r, "metrics-server", 100, nil, nil)
+	addTestService(t, r, "prometheus-adapter", 50, nil, nil)
+	addTestService(t, r, "fallback", 200, nil, nil)
+	r.resourceMetrics["pods"] = NewMetricServiceList()
+	for key, properties := range r.serviceProperties {
+		r.resourceMetrics["pods"].AddService(key.Name, key.Namespace, time.Unix(1, 0), properties.priority)
+	}
+	sources := func(namespace string) []string {
+		clients, err := r.GetResourceMetricsBackends("pods", namespace)
+		require.NoError(t, err)
+		var sources []string
+		for _, client := range clients {
+			sources = append(sources, client.Source())
+		}
+		return sources
+	}
+
+	require.Equal(t, []string{"prometheus-adapter", "metrics-server", "fallback"}, sources("default"))
+	_, err := r.GetResourceMetricsBackends("nodes", "")
+	require.Error(t, err)
+
+	require.NoError(t, r.SetMetricRoutes([]*v1beta1.MetricRoute{
+		testMetricRoute("pods", v1beta1.MetricMatch{MetricType: v1beta1.ResourceMetricsType, Name: "pods"}, "", "metrics-server"),
+	}))
+	require.Equal(t, []string{"metrics-server", "prometheus-adapter", "fallback"}, sources("default"))
+
+	require.NoError(t, r.SetMetricRoutes([]*v1beta1.MetricRoute{
+		testMetricRoute("pods", v1beta1.MetricMatch{MetricType: v1beta1.ResourceMetricsType}, v1beta1.StrictRouteStrategy, "fallback"),
+	}))
+	require.Equal(t, []string{"fallback"}, sources("default"))
+
+	r.RemoveService("fallback", "metrics")
+	_, err = r.GetResourceMetricsBackends("pods", "default")
+	require.Error(t, err)
+	require.NoError(t, r.SetMetricRoutes(nil))
+	require.Equal(t, []string{"prometheus-adapter", "metrics-server"}, sources("default"))
+}
+
+func TestExternalMetricsBackends(t *testing.T) {
+	queueDepth := provider.ExternalMetricInfo{Metric: "queue_depth"}
+	r := New(nil)
+	addTestService(t, r, "push", 1000, nil, []provider.ExternalMetricInfo{queueDepth})
+	addTestService(t, r, "prometheus-adapter", 50, nil, []provider.ExternalMetricInfo{queueDepth})
+	sources := func(namespace string) []string {
+		clients, err := r.GetExternalMetricsBackends(queueDepth, namespace)
+		require.NoError(t, err)
+		var sources []string
+		for _, client := range clients {
+			sources = append(sources, client.Source())
+		}
+		return sources
+	}
+
+	require.Equal(t, []string{"prometheus-adapter", "push"}, sources("default"))
+	_, err := r.GetExternalMetricsBackends(provider.ExternalMetricInfo{Metric: "unknown"}, "default")
+	require.Error(t, err)
+
+	require.NoError(t, r.SetMetricRoutes([]*v1beta1.MetricRoute{
+		testMetricRoute("queues", v1beta1.MetricMatch{MetricType: v1beta1.ExternalMetricsType}, "", "push"),
+	}))
+	require.Equal(t, []string{"push", "prometheus-adapter"}, sources("default"))
+
+	require.NoError(t, r.SetMetricRoutes([]*v1beta1.MetricRoute{
+		testMetricRoute("queues", v1beta1.MetricMatch{MetricType: v1beta1.ExternalMetricsType}, v1beta1.StrictRouteStrategy, "push"),
+	}))
+	require.Equal(t, []string{"push"}, sources("default"))
+}
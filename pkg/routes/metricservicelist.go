@@ -64,6 +64,8 @@ func (m *MetricServiceList) AddService(name, namespace string, created time.Time
 	sort.Sort(m)
 }
 
+// RemoveService removes a service from the list and returns whether the list
+// is empty afterwards.
 func (m *MetricServiceList) RemoveService(namespace, name string) bool {
 	found := -1
 	for i, s := range *m {
@@ -75,10 +77,7 @@ func (m *MetricServiceList) RemoveService(namespace, name string) bool {
 		*m = append((*m)[:found], (*m)[found+1:]...)
 		sort.Sort(m)
 	}
-	if m.Len() > 0 {
-		return true
-	}
-	return false
+	return m.Len() == 0
 }
 
 func (m *MetricServiceList) GetBestMetricService() (*MetricsAPIService, error) {
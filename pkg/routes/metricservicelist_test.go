@@ -13,6 +13,7 @@ func TestMetricServiceList(t *testing.T) {
 		inputAPIServices  []MetricsAPIService
 		outputAPIServices []MetricsAPIService
 		deleteAPIServices []MetricsAPIService
+		empty             bool
 	}{
 		{
 			name: "basic",
@@ -64,15 +65,27 @@ func TestMetricServiceList(t *testing.T) {
 				{Name: "test3", Namespace: "testns", Created: time.Unix(3, 0), Priority: 1},
 			},
 		},
+		{
+			name: "deletion of the last service",
+			inputAPIServices: []MetricsAPIService{
+				{Name: "test1", Namespace: "testns", Created: time.Unix(1, 0), Priority: 1},
+			},
+			deleteAPIServices: []MetricsAPIService{
+				{Name: "test1", Namespace: "testns"},
+			},
+			empty: true,
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			list := make(MetricServiceList, 0)
 			for _, s := range tc.inputAPIServices {
 				list.AddService(s.Name, s.Namespace, s.Created, s.Priority)
 			}
+			empty := false
 			for _, s := range tc.deleteAPIServices {
-				list.RemoveService(s.Namespace, s.Name)
+				empty = list.RemoveService(s.Namespace, s.Name)
 			}
+			require.Equal(t, tc.empty, empty)
 			require.Len(t, list, len(tc.outputAPIServices))
 			for i, o := range list {
 				require.EqualValues(t, tc.outputAPIServices[i], o)
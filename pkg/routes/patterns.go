@@ -0,0 +1,109 @@
+package routes
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+)
+
+// metricPattern is a MetricPattern which is ready to be matched against
+// requests.
+type metricPattern struct {
+	metricType    v1beta1.MetricType
+	metric        *regexp.Regexp
+	groupResource *schema.GroupResource
+}
+
+func compileMetricPatterns(patterns []v1beta1.MetricPattern) ([]metricPattern, error) {
+	compiled := make([]metricPattern, 0, len(patterns))
+	for i, pattern := range patterns {
+		c := metricPattern{metricType: pattern.MetricType}
+		var expr string
+		switch {
+		case pattern.Regex != "" && pattern.Glob != "":
+			return nil, fmt.Errorf("metric pattern %d: regex and glob can't be combined", i)
+		case pattern.Regex != "":
+			expr = "^(?:" + pattern.Regex + ")$"
+		case pattern.Glob != "":
+			expr = globToRegexp(pattern.Glob)
+		default:
+			return nil, fmt.Errorf("metric pattern %d: one of regex and glob must be set", i)
+		}
+		metric, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("metric pattern %d: %v", i, err)
+		}
+		c.metric = metric
+		if pattern.GroupResource != "" {
+			if pattern.MetricType == v1beta1.ExternalMetricsType {
+				return nil, fmt.Errorf("metric pattern %d: groupResource can't be set for external metrics", i)
+			}
+			groupResource := schema.ParseGroupResource(pattern.GroupResource)
+			c.groupResource = &groupResource
+		}
+		compiled = append(compiled, c)
+	}
+	return compiled, nil
+}
+
+// globToRegexp translates a shell pattern, where * matches any sequence of
+// characters and ? a single character, to an anchored regular expression.
+func globToRegexp(glob string) string {
+	var expr strings.Builder
+	expr.WriteString("^")
+	for _, c := range glob {
+		switch c {
+		case '*':
+			expr.WriteString(".*")
+		case '?':
+			expr.WriteString(".")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	expr.WriteString("$")
+	return expr.String()
+}
+
+func (p metricPattern) matches(metricType v1beta1.MetricType, metric string, groupResource *schema.GroupResource) bool {
+	if p.metricType != metricType || !p.metric.MatchString(metric) {
+		return false
+	}
+	return p.groupResource == nil || (groupResource != nil && *p.groupResource == *groupResource)
+}
+
+// undiscoveredMetricServices returns the services for a metric which no
+// service lists in discovery. These are the services with a matching pattern
+// or, when there are none, the default services for the metric type. It
+// returns nil when no service is found.
+func (r *Routes) undiscoveredMetricServices(metricType v1beta1.MetricType, metric string, groupResource *schema.GroupResource) *MetricServiceList {
+	services := NewMetricServiceList()
+	for key, properties := range r.serviceProperties {
+		for _, pattern := range properties.patterns {
+			if pattern.matches(metricType, metric, groupResource) {
+				services.AddService(key.Name, key.Namespace, properties.created, properties.priority)
+				break
+			}
+		}
+	}
+	if services.Len() > 0 {
+		return services
+	}
+	for key, properties := range r.serviceProperties {
+		if !properties.defaultSource {
+			continue
+		}
+		if (metricType == v1beta1.CustomMetricsType && properties.customMetrics) ||
+			(metricType == v1beta1.ExternalMetricsType && properties.externalMetrics) {
+			services.AddService(key.Name, key.Namespace, properties.created, properties.priority)
+		}
+	}
+	if services.Len() > 0 {
+		return services
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package routes
+
+import (
+	"testing"
+
+	"github.com/kubernetes-sigs/custom-metrics-apiserver/pkg/provider"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+)
+
+func TestGlobToRegexp(t *testing.T) {
+	require.Equal(t, `^queue_.*_depth\..$`, globToRegexp("queue_*_depth.?"))
+}
+
+func TestUndiscoveredMetrics(t *testing.T) {
+	discovered := provider.ExternalMetricInfo{Metric: "discovered"}
+
+	r := New(nil)
+	addTestService(t, r, "discovering", 200, nil, []provider.ExternalMetricInfo{discovered})
+	addTestService(t, r, "keda", 100, nil, nil)
+	addTestService(t, r, "prometheus", 50, nil, nil)
+	addTestService(t, r, "fallback", 300, nil, nil)
+
+	setPatterns := func(source string, patterns ...v1beta1.MetricPattern) {
+		compiled, err := compileMetricPatterns(patterns)
+		require.NoError(t, err)
+		key := serviceKey{Name: source, Namespace: "metrics"}
+		properties := r.serviceProperties[key]
+		properties.patterns = compiled
+		properties.customMetrics = true
+		properties.externalMetrics = true
+		r.serviceProperties[key] = properties
+	}
+	setPatterns("keda", v1beta1.MetricPattern{MetricType: v1beta1.ExternalMetricsType, Glob: "s*"})
+	setPatterns("prometheus",
+		v1beta1.MetricPattern{MetricType: v1beta1.ExternalMetricsType, Regex: "s3_.*|sqs_.*"},
+		v1beta1.MetricPattern{MetricType: v1beta1.CustomMetricsType, Regex: "http_.*", GroupResource: "pods"},
+	)
+	setPatterns("fallback")
+	fallback := r.serviceProperties[serviceKey{Name: "fallback", Namespace: "metrics"}]
+	fallback.defaultSource = true
+	r.serviceProperties[serviceKey{Name: "fallback", Namespace: "metrics"}] = fallback
+
+	for _, tc := range []struct {
+		name     string
+		info     interface{}
+		expected string
+	}{
+		{name: "discovered", info: discovered, expected: "discovering"},
+		{name: "best pattern by priority", info: provider.ExternalMetricInfo{Metric: "sqs_messages"}, expected: "prometheus"},
+		{name: "single pattern", info: provider.ExternalMetricInfo{Metric: "stream_lag"}, expected: "keda"},
+		{name: "custom pattern", info: provider.CustomMetricInfo{GroupResource: schema.GroupResource{Resource: "pods"}, Metric: "http_requests"}, expected: "prometheus"},
+		{name: "pattern of other resource", info: provider.CustomMetricInfo{GroupResource: schema.GroupResource{Resource: "services"}, Metric: "http_requests"}, expected: "fallback"},
+		{name: "default", info: provider.ExternalMetricInfo{Metric: "unknown"}, expected: "fallback"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var source string
+			switch info := tc.info.(type) {
+			case provider.CustomMetricInfo:
+				client, err := r.GetMetricsBackend(info, "default")
+				require.NoError(t, err)
+				source = client.Source()
+			case provider.ExternalMetricInfo:
+				clients, err := r.GetExternalMetricsBackends(info, "default")
+				require.NoError(t, err)
+				source = clients[0].Source()
+			}
+			require.Equal(t, tc.expected, source)
+		})
+	}
+
+	delete(r.serviceProperties, serviceKey{Name: "fallback", Namespace: "metrics"})
+	_, err := r.GetExternalMetricsBackends(provider.ExternalMetricInfo{Metric: "unknown"}, "default")
+	require.Error(t, err)
+}
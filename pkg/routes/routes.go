@@ -7,8 +7,12 @@ import (
 
 	"github.com/kubernetes-sigs/custom-metrics-apiserver/pkg/provider"
 	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog"
 
-	"github.com/arjunrn/custom-metrics-router/pkg/metricsclient"
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+	"github.com/arjunrn/custom-metrics-router/pkg/backend"
 )
 
 type serviceKey struct {
@@ -18,9 +22,34 @@ type serviceKey struct {
 
 type ServiceProperties struct {
 	priority            int
+	created             time.Time
+	customMetrics       bool
+	externalMetrics     bool
+	patterns            []metricPattern
+	defaultSource       bool
 	customMetricInfos   map[provider.CustomMetricInfo]struct{}
 	externalMetricInfos map[provider.ExternalMetricInfo]struct{}
-	client              *metricsclient.Client
+	resourceMetrics     map[string]struct{}
+	backend             backend.Backend
+	// stale services were seeded from a snapshot and not discovered since.
+	stale bool
+	// updated is when the routes of the service were last replaced.
+	updated time.Time
+}
+
+// ServiceRouting describes which metrics requests are routed to a service.
+type ServiceRouting struct {
+	Priority          int
+	CreationTimestamp time.Time
+	CustomMetrics     bool
+	ExternalMetrics   bool
+	ResourceMetrics   bool
+	// Patterns match metrics which the service doesn't list in discovery.
+	Patterns []v1beta1.MetricPattern
+	// Default routes metrics which no service serves to the service.
+	Default bool
+	// StaticMetrics are routed to the service without discovery.
+	StaticMetrics *v1beta1.StaticMetrics
 }
 
 type Routes struct {
@@ -28,7 +57,37 @@ type Routes struct {
 	serviceProperties map[serviceKey]ServiceProperties
 	customMetrics     map[provider.CustomMetricInfo]*MetricServiceList
 	externalMetrics   map[provider.ExternalMetricInfo]*MetricServiceList
+	resourceMetrics   map[string]*MetricServiceList
+	metricRoutes      []*metricRoute
+	namespaces        corelisters.NamespaceLister
 	mapper            meta.RESTMapper
+	gracePeriod       time.Duration
+}
+
+// DefaultDiscoveryGracePeriod is how long the routes of a service are kept
+// while its discovery fails.
+const DefaultDiscoveryGracePeriod = 10 * time.Minute
+
+// SourceRouting returns the routing of a CustomMetricsSource.
+func SourceRouting(source *v1beta1.CustomMetricsSource) ServiceRouting {
+	routing := ServiceRouting{
+		Priority:          source.Spec.Routing.Priority,
+		CreationTimestamp: source.CreationTimestamp.Time,
+		Patterns:          source.Spec.Routing.MetricPatterns,
+		Default:           source.Spec.Routing.Default,
+		StaticMetrics:     source.Spec.StaticMetrics,
+	}
+	for _, metricType := range source.Spec.Routing.MetricTypes {
+		switch metricType {
+		case v1beta1.CustomMetricsType:
+			routing.CustomMetrics = true
+		case v1beta1.ExternalMetricsType:
+			routing.ExternalMetrics = true
+		case v1beta1.ResourceMetricsType:
+			routing.ResourceMetrics = true
+		}
+	}
+	return routing
 }
 
 func New(mapper meta.RESTMapper) *Routes {
@@ -36,31 +95,108 @@ func New(mapper meta.RESTMapper) *Routes {
 		serviceProperties: make(map[serviceKey]ServiceProperties),
 		customMetrics:     make(map[provider.CustomMetricInfo]*MetricServiceList),
 		externalMetrics:   make(map[provider.ExternalMetricInfo]*MetricServiceList),
+		resourceMetrics:   make(map[string]*MetricServiceList),
 		mapper:            mapper,
+		gracePeriod:       DefaultDiscoveryGracePeriod,
 	}
 }
 
-// TODO anaik: Refactor so that the old client can be reused when nothing changes.
-func (r *Routes) AddService(name, namespace string, port int32, priority int, insecureTLSSkipVerify bool, creationTimestamp time.Time, customMetrics, externalMetrics bool) error {
-	client, err := metricsclient.NewClient(insecureTLSSkipVerify, name, namespace, port, r.mapper)
+// Mapper returns the mapper which resolves the resources of custom metrics.
+func (r *Routes) Mapper() meta.RESTMapper {
+	return r.mapper
+}
+
+// SetDiscoveryGracePeriod sets how long the routes of a service are kept while
+// its discovery fails. With 0 they are kept until discovery succeeds again.
+func (r *Routes) SetDiscoveryGracePeriod(gracePeriod time.Duration) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.gracePeriod = gracePeriod
+}
+
+// AddService discovers the metrics of the backend of a service and replaces
+// the routes of the service with them. The routes are only replaced once the
+// whole discovery succeeded, see discoveryFailed for failures.
+func (r *Routes) AddService(name, namespace string, metricsBackend backend.Backend, routing ServiceRouting) error {
+	patterns, err := compileMetricPatterns(routing.Patterns)
 	if err != nil {
 		return err
 	}
+	// discovery runs before the lock is taken so that slow backends don't
+	// block the routing of requests to other backends.
+	customMetricInfos, externalMetricInfos, fallback, err := r.discover(metricsBackend, routing)
+	resourceMetrics := make(map[string]struct{})
+	if err == nil && routing.ResourceMetrics {
+		resourceMetrics, err = listResourceMetrics(metricsBackend)
+		if err != nil {
+			fallback = false
+		}
+	}
+	if err != nil {
+		return r.discoveryFailed(name, namespace, metricsBackend, routing, patterns, customMetricInfos, externalMetricInfos, fallback, err)
+	}
 
+	r.setService(name, namespace, metricsBackend, routing, patterns, customMetricInfos, externalMetricInfos, resourceMetrics, false)
+	return nil
+}
+
+func listResourceMetrics(metricsBackend backend.Backend) (map[string]struct{}, error) {
+	resourceBackend, ok := metricsBackend.(backend.ResourceMetricsBackend)
+	if !ok {
+		return nil, fmt.Errorf("the backend of %s doesn't serve resource metrics", metricsBackend.Source())
+	}
+	resourceMetrics, err := resourceBackend.ListResourceMetrics()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list resource metric api resources: %v", err)
+	}
+	return resourceMetrics, nil
+}
+
+// discoveryFailed keeps the previous routes of a service for the grace period,
+// so that a backend which fails discovery temporarily keeps all its routes
+// instead of a part of them. New services and services whose grace period
+// passed only get their static metrics if every failing metric type has any,
+// and are removed otherwise.
+func (r *Routes) discoveryFailed(name, namespace string, metricsBackend backend.Backend, routing ServiceRouting, patterns []metricPattern, customMetricInfos map[provider.CustomMetricInfo]struct{}, externalMetricInfos map[provider.ExternalMetricInfo]struct{}, fallback bool, err error) error {
 	r.lock.Lock()
 	defer r.lock.Unlock()
 	key := serviceKey{Name: name, Namespace: namespace}
-	customMetricInfos := make(map[provider.CustomMetricInfo]struct{})
-	if customMetrics {
-		customMetricInfos, err = client.ListCustomMetricInfos()
-		if err != nil {
-			return fmt.Errorf("failed to list custom metric api resources: %v", err)
-		}
+	serviceProperties, ok := r.serviceProperties[key]
+	if ok && (r.gracePeriod == 0 || time.Since(serviceProperties.updated) < r.gracePeriod) {
+		return fmt.Errorf("keeping the previous routes of service %s/%s: %v", namespace, name, err)
+	}
+	if fallback {
+		klog.Warningf("Routing only the static metrics of %s: %v", metricsBackend.Source(), err)
+		r.replaceService(name, namespace, metricsBackend, routing, patterns, customMetricInfos, externalMetricInfos, make(map[string]struct{}), false)
+		return nil
+	}
+	if ok {
+		klog.Warningf("Removing the routes of service %s/%s, its discovery failed for longer than %s", namespace, name, r.gracePeriod)
+		r.removeService(name, namespace)
 	}
+	return err
+}
+
+// setService replaces the routes of a service with the given metrics.
+func (r *Routes) setService(name, namespace string, metricsBackend backend.Backend, routing ServiceRouting, patterns []metricPattern, customMetricInfos map[provider.CustomMetricInfo]struct{}, externalMetricInfos map[provider.ExternalMetricInfo]struct{}, resourceMetrics map[string]struct{}, stale bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.replaceService(name, namespace, metricsBackend, routing, patterns, customMetricInfos, externalMetricInfos, resourceMetrics, stale)
+}
+
+// replaceService is setService for callers which hold the lock.
+func (r *Routes) replaceService(name, namespace string, metricsBackend backend.Backend, routing ServiceRouting, patterns []metricPattern, customMetricInfos map[provider.CustomMetricInfo]struct{}, externalMetricInfos map[provider.ExternalMetricInfo]struct{}, resourceMetrics map[string]struct{}, stale bool) {
+	priority, creationTimestamp := routing.Priority, routing.CreationTimestamp
+	key := serviceKey{Name: name, Namespace: namespace}
 	if serviceProperties, ok := r.serviceProperties[key]; ok {
+		if serviceProperties.stale && !stale {
+			klog.Infof("Discovery of service %s/%s replaced its routes from the snapshot", namespace, name)
+		}
 		oldMetricInfos := getOldCustomMetricInfos(serviceProperties.customMetricInfos, customMetricInfos)
 		for _, outdated := range oldMetricInfos {
-			r.customMetrics[outdated].RemoveService(name, namespace)
+			if r.customMetrics[outdated].RemoveService(namespace, name) {
+				delete(r.customMetrics, outdated)
+			}
 		}
 	}
 	for mInfo := range customMetricInfos {
@@ -72,17 +208,12 @@ func (r *Routes) AddService(name, namespace string, port int32, priority int, in
 		serviceList.AddService(name, namespace, creationTimestamp, priority)
 	}
 
-	externalMetricInfos := make(map[provider.ExternalMetricInfo]struct{})
-	if externalMetrics {
-		externalMetricInfos, err = client.ListExternalMetrics()
-		if err != nil {
-			return fmt.Errorf("failed to list external metric api resources: %v", err)
-		}
-	}
 	if serviceProperties, ok := r.serviceProperties[key]; ok {
 		oldMetricInfos := getOldExternalMetricInfos(serviceProperties.externalMetricInfos, externalMetricInfos)
 		for _, outdated := range oldMetricInfos {
-			r.externalMetrics[outdated].RemoveService(name, namespace)
+			if r.externalMetrics[outdated].RemoveService(namespace, name) {
+				delete(r.externalMetrics, outdated)
+			}
 		}
 	}
 	for mInfo := range externalMetricInfos {
@@ -93,13 +224,119 @@ func (r *Routes) AddService(name, namespace string, port int32, priority int, in
 		serviceList := r.externalMetrics[mInfo]
 		serviceList.AddService(name, namespace, creationTimestamp, priority)
 	}
+
+	if serviceProperties, ok := r.serviceProperties[key]; ok {
+		for resource := range serviceProperties.resourceMetrics {
+			if _, ok := resourceMetrics[resource]; !ok && r.resourceMetrics[resource].RemoveService(namespace, name) {
+				delete(r.resourceMetrics, resource)
+			}
+		}
+	}
+	for resource := range resourceMetrics {
+		if _, ok := r.resourceMetrics[resource]; !ok {
+			r.resourceMetrics[resource] = NewMetricServiceList()
+		}
+		r.resourceMetrics[resource].AddService(name, namespace, creationTimestamp, priority)
+	}
 	r.serviceProperties[key] = ServiceProperties{
 		priority:            priority,
-		client:              client,
+		created:             creationTimestamp,
+		customMetrics:       routing.CustomMetrics,
+		externalMetrics:     routing.ExternalMetrics,
+		patterns:            patterns,
+		defaultSource:       routing.Default,
+		backend:             metricsBackend,
 		customMetricInfos:   customMetricInfos,
 		externalMetricInfos: externalMetricInfos,
+		resourceMetrics:     resourceMetrics,
+		stale:               stale,
+		updated:             time.Now(),
 	}
-	return nil
+}
+
+// DiscoverService returns the metrics which AddService would route to a new
+// service without adding it.
+func (r *Routes) DiscoverService(metricsBackend backend.Backend, routing ServiceRouting) (map[provider.CustomMetricInfo]struct{}, map[provider.ExternalMetricInfo]struct{}, error) {
+	customMetricInfos, externalMetricInfos, fallback, err := r.discover(metricsBackend, routing)
+	if err != nil && !fallback {
+		return nil, nil, err
+	}
+	if err != nil {
+		klog.Warningf("Routing only the static metrics of %s: %v", metricsBackend.Source(), err)
+	}
+	return customMetricInfos, externalMetricInfos, nil
+}
+
+// discover returns the static metrics of a service together with the metrics
+// it lists in discovery. When the discovery of a metric type fails, only the
+// static metrics of the type are returned together with the error, and
+// fallback tells whether every failing type has static metrics.
+func (r *Routes) discover(metricsBackend backend.Backend, routing ServiceRouting) (customMetricInfos map[provider.CustomMetricInfo]struct{}, externalMetricInfos map[provider.ExternalMetricInfo]struct{}, fallback bool, err error) {
+	customMetricInfos = make(map[provider.CustomMetricInfo]struct{})
+	externalMetricInfos = make(map[provider.ExternalMetricInfo]struct{})
+	static := routing.StaticMetrics
+	if static == nil {
+		static = &v1beta1.StaticMetrics{}
+	}
+	fallback = true
+	if routing.CustomMetrics {
+		for _, metric := range static.CustomMetrics {
+			info, err := r.staticCustomMetricInfo(metric)
+			if err != nil {
+				return nil, nil, false, err
+			}
+			customMetricInfos[info] = struct{}{}
+		}
+		if !static.DisableDiscovery {
+			discovered, listErr := metricsBackend.ListCustomMetricInfos()
+			if listErr != nil {
+				err = fmt.Errorf("failed to list custom metric api resources: %v", listErr)
+				fallback = len(customMetricInfos) > 0
+			}
+			for info := range discovered {
+				customMetricInfos[info] = struct{}{}
+			}
+		}
+	}
+	if routing.ExternalMetrics {
+		for _, metric := range static.ExternalMetrics {
+			externalMetricInfos[provider.ExternalMetricInfo{Metric: metric}] = struct{}{}
+		}
+		if !static.DisableDiscovery {
+			discovered, listErr := metricsBackend.ListExternalMetrics()
+			if listErr != nil {
+				if err == nil {
+					err = fmt.Errorf("failed to list external metric api resources: %v", listErr)
+				}
+				fallback = fallback && len(externalMetricInfos) > 0
+			}
+			for info := range discovered {
+				externalMetricInfos[info] = struct{}{}
+			}
+		}
+	}
+	if err == nil {
+		fallback = false
+	}
+	return customMetricInfos, externalMetricInfos, fallback, err
+}
+
+// staticCustomMetricInfo resolves the resource of a static metric the same
+// way as the resources of discovered metrics.
+func (r *Routes) staticCustomMetricInfo(metric v1beta1.StaticCustomMetric) (provider.CustomMetricInfo, error) {
+	groupResource := schema.ParseGroupResource(metric.Resource)
+	if r.mapper != nil {
+		resource, err := r.mapper.ResourceFor(groupResource.WithVersion(""))
+		if err != nil {
+			return provider.CustomMetricInfo{}, fmt.Errorf("failed to resolve resource %s of static metric %s: %v", metric.Resource, metric.Name, err)
+		}
+		groupResource = resource.GroupResource()
+	}
+	return provider.CustomMetricInfo{
+		GroupResource: groupResource,
+		Namespaced:    metric.Namespaced,
+		Metric:        metric.Name,
+	}, nil
 }
 
 func getOldCustomMetricInfos(old map[provider.CustomMetricInfo]struct{}, new map[provider.CustomMetricInfo]struct{}) []provider.CustomMetricInfo {
@@ -125,57 +362,116 @@ func getOldExternalMetricInfos(old map[provider.ExternalMetricInfo]struct{}, new
 func (r *Routes) RemoveService(name, namespace string) {
 	r.lock.Lock()
 	defer r.lock.Unlock()
+	r.removeService(name, namespace)
+}
+
+// removeService is RemoveService for callers which hold the lock.
+func (r *Routes) removeService(name, namespace string) {
 	key := serviceKey{Name: name, Namespace: namespace}
 	for k, v := range r.customMetrics {
-		empty := v.RemoveService(name, namespace)
-		if empty {
+		if v.RemoveService(namespace, name) {
 			delete(r.customMetrics, k)
 		}
 	}
+	for k, v := range r.externalMetrics {
+		if v.RemoveService(namespace, name) {
+			delete(r.externalMetrics, k)
+		}
+	}
+	for k, v := range r.resourceMetrics {
+		if v.RemoveService(namespace, name) {
+			delete(r.resourceMetrics, k)
+		}
+	}
 	delete(r.serviceProperties, key)
 }
 
-func (r *Routes) GetMetricsBackend(info provider.CustomMetricInfo) (*metricsclient.Client, error) {
+// GetMetricsBackend returns the backend which serves a custom metric for a
+// request in namespace.
+func (r *Routes) GetMetricsBackend(info provider.CustomMetricInfo, namespace string) (backend.Backend, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	services, ok := r.customMetrics[info]
+	if !ok {
+		services = r.undiscoveredMetricServices(v1beta1.CustomMetricsType, info.Metric, &info.GroupResource)
+	}
+	if services == nil {
+		return nil, fmt.Errorf("metric %s is not provided by any metrics backend", info.Metric)
+	}
+	route := r.matchMetricRoute(v1beta1.CustomMetricsType, info.Metric, &info.GroupResource, namespace)
+	return r.backend(services, route, info.Metric)
+}
+
+// GetExternalMetricsBackends returns the backends which serve an external
+// metric for a request in namespace, in the order they should be tried. The
+// sources of a matching MetricRoute come first.
+func (r *Routes) GetExternalMetricsBackends(info provider.ExternalMetricInfo, namespace string) ([]backend.Backend, error) {
 	r.lock.RLock()
 	defer r.lock.RUnlock()
-	var services *MetricServiceList
-	var metricsService ServiceProperties
-	var ok bool
-	if services, ok = r.customMetrics[info]; !ok {
+	services, ok := r.externalMetrics[info]
+	if !ok {
+		services = r.undiscoveredMetricServices(v1beta1.ExternalMetricsType, info.Metric, nil)
+	}
+	if services == nil {
 		return nil, fmt.Errorf("metric %s is not provided by any metrics backend", info.Metric)
 	}
-	service, err := services.GetBestMetricService()
+	ordered := *services
+	if route := r.matchMetricRoute(v1beta1.ExternalMetricsType, info.Metric, nil, namespace); route != nil {
+		ordered = r.routeOrder(services, route)
+	}
+	backends := make([]backend.Backend, 0, len(ordered))
+	for _, service := range ordered {
+		if properties, ok := r.serviceProperties[serviceKey{Name: service.Name, Namespace: service.Namespace}]; ok {
+			backends = append(backends, properties.backend)
+		}
+	}
+	if len(backends) == 0 {
+		return nil, fmt.Errorf("no backend for metric %s", info.Metric)
+	}
+	return backends, nil
+}
+
+func (r *Routes) backend(services *MetricServiceList, route *metricRoute, metric string) (backend.Backend, error) {
+	service, err := r.bestService(services, route)
 	if err != nil {
-		return nil, fmt.Errorf("not backend for metric: %v", info.Metric)
+		return nil, fmt.Errorf("not backend for metric %s: %v", metric, err)
 	}
-	if metricsService, ok = r.serviceProperties[serviceKey{
+	metricsService, ok := r.serviceProperties[serviceKey{
 		Name:      service.Name,
 		Namespace: service.Namespace,
-	}]; !ok {
+	}]
+	if !ok {
 		return nil, fmt.Errorf("properties for metric service %s/%s is missing", service.Namespace, service.Name)
 	}
-	return metricsService.client, nil
+	return metricsService.backend, nil
 }
-func (r *Routes) GetExternalMetricsBackend(info provider.ExternalMetricInfo) (*metricsclient.Client, error) {
+
+// GetResourceMetricsBackends returns the backends which serve resource metrics
+// for resource, nodes or pods, in the order they should be tried. The sources
+// of a matching MetricRoute come first.
+func (r *Routes) GetResourceMetricsBackends(resource, namespace string) ([]backend.ResourceMetricsBackend, error) {
 	r.lock.RLock()
 	defer r.lock.RUnlock()
-	var services *MetricServiceList
-	var metricsService ServiceProperties
-	var ok bool
-	if services, ok = r.externalMetrics[info]; !ok {
-		return nil, fmt.Errorf("metric %s is not provided by any metrics backend", info.Metric)
+	services, ok := r.resourceMetrics[resource]
+	if !ok {
+		return nil, fmt.Errorf("resource metrics for %s are not provided by any metrics backend", resource)
 	}
-	service, err := services.GetBestMetricService()
-	if err != nil {
-		return nil, fmt.Errorf("not backend for metric: %v", info.Metric)
+	ordered := *services
+	if route := r.matchMetricRoute(v1beta1.ResourceMetricsType, resource, nil, namespace); route != nil {
+		ordered = r.routeOrder(services, route)
 	}
-	if metricsService, ok = r.serviceProperties[serviceKey{
-		Name:      service.Name,
-		Namespace: service.Namespace,
-	}]; !ok {
-		return nil, fmt.Errorf("properties for metric service %s/%s is missing", service.Namespace, service.Name)
+	backends := make([]backend.ResourceMetricsBackend, 0, len(ordered))
+	for _, service := range ordered {
+		if properties, ok := r.serviceProperties[serviceKey{Name: service.Name, Namespace: service.Namespace}]; ok {
+			if resourceBackend, ok := properties.backend.(backend.ResourceMetricsBackend); ok {
+				backends = append(backends, resourceBackend)
+			}
+		}
+	}
+	if len(backends) == 0 {
+		return nil, fmt.Errorf("none of the sources of the metric route for %s serves resource metrics", resource)
 	}
-	return metricsService.client, nil
+	return backends, nil
 }
 
 func (r *Routes) ListAllCustomMetrics() []provider.CustomMetricInfo {
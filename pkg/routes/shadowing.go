@@ -0,0 +1,84 @@
+package routes
+
+import (
+	"sort"
+	"time"
+
+	"github.com/kubernetes-sigs/custom-metrics-apiserver/pkg/provider"
+)
+
+// Overlap describes another source which serves some of the metrics of a
+// service.
+type Overlap struct {
+	Source string
+	// Shadowing is the number of shared metrics for which the other source
+	// is preferred.
+	Shadowing int
+	// Shadowed is the number of shared metrics which the other source no
+	// longer serves because the service is preferred.
+	Shadowed int
+}
+
+// MetricInfos returns the metrics which were discovered for a service.
+func (r *Routes) MetricInfos(name, namespace string) (map[provider.CustomMetricInfo]struct{}, map[provider.ExternalMetricInfo]struct{}, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	properties, ok := r.serviceProperties[serviceKey{Name: name, Namespace: namespace}]
+	if !ok {
+		return nil, nil, false
+	}
+	return properties.customMetricInfos, properties.externalMetricInfos, true
+}
+
+// Overlaps returns the sources which share metrics with a service that serves
+// the given metrics with priority. The result is sorted by source name.
+func (r *Routes) Overlaps(name, namespace string, priority int, created time.Time, customMetricInfos map[provider.CustomMetricInfo]struct{}, externalMetricInfos map[provider.ExternalMetricInfo]struct{}) []Overlap {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	candidate := MetricsAPIService{Name: name, Namespace: namespace, Created: created, Priority: priority}
+	overlaps := make(map[serviceKey]*Overlap)
+	compare := func(services *MetricServiceList) {
+		for _, service := range *services {
+			if service.Name == name && service.Namespace == namespace {
+				continue
+			}
+			key := serviceKey{Name: service.Name, Namespace: service.Namespace}
+			overlap, ok := overlaps[key]
+			if !ok {
+				overlap = &Overlap{Source: r.sourceName(key)}
+				overlaps[key] = overlap
+			}
+			if (MetricServiceList{candidate, service}).Less(0, 1) {
+				overlap.Shadowed++
+			} else {
+				overlap.Shadowing++
+			}
+		}
+	}
+	for info := range customMetricInfos {
+		if services, ok := r.customMetrics[info]; ok {
+			compare(services)
+		}
+	}
+	for info := range externalMetricInfos {
+		if services, ok := r.externalMetrics[info]; ok {
+			compare(services)
+		}
+	}
+
+	result := make([]Overlap, 0, len(overlaps))
+	for _, overlap := range overlaps {
+		result = append(result, *overlap)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Source < result[j].Source
+	})
+	return result
+}
+
+func (r *Routes) sourceName(key serviceKey) string {
+	if properties, ok := r.serviceProperties[key]; ok && properties.backend != nil && properties.backend.Source() != "" {
+		return properties.backend.Source()
+	}
+	return key.Namespace + "/" + key.Name
+}
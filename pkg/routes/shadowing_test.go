@@ -0,0 +1,34 @@
+package routes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kubernetes-sigs/custom-metrics-apiserver/pkg/provider"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestOverlaps(t *testing.T) {
+	requests := provider.CustomMetricInfo{GroupResource: schema.GroupResource{Resource: "pods"}, Namespaced: true, Metric: "http_requests"}
+	latency := provider.CustomMetricInfo{GroupResource: schema.GroupResource{Resource: "pods"}, Namespaced: true, Metric: "latency"}
+	queue := provider.ExternalMetricInfo{Metric: "queue_depth"}
+
+	r := New(nil)
+	r.customMetrics[requests] = NewMetricServiceList()
+	r.customMetrics[requests].AddService("low", "metrics", time.Unix(1, 0), 10)
+	r.customMetrics[requests].AddService("high", "metrics", time.Unix(1, 0), 200)
+	r.customMetrics[latency] = NewMetricServiceList()
+	r.customMetrics[latency].AddService("high", "metrics", time.Unix(1, 0), 200)
+	r.externalMetrics[queue] = NewMetricServiceList()
+	r.externalMetrics[queue].AddService("candidate", "metrics", time.Unix(1, 0), 100)
+
+	overlaps := r.Overlaps("candidate", "metrics", 100, time.Unix(2, 0),
+		map[provider.CustomMetricInfo]struct{}{requests: {}, latency: {}},
+		map[provider.ExternalMetricInfo]struct{}{queue: {}},
+	)
+	require.Equal(t, []Overlap{
+		{Source: "metrics/high", Shadowed: 2},
+		{Source: "metrics/low", Shadowing: 1},
+	}, overlaps)
+}
@@ -0,0 +1,43 @@
+package routes
+
+import (
+	"fmt"
+
+	"github.com/kubernetes-sigs/custom-metrics-apiserver/pkg/provider"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/backend"
+)
+
+// SeedService adds the routes of a service from a snapshot of an earlier
+// discovery without contacting the backend. The routes are stale until
+// AddService discovers the service again, and their discovery grace period
+// starts when they are seeded.
+func (r *Routes) SeedService(name, namespace string, metricsBackend backend.Backend, routing ServiceRouting, customMetricInfos map[provider.CustomMetricInfo]struct{}, externalMetricInfos map[provider.ExternalMetricInfo]struct{}, resourceMetrics map[string]struct{}) error {
+	patterns, err := compileMetricPatterns(routing.Patterns)
+	if err != nil {
+		return err
+	}
+	if _, ok := metricsBackend.(backend.ResourceMetricsBackend); !ok && len(resourceMetrics) > 0 {
+		return fmt.Errorf("the backend of %s doesn't serve resource metrics", metricsBackend.Source())
+	}
+	r.setService(name, namespace, metricsBackend, routing, patterns, customMetricInfos, externalMetricInfos, resourceMetrics, true)
+	return nil
+}
+
+// ServiceMetrics returns the metrics which are routed to a service.
+func (r *Routes) ServiceMetrics(name, namespace string) (map[provider.CustomMetricInfo]struct{}, map[provider.ExternalMetricInfo]struct{}, map[string]struct{}, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	properties, ok := r.serviceProperties[serviceKey{Name: name, Namespace: namespace}]
+	if !ok {
+		return nil, nil, nil, false
+	}
+	return properties.customMetricInfos, properties.externalMetricInfos, properties.resourceMetrics, true
+}
+
+// IsStale is true for services whose routes are still those of a snapshot.
+func (r *Routes) IsStale(name, namespace string) bool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.serviceProperties[serviceKey{Name: name, Namespace: namespace}].stale
+}
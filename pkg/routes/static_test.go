@@ -0,0 +1,55 @@
+package routes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kubernetes-sigs/custom-metrics-apiserver/pkg/provider"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+	"github.com/arjunrn/custom-metrics-router/pkg/metricsclient"
+)
+
+func TestAddServiceWithStaticMetrics(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Pod"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+
+	authenticator, err := metricsclient.NewAuthenticator(nil, v1beta1.ServiceReference{}, &v1beta1.Authentication{Mode: v1beta1.NoAuthentication})
+	require.NoError(t, err)
+	client, err := metricsclient.NewClient(metricsclient.Options{Source: "legacy", Name: "legacy", Namespace: "metrics", Port: 443, Authenticator: authenticator}, mapper)
+	require.NoError(t, err)
+	routing := ServiceRouting{
+		Priority:          100,
+		CreationTimestamp: time.Unix(1, 0),
+		CustomMetrics:     true,
+		ExternalMetrics:   true,
+		StaticMetrics: &v1beta1.StaticMetrics{
+			CustomMetrics: []v1beta1.StaticCustomMetric{
+				{Resource: "pods", Name: "http_requests", Namespaced: true},
+				{Resource: "deployments.apps", Name: "replicas_wanted", Namespaced: true},
+			},
+			ExternalMetrics:  []string{"queue_depth"},
+			DisableDiscovery: true,
+		},
+	}
+
+	r := New(mapper)
+	require.NoError(t, r.AddService("legacy", "metrics", client, routing))
+	require.ElementsMatch(t, []provider.CustomMetricInfo{
+		{GroupResource: schema.GroupResource{Resource: "pods"}, Namespaced: true, Metric: "http_requests"},
+		{GroupResource: schema.GroupResource{Group: "apps", Resource: "deployments"}, Namespaced: true, Metric: "replicas_wanted"},
+	}, r.ListAllCustomMetrics())
+	require.Equal(t, []provider.ExternalMetricInfo{{Metric: "queue_depth"}}, r.ListAllExternalMetrics())
+
+	metricsBackends, err := r.GetExternalMetricsBackends(provider.ExternalMetricInfo{Metric: "queue_depth"}, "default")
+	require.NoError(t, err)
+	require.Len(t, metricsBackends, 1)
+	require.Equal(t, "legacy", metricsBackends[0].Source())
+
+	routing.StaticMetrics.CustomMetrics = []v1beta1.StaticCustomMetric{{Resource: "widgets", Name: "spin"}}
+	require.Error(t, r.AddService("legacy", "metrics", client, routing))
+}
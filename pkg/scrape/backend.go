@@ -0,0 +1,59 @@
+package scrape
+
+import (
+	"fmt"
+	"strconv"
+
+	"k8s.io/client-go/rest"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+	"github.com/arjunrn/custom-metrics-router/pkg/backend"
+	"github.com/arjunrn/custom-metrics-router/pkg/metricsclient"
+)
+
+var _ backend.Backend = &Client{}
+
+func init() {
+	backend.Register(v1beta1.ScrapeBackendType, newBackend)
+}
+
+// newBackend returns a client which scrapes the pods with the TLS config and
+// the credentials of a source, which are none by default.
+func newBackend(source *v1beta1.CustomMetricsSource, deps backend.Dependencies) (backend.Backend, error) {
+	spec := &source.Spec.Backend
+	if spec.Scrape == nil {
+		return nil, fmt.Errorf("custom metrics source %s of type %s has no scrape backend", source.Name, v1beta1.ScrapeBackendType)
+	}
+	if deps.KubeClient == nil {
+		return nil, fmt.Errorf("custom metrics source %s of type %s requires a kube client", source.Name, v1beta1.ScrapeBackendType)
+	}
+	if deps.Rates == nil {
+		return nil, fmt.Errorf("custom metrics source %s of type %s requires a rate tracker", source.Name, v1beta1.ScrapeBackendType)
+	}
+	// the pods of any namespace a request names receive the credentials, so
+	// the router's own token is never sent to them.
+	auth := spec.Authentication
+	if auth == nil {
+		auth = &v1beta1.Authentication{Mode: v1beta1.NoAuthentication}
+	}
+	if auth.Mode != v1beta1.NoAuthentication && auth.Mode != v1beta1.SecretTokenAuthentication {
+		return nil, fmt.Errorf("custom metrics source %s of type %s doesn't support the authentication mode %s", source.Name, v1beta1.ScrapeBackendType, auth.Mode)
+	}
+	if spec.RequesterForwarding != "" && spec.RequesterForwarding != v1beta1.NoRequesterForwarding {
+		return nil, fmt.Errorf("custom metrics source %s of type %s doesn't support requester forwarding", source.Name, v1beta1.ScrapeBackendType)
+	}
+	authenticator, err := metricsclient.NewAuthenticator(deps.KubeClient, spec.Service, auth)
+	if err != nil {
+		return nil, fmt.Errorf("invalid authentication for custom metrics source %s: %v", source.Name, err)
+	}
+	host := fmt.Sprintf("%s.%s", spec.Service.Name, spec.Service.Namespace)
+	config, err := metricsclient.InClusterConfig(host, strconv.Itoa(int(spec.Service.Port)), spec.TLS.InsecureSkipVerify, spec.TLS.CABundle, authenticator, v1beta1.NoRequesterForwarding)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate rest config for %s: %v", host, err)
+	}
+	transport, err := rest.TransportFor(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transport: %v", err)
+	}
+	return NewClient(source.Name, transport, deps.KubeClient.CoreV1(), deps.Rates, spec.Scrape), nil
+}
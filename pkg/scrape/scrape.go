@@ -0,0 +1,325 @@
+package scrape
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kubernetes-sigs/custom-metrics-apiserver/pkg/provider"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/klog"
+	"k8s.io/metrics/pkg/apis/custom_metrics"
+	"k8s.io/metrics/pkg/apis/external_metrics"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+	"github.com/arjunrn/custom-metrics-router/pkg/rates"
+)
+
+const (
+	// DefaultPath is the path of the metrics endpoints of backends which don't
+	// set one.
+	DefaultPath = "/metrics"
+	// DefaultTimeout is the timeout of scrapes of backends which don't set one.
+	DefaultTimeout = 10 * time.Second
+	// DefaultMaxConcurrentScrapes limits the concurrent scrapes of a request
+	// of backends which don't set a limit.
+	DefaultMaxConcurrentScrapes = 10
+
+	acceptHeader = `text/plain;version=0.0.4;q=1,*/*;q=0.1`
+)
+
+var podsResource = schema.GroupResource{Resource: "pods"}
+
+type metric struct {
+	family     string
+	rateWindow time.Duration
+}
+
+// Client serves custom metrics of pods by scraping their metrics endpoints.
+type Client struct {
+	source               string
+	scheme               string
+	port                 intstr.IntOrString
+	path                 string
+	httpClient           *http.Client
+	maxConcurrentScrapes int
+	metrics              map[string]metric
+	pods                 corev1client.PodsGetter
+	rates                *rates.Tracker
+}
+
+// NewClient returns a client which scrapes the pods it gets from pods and
+// keeps the samples of rates in tracker.
+func NewClient(source string, transport http.RoundTripper, pods corev1client.PodsGetter, tracker *rates.Tracker, backend *v1beta1.ScrapeBackend) *Client {
+	client := &Client{
+		source:               source,
+		scheme:               string(backend.Scheme),
+		port:                 backend.Port,
+		path:                 backend.Path,
+		httpClient:           &http.Client{Transport: transport, Timeout: DefaultTimeout},
+		maxConcurrentScrapes: int(backend.MaxConcurrentScrapes),
+		metrics:              make(map[string]metric, len(backend.Metrics)),
+		pods:                 pods,
+		rates:                tracker,
+	}
+	if client.scheme == "" {
+		client.scheme = v1beta1.HTTPScheme
+	}
+	if client.path == "" {
+		client.path = DefaultPath
+	}
+	if backend.Timeout != nil {
+		client.httpClient.Timeout = backend.Timeout.Duration
+	}
+	if client.maxConcurrentScrapes <= 0 {
+		client.maxConcurrentScrapes = DefaultMaxConcurrentScrapes
+	}
+	for _, m := range backend.Metrics {
+		parsed := metric{family: m.Family}
+		if parsed.family == "" {
+			parsed.family = m.Name
+		}
+		if m.RateWindow != nil {
+			parsed.rateWindow = m.RateWindow.Duration
+		}
+		client.metrics[m.Name] = parsed
+	}
+	return client
+}
+
+// Source returns the name of the source the client was created for.
+func (c *Client) Source() string {
+	return c.source
+}
+
+func (c *Client) ListCustomMetricInfos() (map[provider.CustomMetricInfo]struct{}, error) {
+	infos := make(map[provider.CustomMetricInfo]struct{}, len(c.metrics))
+	for name := range c.metrics {
+		infos[provider.CustomMetricInfo{GroupResource: podsResource, Namespaced: true, Metric: name}] = struct{}{}
+	}
+	return infos, nil
+}
+
+// ListExternalMetrics returns no metrics, scrape backends only serve custom
+// metrics of pods.
+func (c *Client) ListExternalMetrics() (map[provider.ExternalMetricInfo]struct{}, error) {
+	return map[provider.ExternalMetricInfo]struct{}{}, nil
+}
+
+func (c *Client) GetExternalMetric(name, namespace string, metricSelector labels.Selector) (*external_metrics.ExternalMetricValueList, error) {
+	return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "externalmetrics"}, name)
+}
+
+func (c *Client) GetMetricByName(name types.NamespacedName, info provider.CustomMetricInfo, metricSelector labels.Selector) (*custom_metrics.MetricValue, error) {
+	m, err := c.metric(info)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.httpClient.Timeout)
+	defer cancel()
+	pod, err := c.pods.Pods(name.Namespace).Get(ctx, name.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	values, err := c.scrapePods(m, info.Metric, []corev1.Pod{*pod}, metricSelector)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, apierrors.NewNotFound(info.GroupResource, name.Name)
+	}
+	return &values[0], nil
+}
+
+func (c *Client) GetMetricBySelector(namespace string, selector labels.Selector, info provider.CustomMetricInfo, metricSelector labels.Selector) (*custom_metrics.MetricValueList, error) {
+	m, err := c.metric(info)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.httpClient.Timeout)
+	defer cancel()
+	pods, err := c.pods.Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %v", err)
+	}
+	values, err := c.scrapePods(m, info.Metric, pods.Items, metricSelector)
+	if err != nil {
+		return nil, err
+	}
+	return &custom_metrics.MetricValueList{Items: values}, nil
+}
+
+func (c *Client) metric(info provider.CustomMetricInfo) (metric, error) {
+	m, ok := c.metrics[info.Metric]
+	if !ok || info.GroupResource != podsResource {
+		return metric{}, apierrors.NewNotFound(info.GroupResource, info.Metric)
+	}
+	return m, nil
+}
+
+// scrapePods scrapes the running pods with at most maxConcurrentScrapes
+// scrapes at once. Pods which fail to be scraped are skipped, an error is
+// only returned when no pod has a value.
+func (c *Client) scrapePods(m metric, name string, pods []corev1.Pod, metricSelector labels.Selector) ([]custom_metrics.MetricValue, error) {
+	identifier := custom_metrics.MetricIdentifier{Name: name}
+	if !metricSelector.Empty() {
+		var err error
+		identifier.Selector, err = metav1.ParseToLabelSelector(metricSelector.String())
+		if err != nil {
+			return nil, apierrors.NewBadRequest(err.Error())
+		}
+	}
+
+	values := make([]*custom_metrics.MetricValue, len(pods))
+	errs := make([]error, len(pods))
+	slots := make(chan struct{}, c.maxConcurrentScrapes)
+	var wg sync.WaitGroup
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Status.Phase != corev1.PodRunning || pod.Status.PodIP == "" {
+			continue
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			values[i], errs[i] = c.scrapePod(m, identifier, pod, metricSelector)
+		}(i)
+	}
+	wg.Wait()
+
+	var result []custom_metrics.MetricValue
+	var lastErr error
+	for i, value := range values {
+		if errs[i] != nil {
+			klog.V(2).Infof("Failed to scrape pod %s/%s of %s: %v", pods[i].Namespace, pods[i].Name, c.source, errs[i])
+			lastErr = errs[i]
+		} else if value != nil {
+			result = append(result, *value)
+		}
+	}
+	if len(result) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	return result, nil
+}
+
+// scrapePod returns the value of the metric of a pod, or nil when the pod
+// doesn't have one.
+func (c *Client) scrapePod(m metric, identifier custom_metrics.MetricIdentifier, pod *corev1.Pod, metricSelector labels.Selector) (*custom_metrics.MetricValue, error) {
+	port, err := c.podPort(pod)
+	if err != nil {
+		return nil, err
+	}
+	families, err := c.scrape(c.scheme + "://" + net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(int(port))) + c.path)
+	if err != nil {
+		return nil, err
+	}
+	family, ok := families[m.family]
+	if !ok {
+		return nil, nil
+	}
+	value, ok := sum(family, metricSelector)
+	if !ok || math.IsNaN(value) || math.IsInf(value, 0) {
+		return nil, nil
+	}
+	now := time.Now()
+	var windowSeconds *int64
+	if m.rateWindow > 0 {
+		key := fmt.Sprintf("%s/%s/%s/%s", c.source, identifier.Name, pod.UID, metricSelector)
+		if value, ok = c.rates.Observe(key, now, value, m.rateWindow); !ok {
+			return nil, nil
+		}
+		seconds := int64(m.rateWindow.Seconds())
+		windowSeconds = &seconds
+	}
+	return &custom_metrics.MetricValue{
+		DescribedObject: custom_metrics.ObjectReference{Kind: "Pod", APIVersion: "v1", Namespace: pod.Namespace, Name: pod.Name},
+		Metric:          identifier,
+		Timestamp:       metav1.NewTime(now),
+		WindowSeconds:   windowSeconds,
+		Value:           *resource.NewMilliQuantity(int64(math.Round(value*1000)), resource.DecimalSI),
+	}, nil
+}
+
+// podPort resolves the port of the backend to a port of the containers of the
+// pod.
+func (c *Client) podPort(pod *corev1.Pod) (int32, error) {
+	if c.port.Type == intstr.Int {
+		return c.port.IntVal, nil
+	}
+	for _, container := range pod.Spec.Containers {
+		for _, port := range container.Ports {
+			if port.Name == c.port.StrVal {
+				return port.ContainerPort, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("pod has no container port %s", c.port.StrVal)
+}
+
+func (c *Client) scrape(url string) (map[string]*dto.MetricFamily, error) {
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Accept", acceptHeader)
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("scraping %s failed with status %d", url, response.StatusCode)
+	}
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid metrics of %s: %v", url, err)
+	}
+	return families, nil
+}
+
+// sum adds up the samples of a counter, gauge or untyped family whose labels
+// match the metric selector.
+func sum(family *dto.MetricFamily, metricSelector labels.Selector) (float64, bool) {
+	var total float64
+	var found bool
+	for _, m := range family.Metric {
+		set := make(labels.Set, len(m.Label))
+		for _, pair := range m.Label {
+			set[pair.GetName()] = pair.GetValue()
+		}
+		if !metricSelector.Matches(set) {
+			continue
+		}
+		switch family.GetType() {
+		case dto.MetricType_COUNTER:
+			total += m.GetCounter().GetValue()
+		case dto.MetricType_GAUGE:
+			total += m.GetGauge().GetValue()
+		case dto.MetricType_UNTYPED:
+			total += m.GetUntyped().GetValue()
+		default:
+			return 0, false
+		}
+		found = true
+	}
+	return total, found
+}
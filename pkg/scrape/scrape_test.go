@@ -0,0 +1,267 @@
+package scrape
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kubernetes-sigs/custom-metrics-apiserver/pkg/provider"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+	"github.com/arjunrn/custom-metrics-router/pkg/backend"
+	"github.com/arjunrn/custom-metrics-router/pkg/rates"
+)
+
+// metricsServer serves the metrics of a pod, the counter increases with every
+// scrape.
+type metricsServer struct {
+	*httptest.Server
+	lock     sync.Mutex
+	scrapes  int
+	inFlight int
+	maxLoad  int
+}
+
+func newMetricsServer(t *testing.T, delay time.Duration) *metricsServer {
+	s := &metricsServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/metrics", r.URL.Path)
+		s.lock.Lock()
+		s.scrapes++
+		s.inFlight++
+		if s.inFlight > s.maxLoad {
+			s.maxLoad = s.inFlight
+		}
+		scrapes := s.scrapes
+		s.lock.Unlock()
+		time.Sleep(delay)
+		fmt.Fprintf(w, `# TYPE http_requests_total counter
+http_requests_total{code="200"} %d
+http_requests_total{code="500"} 1
+# TYPE queue_depth gauge
+queue_depth 7.5
+# TYPE latency_seconds summary
+latency_seconds{quantile="0.5"} 0.1
+latency_seconds_sum 2
+latency_seconds_count 20
+`, scrapes*10)
+		s.lock.Lock()
+		s.inFlight--
+		s.lock.Unlock()
+	}))
+	return s
+}
+
+func testPod(t *testing.T, name string, server *metricsServer, phase corev1.PodPhase) *corev1.Pod {
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+	portNumber, err := strconv.Atoi(port)
+	require.NoError(t, err)
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, UID: types.UID(name), Labels: map[string]string{"app": "web"}},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name:  "web",
+			Ports: []corev1.ContainerPort{{Name: "metrics", ContainerPort: int32(portNumber)}},
+		}}},
+		Status: corev1.PodStatus{Phase: phase, PodIP: host},
+	}
+}
+
+func TestClient(t *testing.T) {
+	web0 := newMetricsServer(t, 0)
+	defer web0.Close()
+	web1 := newMetricsServer(t, 0)
+	defer web1.Close()
+	pending := newMetricsServer(t, 0)
+	defer pending.Close()
+	kubeClient := kubefake.NewSimpleClientset(
+		testPod(t, "web-0", web0, corev1.PodRunning),
+		testPod(t, "web-1", web1, corev1.PodRunning),
+		testPod(t, "web-2", pending, corev1.PodPending),
+	)
+	client := NewClient("test-client", http.DefaultTransport, kubeClient.CoreV1(), rates.NewTracker(), &v1beta1.ScrapeBackend{
+		Port: intstr.FromString("metrics"),
+		Metrics: []v1beta1.ScrapeMetric{
+			{Name: "queue_depth"},
+			{Name: "http_requests", Family: "http_requests_total"},
+			{Name: "http_requests_per_second", Family: "http_requests_total", RateWindow: &metav1.Duration{Duration: time.Minute}},
+			{Name: "latency", Family: "latency_seconds"},
+			{Name: "missing"},
+		},
+	})
+
+	infos, err := client.ListCustomMetricInfos()
+	require.NoError(t, err)
+	require.Len(t, infos, 5)
+	require.Contains(t, infos, provider.CustomMetricInfo{GroupResource: podsResource, Namespaced: true, Metric: "queue_depth"})
+
+	pods := func(metric string) provider.CustomMetricInfo {
+		return provider.CustomMetricInfo{GroupResource: podsResource, Namespaced: true, Metric: metric}
+	}
+	for _, tc := range []struct {
+		name           string
+		info           provider.CustomMetricInfo
+		metricSelector labels.Selector
+		expected       map[string]int64
+		expectedError  func(error) bool
+	}{
+		{
+			name:           "gauge",
+			info:           pods("queue_depth"),
+			metricSelector: labels.Everything(),
+			expected:       map[string]int64{"web-0": 7500, "web-1": 7500},
+		},
+		{
+			name:           "counter filtered by the metric selector",
+			info:           pods("http_requests"),
+			metricSelector: labels.SelectorFromSet(labels.Set{"code": "500"}),
+			expected:       map[string]int64{"web-0": 1000, "web-1": 1000},
+		},
+		{
+			name:           "summary",
+			info:           pods("latency"),
+			metricSelector: labels.Everything(),
+			expected:       map[string]int64{},
+		},
+		{
+			name:           "missing family",
+			info:           pods("missing"),
+			metricSelector: labels.Everything(),
+			expected:       map[string]int64{},
+		},
+		{
+			name:           "unknown metric",
+			info:           pods("unknown"),
+			metricSelector: labels.Everything(),
+			expectedError:  apierrors.IsNotFound,
+		},
+		{
+			name:           "other resource",
+			info:           provider.CustomMetricInfo{GroupResource: schema.GroupResource{Group: "apps", Resource: "deployments"}, Namespaced: true, Metric: "queue_depth"},
+			metricSelector: labels.Everything(),
+			expectedError:  apierrors.IsNotFound,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			list, err := client.GetMetricBySelector("default", labels.SelectorFromSet(labels.Set{"app": "web"}), tc.info, tc.metricSelector)
+			if tc.expectedError != nil {
+				require.True(t, tc.expectedError(err), "%v", err)
+				return
+			}
+			require.NoError(t, err)
+			values := make(map[string]int64)
+			for _, item := range list.Items {
+				require.Equal(t, tc.info.Metric, item.Metric.Name)
+				values[item.DescribedObject.Name] = item.Value.MilliValue()
+			}
+			require.Equal(t, tc.expected, values)
+		})
+	}
+	require.Equal(t, 0, pending.scrapes)
+
+	t.Run("by name", func(t *testing.T) {
+		value, err := client.GetMetricByName(types.NamespacedName{Namespace: "default", Name: "web-1"}, pods("queue_depth"), labels.Everything())
+		require.NoError(t, err)
+		require.Equal(t, "Pod", value.DescribedObject.Kind)
+		require.Equal(t, int64(7500), value.Value.MilliValue())
+
+		_, err = client.GetMetricByName(types.NamespacedName{Namespace: "default", Name: "web-2"}, pods("queue_depth"), labels.Everything())
+		require.True(t, apierrors.IsNotFound(err), "%v", err)
+		_, err = client.GetMetricByName(types.NamespacedName{Namespace: "default", Name: "web-9"}, pods("queue_depth"), labels.Everything())
+		require.True(t, apierrors.IsNotFound(err), "%v", err)
+	})
+
+	t.Run("rate", func(t *testing.T) {
+		name := types.NamespacedName{Namespace: "default", Name: "web-0"}
+		_, err := client.GetMetricByName(name, pods("http_requests_per_second"), labels.Everything())
+		require.True(t, apierrors.IsNotFound(err), "%v", err)
+		value, err := client.GetMetricByName(name, pods("http_requests_per_second"), labels.Everything())
+		require.NoError(t, err)
+		require.True(t, value.Value.Sign() > 0)
+		require.Equal(t, int64(60), *value.WindowSeconds)
+	})
+}
+
+func TestMaxConcurrentScrapes(t *testing.T) {
+	for _, tc := range []struct {
+		name                 string
+		maxConcurrentScrapes int32
+	}{
+		{name: "sequential", maxConcurrentScrapes: 1},
+		{name: "concurrent", maxConcurrentScrapes: 3},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// all pods are served by the same server, which sees the
+			// concurrent scrapes.
+			server := newMetricsServer(t, 20*time.Millisecond)
+			defer server.Close()
+			var objects []runtime.Object
+			for i := 0; i < 6; i++ {
+				objects = append(objects, testPod(t, fmt.Sprintf("web-%d", i), server, corev1.PodRunning))
+			}
+			client := NewClient("test-concurrency", http.DefaultTransport, kubefake.NewSimpleClientset(objects...).CoreV1(), rates.NewTracker(), &v1beta1.ScrapeBackend{
+				Port:                 intstr.FromString("metrics"),
+				MaxConcurrentScrapes: tc.maxConcurrentScrapes,
+				Metrics:              []v1beta1.ScrapeMetric{{Name: "queue_depth"}},
+			})
+			list, err := client.GetMetricBySelector("default", labels.Everything(), provider.CustomMetricInfo{GroupResource: podsResource, Namespaced: true, Metric: "queue_depth"}, labels.Everything())
+			require.NoError(t, err)
+			require.Len(t, list.Items, 6)
+			require.True(t, server.maxLoad <= int(tc.maxConcurrentScrapes), "%d concurrent scrapes", server.maxLoad)
+		})
+	}
+}
+
+func TestNewBackend(t *testing.T) {
+	var authorization []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = append(authorization, r.Header.Get("Authorization"))
+		fmt.Fprintln(w, "queue_depth 3")
+	}))
+	defer server.Close()
+	deps := backend.Dependencies{
+		KubeClient: kubefake.NewSimpleClientset(testPod(t, "web-0", &metricsServer{Server: server}, corev1.PodRunning)),
+		Rates:      rates.NewTracker(),
+	}
+	source := &v1beta1.CustomMetricsSource{
+		ObjectMeta: metav1.ObjectMeta{Name: "web"},
+		Spec: v1beta1.CustomMetricsSourceSpec{Backend: v1beta1.Backend{
+			Type:    v1beta1.ScrapeBackendType,
+			Service: v1beta1.ServiceReference{Namespace: "default", Name: "web", Port: 443},
+			Scrape: &v1beta1.ScrapeBackend{
+				Port:    intstr.FromString("metrics"),
+				Metrics: []v1beta1.ScrapeMetric{{Name: "queue_depth"}},
+			},
+		}},
+	}
+
+	// the router's own token is never sent to the pods.
+	scraper, err := newBackend(source, deps)
+	require.NoError(t, err)
+	list, err := scraper.GetMetricBySelector("default", labels.Everything(),
+		provider.CustomMetricInfo{GroupResource: podsResource, Namespaced: true, Metric: "queue_depth"}, labels.Everything())
+	require.NoError(t, err)
+	require.Len(t, list.Items, 1)
+	require.Equal(t, []string{""}, authorization)
+
+	for _, mode := range []v1beta1.AuthenticationMode{v1beta1.ServiceAccountAuthentication, v1beta1.ImpersonationAuthentication} {
+		source.Spec.Backend.Authentication = &v1beta1.Authentication{Mode: mode}
+		_, err = newBackend(source, deps)
+		require.Error(t, err)
+	}
+}
@@ -0,0 +1,186 @@
+package series
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog"
+)
+
+const (
+	// MetricNameLabel is the label with the name of the metric of a series.
+	MetricNameLabel = "__name__"
+	// expireInterval is how often series older than the maximum age are
+	// removed.
+	expireInterval = 30 * time.Second
+)
+
+// Series is the latest sample of a series.
+type Series struct {
+	// Labels include the name of the metric as __name__.
+	Labels map[string]string
+	// Namespace is the namespace which the sender of the series was
+	// authorized for, or empty when it may send series of all namespaces.
+	Namespace string
+	Value     float64
+	Timestamp time.Time
+}
+
+// Store keeps the latest sample of a bounded number of series. The stores of
+// the receivers of the router outlive the backends which read from them, which
+// are created again on every resync.
+type Store struct {
+	lock      sync.RWMutex
+	series    map[string]*Series
+	byName    map[string]map[string]*Series
+	maxSeries int
+	maxAge    time.Duration
+	now       func() time.Time
+}
+
+// NewStore returns a store which keeps at most maxSeries series for maxAge
+// after their latest sample.
+func NewStore(maxSeries int, maxAge time.Duration) *Store {
+	return &Store{
+		series:    make(map[string]*Series),
+		byName:    make(map[string]map[string]*Series),
+		maxSeries: maxSeries,
+		maxAge:    maxAge,
+		now:       time.Now,
+	}
+}
+
+// Set stores a sample of the series with the given labels, which include the
+// name of the metric as __name__, for a sender authorized for namespace. It
+// returns false when the series was dropped because the store is full.
+func (s *Store) Set(namespace string, labels map[string]string, value float64, timestamp time.Time) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.set(namespace, labels, value, s.clamp(timestamp))
+}
+
+// Remove ends the series with the given labels unless it has a sample after
+// timestamp.
+func (s *Store) Remove(labels map[string]string, timestamp time.Time) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.remove(labels, s.clamp(timestamp))
+}
+
+// Select returns copies of the series of a metric which have all the labels
+// of match.
+func (s *Store) Select(name string, match map[string]string) []Series {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	var selected []Series
+	for _, series := range s.byName[name] {
+		matches := true
+		for label, value := range match {
+			if series.Labels[label] != value {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			selected = append(selected, *series)
+		}
+	}
+	return selected
+}
+
+// Expire removes the series without a sample within the maximum age.
+func (s *Store) Expire() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	oldest := s.now().Add(-s.maxAge)
+	for key, series := range s.series {
+		if series.Timestamp.Before(oldest) {
+			s.delete(key, series.Labels[MetricNameLabel])
+		}
+	}
+}
+
+// Run removes expired series until stopCh is closed.
+func (s *Store) Run(stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+	klog.Infof("Starting series store")
+	defer klog.Infof("Shutting down series store")
+	wait.Until(s.Expire, expireInterval, stopCh)
+}
+
+// clamp returns now for timestamps in the future, which the clocks of senders
+// may report. Such samples would neither expire nor be replaced by later ones.
+func (s *Store) clamp(timestamp time.Time) time.Time {
+	if now := s.now(); timestamp.After(now) {
+		return now
+	}
+	return timestamp
+}
+
+// set stores a sample unless the series has a newer one. The lock must be
+// held.
+func (s *Store) set(namespace string, labels map[string]string, value float64, timestamp time.Time) bool {
+	name := labels[MetricNameLabel]
+	if name == "" {
+		return true
+	}
+	key := seriesKey(labels)
+	series, ok := s.series[key]
+	if ok && series.Timestamp.After(timestamp) {
+		return true
+	}
+	if !ok {
+		if len(s.series) >= s.maxSeries {
+			return false
+		}
+		series = &Series{Labels: labels}
+		s.series[key] = series
+		if s.byName[name] == nil {
+			s.byName[name] = make(map[string]*Series)
+		}
+		s.byName[name][key] = series
+	}
+	series.Namespace = namespace
+	series.Value = value
+	series.Timestamp = timestamp
+	return true
+}
+
+// remove deletes a series unless it has a newer sample. The lock must be held.
+func (s *Store) remove(labels map[string]string, timestamp time.Time) {
+	key := seriesKey(labels)
+	if series, ok := s.series[key]; ok && !series.Timestamp.After(timestamp) {
+		s.delete(key, labels[MetricNameLabel])
+	}
+}
+
+// delete removes a series. The lock must be held.
+func (s *Store) delete(key, name string) {
+	delete(s.series, key)
+	delete(s.byName[name], key)
+	if len(s.byName[name]) == 0 {
+		delete(s.byName, name)
+	}
+}
+
+// seriesKey returns a key which identifies a series independently of the
+// order of its labels.
+func seriesKey(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var key strings.Builder
+	for _, name := range names {
+		key.WriteString(name)
+		key.WriteByte(0)
+		key.WriteString(labels[name])
+		key.WriteByte(0)
+	}
+	return key.String()
+}
@@ -0,0 +1,75 @@
+package series
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	now := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	store := NewStore(3, time.Minute)
+	store.now = func() time.Time { return now }
+
+	require.True(t, store.Set("", map[string]string{"__name__": "jobs_pending", "queue": "orders"}, 1, now.Add(-time.Second)))
+	require.True(t, store.Set("shop", map[string]string{"__name__": "jobs_pending", "queue": "billing"}, 2, now.Add(-time.Second)))
+	require.True(t, store.Set("", map[string]string{"__name__": "jobs_running", "queue": "orders"}, 3, now.Add(-2*time.Minute)))
+	// series without a name are ignored.
+	require.True(t, store.Set("", map[string]string{"queue": "orders"}, 4, now))
+
+	// a series is identified by its labels, not by the sender's namespace.
+	require.True(t, store.Set("", map[string]string{"queue": "orders", "__name__": "jobs_pending"}, 5, now))
+	// older samples don't replace newer ones.
+	require.True(t, store.Set("", map[string]string{"__name__": "jobs_pending", "queue": "billing"}, 6, now.Add(-time.Minute)))
+	require.False(t, store.Set("", map[string]string{"__name__": "jobs_failed", "queue": "orders"}, 7, now))
+
+	values := func(name string, match map[string]string) map[string]float64 {
+		values := make(map[string]float64)
+		for _, series := range store.Select(name, match) {
+			values[series.Labels["queue"]+"/"+series.Namespace] = series.Value
+		}
+		return values
+	}
+	require.Equal(t, map[string]float64{"orders/": 5, "billing/shop": 2}, values("jobs_pending", nil))
+	require.Equal(t, map[string]float64{"billing/shop": 2}, values("jobs_pending", map[string]string{"queue": "billing"}))
+	require.Empty(t, values("jobs_failed", nil))
+
+	// a removal older than the latest sample is ignored.
+	store.Remove(map[string]string{"__name__": "jobs_pending", "queue": "orders"}, now.Add(-time.Second))
+	store.Remove(map[string]string{"__name__": "jobs_pending", "queue": "billing"}, now)
+	require.Equal(t, map[string]float64{"orders/": 5}, values("jobs_pending", nil))
+
+	store.Expire()
+	require.Empty(t, values("jobs_running", nil))
+	require.Len(t, store.series, 1)
+	require.Len(t, store.byName, 1)
+}
+
+func TestStoreClampsFutureTimestamps(t *testing.T) {
+	now := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	store := NewStore(3, time.Minute)
+	store.now = func() time.Time { return now }
+	labels := map[string]string{"__name__": "jobs_pending", "queue": "orders"}
+
+	require.True(t, store.Set("", labels, 1, now.Add(24*time.Hour)))
+	require.Equal(t, now, store.Select("jobs_pending", nil)[0].Timestamp)
+
+	// a later sample replaces the one from the future.
+	now = now.Add(time.Second)
+	require.True(t, store.Set("", labels, 2, now))
+	require.Equal(t, 2.0, store.Select("jobs_pending", nil)[0].Value)
+
+	// a removal from the future doesn't keep later samples out.
+	store.Remove(labels, now.Add(24*time.Hour))
+	require.Empty(t, store.Select("jobs_pending", nil))
+	now = now.Add(time.Second)
+	require.True(t, store.Set("", labels, 3, now))
+	require.Equal(t, 3.0, store.Select("jobs_pending", nil)[0].Value)
+
+	// a sample from the future expires after the maximum age.
+	require.True(t, store.Set("", labels, 4, now.Add(24*time.Hour)))
+	now = now.Add(2 * time.Minute)
+	store.Expire()
+	require.Empty(t, store.Select("jobs_pending", nil))
+}
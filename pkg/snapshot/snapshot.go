@@ -0,0 +1,323 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kubernetes-sigs/custom-metrics-apiserver/pkg/provider"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+)
+
+// configMapKey is the key of the snapshot in a ConfigMap.
+const configMapKey = "snapshot.json"
+
+// Snapshot is the last successful discovery of every source.
+type Snapshot struct {
+	Sources []Source `json:"sources"`
+}
+
+// Source is a source together with the metrics which were discovered for it.
+type Source struct {
+	// Owner is the controller which added the source.
+	Owner             string                          `json:"owner"`
+	Name              string                          `json:"name"`
+	CreationTimestamp metav1.Time                     `json:"creationTimestamp,omitempty"`
+	Spec              v1beta1.CustomMetricsSourceSpec `json:"spec"`
+	DiscoveredAt      metav1.Time                     `json:"discoveredAt"`
+	CustomMetrics     []CustomMetric                  `json:"customMetrics,omitempty"`
+	ExternalMetrics   []string                        `json:"externalMetrics,omitempty"`
+	ResourceMetrics   []string                        `json:"resourceMetrics,omitempty"`
+}
+
+type CustomMetric struct {
+	GroupResource string `json:"groupResource"`
+	Namespaced    bool   `json:"namespaced"`
+	Metric        string `json:"metric"`
+}
+
+// CustomMetricsSource returns the source as an object.
+func (s *Source) CustomMetricsSource() *v1beta1.CustomMetricsSource {
+	return &v1beta1.CustomMetricsSource{
+		ObjectMeta: metav1.ObjectMeta{Name: s.Name, CreationTimestamp: s.CreationTimestamp},
+		Spec:       *s.Spec.DeepCopy(),
+	}
+}
+
+// Metrics returns the metrics of the source in the form of the routes.
+func (s *Source) Metrics() (map[provider.CustomMetricInfo]struct{}, map[provider.ExternalMetricInfo]struct{}, map[string]struct{}) {
+	customMetricInfos := make(map[provider.CustomMetricInfo]struct{}, len(s.CustomMetrics))
+	for _, metric := range s.CustomMetrics {
+		customMetricInfos[provider.CustomMetricInfo{
+			GroupResource: schema.ParseGroupResource(metric.GroupResource),
+			Namespaced:    metric.Namespaced,
+			Metric:        metric.Metric,
+		}] = struct{}{}
+	}
+	externalMetricInfos := make(map[provider.ExternalMetricInfo]struct{}, len(s.ExternalMetrics))
+	for _, metric := range s.ExternalMetrics {
+		externalMetricInfos[provider.ExternalMetricInfo{Metric: metric}] = struct{}{}
+	}
+	resourceMetrics := make(map[string]struct{}, len(s.ResourceMetrics))
+	for _, resource := range s.ResourceMetrics {
+		resourceMetrics[resource] = struct{}{}
+	}
+	return customMetricInfos, externalMetricInfos, resourceMetrics
+}
+
+// Store persists a Snapshot.
+type Store interface {
+	// Load returns an empty Snapshot when none was saved yet.
+	Load() (*Snapshot, error)
+	Save(snapshot *Snapshot) error
+}
+
+// FileStore keeps the snapshot in a file, e.g. on a persistent volume.
+type FileStore struct {
+	Path string
+}
+
+func (s *FileStore) Load() (*Snapshot, error) {
+	data, err := ioutil.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return &Snapshot{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decode(data)
+}
+
+func (s *FileStore) Save(snapshot *Snapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(s.Path), filepath.Base(s.Path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.Path)
+}
+
+// ConfigMapStore keeps the snapshot in a ConfigMap, so that it is shared by
+// the replicas of the router.
+type ConfigMapStore struct {
+	Client    kubernetes.Interface
+	Namespace string
+	Name      string
+}
+
+func (s *ConfigMapStore) Load() (*Snapshot, error) {
+	configMap, err := s.Client.CoreV1().ConfigMaps(s.Namespace).Get(context.TODO(), s.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return &Snapshot{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decode([]byte(configMap.Data[configMapKey]))
+}
+
+func (s *ConfigMapStore) Save(snapshot *Snapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	configMaps := s.Client.CoreV1().ConfigMaps(s.Namespace)
+	configMap, err := configMaps.Get(context.TODO(), s.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		_, err = configMaps.Create(context.TODO(), &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: s.Namespace, Name: s.Name},
+			Data:       map[string]string{configMapKey: string(data)},
+		}, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	configMap = configMap.DeepCopy()
+	configMap.Data = map[string]string{configMapKey: string(data)}
+	_, err = configMaps.Update(context.TODO(), configMap, metav1.UpdateOptions{})
+	return err
+}
+
+func decode(data []byte) (*Snapshot, error) {
+	snapshot := &Snapshot{}
+	if len(data) == 0 {
+		return snapshot, nil
+	}
+	if err := json.Unmarshal(data, snapshot); err != nil {
+		return nil, fmt.Errorf("invalid discovery snapshot: %v", err)
+	}
+	return snapshot, nil
+}
+
+// Recorder keeps the last successful discovery of every source and saves it
+// to a Store. A nil Recorder records nothing.
+type Recorder struct {
+	store   Store
+	lock    sync.Mutex
+	sources map[string]Source
+	dirty   bool
+}
+
+func NewRecorder(store Store) *Recorder {
+	return &Recorder{store: store, sources: make(map[string]Source)}
+}
+
+// Load reads the sources of the saved snapshot. They are saved again until
+// they are forgotten or recorded anew.
+func (r *Recorder) Load() ([]Source, error) {
+	if r == nil {
+		return nil, nil
+	}
+	snapshot, err := r.store.Load()
+	if err != nil {
+		return nil, err
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, source := range snapshot.Sources {
+		r.sources[serviceKey(source.Spec.Backend.Service)] = source
+	}
+	return snapshot.Sources, nil
+}
+
+// Record replaces the snapshot of a source with the metrics it was just
+// discovered with.
+func (r *Recorder) Record(owner string, source *v1beta1.CustomMetricsSource, customMetricInfos map[provider.CustomMetricInfo]struct{}, externalMetricInfos map[provider.ExternalMetricInfo]struct{}, resourceMetrics map[string]struct{}) {
+	if r == nil {
+		return
+	}
+	recorded := Source{
+		Owner:             owner,
+		Name:              source.Name,
+		CreationTimestamp: source.CreationTimestamp,
+		Spec:              *source.Spec.DeepCopy(),
+		DiscoveredAt:      metav1.Now(),
+	}
+	for info := range customMetricInfos {
+		recorded.CustomMetrics = append(recorded.CustomMetrics, CustomMetric{
+			GroupResource: info.GroupResource.String(),
+			Namespaced:    info.Namespaced,
+			Metric:        info.Metric,
+		})
+	}
+	sort.Slice(recorded.CustomMetrics, func(i, j int) bool {
+		a, b := recorded.CustomMetrics[i], recorded.CustomMetrics[j]
+		if a.GroupResource != b.GroupResource {
+			return a.GroupResource < b.GroupResource
+		}
+		return a.Metric < b.Metric
+	})
+	for info := range externalMetricInfos {
+		recorded.ExternalMetrics = append(recorded.ExternalMetrics, info.Metric)
+	}
+	sort.Strings(recorded.ExternalMetrics)
+	for resource := range resourceMetrics {
+		recorded.ResourceMetrics = append(recorded.ResourceMetrics, resource)
+	}
+	sort.Strings(recorded.ResourceMetrics)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.sources[serviceKey(source.Spec.Backend.Service)] = recorded
+	r.dirty = true
+}
+
+// Forget removes the source of a service from the snapshot.
+func (r *Recorder) Forget(namespace, name string) {
+	if r == nil {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	key := namespace + "/" + name
+	if _, ok := r.sources[key]; ok {
+		delete(r.sources, key)
+		r.dirty = true
+	}
+}
+
+// Sources returns the recorded sources of an owner.
+func (r *Recorder) Sources(owner string) []Source {
+	if r == nil {
+		return nil
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	var sources []Source
+	for _, source := range r.sources {
+		if source.Owner == owner {
+			sources = append(sources, source)
+		}
+	}
+	return sources
+}
+
+// Save writes the snapshot when sources changed since it was last saved.
+func (r *Recorder) Save() error {
+	r.lock.Lock()
+	if !r.dirty {
+		r.lock.Unlock()
+		return nil
+	}
+	snapshot := &Snapshot{}
+	for _, source := range r.sources {
+		snapshot.Sources = append(snapshot.Sources, source)
+	}
+	r.dirty = false
+	r.lock.Unlock()
+
+	sort.Slice(snapshot.Sources, func(i, j int) bool {
+		return snapshot.Sources[i].Name < snapshot.Sources[j].Name
+	})
+	if err := r.store.Save(snapshot); err != nil {
+		r.lock.Lock()
+		r.dirty = true
+		r.lock.Unlock()
+		return fmt.Errorf("failed to save discovery snapshot: %v", err)
+	}
+	return nil
+}
+
+// Run saves the snapshot in the interval until stopCh is closed.
+func (r *Recorder) Run(interval time.Duration, stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+	klog.Infof("Starting discovery snapshot recorder")
+	defer klog.Infof("Shutting down discovery snapshot recorder")
+
+	wait.Until(func() {
+		if err := r.Save(); err != nil {
+			utilruntime.HandleError(err)
+		}
+	}, interval, stopCh)
+}
+
+func serviceKey(service v1beta1.ServiceReference) string {
+	return service.Namespace + "/" + service.Name
+}
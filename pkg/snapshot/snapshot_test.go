@@ -0,0 +1,92 @@
+package snapshot
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/kubernetes-sigs/custom-metrics-apiserver/pkg/provider"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+)
+
+func testSource(name, namespace string) *v1beta1.CustomMetricsSource {
+	return &v1beta1.CustomMetricsSource{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: v1beta1.CustomMetricsSourceSpec{
+			Backend: v1beta1.Backend{
+				Service: v1beta1.ServiceReference{Namespace: namespace, Name: name, Port: 443},
+			},
+			Routing: v1beta1.Routing{Priority: 1},
+		},
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		store func(t *testing.T) Store
+	}{
+		{
+			name: "file",
+			store: func(t *testing.T) Store {
+				return &FileStore{Path: filepath.Join(t.TempDir(), "snapshot.json")}
+			},
+		},
+		{
+			name: "configmap",
+			store: func(t *testing.T) Store {
+				return &ConfigMapStore{Client: fake.NewSimpleClientset(), Namespace: "custom-metrics", Name: "snapshot"}
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			store := tc.store(t)
+			recorder := NewRecorder(store)
+			sources, err := recorder.Load()
+			require.NoError(t, err)
+			require.Empty(t, sources)
+
+			customMetricInfos := map[provider.CustomMetricInfo]struct{}{
+				{GroupResource: schema.GroupResource{Group: "apps", Resource: "deployments"}, Namespaced: true, Metric: "queue_depth"}: {},
+			}
+			externalMetricInfos := map[provider.ExternalMetricInfo]struct{}{{Metric: "stream_lag"}: {}}
+			recorder.Record("owner", testSource("prometheus", "monitoring"), customMetricInfos, externalMetricInfos, nil)
+			recorder.Record("other", testSource("keda", "keda"), nil, externalMetricInfos, map[string]struct{}{"pods": {}})
+			require.NoError(t, recorder.Save())
+			require.Len(t, recorder.Sources("owner"), 1)
+
+			loaded := NewRecorder(store)
+			sources, err = loaded.Load()
+			require.NoError(t, err)
+			require.Len(t, sources, 2)
+			require.Equal(t, "keda", sources[0].Name)
+			require.Equal(t, "prometheus", sources[1].Name)
+			custom, external, resource := sources[1].Metrics()
+			require.Equal(t, customMetricInfos, custom)
+			require.Equal(t, externalMetricInfos, external)
+			require.Empty(t, resource)
+			require.Equal(t, testSource("prometheus", "monitoring").Spec, sources[1].CustomMetricsSource().Spec)
+
+			loaded.Forget("monitoring", "prometheus")
+			require.NoError(t, loaded.Save())
+			sources, err = NewRecorder(store).Load()
+			require.NoError(t, err)
+			require.Len(t, sources, 1)
+			require.Equal(t, "keda", sources[0].Name)
+		})
+	}
+}
+
+func TestNilRecorder(t *testing.T) {
+	var recorder *Recorder
+	sources, err := recorder.Load()
+	require.NoError(t, err)
+	require.Empty(t, sources)
+	recorder.Record("owner", testSource("prometheus", "monitoring"), nil, nil, nil)
+	recorder.Forget("monitoring", "prometheus")
+	require.Empty(t, recorder.Sources("owner"))
+}
@@ -0,0 +1,645 @@
+package validation
+
+import (
+	"crypto/x509"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/prometheus/common/model"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	utilvalidation "k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+	"github.com/arjunrn/custom-metrics-router/pkg/backend"
+	"github.com/arjunrn/custom-metrics-router/pkg/httpjson"
+	"github.com/arjunrn/custom-metrics-router/pkg/prometheus"
+)
+
+// ValidateCustomMetricsSourceSpec checks the fields of a source which don't
+// depend on the state of the cluster.
+func ValidateCustomMetricsSourceSpec(spec *v1beta1.CustomMetricsSourceSpec, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	backend := &spec.Backend
+	backendPath := path.Child("backend")
+	servicePath := backendPath.Child("service")
+	for _, msg := range utilvalidation.IsDNS1123Label(backend.Service.Namespace) {
+		allErrs = append(allErrs, field.Invalid(servicePath.Child("namespace"), backend.Service.Namespace, msg))
+	}
+	for _, msg := range utilvalidation.IsDNS1035Label(backend.Service.Name) {
+		allErrs = append(allErrs, field.Invalid(servicePath.Child("name"), backend.Service.Name, msg))
+	}
+	for _, msg := range utilvalidation.IsValidPortNum(int(backend.Service.Port)) {
+		allErrs = append(allErrs, field.Invalid(servicePath.Child("port"), backend.Service.Port, msg))
+	}
+	if len(backend.TLS.CABundle) > 0 {
+		if backend.TLS.InsecureSkipVerify {
+			allErrs = append(allErrs, field.Invalid(backendPath.Child("tls", "caBundle"), "<bundle>",
+				"can't be combined with insecureSkipVerify"))
+		} else if !x509.NewCertPool().AppendCertsFromPEM(backend.TLS.CABundle) {
+			allErrs = append(allErrs, field.Invalid(backendPath.Child("tls", "caBundle"), "<bundle>",
+				"must contain at least one PEM encoded certificate"))
+		}
+	}
+
+	metricTypesPath := path.Child("routing", "metricTypes")
+	if len(spec.Routing.MetricTypes) == 0 {
+		allErrs = append(allErrs, field.Required(metricTypesPath, "at least one metric type must be set"))
+	}
+	seen := make(map[v1beta1.MetricType]struct{})
+	for i, metricType := range spec.Routing.MetricTypes {
+		switch metricType {
+		case v1beta1.CustomMetricsType, v1beta1.ExternalMetricsType, v1beta1.ResourceMetricsType:
+		default:
+			allErrs = append(allErrs, field.NotSupported(metricTypesPath.Index(i), metricType,
+				[]string{v1beta1.CustomMetricsType, v1beta1.ExternalMetricsType, v1beta1.ResourceMetricsType}))
+		}
+		if _, ok := seen[metricType]; ok {
+			allErrs = append(allErrs, field.Duplicate(metricTypesPath.Index(i), metricType))
+		}
+		seen[metricType] = struct{}{}
+	}
+	allErrs = append(allErrs, validateMetricPatterns(spec.Routing.MetricPatterns, seen, path.Child("routing", "metricPatterns"))...)
+	allErrs = append(allErrs, validateStaticMetrics(spec.StaticMetrics, seen, path.Child("staticMetrics"))...)
+
+	allErrs = append(allErrs, validateAuthentication(backend.Authentication, backend.Service.Namespace, backendPath.Child("authentication"))...)
+	allErrs = append(allErrs, validateBackendType(backend.Type, backendPath.Child("type"))...)
+	allErrs = append(allErrs, validatePrometheus(backend, seen, backendPath)...)
+	allErrs = append(allErrs, validatePlugin(backend, seen, backendPath)...)
+	allErrs = append(allErrs, validateHTTP(backend, seen, backendPath)...)
+	allErrs = append(allErrs, validateScrape(backend, seen, backendPath)...)
+	allErrs = append(allErrs, validateObjectField(backend, seen, backendPath)...)
+	allErrs = append(allErrs, validateRemoteWrite(backend, seen, backendPath)...)
+	allErrs = append(allErrs, validateOTLP(backend, seen, backendPath)...)
+	if backend.RequesterForwarding == v1beta1.ImpersonationRequesterForwarding &&
+		backend.Authentication != nil && backend.Authentication.Mode == v1beta1.ImpersonationAuthentication {
+		allErrs = append(allErrs, field.Invalid(backendPath.Child("requesterForwarding"), backend.RequesterForwarding,
+			"can't be combined with authentication mode Impersonation"))
+	}
+	return allErrs
+}
+
+func validateMetricPatterns(patterns []v1beta1.MetricPattern, metricTypes map[v1beta1.MetricType]struct{}, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	for i, pattern := range patterns {
+		patternPath := path.Index(i)
+		if _, ok := metricTypes[pattern.MetricType]; !ok {
+			allErrs = append(allErrs, field.Invalid(patternPath.Child("metricType"), pattern.MetricType,
+				"must be one of the metric types of the source"))
+		} else if pattern.MetricType == v1beta1.ResourceMetricsType {
+			allErrs = append(allErrs, field.NotSupported(patternPath.Child("metricType"), pattern.MetricType,
+				[]string{v1beta1.CustomMetricsType, v1beta1.ExternalMetricsType}))
+		}
+		switch {
+		case pattern.Regex != "" && pattern.Glob != "":
+			allErrs = append(allErrs, field.Invalid(patternPath, pattern.Glob, "regex and glob can't be combined"))
+		case pattern.Regex != "":
+			if _, err := regexp.Compile(pattern.Regex); err != nil {
+				allErrs = append(allErrs, field.Invalid(patternPath.Child("regex"), pattern.Regex, err.Error()))
+			}
+		case pattern.Glob == "":
+			allErrs = append(allErrs, field.Required(patternPath, "one of regex and glob must be set"))
+		}
+		if pattern.GroupResource != "" && pattern.MetricType == v1beta1.ExternalMetricsType {
+			allErrs = append(allErrs, field.Invalid(patternPath.Child("groupResource"), pattern.GroupResource,
+				"can't be set for external metrics"))
+		}
+	}
+	return allErrs
+}
+
+func validateStaticMetrics(static *v1beta1.StaticMetrics, metricTypes map[v1beta1.MetricType]struct{}, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if static == nil {
+		return allErrs
+	}
+	if _, ok := metricTypes[v1beta1.CustomMetricsType]; !ok && len(static.CustomMetrics) > 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("customMetrics"), len(static.CustomMetrics),
+			"requires the metric type CustomMetrics"))
+	}
+	for i, metric := range static.CustomMetrics {
+		if metric.Resource == "" {
+			allErrs = append(allErrs, field.Required(path.Child("customMetrics").Index(i).Child("resource"), ""))
+		}
+		if metric.Name == "" {
+			allErrs = append(allErrs, field.Required(path.Child("customMetrics").Index(i).Child("name"), ""))
+		}
+	}
+	if _, ok := metricTypes[v1beta1.ExternalMetricsType]; !ok && len(static.ExternalMetrics) > 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("externalMetrics"), len(static.ExternalMetrics),
+			"requires the metric type ExternalMetrics"))
+	}
+	for i, metric := range static.ExternalMetrics {
+		if metric == "" {
+			allErrs = append(allErrs, field.Required(path.Child("externalMetrics").Index(i), ""))
+		}
+	}
+	return allErrs
+}
+
+func validateBackendType(backendType v1beta1.BackendType, path *field.Path) field.ErrorList {
+	if !backend.IsRegistered(backendType) {
+		return field.ErrorList{field.NotSupported(path, backendType, backend.Types())}
+	}
+	return nil
+}
+
+func validatePrometheus(backend *v1beta1.Backend, metricTypes map[v1beta1.MetricType]struct{}, backendPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	prom := backend.Prometheus
+	path := backendPath.Child("prometheus")
+	if backend.Type != v1beta1.PrometheusBackendType {
+		if prom != nil {
+			allErrs = append(allErrs, field.Invalid(path, "", "requires the type Prometheus"))
+		}
+		return allErrs
+	}
+	if prom == nil {
+		return append(allErrs, field.Required(path, "required for the type Prometheus"))
+	}
+	switch prom.Scheme {
+	case "", v1beta1.HTTPScheme, v1beta1.HTTPSScheme:
+	default:
+		allErrs = append(allErrs, field.NotSupported(path.Child("scheme"), prom.Scheme,
+			[]string{v1beta1.HTTPScheme, v1beta1.HTTPSScheme}))
+	}
+	if _, ok := metricTypes[v1beta1.ResourceMetricsType]; ok {
+		allErrs = append(allErrs, field.Invalid(path, "", "prometheus backends don't serve the metric type ResourceMetrics"))
+	}
+	if backend.RequesterForwarding != "" && backend.RequesterForwarding != v1beta1.NoRequesterForwarding {
+		allErrs = append(allErrs, field.Invalid(backendPath.Child("requesterForwarding"), backend.RequesterForwarding,
+			"isn't supported by prometheus backends"))
+	}
+	if _, ok := metricTypes[v1beta1.CustomMetricsType]; !ok && len(prom.CustomMetrics) > 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("customMetrics"), len(prom.CustomMetrics),
+			"requires the metric type CustomMetrics"))
+	}
+	for i, metric := range prom.CustomMetrics {
+		metricPath := path.Child("customMetrics").Index(i)
+		if metric.Name == "" {
+			allErrs = append(allErrs, field.Required(metricPath.Child("name"), ""))
+		}
+		if metric.Resource == "" {
+			allErrs = append(allErrs, field.Required(metricPath.Child("resource"), ""))
+		}
+		allErrs = append(allErrs, validateQuery(metric.Query, metricPath.Child("query"))...)
+	}
+	if _, ok := metricTypes[v1beta1.ExternalMetricsType]; !ok && len(prom.ExternalMetrics) > 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("externalMetrics"), len(prom.ExternalMetrics),
+			"requires the metric type ExternalMetrics"))
+	}
+	for i, metric := range prom.ExternalMetrics {
+		metricPath := path.Child("externalMetrics").Index(i)
+		if metric.Name == "" {
+			allErrs = append(allErrs, field.Required(metricPath.Child("name"), ""))
+		}
+		allErrs = append(allErrs, validateQuery(metric.Query, metricPath.Child("query"))...)
+	}
+	return allErrs
+}
+
+func validatePlugin(backend *v1beta1.Backend, metricTypes map[v1beta1.MetricType]struct{}, backendPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	path := backendPath.Child("plugin")
+	if backend.Type != v1beta1.PluginBackendType {
+		if backend.Plugin != nil {
+			allErrs = append(allErrs, field.Invalid(path, "", "requires the type Plugin"))
+		}
+		return allErrs
+	}
+	if _, ok := metricTypes[v1beta1.ResourceMetricsType]; ok {
+		allErrs = append(allErrs, field.Invalid(path, "", "plugin backends don't serve the metric type ResourceMetrics"))
+	}
+	if backend.RequesterForwarding != "" && backend.RequesterForwarding != v1beta1.NoRequesterForwarding {
+		allErrs = append(allErrs, field.Invalid(backendPath.Child("requesterForwarding"), backend.RequesterForwarding,
+			"isn't supported by plugin backends"))
+	}
+	if backend.Plugin != nil && backend.Plugin.Socket != "" && !filepath.IsAbs(backend.Plugin.Socket) {
+		allErrs = append(allErrs, field.Invalid(path.Child("socket"), backend.Plugin.Socket, "must be an absolute path"))
+	}
+	return allErrs
+}
+
+func validateHTTP(backend *v1beta1.Backend, metricTypes map[v1beta1.MetricType]struct{}, backendPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	httpBackend := backend.HTTP
+	path := backendPath.Child("http")
+	if backend.Type != v1beta1.HTTPBackendType {
+		if httpBackend != nil {
+			allErrs = append(allErrs, field.Invalid(path, "", "requires the type HTTP"))
+		}
+		return allErrs
+	}
+	if httpBackend == nil {
+		return append(allErrs, field.Required(path, "required for the type HTTP"))
+	}
+	switch httpBackend.Scheme {
+	case "", v1beta1.HTTPScheme, v1beta1.HTTPSScheme:
+	default:
+		allErrs = append(allErrs, field.NotSupported(path.Child("scheme"), httpBackend.Scheme,
+			[]string{v1beta1.HTTPScheme, v1beta1.HTTPSScheme}))
+	}
+	for metricType := range metricTypes {
+		if metricType != v1beta1.ExternalMetricsType {
+			allErrs = append(allErrs, field.Invalid(path, "", "http backends only serve the metric type ExternalMetrics"))
+			break
+		}
+	}
+	if backend.RequesterForwarding != "" && backend.RequesterForwarding != v1beta1.NoRequesterForwarding {
+		allErrs = append(allErrs, field.Invalid(backendPath.Child("requesterForwarding"), backend.RequesterForwarding,
+			"isn't supported by http backends"))
+	}
+	if httpBackend.Timeout != nil && httpBackend.Timeout.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("timeout"), httpBackend.Timeout.Duration.String(), "must be positive"))
+	}
+	if httpBackend.CacheTTL != nil && httpBackend.CacheTTL.Duration < 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("cacheTTL"), httpBackend.CacheTTL.Duration.String(), "must not be negative"))
+	}
+	if len(httpBackend.ExternalMetrics) == 0 {
+		allErrs = append(allErrs, field.Required(path.Child("externalMetrics"), "at least one external metric must be set"))
+	}
+	for i, metric := range httpBackend.ExternalMetrics {
+		metricPath := path.Child("externalMetrics").Index(i)
+		if metric.Name == "" {
+			allErrs = append(allErrs, field.Required(metricPath.Child("name"), ""))
+		}
+		if metric.Path == "" {
+			allErrs = append(allErrs, field.Required(metricPath.Child("path"), ""))
+		} else if _, err := httpjson.ParsePath(metric.Path); err != nil {
+			allErrs = append(allErrs, field.Invalid(metricPath.Child("path"), metric.Path, err.Error()))
+		}
+		if _, err := httpjson.ParseItems(metric.Items); err != nil {
+			allErrs = append(allErrs, field.Invalid(metricPath.Child("items"), metric.Items, err.Error()))
+		}
+		allErrs = append(allErrs, validateExtractor(metric.Value, metricPath.Child("value"))...)
+		for name, expression := range metric.Labels {
+			for _, msg := range utilvalidation.IsQualifiedName(name) {
+				allErrs = append(allErrs, field.Invalid(metricPath.Child("labels").Key(name), name, msg))
+			}
+			allErrs = append(allErrs, validateExtractor(expression, metricPath.Child("labels").Key(name))...)
+		}
+	}
+	return allErrs
+}
+
+func validateScrape(backend *v1beta1.Backend, metricTypes map[v1beta1.MetricType]struct{}, backendPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	scrape := backend.Scrape
+	path := backendPath.Child("scrape")
+	if backend.Type != v1beta1.ScrapeBackendType {
+		if scrape != nil {
+			allErrs = append(allErrs, field.Invalid(path, "", "requires the type Scrape"))
+		}
+		return allErrs
+	}
+	if scrape == nil {
+		return append(allErrs, field.Required(path, "required for the type Scrape"))
+	}
+	switch scrape.Scheme {
+	case "", v1beta1.HTTPScheme, v1beta1.HTTPSScheme:
+	default:
+		allErrs = append(allErrs, field.NotSupported(path.Child("scheme"), scrape.Scheme,
+			[]string{v1beta1.HTTPScheme, v1beta1.HTTPSScheme}))
+	}
+	for metricType := range metricTypes {
+		if metricType != v1beta1.CustomMetricsType {
+			allErrs = append(allErrs, field.Invalid(path, "", "scrape backends only serve the metric type CustomMetrics"))
+			break
+		}
+	}
+	if backend.RequesterForwarding != "" && backend.RequesterForwarding != v1beta1.NoRequesterForwarding {
+		allErrs = append(allErrs, field.Invalid(backendPath.Child("requesterForwarding"), backend.RequesterForwarding,
+			"isn't supported by scrape backends"))
+	}
+	// any pod which a request selects receives the credentials, so they may
+	// not be ones of the router.
+	if auth := backend.Authentication; auth != nil && auth.Mode != v1beta1.NoAuthentication && auth.Mode != v1beta1.SecretTokenAuthentication {
+		allErrs = append(allErrs, field.NotSupported(backendPath.Child("authentication", "mode"), auth.Mode,
+			[]string{v1beta1.NoAuthentication, v1beta1.SecretTokenAuthentication}))
+	}
+	if scrape.Port.Type == intstr.Int {
+		for _, msg := range utilvalidation.IsValidPortNum(scrape.Port.IntValue()) {
+			allErrs = append(allErrs, field.Invalid(path.Child("port"), scrape.Port.IntVal, msg))
+		}
+	} else {
+		for _, msg := range utilvalidation.IsValidPortName(scrape.Port.StrVal) {
+			allErrs = append(allErrs, field.Invalid(path.Child("port"), scrape.Port.StrVal, msg))
+		}
+	}
+	if scrape.Path != "" && !strings.HasPrefix(scrape.Path, "/") {
+		allErrs = append(allErrs, field.Invalid(path.Child("path"), scrape.Path, "must be an absolute path"))
+	}
+	if scrape.Timeout != nil && scrape.Timeout.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("timeout"), scrape.Timeout.Duration.String(), "must be positive"))
+	}
+	if scrape.MaxConcurrentScrapes < 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("maxConcurrentScrapes"), scrape.MaxConcurrentScrapes, "must not be negative"))
+	}
+	if len(scrape.Metrics) == 0 {
+		allErrs = append(allErrs, field.Required(path.Child("metrics"), "at least one metric must be set"))
+	}
+	names := make(map[string]struct{}, len(scrape.Metrics))
+	for i, metric := range scrape.Metrics {
+		metricPath := path.Child("metrics").Index(i)
+		if metric.Name == "" {
+			allErrs = append(allErrs, field.Required(metricPath.Child("name"), ""))
+		} else if _, ok := names[metric.Name]; ok {
+			allErrs = append(allErrs, field.Duplicate(metricPath.Child("name"), metric.Name))
+		}
+		names[metric.Name] = struct{}{}
+		family := metric.Family
+		if family == "" {
+			family = metric.Name
+		}
+		if family != "" && !model.IsValidMetricName(model.LabelValue(family)) {
+			allErrs = append(allErrs, field.Invalid(metricPath.Child("family"), family, "must be a valid Prometheus metric name"))
+		}
+		if metric.RateWindow != nil && metric.RateWindow.Duration <= 0 {
+			allErrs = append(allErrs, field.Invalid(metricPath.Child("rateWindow"), metric.RateWindow.Duration.String(), "must be positive"))
+		}
+	}
+	return allErrs
+}
+
+func validateObjectField(backend *v1beta1.Backend, metricTypes map[v1beta1.MetricType]struct{}, backendPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	objectField := backend.ObjectField
+	path := backendPath.Child("objectField")
+	if backend.Type != v1beta1.ObjectFieldBackendType {
+		if objectField != nil {
+			allErrs = append(allErrs, field.Invalid(path, "", "requires the type ObjectField"))
+		}
+		return allErrs
+	}
+	if objectField == nil {
+		return append(allErrs, field.Required(path, "required for the type ObjectField"))
+	}
+	for metricType := range metricTypes {
+		if metricType != v1beta1.CustomMetricsType {
+			allErrs = append(allErrs, field.Invalid(path, "", "object field backends only serve the metric type CustomMetrics"))
+			break
+		}
+	}
+	if backend.RequesterForwarding != "" && backend.RequesterForwarding != v1beta1.NoRequesterForwarding {
+		allErrs = append(allErrs, field.Invalid(backendPath.Child("requesterForwarding"), backend.RequesterForwarding,
+			"isn't supported by object field backends"))
+	}
+	if len(objectField.Metrics) == 0 {
+		allErrs = append(allErrs, field.Required(path.Child("metrics"), "at least one metric must be set"))
+	}
+	for i, metric := range objectField.Metrics {
+		metricPath := path.Child("metrics").Index(i)
+		if metric.Name == "" {
+			allErrs = append(allErrs, field.Required(metricPath.Child("name"), ""))
+		}
+		if metric.Resource == "" {
+			allErrs = append(allErrs, field.Required(metricPath.Child("resource"), ""))
+		}
+		allErrs = append(allErrs, validateExtractor(metric.Field, metricPath.Child("field"))...)
+	}
+	return allErrs
+}
+
+func validateRemoteWrite(backend *v1beta1.Backend, metricTypes map[v1beta1.MetricType]struct{}, backendPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	remoteWrite := backend.RemoteWrite
+	path := backendPath.Child("remoteWrite")
+	if backend.Type != v1beta1.RemoteWriteBackendType {
+		if remoteWrite != nil {
+			allErrs = append(allErrs, field.Invalid(path, "", "requires the type RemoteWrite"))
+		}
+		return allErrs
+	}
+	if remoteWrite == nil {
+		return append(allErrs, field.Required(path, "required for the type RemoteWrite"))
+	}
+	if _, ok := metricTypes[v1beta1.ResourceMetricsType]; ok {
+		allErrs = append(allErrs, field.Invalid(path, "", "remote-write backends don't serve the metric type ResourceMetrics"))
+	}
+	if backend.RequesterForwarding != "" && backend.RequesterForwarding != v1beta1.NoRequesterForwarding {
+		allErrs = append(allErrs, field.Invalid(backendPath.Child("requesterForwarding"), backend.RequesterForwarding,
+			"isn't supported by remote-write backends"))
+	}
+	if _, ok := metricTypes[v1beta1.CustomMetricsType]; !ok && len(remoteWrite.CustomMetrics) > 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("customMetrics"), len(remoteWrite.CustomMetrics),
+			"requires the metric type CustomMetrics"))
+	}
+	for i, metric := range remoteWrite.CustomMetrics {
+		metricPath := path.Child("customMetrics").Index(i)
+		if metric.Name == "" {
+			allErrs = append(allErrs, field.Required(metricPath.Child("name"), ""))
+		}
+		if metric.Resource == "" {
+			allErrs = append(allErrs, field.Required(metricPath.Child("resource"), ""))
+		}
+		allErrs = append(allErrs, validateSeries(metric.Series, metric.MatchLabels, metricPath)...)
+		allErrs = append(allErrs, validateLabelName(metric.NamespaceLabel, metricPath.Child("namespaceLabel"))...)
+		allErrs = append(allErrs, validateLabelName(metric.ObjectLabel, metricPath.Child("objectLabel"))...)
+	}
+	if _, ok := metricTypes[v1beta1.ExternalMetricsType]; !ok && len(remoteWrite.ExternalMetrics) > 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("externalMetrics"), len(remoteWrite.ExternalMetrics),
+			"requires the metric type ExternalMetrics"))
+	}
+	for i, metric := range remoteWrite.ExternalMetrics {
+		metricPath := path.Child("externalMetrics").Index(i)
+		if metric.Name == "" {
+			allErrs = append(allErrs, field.Required(metricPath.Child("name"), ""))
+		}
+		allErrs = append(allErrs, validateSeries(metric.Series, metric.MatchLabels, metricPath)...)
+		allErrs = append(allErrs, validateLabelName(metric.NamespaceLabel, metricPath.Child("namespaceLabel"))...)
+	}
+	return allErrs
+}
+
+func validateOTLP(backend *v1beta1.Backend, metricTypes map[v1beta1.MetricType]struct{}, backendPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	otlp := backend.OTLP
+	path := backendPath.Child("otlp")
+	if backend.Type != v1beta1.OTLPBackendType {
+		if otlp != nil {
+			allErrs = append(allErrs, field.Invalid(path, "", "requires the type OTLP"))
+		}
+		return allErrs
+	}
+	if otlp == nil {
+		return append(allErrs, field.Required(path, "required for the type OTLP"))
+	}
+	if _, ok := metricTypes[v1beta1.ResourceMetricsType]; ok {
+		allErrs = append(allErrs, field.Invalid(path, "", "OTLP backends don't serve the metric type ResourceMetrics"))
+	}
+	if backend.RequesterForwarding != "" && backend.RequesterForwarding != v1beta1.NoRequesterForwarding {
+		allErrs = append(allErrs, field.Invalid(backendPath.Child("requesterForwarding"), backend.RequesterForwarding,
+			"isn't supported by OTLP backends"))
+	}
+	if _, ok := metricTypes[v1beta1.CustomMetricsType]; !ok && len(otlp.CustomMetrics) > 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("customMetrics"), len(otlp.CustomMetrics),
+			"requires the metric type CustomMetrics"))
+	}
+	for i, metric := range otlp.CustomMetrics {
+		metricPath := path.Child("customMetrics").Index(i)
+		if metric.Name == "" {
+			allErrs = append(allErrs, field.Required(metricPath.Child("name"), ""))
+		}
+		if metric.Metric == "" {
+			allErrs = append(allErrs, field.Required(metricPath.Child("metric"), ""))
+		}
+		if metric.Resource == "" {
+			allErrs = append(allErrs, field.Required(metricPath.Child("resource"), ""))
+		}
+		allErrs = append(allErrs, validateAttributeLabels(metric.Labels, metricPath.Child("labels"))...)
+	}
+	if _, ok := metricTypes[v1beta1.ExternalMetricsType]; !ok && len(otlp.ExternalMetrics) > 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("externalMetrics"), len(otlp.ExternalMetrics),
+			"requires the metric type ExternalMetrics"))
+	}
+	for i, metric := range otlp.ExternalMetrics {
+		metricPath := path.Child("externalMetrics").Index(i)
+		if metric.Name == "" {
+			allErrs = append(allErrs, field.Required(metricPath.Child("name"), ""))
+		}
+		if metric.Metric == "" {
+			allErrs = append(allErrs, field.Required(metricPath.Child("metric"), ""))
+		}
+		allErrs = append(allErrs, validateAttributeLabels(metric.Labels, metricPath.Child("labels"))...)
+	}
+	return allErrs
+}
+
+// validateAttributeLabels checks a mapping of metric labels to attributes.
+func validateAttributeLabels(labels map[string]string, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	for name, attribute := range labels {
+		for _, msg := range utilvalidation.IsQualifiedName(name) {
+			allErrs = append(allErrs, field.Invalid(path.Key(name), name, msg))
+		}
+		if attribute == "" {
+			allErrs = append(allErrs, field.Required(path.Key(name), "the attribute of the label is required"))
+		}
+	}
+	return allErrs
+}
+
+func validateSeries(series string, matchLabels map[string]string, metricPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if series == "" {
+		allErrs = append(allErrs, field.Required(metricPath.Child("series"), ""))
+	} else if !model.IsValidMetricName(model.LabelValue(series)) {
+		allErrs = append(allErrs, field.Invalid(metricPath.Child("series"), series, "must be a valid Prometheus metric name"))
+	}
+	for label := range matchLabels {
+		allErrs = append(allErrs, validateLabelName(label, metricPath.Child("matchLabels").Key(label))...)
+	}
+	return allErrs
+}
+
+// validateLabelName checks the name of a Prometheus label unless it is empty.
+func validateLabelName(label string, path *field.Path) field.ErrorList {
+	if label != "" && !model.LabelName(label).IsValid() {
+		return field.ErrorList{field.Invalid(path, label, "must be a valid Prometheus label name")}
+	}
+	return nil
+}
+
+func validateExtractor(expression string, path *field.Path) field.ErrorList {
+	if expression == "" {
+		return field.ErrorList{field.Required(path, "")}
+	}
+	if _, err := httpjson.ParseExtractor(expression); err != nil {
+		return field.ErrorList{field.Invalid(path, expression, err.Error())}
+	}
+	return nil
+}
+
+func validateQuery(query string, path *field.Path) field.ErrorList {
+	if query == "" {
+		return field.ErrorList{field.Required(path, "")}
+	}
+	if _, err := prometheus.ParseQuery(query); err != nil {
+		return field.ErrorList{field.Invalid(path, query, err.Error())}
+	}
+	return nil
+}
+
+// validateAuthentication checks the credentials of a backend. Secrets and
+// service accounts must be in the namespace of the backend, so that a source
+// can't send the credentials of other namespaces to a backend.
+func validateAuthentication(auth *v1beta1.Authentication, namespace string, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if auth == nil {
+		return allErrs
+	}
+	switch auth.Mode {
+	case v1beta1.SecretTokenAuthentication:
+		if auth.SecretToken == nil {
+			allErrs = append(allErrs, field.Required(path.Child("secretToken"), "required for mode SecretToken"))
+		} else if auth.SecretToken.Namespace != namespace {
+			allErrs = append(allErrs, field.Invalid(path.Child("secretToken", "namespace"), auth.SecretToken.Namespace,
+				"must be the namespace of the backend service"))
+		}
+	case v1beta1.ProjectedTokenAuthentication:
+		if auth.ProjectedToken == nil {
+			allErrs = append(allErrs, field.Required(path.Child("projectedToken"), "required for mode ProjectedToken"))
+		} else {
+			if auth.ProjectedToken.ServiceAccount.Namespace != namespace {
+				allErrs = append(allErrs, field.Invalid(path.Child("projectedToken", "serviceAccount", "namespace"),
+					auth.ProjectedToken.ServiceAccount.Namespace, "must be the namespace of the backend service"))
+			}
+			if auth.ProjectedToken.ExpirationSeconds != nil && *auth.ProjectedToken.ExpirationSeconds < 600 {
+				allErrs = append(allErrs, field.Invalid(path.Child("projectedToken", "expirationSeconds"),
+					*auth.ProjectedToken.ExpirationSeconds, "must be at least 600"))
+			}
+		}
+	case v1beta1.ImpersonationAuthentication:
+		if auth.Impersonation == nil || auth.Impersonation.UserName == "" {
+			allErrs = append(allErrs, field.Required(path.Child("impersonation", "userName"), "required for mode Impersonation"))
+		}
+	}
+	return allErrs
+}
+
+// ValidateMetricRouteSpec checks the fields of a MetricRoute.
+func ValidateMetricRouteSpec(spec *v1beta1.MetricRouteSpec, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	matchPath := path.Child("match")
+	match := spec.Match
+	switch match.MetricType {
+	case v1beta1.CustomMetricsType, v1beta1.ExternalMetricsType, v1beta1.ResourceMetricsType:
+	default:
+		allErrs = append(allErrs, field.NotSupported(matchPath.Child("metricType"), match.MetricType,
+			[]string{v1beta1.CustomMetricsType, v1beta1.ExternalMetricsType, v1beta1.ResourceMetricsType}))
+	}
+	if match.Name != "" && match.NameRegex != "" {
+		allErrs = append(allErrs, field.Invalid(matchPath.Child("nameRegex"), match.NameRegex, "can't be combined with name"))
+	} else if match.NameRegex != "" {
+		if _, err := regexp.Compile(match.NameRegex); err != nil {
+			allErrs = append(allErrs, field.Invalid(matchPath.Child("nameRegex"), match.NameRegex, err.Error()))
+		}
+	}
+	if match.GroupResource != "" && match.MetricType != v1beta1.CustomMetricsType {
+		allErrs = append(allErrs, field.Invalid(matchPath.Child("groupResource"), match.GroupResource,
+			"can only be set for custom metrics"))
+	}
+	if match.NamespaceSelector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(match.NamespaceSelector); err != nil {
+			allErrs = append(allErrs, field.Invalid(matchPath.Child("namespaceSelector"), match.NamespaceSelector, err.Error()))
+		}
+	}
+
+	if len(spec.Sources) == 0 {
+		allErrs = append(allErrs, field.Required(path.Child("sources"), "at least one source must be set"))
+	}
+	for i, source := range spec.Sources {
+		if source.Name == "" {
+			allErrs = append(allErrs, field.Required(path.Child("sources").Index(i).Child("name"), ""))
+		}
+	}
+	switch spec.Strategy {
+	case "", v1beta1.FailoverRouteStrategy, v1beta1.StrictRouteStrategy:
+	default:
+		allErrs = append(allErrs, field.NotSupported(path.Child("strategy"), spec.Strategy,
+			[]string{v1beta1.FailoverRouteStrategy, v1beta1.StrictRouteStrategy}))
+	}
+	return allErrs
+}
@@ -0,0 +1,98 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1alpha1"
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+)
+
+// ConvertPath is the path the CustomMetricsSource conversion is served at.
+const ConvertPath = "/convert"
+
+func (s *Server) convert(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to read request: %v", err), http.StatusBadRequest)
+		return
+	}
+	review := apiextensionsv1.ConversionReview{}
+	if err := json.Unmarshal(body, &review); err != nil || review.Request == nil {
+		http.Error(w, "request body must be a ConversionReview with a request", http.StatusBadRequest)
+		return
+	}
+
+	review.Response = &apiextensionsv1.ConversionResponse{
+		UID:    review.Request.UID,
+		Result: metav1.Status{Status: metav1.StatusSuccess},
+	}
+	for _, object := range review.Request.Objects {
+		converted, err := convertSource(object.Raw, review.Request.DesiredAPIVersion)
+		if err != nil {
+			review.Response.ConvertedObjects = nil
+			review.Response.Result = metav1.Status{Status: metav1.StatusFailure, Message: err.Error()}
+			break
+		}
+		review.Response.ConvertedObjects = append(review.Response.ConvertedObjects, runtime.RawExtension{Raw: converted})
+	}
+	review.Request = nil
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(review); err != nil {
+		utilruntime.HandleError(fmt.Errorf("failed to write conversion response: %v", err))
+	}
+}
+
+// convertSource converts a CustomMetricsSource to desiredAPIVersion through
+// the v1beta1 hub version.
+func convertSource(raw []byte, desiredAPIVersion string) ([]byte, error) {
+	typeMeta := metav1.TypeMeta{}
+	if err := json.Unmarshal(raw, &typeMeta); err != nil {
+		return nil, fmt.Errorf("failed to decode object: %v", err)
+	}
+	if typeMeta.APIVersion == desiredAPIVersion {
+		return raw, nil
+	}
+
+	hub := &v1beta1.CustomMetricsSource{}
+	switch typeMeta.APIVersion {
+	case v1beta1.SchemeGroupVersion.String():
+		if err := json.Unmarshal(raw, hub); err != nil {
+			return nil, fmt.Errorf("failed to decode custom metrics source: %v", err)
+		}
+	case v1alpha1.SchemeGroupVersion.String():
+		source := &v1alpha1.CustomMetricsSource{}
+		if err := json.Unmarshal(raw, source); err != nil {
+			return nil, fmt.Errorf("failed to decode custom metrics source: %v", err)
+		}
+		if err := source.ConvertTo(hub); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported api version %q", typeMeta.APIVersion)
+	}
+
+	switch desiredAPIVersion {
+	case v1beta1.SchemeGroupVersion.String():
+		return json.Marshal(hub)
+	case v1alpha1.SchemeGroupVersion.String():
+		source := &v1alpha1.CustomMetricsSource{}
+		if err := source.ConvertFrom(hub); err != nil {
+			return nil, err
+		}
+		return json.Marshal(source)
+	default:
+		return nil, fmt.Errorf("unsupported api version %q", desiredAPIVersion)
+	}
+}
@@ -0,0 +1,95 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1alpha1"
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+)
+
+func TestConvert(t *testing.T) {
+	alpha := &v1alpha1.CustomMetricsSource{
+		TypeMeta:   metav1.TypeMeta{APIVersion: v1alpha1.SchemeGroupVersion.String(), Kind: "CustomMetricsSource"},
+		ObjectMeta: metav1.ObjectMeta{Name: "test"},
+		Spec: v1alpha1.CustomMetricsSourceSpec{
+			Service:     v1alpha1.Service{Namespace: "custom-metrics", Name: "adapter", Port: 443},
+			Priority:    100,
+			MetricTypes: []v1alpha1.MetricType{v1alpha1.ExternalMetricsType},
+		},
+	}
+	for _, tc := range []struct {
+		name              string
+		object            interface{}
+		desiredAPIVersion string
+		success           bool
+	}{
+		{
+			name:              "v1alpha1 to v1beta1",
+			object:            alpha,
+			desiredAPIVersion: v1beta1.SchemeGroupVersion.String(),
+			success:           true,
+		},
+		{
+			name:              "same version",
+			object:            alpha,
+			desiredAPIVersion: v1alpha1.SchemeGroupVersion.String(),
+			success:           true,
+		},
+		{
+			name:              "unknown version",
+			object:            alpha,
+			desiredAPIVersion: "metricsrouter.io/v2",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			raw, err := json.Marshal(tc.object)
+			require.NoError(t, err)
+			body, err := json.Marshal(apiextensionsv1.ConversionReview{
+				TypeMeta: metav1.TypeMeta{APIVersion: "apiextensions.k8s.io/v1", Kind: "ConversionReview"},
+				Request: &apiextensionsv1.ConversionRequest{
+					UID:               types.UID("1234"),
+					DesiredAPIVersion: tc.desiredAPIVersion,
+					Objects:           []runtime.RawExtension{{Raw: raw}},
+				},
+			})
+			require.NoError(t, err)
+
+			server := NewServer(nil)
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, ConvertPath, bytes.NewReader(body)))
+			require.Equal(t, http.StatusOK, recorder.Code)
+
+			response := apiextensionsv1.ConversionReview{}
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+			require.Equal(t, types.UID("1234"), response.Response.UID)
+			if !tc.success {
+				require.Equal(t, metav1.StatusFailure, response.Response.Result.Status)
+				require.Empty(t, response.Response.ConvertedObjects)
+				return
+			}
+			require.Equal(t, metav1.StatusSuccess, response.Response.Result.Status)
+			require.Len(t, response.Response.ConvertedObjects, 1)
+
+			typeMeta := metav1.TypeMeta{}
+			require.NoError(t, json.Unmarshal(response.Response.ConvertedObjects[0].Raw, &typeMeta))
+			require.Equal(t, tc.desiredAPIVersion, typeMeta.APIVersion)
+			if tc.desiredAPIVersion == v1beta1.SchemeGroupVersion.String() {
+				beta := &v1beta1.CustomMetricsSource{}
+				require.NoError(t, json.Unmarshal(response.Response.ConvertedObjects[0].Raw, beta))
+				require.Equal(t, "adapter", beta.Spec.Backend.Service.Name)
+				require.Equal(t, 100, beta.Spec.Routing.Priority)
+				require.Equal(t, []v1beta1.MetricType{v1beta1.ExternalMetricsType}, beta.Spec.Routing.MetricTypes)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kubernetes-sigs/custom-metrics-apiserver/pkg/provider"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+	"github.com/arjunrn/custom-metrics-router/pkg/backend"
+	"github.com/arjunrn/custom-metrics-router/pkg/clientset"
+	"github.com/arjunrn/custom-metrics-router/pkg/routes"
+	"github.com/arjunrn/custom-metrics-router/pkg/validation"
+)
+
+// SourceValidator checks CustomMetricsSources before they are admitted.
+type SourceValidator struct {
+	clientSet       clientset.Interface
+	customRoutes    *routes.Routes
+	mapper          meta.RESTMapper
+	deps            backend.Dependencies
+	dryRunDiscovery bool
+}
+
+// NewSourceValidator returns a SourceValidator. With dryRunDiscovery the
+// metrics of the backend are listed before a source is admitted, except for
+// dry-run requests, because creating the backend may read Secrets and request
+// tokens.
+func NewSourceValidator(clientSet clientset.Interface, customRoutes *routes.Routes, mapper meta.RESTMapper, deps backend.Dependencies, dryRunDiscovery bool) *SourceValidator {
+	return &SourceValidator{
+		clientSet:       clientSet,
+		customRoutes:    customRoutes,
+		mapper:          mapper,
+		deps:            deps,
+		dryRunDiscovery: dryRunDiscovery,
+	}
+}
+
+// Validate returns the problems which prevent the source from being admitted
+// and warnings about problems which don't. The backend isn't discovered for
+// dry-run requests. requester is the user who creates or updates the source.
+func (v *SourceValidator) Validate(ctx context.Context, source *v1beta1.CustomMetricsSource, requester authenticationv1.UserInfo, dryRun bool) (field.ErrorList, []string) {
+	specPath := field.NewPath("spec")
+	allErrs := validation.ValidateCustomMetricsSourceSpec(&source.Spec, specPath)
+	if len(allErrs) > 0 {
+		return allErrs, nil
+	}
+	if allErrs := v.validateObjectAccess(ctx, source, requester, specPath); len(allErrs) > 0 {
+		return allErrs, nil
+	}
+
+	servicePath := specPath.Child("backend", "service")
+	service := source.Spec.Backend.Service
+	if _, err := v.clientSet.CoreV1().Services(service.Namespace).Get(ctx, service.Name, metav1.GetOptions{}); err != nil {
+		if apierrors.IsNotFound(err) {
+			allErrs = append(allErrs, field.NotFound(servicePath, fmt.Sprintf("%s/%s", service.Namespace, service.Name)))
+		} else {
+			allErrs = append(allErrs, field.InternalError(servicePath, err))
+		}
+	}
+	sources, err := v.clientSet.MetricsrouterV1beta1().CustomMetricsSources().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return append(allErrs, field.InternalError(servicePath, err)), nil
+	}
+	for _, other := range sources.Items {
+		// routes are keyed by the service, so two sources can't share one
+		// even with different ports.
+		if other.Name != source.Name && other.Spec.Backend.Service.Name == service.Name && other.Spec.Backend.Service.Namespace == service.Namespace {
+			allErrs = append(allErrs, field.Duplicate(servicePath, fmt.Sprintf("service is already used by custom metrics source %s", other.Name)))
+		}
+	}
+	if len(allErrs) > 0 {
+		return allErrs, nil
+	}
+
+	customMetricInfos, externalMetricInfos, ok := v.customRoutes.MetricInfos(service.Name, service.Namespace)
+	if v.dryRunDiscovery && !dryRun {
+		customMetricInfos, externalMetricInfos, err = v.discover(source)
+		if err != nil {
+			return field.ErrorList{field.Invalid(servicePath, service, fmt.Sprintf("discovery failed: %v", err))}, nil
+		}
+	} else if !ok {
+		return nil, nil
+	}
+	return nil, overlapWarnings(v.customRoutes.Overlaps(
+		service.Name,
+		service.Namespace,
+		source.Spec.Routing.Priority,
+		source.CreationTimestamp.Time,
+		customMetricInfos,
+		externalMetricInfos,
+	))
+}
+
+// validateObjectAccess checks that the requester may list and watch the
+// resources of an object field backend in all namespaces. The router reads
+// them with its own informers, so a source could otherwise serve fields of
+// objects which its author can't read.
+func (v *SourceValidator) validateObjectAccess(ctx context.Context, source *v1beta1.CustomMetricsSource, requester authenticationv1.UserInfo, specPath *field.Path) field.ErrorList {
+	objectField := source.Spec.Backend.ObjectField
+	if backend.TypeOf(source.Spec.Backend.Type) != v1beta1.ObjectFieldBackendType || objectField == nil {
+		return nil
+	}
+	extra := make(map[string]authorizationv1.ExtraValue, len(requester.Extra))
+	for k, value := range requester.Extra {
+		extra[k] = authorizationv1.ExtraValue(value)
+	}
+	var allErrs field.ErrorList
+	metricsPath := specPath.Child("backend", "objectField", "metrics")
+	for i, metric := range objectField.Metrics {
+		resourcePath := metricsPath.Index(i).Child("resource")
+		groupResource := schema.ParseGroupResource(metric.Resource)
+		for _, verb := range []string{"list", "watch"} {
+			review, err := v.clientSet.AuthorizationV1().SubjectAccessReviews().Create(ctx, &authorizationv1.SubjectAccessReview{
+				Spec: authorizationv1.SubjectAccessReviewSpec{
+					ResourceAttributes: &authorizationv1.ResourceAttributes{
+						Verb:     verb,
+						Group:    groupResource.Group,
+						Resource: groupResource.Resource,
+					},
+					User:   requester.Username,
+					Groups: requester.Groups,
+					Extra:  extra,
+					UID:    requester.UID,
+				},
+			}, metav1.CreateOptions{})
+			if err != nil {
+				allErrs = append(allErrs, field.InternalError(resourcePath, err))
+				break
+			}
+			if !review.Status.Allowed {
+				allErrs = append(allErrs, field.Forbidden(resourcePath, fmt.Sprintf("%s may not %s %s in all namespaces", requester.Username, verb, groupResource)))
+				break
+			}
+		}
+	}
+	return allErrs
+}
+
+func (v *SourceValidator) discover(source *v1beta1.CustomMetricsSource) (map[provider.CustomMetricInfo]struct{}, map[provider.ExternalMetricInfo]struct{}, error) {
+	metricsBackend, err := backend.New(source, v.deps)
+	if err != nil {
+		return nil, nil, err
+	}
+	return v.customRoutes.DiscoverService(metricsBackend, routes.SourceRouting(source))
+}
+
+func overlapWarnings(overlaps []routes.Overlap) []string {
+	var warnings []string
+	for _, overlap := range overlaps {
+		if overlap.Shadowing > 0 {
+			warnings = append(warnings, fmt.Sprintf("%d metrics of this source are shadowed by custom metrics source %s", overlap.Shadowing, overlap.Source))
+		}
+		if overlap.Shadowed > 0 {
+			warnings = append(warnings, fmt.Sprintf("this source shadows %d metrics of custom metrics source %s", overlap.Shadowed, overlap.Source))
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,128 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/klog"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+)
+
+// ValidateSourcePath is the path the CustomMetricsSource validation is served at.
+const ValidateSourcePath = "/validate-custommetricssource"
+
+const maxRequestBytes = 3 * 1024 * 1024
+
+// admissionResponse adds the warnings which are understood by API servers
+// since Kubernetes 1.19 to the AdmissionResponse of the vendored API.
+type admissionResponse struct {
+	admissionv1.AdmissionResponse `json:",inline"`
+	Warnings                      []string `json:"warnings,omitempty"`
+}
+
+type admissionReview struct {
+	metav1.TypeMeta `json:",inline"`
+	Request         *admissionv1.AdmissionRequest `json:"request,omitempty"`
+	Response        *admissionResponse            `json:"response,omitempty"`
+}
+
+// Server serves the admission and conversion webhooks of the router.
+type Server struct {
+	validator *SourceValidator
+	mux       *http.ServeMux
+}
+
+func NewServer(validator *SourceValidator) *Server {
+	s := &Server{validator: validator, mux: http.NewServeMux()}
+	s.mux.HandleFunc(ValidateSourcePath, s.validateSource)
+	s.mux.HandleFunc(ConvertPath, s.convert)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Run serves the webhooks with TLS on address until stopCh is closed.
+func (s *Server) Run(address, certFile, keyFile string, stopCh <-chan struct{}) error {
+	server := &http.Server{Addr: address, Handler: s}
+	go func() {
+		<-stopCh
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			utilruntime.HandleError(fmt.Errorf("failed to shut down webhook server: %v", err))
+		}
+	}()
+	klog.Infof("Serving admission webhooks on %s", address)
+	if err := server.ListenAndServeTLS(certFile, keyFile); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) validateSource(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to read request: %v", err), http.StatusBadRequest)
+		return
+	}
+	review := admissionReview{}
+	if err := json.Unmarshal(body, &review); err != nil || review.Request == nil {
+		http.Error(w, "request body must be an AdmissionReview with a request", http.StatusBadRequest)
+		return
+	}
+
+	review.Response = s.admitSource(r.Context(), review.Request)
+	review.Response.UID = review.Request.UID
+	review.Request = nil
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(review); err != nil {
+		utilruntime.HandleError(fmt.Errorf("failed to write admission response: %v", err))
+	}
+}
+
+func (s *Server) admitSource(ctx context.Context, request *admissionv1.AdmissionRequest) *admissionResponse {
+	if request.Operation == admissionv1.Delete {
+		return &admissionResponse{AdmissionResponse: admissionv1.AdmissionResponse{Allowed: true}}
+	}
+	source := &v1beta1.CustomMetricsSource{}
+	if err := json.Unmarshal(request.Object.Raw, source); err != nil {
+		return deny(metav1.StatusReasonBadRequest, fmt.Sprintf("failed to decode custom metrics source: %v", err))
+	}
+
+	dryRun := request.DryRun != nil && *request.DryRun
+	allErrs, warnings := s.validator.Validate(ctx, source, request.UserInfo, dryRun)
+	if len(allErrs) > 0 {
+		return deny(metav1.StatusReasonInvalid, allErrs.ToAggregate().Error())
+	}
+	return &admissionResponse{
+		AdmissionResponse: admissionv1.AdmissionResponse{Allowed: true},
+		Warnings:          warnings,
+	}
+}
+
+func deny(reason metav1.StatusReason, message string) *admissionResponse {
+	return &admissionResponse{
+		AdmissionResponse: admissionv1.AdmissionResponse{
+			Allowed: false,
+			Result: &metav1.Status{
+				Status:  metav1.StatusFailure,
+				Reason:  reason,
+				Message: message,
+			},
+		},
+	}
+}
@@ -0,0 +1,420 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/arjunrn/custom-metrics-router/pkg/apis/metricsrouter.io/v1beta1"
+	"github.com/arjunrn/custom-metrics-router/pkg/backend"
+	metricsrouterfake "github.com/arjunrn/custom-metrics-router/pkg/client/clientset/versioned/fake"
+	"github.com/arjunrn/custom-metrics-router/pkg/clientset"
+	_ "github.com/arjunrn/custom-metrics-router/pkg/objectfield"
+	_ "github.com/arjunrn/custom-metrics-router/pkg/otlp"
+	_ "github.com/arjunrn/custom-metrics-router/pkg/plugin"
+	_ "github.com/arjunrn/custom-metrics-router/pkg/remotewrite"
+	"github.com/arjunrn/custom-metrics-router/pkg/routes"
+	_ "github.com/arjunrn/custom-metrics-router/pkg/scrape"
+)
+
+func testSource(name string, mutate func(spec *v1beta1.CustomMetricsSourceSpec)) *v1beta1.CustomMetricsSource {
+	source := &v1beta1.CustomMetricsSource{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: v1beta1.CustomMetricsSourceSpec{
+			Backend: v1beta1.Backend{
+				Service: v1beta1.ServiceReference{Namespace: "custom-metrics", Name: "adapter", Port: 443},
+			},
+			Routing: v1beta1.Routing{
+				Priority:    100,
+				MetricTypes: []v1beta1.MetricType{v1beta1.CustomMetricsType},
+			},
+		},
+	}
+	if mutate != nil {
+		mutate(&source.Spec)
+	}
+	return source
+}
+
+func TestValidateSource(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		source   *v1beta1.CustomMetricsSource
+		existing []runtime.Object
+		// discovery enables the dry-run discovery of the validator and
+		// dryRun marks the admission request as dry run.
+		discovery bool
+		dryRun    bool
+		// user creates the source, admin by default.
+		user    string
+		allowed bool
+	}{
+		{
+			name:    "valid",
+			source:  testSource("test", nil),
+			allowed: true,
+		},
+		{
+			name:   "invalid port",
+			source: testSource("test", func(spec *v1beta1.CustomMetricsSourceSpec) { spec.Backend.Service.Port = 0 }),
+		},
+		{
+			name:   "no metric types",
+			source: testSource("test", func(spec *v1beta1.CustomMetricsSourceSpec) { spec.Routing.MetricTypes = nil }),
+		},
+		{
+			name: "duplicate metric types",
+			source: testSource("test", func(spec *v1beta1.CustomMetricsSourceSpec) {
+				spec.Routing.MetricTypes = []v1beta1.MetricType{v1beta1.CustomMetricsType, v1beta1.CustomMetricsType}
+			}),
+		},
+		{
+			name:   "missing service",
+			source: testSource("test", func(spec *v1beta1.CustomMetricsSourceSpec) { spec.Backend.Service.Name = "missing" }),
+		},
+		{
+			name:     "duplicate service",
+			source:   testSource("test", nil),
+			existing: []runtime.Object{testSource("other", func(spec *v1beta1.CustomMetricsSourceSpec) { spec.Backend.Service.Port = 8443 })},
+		},
+		{
+			name:     "update of the same source",
+			source:   testSource("test", nil),
+			existing: []runtime.Object{testSource("test", nil)},
+			allowed:  true,
+		},
+		{
+			name: "invalid ca bundle",
+			source: testSource("test", func(spec *v1beta1.CustomMetricsSourceSpec) {
+				spec.Backend.TLS.CABundle = []byte("not a certificate")
+			}),
+		},
+		{
+			name: "metric pattern",
+			source: testSource("test", func(spec *v1beta1.CustomMetricsSourceSpec) {
+				spec.Routing.MetricPatterns = []v1beta1.MetricPattern{{MetricType: v1beta1.CustomMetricsType, Glob: "http_*"}}
+			}),
+			allowed: true,
+		},
+		{
+			name: "invalid metric pattern regex",
+			source: testSource("test", func(spec *v1beta1.CustomMetricsSourceSpec) {
+				spec.Routing.MetricPatterns = []v1beta1.MetricPattern{{MetricType: v1beta1.CustomMetricsType, Regex: "("}}
+			}),
+		},
+		{
+			name: "metric pattern for other metric type",
+			source: testSource("test", func(spec *v1beta1.CustomMetricsSourceSpec) {
+				spec.Routing.MetricPatterns = []v1beta1.MetricPattern{{MetricType: v1beta1.ExternalMetricsType, Glob: "queue_*"}}
+			}),
+		},
+		{
+			name: "static metrics of other metric type",
+			source: testSource("test", func(spec *v1beta1.CustomMetricsSourceSpec) {
+				spec.StaticMetrics = &v1beta1.StaticMetrics{ExternalMetrics: []string{"queue_depth"}}
+			}),
+		},
+		{
+			name: "prometheus backend",
+			source: testSource("test", func(spec *v1beta1.CustomMetricsSourceSpec) {
+				spec.Backend.Type = v1beta1.PrometheusBackendType
+				spec.Backend.Prometheus = &v1beta1.PrometheusBackend{CustomMetrics: []v1beta1.PrometheusCustomMetric{
+					{Name: "http_requests", Resource: "pods", Namespaced: true, Query: `sum(rate(http_requests_total{namespace="{{.Namespace}}",pod=~"{{.Names}}"}[2m])) by (pod)`},
+				}}
+			}),
+			allowed: true,
+		},
+		{
+			name: "invalid prometheus query template",
+			source: testSource("test", func(spec *v1beta1.CustomMetricsSourceSpec) {
+				spec.Backend.Type = v1beta1.PrometheusBackendType
+				spec.Backend.Prometheus = &v1beta1.PrometheusBackend{CustomMetrics: []v1beta1.PrometheusCustomMetric{
+					{Name: "http_requests", Resource: "pods", Query: "{{.Names"},
+				}}
+			}),
+		},
+		{
+			name: "prometheus backend without its type",
+			source: testSource("test", func(spec *v1beta1.CustomMetricsSourceSpec) {
+				spec.Backend.Prometheus = &v1beta1.PrometheusBackend{}
+			}),
+		},
+		{
+			name: "prometheus type without its backend",
+			source: testSource("test", func(spec *v1beta1.CustomMetricsSourceSpec) {
+				spec.Backend.Type = v1beta1.PrometheusBackendType
+			}),
+		},
+		{
+			name: "plugin backend",
+			source: testSource("test", func(spec *v1beta1.CustomMetricsSourceSpec) {
+				spec.Backend.Type = v1beta1.PluginBackendType
+				spec.Backend.Plugin = &v1beta1.PluginBackend{Socket: "/var/run/metrics-plugin/plugin.sock"}
+			}),
+			allowed: true,
+		},
+		{
+			name: "plugin backend with relative socket",
+			source: testSource("test", func(spec *v1beta1.CustomMetricsSourceSpec) {
+				spec.Backend.Type = v1beta1.PluginBackendType
+				spec.Backend.Plugin = &v1beta1.PluginBackend{Socket: "plugin.sock"}
+			}),
+		},
+		{
+			name: "http backend",
+			source: testSource("test", func(spec *v1beta1.CustomMetricsSourceSpec) {
+				spec.Backend.Type = v1beta1.HTTPBackendType
+				spec.Backend.HTTP = &v1beta1.HTTPBackend{ExternalMetrics: []v1beta1.HTTPExternalMetric{{
+					Name:   "queue_length",
+					Path:   "/queues?selector={{.Selector}}",
+					Items:  "{.queues[*]}",
+					Value:  "{.length}",
+					Labels: map[string]string{"queue": "{.name}"},
+				}}}
+				spec.Routing.MetricTypes = []v1beta1.MetricType{v1beta1.ExternalMetricsType}
+			}),
+			allowed: true,
+		},
+		{
+			name: "http backend with custom metrics",
+			source: testSource("test", func(spec *v1beta1.CustomMetricsSourceSpec) {
+				spec.Backend.Type = v1beta1.HTTPBackendType
+				spec.Backend.HTTP = &v1beta1.HTTPBackend{ExternalMetrics: []v1beta1.HTTPExternalMetric{{
+					Name:  "queue_length",
+					Path:  "/queues",
+					Value: "{.length}",
+				}}}
+			}),
+		},
+		{
+			name: "http backend with invalid value",
+			source: testSource("test", func(spec *v1beta1.CustomMetricsSourceSpec) {
+				spec.Backend.Type = v1beta1.HTTPBackendType
+				spec.Backend.HTTP = &v1beta1.HTTPBackend{ExternalMetrics: []v1beta1.HTTPExternalMetric{{
+					Name:  "queue_length",
+					Path:  "/queues",
+					Value: "{{.length",
+				}}}
+				spec.Routing.MetricTypes = []v1beta1.MetricType{v1beta1.ExternalMetricsType}
+			}),
+		},
+		{
+			name: "scrape backend",
+			source: testSource("test", func(spec *v1beta1.CustomMetricsSourceSpec) {
+				spec.Backend.Type = v1beta1.ScrapeBackendType
+				spec.Backend.Scrape = &v1beta1.ScrapeBackend{
+					Port: intstr.FromString("metrics"),
+					Metrics: []v1beta1.ScrapeMetric{
+						{Name: "http_requests_per_second", Family: "http_requests_total", RateWindow: &metav1.Duration{Duration: time.Minute}},
+					},
+				}
+			}),
+			allowed: true,
+		},
+		{
+			name: "scrape backend with the router's credentials",
+			source: testSource("test", func(spec *v1beta1.CustomMetricsSourceSpec) {
+				spec.Backend.Type = v1beta1.ScrapeBackendType
+				spec.Backend.Authentication = &v1beta1.Authentication{Mode: v1beta1.ServiceAccountAuthentication}
+				spec.Backend.Scrape = &v1beta1.ScrapeBackend{
+					Port:    intstr.FromInt(8080),
+					Metrics: []v1beta1.ScrapeMetric{{Name: "requests"}},
+				}
+			}),
+		},
+		{
+			name: "scrape backend with invalid family",
+			source: testSource("test", func(spec *v1beta1.CustomMetricsSourceSpec) {
+				spec.Backend.Type = v1beta1.ScrapeBackendType
+				spec.Backend.Scrape = &v1beta1.ScrapeBackend{
+					Port:    intstr.FromInt(8080),
+					Metrics: []v1beta1.ScrapeMetric{{Name: "requests", Family: "http-requests"}},
+				}
+			}),
+		},
+		{
+			name: "object field backend",
+			source: testSource("test", func(spec *v1beta1.CustomMetricsSourceSpec) {
+				spec.Backend.Type = v1beta1.ObjectFieldBackendType
+				spec.Backend.ObjectField = &v1beta1.ObjectFieldBackend{Metrics: []v1beta1.ObjectFieldMetric{
+					{Name: "backlog", Resource: "queues.example.com", Namespaced: true, Field: "{.status.backlog}"},
+				}}
+			}),
+			allowed: true,
+		},
+		{
+			name: "object field backend of a user who may not list the resource",
+			source: testSource("test", func(spec *v1beta1.CustomMetricsSourceSpec) {
+				spec.Backend.Type = v1beta1.ObjectFieldBackendType
+				spec.Backend.ObjectField = &v1beta1.ObjectFieldBackend{Metrics: []v1beta1.ObjectFieldMetric{
+					{Name: "backlog", Resource: "queues.example.com", Namespaced: true, Field: "{.status.backlog}"},
+				}}
+			}),
+			user: "developer",
+		},
+		{
+			name: "object field backend without resource",
+			source: testSource("test", func(spec *v1beta1.CustomMetricsSourceSpec) {
+				spec.Backend.Type = v1beta1.ObjectFieldBackendType
+				spec.Backend.ObjectField = &v1beta1.ObjectFieldBackend{Metrics: []v1beta1.ObjectFieldMetric{
+					{Name: "backlog", Field: "{.status.backlog}"},
+				}}
+			}),
+		},
+		{
+			name: "remote-write backend",
+			source: testSource("test", func(spec *v1beta1.CustomMetricsSourceSpec) {
+				spec.Backend.Type = v1beta1.RemoteWriteBackendType
+				spec.Backend.RemoteWrite = &v1beta1.RemoteWriteBackend{CustomMetrics: []v1beta1.RemoteWriteCustomMetric{
+					{Name: "jobs", Series: "worker_jobs_pending", Resource: "pods", Namespaced: true, ObjectLabel: "kubernetes_pod_name"},
+				}}
+			}),
+			allowed: true,
+		},
+		{
+			name: "remote-write backend with external metrics but only custom metrics routed",
+			source: testSource("test", func(spec *v1beta1.CustomMetricsSourceSpec) {
+				spec.Backend.Type = v1beta1.RemoteWriteBackendType
+				spec.Backend.RemoteWrite = &v1beta1.RemoteWriteBackend{ExternalMetrics: []v1beta1.RemoteWriteExternalMetric{
+					{Name: "jobs", Series: "worker_jobs_pending"},
+				}}
+			}),
+		},
+		{
+			name: "remote-write backend with invalid series",
+			source: testSource("test", func(spec *v1beta1.CustomMetricsSourceSpec) {
+				spec.Backend.Type = v1beta1.RemoteWriteBackendType
+				spec.Backend.RemoteWrite = &v1beta1.RemoteWriteBackend{CustomMetrics: []v1beta1.RemoteWriteCustomMetric{
+					{Name: "jobs", Series: "worker-jobs", Resource: "pods", Namespaced: true},
+				}}
+			}),
+		},
+		{
+			name: "OTLP backend",
+			source: testSource("test", func(spec *v1beta1.CustomMetricsSourceSpec) {
+				spec.Backend.Type = v1beta1.OTLPBackendType
+				spec.Backend.OTLP = &v1beta1.OTLPBackend{CustomMetrics: []v1beta1.OTLPCustomMetric{
+					{Name: "jobs", Metric: "worker.jobs", Resource: "pods", Namespaced: true, Labels: map[string]string{"state": "job.state"}},
+				}}
+			}),
+			allowed: true,
+		},
+		{
+			name: "OTLP backend with an invalid label",
+			source: testSource("test", func(spec *v1beta1.CustomMetricsSourceSpec) {
+				spec.Backend.Type = v1beta1.OTLPBackendType
+				spec.Backend.OTLP = &v1beta1.OTLPBackend{CustomMetrics: []v1beta1.OTLPCustomMetric{
+					{Name: "jobs", Metric: "worker.jobs", Resource: "pods", Namespaced: true, Labels: map[string]string{"job state": "job.state"}},
+				}}
+			}),
+		},
+		{
+			name: "OTLP settings without the type OTLP",
+			source: testSource("test", func(spec *v1beta1.CustomMetricsSourceSpec) {
+				spec.Backend.OTLP = &v1beta1.OTLPBackend{}
+			}),
+		},
+		{
+			name: "unknown backend type",
+			source: testSource("test", func(spec *v1beta1.CustomMetricsSourceSpec) {
+				spec.Backend.Type = "Graphite"
+			}),
+		},
+		{
+			name: "secret outside the namespace of the backend",
+			source: testSource("test", func(spec *v1beta1.CustomMetricsSourceSpec) {
+				spec.Backend.Authentication = &v1beta1.Authentication{
+					Mode:        v1beta1.SecretTokenAuthentication,
+					SecretToken: &v1beta1.SecretKeySelector{Namespace: "kube-system", Name: "admin-token", Key: "token"},
+				}
+			}),
+		},
+		{
+			name: "discovery with an unreadable secret",
+			source: testSource("test", func(spec *v1beta1.CustomMetricsSourceSpec) {
+				spec.Backend.Authentication = &v1beta1.Authentication{
+					Mode:        v1beta1.SecretTokenAuthentication,
+					SecretToken: &v1beta1.SecretKeySelector{Namespace: "custom-metrics", Name: "adapter-token", Key: "token"},
+				}
+			}),
+			discovery: true,
+		},
+		{
+			name: "no discovery for dry-run requests",
+			source: testSource("test", func(spec *v1beta1.CustomMetricsSourceSpec) {
+				spec.Backend.Authentication = &v1beta1.Authentication{
+					Mode:        v1beta1.SecretTokenAuthentication,
+					SecretToken: &v1beta1.SecretKeySelector{Namespace: "custom-metrics", Name: "adapter-token", Key: "token"},
+				}
+			}),
+			discovery: true,
+			dryRun:    true,
+			allowed:   true,
+		},
+		{
+			name: "missing secret reference",
+			source: testSource("test", func(spec *v1beta1.CustomMetricsSourceSpec) {
+				spec.Backend.Authentication = &v1beta1.Authentication{Mode: v1beta1.SecretTokenAuthentication}
+			}),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			kubeClient := kubefake.NewSimpleClientset(&corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "custom-metrics", Name: "adapter"},
+			})
+			kubeClient.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+				review.Status.Allowed = review.Spec.User == "admin"
+				return true, review, nil
+			})
+			clientSet := clientset.NewClientSet(kubeClient, metricsrouterfake.NewSimpleClientset(tc.existing...))
+			mapper := meta.NewDefaultRESTMapper(nil)
+			deps := backend.Dependencies{KubeClient: clientSet, DynamicClient: clientSet.Dynamic(), Mapper: mapper}
+			server := NewServer(NewSourceValidator(clientSet, routes.New(mapper), mapper, deps, tc.discovery))
+			user := tc.user
+			if user == "" {
+				user = "admin"
+			}
+
+			raw, err := json.Marshal(tc.source)
+			require.NoError(t, err)
+			body, err := json.Marshal(admissionReview{
+				TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1", Kind: "AdmissionReview"},
+				Request: &admissionv1.AdmissionRequest{
+					UID:       types.UID("1234"),
+					Operation: admissionv1.Create,
+					Object:    runtime.RawExtension{Raw: raw},
+					DryRun:    &tc.dryRun,
+					UserInfo:  authenticationv1.UserInfo{Username: user},
+				},
+			})
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, ValidateSourcePath, bytes.NewReader(body)))
+			require.Equal(t, http.StatusOK, recorder.Code)
+
+			response := admissionReview{}
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+			require.Equal(t, "AdmissionReview", response.Kind)
+			require.Equal(t, types.UID("1234"), response.Response.UID)
+			require.Equal(t, tc.allowed, response.Response.Allowed, "%v", response.Response.Result)
+		})
+	}
+}